@@ -57,6 +57,30 @@ type Config struct {
 	MaxRequestSize  int64 // en bytes
 	RequestTimeout  time.Duration
 	SessionDuration time.Duration
+
+	// Configuración de correo (SMTP) para el envío programado de reportes
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTLS      string // "none", "starttls" o "tls" (TLS implícito, puerto 465)
+
+	// Datos del emisor que se imprimen en los recibos de alquiler
+	ReciboEmisor     string
+	ReciboCUIT       string
+	ReciboDomicilio  string
+	ReciboPuntoVenta int
+
+	// Pasarela de mensajería (WhatsApp/SMS) para avisos a inquilinos
+	MensajeriaProveedor string // "fake" (solo log) o "http"
+	MensajeriaURL       string
+	MensajeriaToken     string
+	MensajeriaRemitente string
+
+	// Almacenamiento de archivos (imágenes de propiedades)
+	AlmacenamientoTipo string // "gridfs" (MongoDB) o "disco"
+	AlmacenamientoDir  string // carpeta para el tipo "disco"
 }
 
 var AppConfig *Config
@@ -121,6 +145,30 @@ func LoadConfig() *Config {
 		MaxRequestSize:  int64(getEnvAsInt("MAX_REQUEST_SIZE_MB", 10)) * 1024 * 1024,
 		RequestTimeout:  time.Duration(getEnvAsInt("REQUEST_TIMEOUT_SECONDS", 30)) * time.Second,
 		SessionDuration: time.Duration(getEnvAsInt("SESSION_DURATION_HOURS", 24)) * time.Hour,
+
+		// SMTP (vacío = envío de correos deshabilitado)
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUser:     getEnv("SMTP_USER", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "cajafuerte@localhost"),
+		SMTPTLS:      getEnv("SMTP_TLS", "starttls"),
+
+		// Recibos de alquiler
+		ReciboEmisor:     getEnv("RECIBO_EMISOR", getEnv("APP_NAME", "CajaFuerte")),
+		ReciboCUIT:       getEnv("RECIBO_CUIT", ""),
+		ReciboDomicilio:  getEnv("RECIBO_DOMICILIO", ""),
+		ReciboPuntoVenta: getEnvAsInt("RECIBO_PUNTO_VENTA", 1),
+
+		// Mensajería (fake = los mensajes solo se registran en el log)
+		MensajeriaProveedor: getEnv("MENSAJERIA_PROVEEDOR", "fake"),
+		MensajeriaURL:       getEnv("MENSAJERIA_URL", ""),
+		MensajeriaToken:     getEnv("MENSAJERIA_TOKEN", ""),
+		MensajeriaRemitente: getEnv("MENSAJERIA_REMITENTE", ""),
+
+		// Archivos: por defecto en GridFS, junto con los datos de alquileres
+		AlmacenamientoTipo: getEnv("ALMACENAMIENTO_TIPO", "gridfs"),
+		AlmacenamientoDir:  getEnv("ALMACENAMIENTO_DIR", "./data/archivos"),
 	}
 
 	// Validaciones críticas para producción
@@ -3,20 +3,26 @@ package controllers
 import (
 	"caja-fuerte/models"
 	"caja-fuerte/services"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AlquilerController struct {
-	service *services.AlquilerService
+	service    *services.AlquilerService
+	punitorios *services.PunitorioService
+	mora       *services.MoraService
 }
 
 func NewAlquilerController() *AlquilerController {
 	return &AlquilerController{
-		service: services.NewAlquilerService(),
+		service:    services.NewAlquilerService(),
+		punitorios: services.NewPunitorioService(),
+		mora:       services.NewMoraService(),
 	}
 }
 
@@ -33,12 +39,18 @@ func (c *AlquilerController) AlquileresPage(ctx *gin.Context) {
 // API CRUD Propiedades
 // ─────────────────────────────────────────────────────────────────────────────
 
-// GET /api/alquileres/propiedades
+// GET /api/alquileres/propiedades?busqueda=&estado=&edificio_id=&anio=
 func (c *AlquilerController) GetPropiedades(ctx *gin.Context) {
 	busqueda := ctx.Query("busqueda")
 	estado := ctx.Query("estado")
+	edificioID := ctx.Query("edificio_id")
 	anioStr := ctx.Query("anio")
 
+	if edificioID != "" && !primitive.IsValidObjectID(edificioID) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de edificio inválido"})
+		return
+	}
+
 	anio := time.Now().Year()
 	if anioStr != "" {
 		if v, err := strconv.Atoi(anioStr); err == nil {
@@ -46,7 +58,7 @@ func (c *AlquilerController) GetPropiedades(ctx *gin.Context) {
 		}
 	}
 
-	props, err := c.service.GetPropiedades(busqueda, estado, anio)
+	props, err := c.service.GetPropiedades(busqueda, estado, edificioID, anio)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener propiedades: " + err.Error()})
 		return
@@ -59,10 +71,11 @@ func (c *AlquilerController) GetPropiedades(ctx *gin.Context) {
 	})
 }
 
-// GET /api/alquileres/propiedades/:id
+// GET /api/alquileres/propiedades/:id?anio=2026
 func (c *AlquilerController) GetPropiedadByID(ctx *gin.Context) {
 	id := ctx.Param("id")
-	prop, err := c.service.GetPropiedadByID(id)
+	anio, _ := strconv.Atoi(ctx.Query("anio"))
+	prop, err := c.service.GetPropiedadAnio(id, anio)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -70,6 +83,16 @@ func (c *AlquilerController) GetPropiedadByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, prop)
 }
 
+// GET /api/alquileres/propiedades/:id/periodos — libro completo de períodos (todos los años)
+func (c *AlquilerController) GetPeriodos(ctx *gin.Context) {
+	periodos, err := c.service.GetPeriodos(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"periodos": periodos, "total": len(periodos)})
+}
+
 // POST /api/alquileres/propiedades
 func (c *AlquilerController) CrearPropiedad(ctx *gin.Context) {
 	var req models.CrearPropiedadRequest
@@ -100,7 +123,7 @@ func (c *AlquilerController) ActualizarPropiedad(ctx *gin.Context) {
 		return
 	}
 
-	prop, err := c.service.ActualizarPropiedad(id, req)
+	prop, err := c.service.ActualizarPropiedad(id, req, ctx.GetUint("user_id"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -115,7 +138,7 @@ func (c *AlquilerController) ActualizarPropiedad(ctx *gin.Context) {
 // DELETE /api/alquileres/propiedades/:id
 func (c *AlquilerController) EliminarPropiedad(ctx *gin.Context) {
 	id := ctx.Param("id")
-	if err := c.service.EliminarPropiedad(id); err != nil {
+	if err := c.service.EliminarPropiedad(id, ctx.GetUint("user_id")); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -127,7 +150,7 @@ func (c *AlquilerController) EliminarMetadataField(ctx *gin.Context) {
 	id := ctx.Param("id")
 	campo := ctx.Param("campo")
 
-	prop, err := c.service.EliminarMetadataField(id, campo)
+	prop, err := c.service.EliminarMetadataField(id, campo, ctx.GetUint("user_id"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -149,20 +172,55 @@ func (c *AlquilerController) RegistrarPago(ctx *gin.Context) {
 	}
 
 	userID := ctx.GetUint("user_id")
-	prop, err := c.service.RegistrarPago(id, req, userID)
+	prop, recibo, err := c.service.RegistrarPago(id, req, userID)
+	if errors.Is(err, services.ErrPeriodoModificado) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		// Log detallado para facilitar el diagnóstico
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "detalle": "prop=" + id + " mes=" + strconv.Itoa(req.Mes)})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "detalle": "prop=" + id + " anio=" + strconv.Itoa(req.Anio) + " mes=" + strconv.Itoa(req.Mes)})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message":   "Pago registrado exitosamente",
 		"propiedad": prop,
+		"recibo":    recibo,
 	})
 }
 
-// DELETE /api/alquileres/propiedades/:id/pago/:mes
+// GET /api/alquileres/cajas-destino
+// Cajas abiertas que pueden recibir un cobro (arco_id en el body del pago).
+func (c *AlquilerController) GetCajasDestino(ctx *gin.Context) {
+	cajas, err := c.service.CajasDestino(ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las cajas abiertas: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"cajas": cajas, "total": len(cajas)})
+}
+
+// GET /api/alquileres/propiedades/:id/pago/:mes/liquidacion?anio=2026
+// Saldo del período y punitorios a la fecha (para precargar el cobro).
+func (c *AlquilerController) GetLiquidacion(ctx *gin.Context) {
+	mes, err := strconv.Atoi(ctx.Param("mes"))
+	if err != nil || mes < 0 || mes > 11 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mes inválido"})
+		return
+	}
+	anio, _ := strconv.Atoi(ctx.Query("anio"))
+
+	liq, err := c.service.GetLiquidacion(ctx.Param("id"), anio, mes)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, liq)
+}
+
+// DELETE /api/alquileres/propiedades/:id/pago/:mes?anio=2026 (sin anio: año en curso)
+// Revierte el último cobro del período.
 func (c *AlquilerController) DeshacerPago(ctx *gin.Context) {
 	id := ctx.Param("id")
 	mesStr := ctx.Param("mes")
@@ -172,8 +230,14 @@ func (c *AlquilerController) DeshacerPago(ctx *gin.Context) {
 		return
 	}
 
+	anio, _ := strconv.Atoi(ctx.Query("anio"))
+
 	userID := ctx.GetUint("user_id")
-	prop, err := c.service.DeshacerPago(id, mes, userID)
+	prop, err := c.service.DeshacerPago(id, anio, mes, userID)
+	if errors.Is(err, services.ErrPeriodoModificado) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -185,6 +249,81 @@ func (c *AlquilerController) DeshacerPago(ctx *gin.Context) {
 	})
 }
 
+// GET /api/alquileres/punitorios — política general de punitorios
+func (c *AlquilerController) GetPoliticaPunitorio(ctx *gin.Context) {
+	pol, err := c.punitorios.GetPolitica()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la política de punitorios"})
+		return
+	}
+	ctx.JSON(http.StatusOK, pol)
+}
+
+// PUT /api/alquileres/punitorios
+// Body: {"activo":true,"tipo":"diario|fijo","tasa_diaria":0.5,"monto_fijo":0,"tope_pct":30}
+func (c *AlquilerController) GuardarPoliticaPunitorio(ctx *gin.Context) {
+	var req models.PoliticaPunitorio
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	pol, err := c.punitorios.GuardarPolitica(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Política de punitorios actualizada", "politica": pol})
+}
+
+// GET /api/alquileres/mora/reglas — vencimiento, gracia y niveles de atraso generales
+func (c *AlquilerController) GetReglasMora(ctx *gin.Context) {
+	reglas, err := c.mora.GetReglas()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las reglas de mora"})
+		return
+	}
+	ctx.JSON(http.StatusOK, reglas)
+}
+
+// PUT /api/alquileres/mora/reglas
+// Body: {"dia_vencimiento":10,"dias_gracia":5,"cron":"30 0 * * *","niveles":[{"estado":"late_1","dias_desde":1,"nombre":"Atraso leve"}]}
+func (c *AlquilerController) GuardarReglasMora(ctx *gin.Context) {
+	var req models.ReglasMora
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	reglas, err := c.mora.GuardarReglas(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reglas de mora actualizadas", "reglas": reglas})
+}
+
+// GET /api/alquileres/propiedades/:id/pago/:mes/historial?anio=2026
+// Cambios de estado del período (mora, pagos y reversiones).
+func (c *AlquilerController) GetHistorialPeriodo(ctx *gin.Context) {
+	mes, err := strconv.Atoi(ctx.Param("mes"))
+	if err != nil || mes < 0 || mes > 11 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mes inválido"})
+		return
+	}
+	anio, _ := strconv.Atoi(ctx.Query("anio"))
+	if anio == 0 {
+		anio = time.Now().Year()
+	}
+
+	historial, err := c.mora.Historial(ctx.Param("id"), anio, mes)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"historial": historial, "total": len(historial)})
+}
+
 // ─────────────────────────────────────────────────────────────────────────────
 // API Resumen / Reportes (solo Admin General)
 // ─────────────────────────────────────────────────────────────────────────────
@@ -207,14 +346,19 @@ func (c *AlquilerController) GetResumen(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, resumen)
 }
 
-// GET /api/alquileres/resumen/movimientos?periodo=dia|mes|anio
+// GET /api/alquileres/resumen/movimientos?periodo=dia|mes|anio&base=AAAA-MM
 func (c *AlquilerController) GetResumenMovimientos(ctx *gin.Context) {
 	periodo := ctx.DefaultQuery("periodo", "mes")
 	if periodo != "dia" && periodo != "mes" && periodo != "anio" {
 		periodo = "mes"
 	}
 
-	resultado, err := c.service.GetMovimientosAlquiler(periodo)
+	base, ok := parseMesBase(ctx)
+	if !ok {
+		return
+	}
+
+	resultado, err := c.service.GetMovimientosAlquiler(periodo, base)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -244,7 +388,7 @@ func (c *AlquilerController) ActualizarMonto(ctx *gin.Context) {
 		return
 	}
 
-	prop, err := c.service.ActualizarMonto(id, req)
+	prop, err := c.service.ActualizarMonto(id, req, ctx.GetUint("user_id"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -264,7 +408,7 @@ func (c *AlquilerController) PosponerActualizacion(ctx *gin.Context) {
 		return
 	}
 
-	prop, err := c.service.PosponerActualizacion(id, req.PosponerHasta)
+	prop, err := c.service.PosponerActualizacion(id, req.PosponerHasta, ctx.GetUint("user_id"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -276,11 +420,17 @@ func (c *AlquilerController) PosponerActualizacion(ctx *gin.Context) {
 	})
 }
 
-// POST /api/alquileres/actualizar-morosos (puede llamarse vía cron o manualmente)
+// POST /api/alquileres/actualizar-morosos
+// Fuerza la actualización que el Scheduler corre según las reglas de mora.
 func (c *AlquilerController) ActualizarMorosos(ctx *gin.Context) {
-	if err := c.service.ActualizarEstadosMorosos(); err != nil {
+	res, err := c.mora.ActualizarEstados(models.OrigenMoraManual, ctx.GetUint("user_id"))
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "Estados de morosidad actualizados"})
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":      "Estados de morosidad actualizados",
+		"revisados":    res.Revisados,
+		"actualizados": res.Actualizados,
+	})
 }
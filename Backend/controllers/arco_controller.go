@@ -105,29 +105,30 @@ func (c *ArcoController) CerrarArco(ctx *gin.Context) {
 	}
 
 	totalContadoStr := ctx.PostForm("total_contado")
-	var totalContado float64
+	var totalContado *float64
 	if totalContadoStr != "" {
-		if v, err := strconv.ParseFloat(totalContadoStr, 64); err == nil {
-			totalContado = v
+		if v, err := strconv.ParseFloat(totalContadoStr, 64); err == nil && v >= 0 {
+			totalContado = &v
 		}
 	}
 
-	arco, err := c.arcoService.CerrarArcoConRetiro(uint(arcoID), userID, retiroAmount)
+	arco, err := c.arcoService.CerrarArcoConRetiro(uint(arcoID), userID, retiroAmount, totalContado)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Calcular diferencia si se envió total contado
-	var diferencia float64
-	if totalContado > 0 {
-		diferencia = totalContado - arco.SaldoFinal
+	// La diferencia queda registrada en el arco (sobrante > 0, faltante < 0)
+	var diferencia, contado float64
+	if arco.Diferencia != nil {
+		diferencia = *arco.Diferencia
+		contado = *arco.TotalContado
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"arco":          arco,
 		"diferencia":    diferencia,
-		"total_contado": totalContado,
+		"total_contado": contado,
 	})
 }
 
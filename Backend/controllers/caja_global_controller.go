@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"caja-fuerte/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CajaGlobalController struct {
+	service *services.CajaGlobalService
+}
+
+func NewCajaGlobalController() *CajaGlobalController {
+	return &CajaGlobalController{
+		service: services.NewCajaGlobalService(),
+	}
+}
+
+// GET /api/caja-global/saldo?fecha=2025-03-10 | fecha=2025-03-10T15:04:05-03:00
+// Sin fecha devuelve el saldo actual. Con una fecha (día) devuelve el saldo al
+// cierre de ese día; con un instante RFC3339 lo calcula exactamente en ese momento.
+func (c *CajaGlobalController) GetSaldo(ctx *gin.Context) {
+	fechaStr := ctx.Query("fecha")
+
+	if fechaStr == "" {
+		saldo, err := c.service.GetSaldoEnFecha(time.Now())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular saldo global: " + err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, saldo)
+		return
+	}
+
+	if t, err := time.Parse(time.RFC3339, fechaStr); err == nil {
+		saldo, err := c.service.GetSaldoEnFecha(t)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular saldo global: " + err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, saldo)
+		return
+	}
+
+	dia, err := time.ParseInLocation("2006-01-02", fechaStr, time.Local)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida (use AAAA-MM-DD o RFC3339)"})
+		return
+	}
+
+	saldo, err := c.service.GetSaldoDia(dia)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al calcular saldo global: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, saldo)
+}
+
+// GET /api/caja-global/historial?desde=2025-03-01&hasta=2025-03-31
+func (c *CajaGlobalController) GetHistorial(ctx *gin.Context) {
+	hasta := time.Now()
+	if v := ctx.Query("hasta"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'hasta' inválida (use AAAA-MM-DD)"})
+			return
+		}
+		hasta = t
+	}
+
+	desde := hasta.AddDate(0, 0, -30)
+	if v := ctx.Query("desde"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'desde' inválida (use AAAA-MM-DD)"})
+			return
+		}
+		desde = t
+	}
+
+	historial, err := c.service.GetHistorial(desde, hasta)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"historial": historial,
+		"total":     len(historial),
+	})
+}
+
+// POST /api/caja-global/snapshot?fecha=2025-03-10
+// Regenera el snapshot de un día (por ejemplo tras corregir movimientos de días pasados).
+func (c *CajaGlobalController) RegenerarSnapshot(ctx *gin.Context) {
+	dia := time.Now().AddDate(0, 0, -1)
+	if v := ctx.Query("fecha"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida (use AAAA-MM-DD)"})
+			return
+		}
+		dia = t
+	}
+
+	snap, err := c.service.GuardarSnapshot(dia)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar snapshot: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Snapshot regenerado",
+		"snapshot": snap,
+	})
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConciliacionController expone el outbox de caja de los cobros de alquiler y
+// la conciliación entre cobros (MongoDB) y movimientos (MySQL).
+type ConciliacionController struct {
+	service     *services.ConciliacionService
+	operaciones *services.OperacionCajaService
+}
+
+func NewConciliacionController() *ConciliacionController {
+	return &ConciliacionController{
+		service:     services.NewConciliacionService(),
+		operaciones: services.NewOperacionCajaService(),
+	}
+}
+
+// rangoConciliacion interpreta desde/hasta (AAAA-MM-DD, hasta inclusive). Sin
+// fechas devuelve los últimos 90 días.
+func rangoConciliacion(desdeStr, hastaStr string) (time.Time, time.Time, bool) {
+	hoy := time.Now()
+	hasta := time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	if hastaStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", hastaStr, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		hasta = t.AddDate(0, 0, 1)
+	}
+	desde := hasta.AddDate(0, 0, -90)
+	if desdeStr != "" {
+		t, err := time.ParseInLocation("2006-01-02", desdeStr, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		desde = t
+	}
+	return desde, hasta, true
+}
+
+// GET /api/alquileres/conciliacion-caja?desde=2025-01-01&hasta=2025-03-31
+func (c *ConciliacionController) Reporte(ctx *gin.Context) {
+	desde, hasta, ok := rangoConciliacion(ctx.Query("desde"), ctx.Query("hasta"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fechas inválidas (AAAA-MM-DD)"})
+		return
+	}
+	rep, err := c.service.Conciliar(desde, hasta)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al conciliar cobros y caja: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rep)
+}
+
+// POST /api/alquileres/conciliacion-caja/reparar
+func (c *ConciliacionController) Reparar(ctx *gin.Context) {
+	var req models.RepararConciliacionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	desde, hasta, ok := rangoConciliacion(req.Desde, req.Hasta)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fechas inválidas (AAAA-MM-DD)"})
+		return
+	}
+	rep, err := c.service.Reparar(desde, hasta, req.Tipos, req.ArcoID, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al reparar diferencias: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rep)
+}
+
+// GET /api/alquileres/operaciones-caja?estado=pendiente
+func (c *ConciliacionController) ListarOperaciones(ctx *gin.Context) {
+	estado := ctx.Query("estado")
+	switch estado {
+	case "", models.OpPendiente, models.OpAplicada, models.OpCancelada, models.OpRevision:
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Estado inválido (pendiente, aplicada, cancelada o revision)"})
+		return
+	}
+	ops, err := c.operaciones.Listar(estado)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener operaciones de caja: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"operaciones": ops, "total": len(ops)})
+}
+
+// POST /api/alquileres/operaciones-caja/procesar
+// Reintenta ya las operaciones pendientes (normalmente cada 5 minutos).
+func (c *ConciliacionController) ProcesarOperaciones(ctx *gin.Context) {
+	res, err := c.operaciones.ProcesarPendientes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al procesar operaciones de caja: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// PUT /api/alquileres/operaciones-caja/:id/caja
+// Cambia la caja destino de un movimiento pendiente o en revisión y lo aplica.
+func (c *ConciliacionController) ReasignarOperacion(ctx *gin.Context) {
+	var req models.ReasignarOperacionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	op, err := c.operaciones.Reasignar(ctx.Param("id"), req.ArcoID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Operación aplicada", "operacion": op})
+}
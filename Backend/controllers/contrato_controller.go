@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ContratoController struct {
+	service *services.ContratoService
+}
+
+func NewContratoController() *ContratoController {
+	return &ContratoController{
+		service: services.NewContratoService(),
+	}
+}
+
+// GET /api/alquileres/contratos?propiedad_id=...&estado=vigente|finalizado|rescindido
+func (c *ContratoController) Listar(ctx *gin.Context) {
+	contratos, err := c.service.Listar(ctx.Query("propiedad_id"), ctx.Query("estado"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"contratos": contratos, "total": len(contratos)})
+}
+
+// GET /api/alquileres/propiedades/:id/contratos — historial de contratos de la propiedad
+func (c *ContratoController) ListarPorPropiedad(ctx *gin.Context) {
+	contratos, err := c.service.Listar(ctx.Param("id"), "")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"contratos": contratos, "total": len(contratos)})
+}
+
+// GET /api/alquileres/contratos/:id — contrato con sus períodos y saldo
+func (c *ContratoController) GetDetalle(ctx *gin.Context) {
+	detalle, err := c.service.GetDetalle(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, detalle)
+}
+
+// POST /api/alquileres/propiedades/:id/contratos
+func (c *ContratoController) Crear(ctx *gin.Context) {
+	var req models.CrearContratoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	contrato, err := c.service.Crear(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Contrato creado", "contrato": contrato})
+}
+
+// PUT /api/alquileres/contratos/:id
+func (c *ContratoController) Actualizar(ctx *gin.Context) {
+	var req models.ActualizarContratoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	contrato, err := c.service.Actualizar(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Contrato actualizado", "contrato": contrato})
+}
+
+// PUT /api/alquileres/contratos/:id/actualizar-monto — actualización por índice
+func (c *ContratoController) ActualizarMonto(ctx *gin.Context) {
+	var req models.ActualizarMontoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	contrato, err := c.service.ActualizarMonto(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Monto actualizado correctamente", "contrato": contrato})
+}
+
+// POST /api/alquileres/contratos/:id/finalizar
+// Body: {"estado":"finalizado|rescindido","fecha":"2026-03-31T00:00:00-03:00","motivo":"..."}
+func (c *ContratoController) Finalizar(ctx *gin.Context) {
+	var req models.FinalizarContratoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	contrato, err := c.service.Finalizar(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Contrato finalizado", "contrato": contrato})
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CotizacionController expone la cotización diaria del dólar (oficial, MEP,
+// blue) y el recálculo en pesos de los alquileres en dólares.
+type CotizacionController struct {
+	service *services.CotizacionService
+}
+
+func NewCotizacionController() *CotizacionController {
+	return &CotizacionController{service: services.NewCotizacionService()}
+}
+
+// GET /api/alquileres/cotizaciones
+// Última cotización guardada de cada tipo de dólar
+func (c *CotizacionController) Estado(ctx *gin.Context) {
+	estado, err := c.service.Estado()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener cotizaciones: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"cotizaciones": estado})
+}
+
+// GET /api/alquileres/cotizaciones/:tipo?desde=2025-01-01&hasta=2025-03-31
+// Sin rango devuelve los últimos 30 días.
+func (c *CotizacionController) Listar(ctx *gin.Context) {
+	hasta := time.Now()
+	desde := hasta.AddDate(0, 0, -30)
+	if v := ctx.Query("desde"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'desde' inválida (AAAA-MM-DD)"})
+			return
+		}
+		desde = t
+	}
+	if v := ctx.Query("hasta"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'hasta' inválida (AAAA-MM-DD)"})
+			return
+		}
+		hasta = t
+	}
+
+	cotizaciones, err := c.service.Listar(ctx.Param("tipo"), desde, hasta)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"cotizaciones": cotizaciones, "total": len(cotizaciones)})
+}
+
+// GET /api/alquileres/cotizaciones/:tipo/vigente?fecha=2025-03-10
+// Cotización que corresponde a una fecha (sin fecha, la de hoy)
+func (c *CotizacionController) Vigente(ctx *gin.Context) {
+	fecha := time.Now()
+	if v := ctx.Query("fecha"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida (AAAA-MM-DD)"})
+			return
+		}
+		fecha = t
+	}
+	cot, err := c.service.Vigente(ctx.Param("tipo"), fecha)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, cot)
+}
+
+// POST /api/alquileres/cotizaciones/:tipo/sincronizar
+func (c *CotizacionController) Sincronizar(ctx *gin.Context) {
+	n, err := c.service.Sincronizar(ctx.Param("tipo"))
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo sincronizar la cotización: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Cotización sincronizada", "valores": n})
+}
+
+// PUT /api/alquileres/cotizaciones/:tipo
+// Carga o corrige la cotización de un día; queda marcada como manual.
+func (c *CotizacionController) Cargar(ctx *gin.Context) {
+	var req models.CargarCotizacionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	cot, err := c.service.Cargar(ctx.Param("tipo"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Cotización guardada", "cotizacion": cot})
+}
+
+// DELETE /api/alquileres/cotizaciones/:tipo?fecha=2025-03-10
+func (c *CotizacionController) Eliminar(ctx *gin.Context) {
+	if err := c.service.Eliminar(ctx.Param("tipo"), ctx.Query("fecha")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Cotización eliminada"})
+}
+
+// POST /api/alquileres/cotizaciones/recalcular
+// Pasa a pesos los períodos impagos de las propiedades en dólares.
+func (c *CotizacionController) Recalcular(ctx *gin.Context) {
+	res, err := c.service.ActualizarMontosDolares()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error recalculando montos: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentoController maneja los documentos adjuntos (contratos firmados,
+// inventarios, pólizas) de propiedades y contratos.
+type DocumentoController struct {
+	service *services.DocumentoService
+}
+
+func NewDocumentoController() *DocumentoController {
+	return &DocumentoController{service: services.NewDocumentoService()}
+}
+
+// GET /api/alquileres/propiedades/:id/documentos
+func (c *DocumentoController) ListarPorPropiedad(ctx *gin.Context) {
+	docs, err := c.service.ListarPorPropiedad(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"documentos": docs, "total": len(docs)})
+}
+
+// GET /api/alquileres/contratos/:id/documentos
+func (c *DocumentoController) ListarPorContrato(ctx *gin.Context) {
+	docs, err := c.service.ListarPorContrato(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"documentos": docs, "total": len(docs)})
+}
+
+// POST /api/alquileres/propiedades/:id/documentos
+// multipart: archivo, tipo, descripcion, vencimiento, contrato_id
+func (c *DocumentoController) Subir(ctx *gin.Context) {
+	var req models.SubirDocumentoRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	archivo, err := ctx.FormFile("archivo")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe adjuntar el documento en el campo 'archivo'"})
+		return
+	}
+	f, err := archivo.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + err.Error()})
+		return
+	}
+	defer f.Close()
+	datos, err := io.ReadAll(f)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + err.Error()})
+		return
+	}
+
+	doc, err := c.service.Subir(ctx.Param("id"), req, archivo.Filename, datos, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Documento agregado", "documento": doc})
+}
+
+// PUT /api/alquileres/documentos/:id
+func (c *DocumentoController) Actualizar(ctx *gin.Context) {
+	var req models.ActualizarDocumentoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	doc, err := c.service.Actualizar(ctx.Param("id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Documento actualizado", "documento": doc})
+}
+
+// DELETE /api/alquileres/documentos/:id
+func (c *DocumentoController) Eliminar(ctx *gin.Context) {
+	if err := c.service.Eliminar(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Documento eliminado"})
+}
+
+// GET /api/alquileres/documentos/:id/archivo?descargar=1
+// Sin "descargar" se muestra en el navegador (PDF o imagen).
+func (c *DocumentoController) Archivo(ctx *gin.Context) {
+	doc, r, err := c.service.Abrir(ctx.Param("id"))
+	if errors.Is(err, services.ErrArchivoNoEncontrado) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "El archivo del documento no existe"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer r.Close()
+
+	disposicion := "inline"
+	if ctx.Query("descargar") != "" {
+		disposicion = "attachment"
+	}
+	ctx.Header("Content-Type", doc.ContentType)
+	ctx.Header("Content-Length", strconv.FormatInt(doc.Tamanio, 10))
+	ctx.Header("Content-Disposition", mime.FormatMediaType(disposicion, map[string]string{"filename": doc.Nombre}))
+	ctx.Header("Cache-Control", "private, no-cache")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Status(http.StatusOK)
+	io.Copy(ctx.Writer, r)
+}
+
+// GET /api/alquileres/documentos/vencimientos?dias=30
+func (c *DocumentoController) Vencimientos(ctx *gin.Context) {
+	dias, err := strconv.Atoi(ctx.DefaultQuery("dias", "30"))
+	if err != nil || dias < 0 || dias > 365 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "El parámetro 'dias' debe estar entre 0 y 365"})
+		return
+	}
+	docs, err := c.service.Vencimientos(dias)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener vencimientos: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"documentos": docs, "total": len(docs)})
+}
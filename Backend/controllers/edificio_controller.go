@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EdificioController maneja los edificios, sus unidades y los gastos comunes.
+type EdificioController struct {
+	service *services.EdificioService
+}
+
+func NewEdificioController() *EdificioController {
+	return &EdificioController{service: services.NewEdificioService()}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// API Edificios
+// ─────────────────────────────────────────────────────────────────────────────
+
+// GET /api/alquileres/edificios
+func (c *EdificioController) Listar(ctx *gin.Context) {
+	edificios, err := c.service.Listar()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener edificios: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"edificios": edificios, "total": len(edificios)})
+}
+
+// GET /api/alquileres/edificios/:id — ficha con sus unidades
+func (c *EdificioController) GetDetalle(ctx *gin.Context) {
+	detalle, err := c.service.GetDetalle(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, detalle)
+}
+
+// GET /api/alquileres/edificios/:id/resumen?anio=2026
+func (c *EdificioController) GetResumen(ctx *gin.Context) {
+	anio, _ := strconv.Atoi(ctx.Query("anio"))
+	resumen, err := c.service.GetResumen(ctx.Param("id"), anio)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, resumen)
+}
+
+// POST /api/alquileres/edificios
+func (c *EdificioController) Crear(ctx *gin.Context) {
+	var req models.EdificioRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	edificio, err := c.service.Crear(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Edificio creado", "edificio": edificio})
+}
+
+// PUT /api/alquileres/edificios/:id
+func (c *EdificioController) Actualizar(ctx *gin.Context) {
+	var req models.EdificioRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	edificio, err := c.service.Actualizar(ctx.Param("id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Edificio actualizado", "edificio": edificio})
+}
+
+// DELETE /api/alquileres/edificios/:id — las unidades quedan como propiedades independientes
+func (c *EdificioController) Eliminar(ctx *gin.Context) {
+	if err := c.service.Eliminar(ctx.Param("id"), ctx.GetUint("user_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Edificio eliminado"})
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// API Gastos comunes
+// ─────────────────────────────────────────────────────────────────────────────
+
+// GET /api/alquileres/edificios/:id/gastos
+func (c *EdificioController) ListarGastos(ctx *gin.Context) {
+	gastos, err := c.service.ListarGastos(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"gastos": gastos, "total": len(gastos)})
+}
+
+// POST /api/alquileres/edificios/:id/gastos
+// Se prorratea entre las unidades por coeficiente; con "registrar_en_caja"
+// se crea un único Egreso por el total.
+func (c *EdificioController) CrearGasto(ctx *gin.Context) {
+	var req models.CrearGastoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	gasto, err := c.service.CrearGasto(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Gasto común registrado", "gasto": gasto})
+}
+
+// DELETE /api/alquileres/gastos-edificio/:id — solo si ninguna parte se liquidó
+func (c *EdificioController) EliminarGasto(ctx *gin.Context) {
+	if err := c.service.EliminarGasto(ctx.Param("id"), ctx.GetUint("user_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Gasto común eliminado"})
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"caja-fuerte/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HistorialPropiedadController expone el registro de cambios de las propiedades
+// y la historia de su alquiler mensual.
+type HistorialPropiedadController struct {
+	service *services.HistorialPropiedadService
+}
+
+func NewHistorialPropiedadController() *HistorialPropiedadController {
+	return &HistorialPropiedadController{service: services.NewHistorialPropiedadService()}
+}
+
+// GET /api/alquileres/propiedades/:id/historial?campo=alquiler_mensual
+func (c *HistorialPropiedadController) Cambios(ctx *gin.Context) {
+	cambios, err := c.service.Cambios(ctx.Param("id"), ctx.Query("campo"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"cambios": cambios, "total": len(cambios)})
+}
+
+// GET /api/alquileres/propiedades/:id/montos — alquileres con su fecha de vigencia
+func (c *HistorialPropiedadController) Montos(ctx *gin.Context) {
+	montos, err := c.service.Montos(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"montos": montos, "total": len(montos)})
+}
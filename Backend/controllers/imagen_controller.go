@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"caja-fuerte/services"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImagenController sirve y administra las imágenes de las propiedades
+// guardadas en el almacén de archivos.
+type ImagenController struct {
+	service *services.ImagenService
+}
+
+func NewImagenController() *ImagenController {
+	return &ImagenController{service: services.NewImagenService()}
+}
+
+// GET /api/alquileres/imagenes/:id
+// GET /api/alquileres/imagenes/:id/miniatura
+// El contenido de un ID no cambia, así que se puede cachear sin revalidar.
+// El 304 solo se responde si la imagen sigue en el almacén.
+func (c *ImagenController) Servir(ctx *gin.Context) {
+	id := ctx.Param("id")
+	miniatura := ctx.FullPath() == "/api/alquileres/imagenes/:id/miniatura"
+
+	r, info, err := c.service.Abrir(id, miniatura)
+	if errors.Is(err, services.ErrArchivoNoEncontrado) {
+		ctx.Header("Cache-Control", "no-store")
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Imagen no encontrada"})
+		return
+	}
+	if err != nil {
+		ctx.Header("Cache-Control", "no-store")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al leer la imagen: " + err.Error()})
+		return
+	}
+	defer r.Close()
+
+	etag := `"` + id + `"`
+	if miniatura {
+		etag = `"` + id + `-min"`
+	}
+	ctx.Header("Cache-Control", "private, max-age=31536000, immutable")
+	ctx.Header("ETag", etag)
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Length", strconv.FormatInt(info.Tamanio, 10))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Status(http.StatusOK)
+	io.Copy(ctx.Writer, r)
+}
+
+// POST /api/alquileres/propiedades/:id/imagenes  (multipart, campo "imagen")
+func (c *ImagenController) Subir(ctx *gin.Context) {
+	archivo, err := ctx.FormFile("imagen")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe adjuntar la imagen en el campo 'imagen'"})
+		return
+	}
+	f, err := archivo.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + err.Error()})
+		return
+	}
+	defer f.Close()
+	datos, err := io.ReadAll(f)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + err.Error()})
+		return
+	}
+
+	url, err := c.service.Agregar(ctx.Param("id"), datos, archivo.Filename, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Imagen agregada", "url": url, "miniatura": url + "/miniatura"})
+}
+
+// DELETE /api/alquileres/propiedades/:id/imagenes/:imagen
+func (c *ImagenController) Quitar(ctx *gin.Context) {
+	if err := c.service.Quitar(ctx.Param("id"), ctx.Param("imagen"), ctx.GetUint("user_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Imagen eliminada"})
+}
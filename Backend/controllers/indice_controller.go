@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IndiceController expone la copia local de los índices de actualización
+// (IPC, ICL, CER, UVA, CAC): estado, valores, sincronización y carga manual.
+type IndiceController struct {
+	service *services.InflacionService
+}
+
+func NewIndiceController() *IndiceController {
+	return &IndiceController{service: services.NewInflacionService()}
+}
+
+// GET /api/alquileres/indices
+// Índices que se pueden asignar a un contrato, con la frescura de sus datos
+func (c *IndiceController) Listar(ctx *gin.Context) {
+	indices, err := c.service.EstadoIndices()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener índices: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"indices": indices})
+}
+
+// GET /api/alquileres/indices/:codigo/valores?desde=2025-01-01&hasta=2025-12-31
+// Sin rango devuelve los últimos 12 meses.
+func (c *IndiceController) ListarValores(ctx *gin.Context) {
+	hasta := time.Now()
+	desde := hasta.AddDate(-1, 0, 0)
+	if v := ctx.Query("desde"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'desde' inválida (AAAA-MM-DD)"})
+			return
+		}
+		desde = t
+	}
+	if v := ctx.Query("hasta"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'hasta' inválida (AAAA-MM-DD)"})
+			return
+		}
+		hasta = t
+	}
+
+	valores, err := c.service.ListarValores(ctx.Param("codigo"), desde, hasta)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"valores": valores, "total": len(valores)})
+}
+
+// POST /api/alquileres/indices/:codigo/sincronizar
+// Fuerza la actualización de la copia local desde la fuente automática.
+func (c *IndiceController) Sincronizar(ctx *gin.Context) {
+	n, err := c.service.SincronizarIndice(ctx.Param("codigo"))
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo sincronizar el índice: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Índice sincronizado", "valores": n})
+}
+
+// PUT /api/alquileres/indices/:codigo/valores
+// Carga o corrige un valor; queda marcado como manual.
+func (c *IndiceController) CargarValor(ctx *gin.Context) {
+	var req models.CargarValorIndiceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	if err := c.service.CargarValor(ctx.Param("codigo"), req.Fecha, req.Valor, ctx.GetUint("user_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Valor guardado"})
+}
+
+// DELETE /api/alquileres/indices/:codigo/valores?fecha=2025-03-01
+func (c *IndiceController) EliminarValor(ctx *gin.Context) {
+	if err := c.service.EliminarValor(ctx.Param("codigo"), ctx.Query("fecha")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Valor eliminado"})
+}
+
+// POST /api/alquileres/indices/:codigo/importar  (multipart, campo "archivo")
+// CSV con columnas fecha,valor.
+func (c *IndiceController) ImportarCSV(ctx *gin.Context) {
+	archivo, err := ctx.FormFile("archivo")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe adjuntar el archivo CSV en el campo 'archivo'"})
+		return
+	}
+	f, err := archivo.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + err.Error()})
+		return
+	}
+	defer f.Close()
+
+	res, err := c.service.ImportarCSV(ctx.Param("codigo"), f, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificacionController expone la configuración y el registro de avisos a
+// inquilinos (recordatorios de vencimiento, atraso y actualización).
+type NotificacionController struct {
+	service *services.NotificacionService
+}
+
+func NewNotificacionController() *NotificacionController {
+	return &NotificacionController{service: services.NewNotificacionService()}
+}
+
+// GET /api/alquileres/notificaciones/config
+func (c *NotificacionController) GetConfig(ctx *gin.Context) {
+	cfg, err := c.service.GetConfig()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener la configuración: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, cfg)
+}
+
+// PUT /api/alquileres/notificaciones/config
+func (c *NotificacionController) GuardarConfig(ctx *gin.Context) {
+	var req models.ConfigNotificaciones
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	cfg, err := c.service.GuardarConfig(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Configuración de avisos actualizada", "config": cfg})
+}
+
+// GET /api/alquileres/notificaciones?propiedad_id=...&persona_id=...&tipo=aviso_mora&estado=error&limite=100
+// Registro de envíos, del más reciente al más antiguo.
+func (c *NotificacionController) Listar(ctx *gin.Context) {
+	limite, _ := strconv.ParseInt(ctx.Query("limite"), 10, 64)
+	envios, err := c.service.Listar(models.FiltroNotificaciones{
+		PropiedadID: ctx.Query("propiedad_id"),
+		PersonaID:   ctx.Query("persona_id"),
+		Tipo:        ctx.Query("tipo"),
+		Estado:      ctx.Query("estado"),
+		Limite:      limite,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"notificaciones": envios, "total": len(envios)})
+}
+
+// POST /api/alquileres/notificaciones/recordatorios
+// Corre ahora los recordatorios de vencimiento (no repite los ya enviados).
+func (c *NotificacionController) EnviarRecordatorios(ctx *gin.Context) {
+	res, err := c.service.EnviarRecordatorios()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error enviando recordatorios: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OcupacionController expone el historial de ocupación de las propiedades y
+// las métricas de vacancia.
+type OcupacionController struct {
+	service *services.OcupacionService
+}
+
+func NewOcupacionController() *OcupacionController {
+	return &OcupacionController{service: services.NewOcupacionService()}
+}
+
+// GET /api/alquileres/propiedades/:id/ocupacion
+func (c *OcupacionController) Historial(ctx *gin.Context) {
+	h, err := c.service.Historial(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, h)
+}
+
+// GET /api/alquileres/ocupacion?desde=2025-01-01&hasta=2025-12-31
+// Sin "desde" se toma el 1° de enero del año de "hasta" (por defecto, hoy).
+func (c *OcupacionController) Reporte(ctx *gin.Context) {
+	desde, hasta, ok := rangoConciliacion(ctx.Query("desde"), ctx.Query("hasta"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fechas inválidas (AAAA-MM-DD)"})
+		return
+	}
+	if ctx.Query("desde") == "" {
+		desde = time.Date(hasta.AddDate(0, 0, -1).Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+	}
+	rep, err := c.service.Reporte(desde, hasta)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rep)
+}
+
+// PUT /api/alquileres/ocupacion/:id — motivo o alquiler de referencia de un tramo
+func (c *OcupacionController) ActualizarTramo(ctx *gin.Context) {
+	var req models.ActualizarTramoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	tramo, err := c.service.ActualizarTramo(ctx.Param("id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Tramo actualizado", "tramo": tramo})
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonaController struct {
+	service *services.PersonaService
+}
+
+func NewPersonaController() *PersonaController {
+	return &PersonaController{
+		service: services.NewPersonaService(),
+	}
+}
+
+// GET /api/alquileres/personas?busqueda=perez&rol=inquilino|garante
+func (c *PersonaController) Listar(ctx *gin.Context) {
+	rol := ctx.Query("rol")
+	if rol != "" && rol != models.RolInquilino && rol != models.RolGarante {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Rol inválido (inquilino o garante)"})
+		return
+	}
+
+	personas, err := c.service.Listar(ctx.Query("busqueda"), rol)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener personas: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"personas": personas, "total": len(personas)})
+}
+
+// GET /api/alquileres/personas/:id — ficha con contratos e historial de pagos
+func (c *PersonaController) GetDetalle(ctx *gin.Context) {
+	detalle, err := c.service.GetDetalle(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, detalle)
+}
+
+// POST /api/alquileres/personas
+func (c *PersonaController) Crear(ctx *gin.Context) {
+	var req models.PersonaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	persona, err := c.service.Crear(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Persona creada", "persona": persona})
+}
+
+// PUT /api/alquileres/personas/:id
+func (c *PersonaController) Actualizar(ctx *gin.Context) {
+	var req models.PersonaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	persona, err := c.service.Actualizar(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Persona actualizada", "persona": persona})
+}
+
+// DELETE /api/alquileres/personas/:id
+func (c *PersonaController) Eliminar(ctx *gin.Context) {
+	if err := c.service.Eliminar(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Persona eliminada"})
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PropietarioController struct {
+	service       *services.PropietarioService
+	gastos        *services.GastoService
+	liquidaciones *services.LiquidacionService
+}
+
+func NewPropietarioController() *PropietarioController {
+	return &PropietarioController{
+		service:       services.NewPropietarioService(),
+		gastos:        services.NewGastoService(),
+		liquidaciones: services.NewLiquidacionService(),
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// API Propietarios
+// ─────────────────────────────────────────────────────────────────────────────
+
+// GET /api/alquileres/propietarios?busqueda=garcia
+func (c *PropietarioController) Listar(ctx *gin.Context) {
+	propietarios, err := c.service.Listar(ctx.Query("busqueda"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener propietarios: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"propietarios": propietarios, "total": len(propietarios)})
+}
+
+// GET /api/alquileres/propietarios/:id — ficha con sus propiedades
+func (c *PropietarioController) GetDetalle(ctx *gin.Context) {
+	detalle, err := c.service.GetDetalle(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, detalle)
+}
+
+// POST /api/alquileres/propietarios
+func (c *PropietarioController) Crear(ctx *gin.Context) {
+	var req models.PropietarioRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	propietario, err := c.service.Crear(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Propietario creado", "propietario": propietario})
+}
+
+// PUT /api/alquileres/propietarios/:id
+func (c *PropietarioController) Actualizar(ctx *gin.Context) {
+	var req models.PropietarioRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	propietario, err := c.service.Actualizar(ctx.Param("id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Propietario actualizado", "propietario": propietario})
+}
+
+// DELETE /api/alquileres/propietarios/:id
+func (c *PropietarioController) Eliminar(ctx *gin.Context) {
+	if err := c.service.Eliminar(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Propietario eliminado"})
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// API Gastos de propiedades
+// ─────────────────────────────────────────────────────────────────────────────
+
+// GET /api/alquileres/propiedades/:id/gastos
+func (c *PropietarioController) ListarGastos(ctx *gin.Context) {
+	gastos, err := c.gastos.Listar(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"gastos": gastos, "total": len(gastos)})
+}
+
+// POST /api/alquileres/propiedades/:id/gastos
+// Body: {"categoria":"reparacion|abl|expensas|impuestos|servicios|seguro|otro","descripcion","monto",
+// "fecha","a_cargo_de":"propietario|inquilino|administracion","registrar_en_caja" | "movement_id"}
+func (c *PropietarioController) CrearGasto(ctx *gin.Context) {
+	var req models.CrearGastoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	gasto, err := c.gastos.Crear(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Gasto registrado", "gasto": gasto})
+}
+
+// PUT /api/alquileres/gastos/:id — solo gastos no liquidados
+func (c *PropietarioController) ActualizarGasto(ctx *gin.Context) {
+	var req models.ActualizarGastoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	gasto, err := c.gastos.Actualizar(ctx.Param("id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Gasto actualizado", "gasto": gasto})
+}
+
+// DELETE /api/alquileres/gastos/:id
+func (c *PropietarioController) EliminarGasto(ctx *gin.Context) {
+	if err := c.gastos.Eliminar(ctx.Param("id"), ctx.GetUint("user_id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Gasto eliminado"})
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// API Liquidaciones a propietarios
+// ─────────────────────────────────────────────────────────────────────────────
+
+// GET /api/alquileres/propietarios/:id/liquidacion?anio=2026&mes=2 — vista previa
+// (sin anio/mes: mes anterior)
+func (c *PropietarioController) PrevisualizarLiquidacion(ctx *gin.Context) {
+	anterior := time.Now().AddDate(0, -1, 0)
+	anio, mes := anterior.Year(), int(anterior.Month())-1
+	if v := ctx.Query("anio"); v != "" {
+		anio, _ = strconv.Atoi(v)
+	}
+	if v := ctx.Query("mes"); v != "" {
+		m, err := strconv.Atoi(v)
+		if err != nil || m < 0 || m > 11 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mes inválido"})
+			return
+		}
+		mes = m
+	}
+
+	liq, err := c.liquidaciones.Previsualizar(ctx.Param("id"), anio, mes)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, liq)
+}
+
+// GET /api/alquileres/propietarios/:id/liquidaciones
+func (c *PropietarioController) ListarLiquidaciones(ctx *gin.Context) {
+	liquidaciones, err := c.liquidaciones.Listar(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"liquidaciones": liquidaciones, "total": len(liquidaciones)})
+}
+
+// POST /api/alquileres/propietarios/:id/liquidaciones
+// Body: {"anio":2026,"mes":2}
+func (c *PropietarioController) GenerarLiquidacion(ctx *gin.Context) {
+	var req models.GenerarLiquidacionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	liq, err := c.liquidaciones.Generar(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Liquidación generada", "liquidacion": liq})
+}
+
+// GET /api/alquileres/liquidaciones/:id
+func (c *PropietarioController) GetLiquidacion(ctx *gin.Context) {
+	liq, err := c.liquidaciones.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, liq)
+}
+
+// GET /api/alquileres/liquidaciones/:id/pdf — resumen para el propietario
+func (c *PropietarioController) DescargarLiquidacion(ctx *gin.Context) {
+	nombre, datos, err := c.liquidaciones.GenerarPDF(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nombre))
+	ctx.Data(http.StatusOK, "application/pdf", datos)
+}
+
+// POST /api/alquileres/liquidaciones/:id/pagar — registra el Egreso en caja
+func (c *PropietarioController) PagarLiquidacion(ctx *gin.Context) {
+	liq, err := c.liquidaciones.Pagar(ctx.Param("id"), ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Liquidación pagada", "liquidacion": liq})
+}
+
+// POST /api/alquileres/liquidaciones/:id/anular
+func (c *PropietarioController) AnularLiquidacion(ctx *gin.Context) {
+	liq, err := c.liquidaciones.Anular(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Liquidación anulada", "liquidacion": liq})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReciboController struct {
+	service *services.ReciboService
+}
+
+func NewReciboController() *ReciboController {
+	return &ReciboController{
+		service: services.NewReciboService(),
+	}
+}
+
+// GET /api/alquileres/recibos?propiedad_id=&inquilino_id=&estado=emitido|anulado&desde=2026-01-01&hasta=2026-01-31
+func (c *ReciboController) Listar(ctx *gin.Context) {
+	filtro := models.FiltroRecibos{
+		PropiedadID: ctx.Query("propiedad_id"),
+		InquilinoID: ctx.Query("inquilino_id"),
+		Estado:      ctx.Query("estado"),
+	}
+	if v := ctx.Query("desde"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha desde inválida (use AAAA-MM-DD)"})
+			return
+		}
+		filtro.Desde = &t
+	}
+	if v := ctx.Query("hasta"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha hasta inválida (use AAAA-MM-DD)"})
+			return
+		}
+		// hasta es inclusivo
+		t = t.AddDate(0, 0, 1)
+		filtro.Hasta = &t
+	}
+
+	recibos, err := c.service.Listar(filtro)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"recibos": recibos, "total": len(recibos)})
+}
+
+// GET /api/alquileres/recibos/:id
+func (c *ReciboController) GetByID(ctx *gin.Context) {
+	rec, err := c.service.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rec)
+}
+
+// GET /api/alquileres/recibos/:id/pdf — descarga del recibo (no cuenta como reimpresión)
+func (c *ReciboController) DescargarPDF(ctx *gin.Context) {
+	nombre, datos, err := c.service.GenerarPDF(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nombre))
+	ctx.Data(http.StatusOK, "application/pdf", datos)
+}
+
+// POST /api/alquileres/recibos/:id/reimprimir — registra una reimpresión; el
+// PDF se descarga después por GET /pdf (sale como DUPLICADO)
+func (c *ReciboController) Reimprimir(ctx *gin.Context) {
+	rec, err := c.service.Reimprimir(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reimpresión registrada", "recibo": rec})
+}
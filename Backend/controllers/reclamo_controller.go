@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReclamoController maneja los reclamos de mantenimiento de las propiedades.
+type ReclamoController struct {
+	service *services.ReclamoService
+}
+
+func NewReclamoController() *ReclamoController {
+	return &ReclamoController{service: services.NewReclamoService()}
+}
+
+// GET /api/alquileres/reclamos?estado=pendientes&prioridad=urgente
+// Sin estado devuelve los pendientes (abiertos, asignados y en curso);
+// estado=todos incluye también los resueltos, cerrados y cancelados.
+func (c *ReclamoController) Listar(ctx *gin.Context) {
+	estado := ctx.DefaultQuery("estado", "pendientes")
+	if estado == "todos" {
+		estado = ""
+	}
+	reclamos, err := c.service.Listar(ctx.Query("propiedad_id"), estado, ctx.Query("prioridad"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"reclamos": reclamos, "total": len(reclamos)})
+}
+
+// GET /api/alquileres/propiedades/:id/reclamos?estado=pendientes
+func (c *ReclamoController) ListarPorPropiedad(ctx *gin.Context) {
+	reclamos, err := c.service.Listar(ctx.Param("id"), ctx.Query("estado"), ctx.Query("prioridad"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"reclamos": reclamos, "total": len(reclamos)})
+}
+
+// GET /api/alquileres/reclamos/:id
+func (c *ReclamoController) GetByID(ctx *gin.Context) {
+	reclamo, err := c.service.GetByID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, reclamo)
+}
+
+// POST /api/alquileres/propiedades/:id/reclamos
+func (c *ReclamoController) Crear(ctx *gin.Context) {
+	var req models.CrearReclamoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	reclamo, err := c.service.Crear(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Reclamo registrado", "reclamo": reclamo})
+}
+
+// PUT /api/alquileres/reclamos/:id
+func (c *ReclamoController) Actualizar(ctx *gin.Context) {
+	var req models.ActualizarReclamoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	reclamo, err := c.service.Actualizar(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reclamo actualizado", "reclamo": reclamo})
+}
+
+// POST /api/alquileres/reclamos/:id/estado
+func (c *ReclamoController) CambiarEstado(ctx *gin.Context) {
+	var req models.CambiarEstadoReclamoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	reclamo, err := c.service.CambiarEstado(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Estado actualizado", "reclamo": reclamo})
+}
+
+// DELETE /api/alquileres/reclamos/:id — solo sin pago registrado
+func (c *ReclamoController) Eliminar(ctx *gin.Context) {
+	if err := c.service.Eliminar(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reclamo eliminado"})
+}
+
+// POST /api/alquileres/reclamos/:id/pago — registra el arreglo como gasto de la propiedad
+func (c *ReclamoController) Pagar(ctx *gin.Context) {
+	var req models.PagarReclamoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+	reclamo, err := c.service.Pagar(ctx.Param("id"), req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Pago registrado", "reclamo": reclamo})
+}
+
+// DELETE /api/alquileres/reclamos/:id/pago
+func (c *ReclamoController) AnularPago(ctx *gin.Context) {
+	reclamo, err := c.service.AnularPago(ctx.Param("id"), ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Pago anulado", "reclamo": reclamo})
+}
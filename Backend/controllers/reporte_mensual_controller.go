@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"caja-fuerte/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReporteController struct {
+	service    *services.ReporteService
+	proyeccion *services.ProyeccionService
+}
+
+func NewReporteController() *ReporteController {
+	return &ReporteController{
+		service:    services.NewReporteService(),
+		proyeccion: services.NewProyeccionService(),
+	}
+}
+
+// GET /api/reportes/mensual?desde=2024-01&hasta=2025-12&base=2025-12&concepto_id=3
+// Con base=AAAA-MM agrega las cifras en pesos reales (constantes de ese mes).
+func (c *ReporteController) GetResumenMensual(ctx *gin.Context) {
+	now := time.Now()
+	hasta := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	desde := hasta.AddDate(0, -11, 0)
+
+	if v := ctx.Query("desde"); v != "" {
+		t, err := time.Parse("2006-01", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mes 'desde' inválido (use AAAA-MM)"})
+			return
+		}
+		desde = t
+	}
+	if v := ctx.Query("hasta"); v != "" {
+		t, err := time.Parse("2006-01", v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mes 'hasta' inválido (use AAAA-MM)"})
+			return
+		}
+		hasta = t
+	}
+
+	base, ok := parseMesBase(ctx)
+	if !ok {
+		return
+	}
+
+	var conceptID uint
+	if v := ctx.Query("concepto_id"); v != "" {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			conceptID = uint(id)
+		}
+	}
+
+	reporte, err := c.service.GetResumenMensual(desde, hasta, base, conceptID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, reporte)
+}
+
+// GET /api/reportes/interanual?anio=2025&base=2025-06
+// Compara cada mes con el mismo mes del año anterior en pesos nominales y reales.
+func (c *ReporteController) GetComparativoInteranual(ctx *gin.Context) {
+	anio := time.Now().Year()
+	if v := ctx.Query("anio"); v != "" {
+		a, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Año inválido"})
+			return
+		}
+		anio = a
+	}
+
+	base, ok := parseMesBase(ctx)
+	if !ok {
+		return
+	}
+
+	comp, err := c.service.GetComparativoInteranual(anio, base)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al generar comparativo: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, comp)
+}
+
+// GET /api/reportes/cajeros?desde=2025-01-01&hasta=2025-06-30&user_id=4
+// Faltantes/sobrantes, duración de arcos, cierres automáticos y movimientos
+// eliminados por usuario, con la tendencia mes a mes. Por defecto, últimos 6 meses.
+func (c *ReporteController) GetDesempenoCajeros(ctx *gin.Context) {
+	hasta := time.Now()
+	if v := ctx.Query("hasta"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'hasta' inválida (use AAAA-MM-DD)"})
+			return
+		}
+		hasta = t
+	}
+
+	desde := time.Date(hasta.Year(), hasta.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -5, 0)
+	if v := ctx.Query("desde"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha 'desde' inválida (use AAAA-MM-DD)"})
+			return
+		}
+		desde = t
+	}
+
+	var userID uint
+	if v := ctx.Query("user_id"); v != "" {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			userID = uint(id)
+		}
+	}
+
+	reporte, err := c.service.GetDesempenoCajeros(desde, hasta, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, reporte)
+}
+
+// GET /api/reportes/proyeccion?meses=12&historia=6
+// Proyecta ingresos (alquileres ponderados por historial de pago y otros ingresos)
+// y egresos por concepto para los próximos meses, con el saldo acumulado.
+func (c *ReporteController) GetProyeccion(ctx *gin.Context) {
+	meses := 12
+	if v := ctx.Query("meses"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Cantidad de meses inválida"})
+			return
+		}
+		meses = n
+	}
+	historia := 6
+	if v := ctx.Query("historia"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Meses de historia inválidos"})
+			return
+		}
+		historia = n
+	}
+
+	proy, err := c.proyeccion.GetProyeccion(meses, historia)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, proy)
+}
+
+// POST /api/reportes/ipc/sincronizar
+// Fuerza la actualización de la copia local del IPC.
+func (c *ReporteController) SincronizarIPC(ctx *gin.Context) {
+	n, err := services.NewInflacionService().SincronizarIPC()
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "No se pudo sincronizar el IPC: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Serie IPC sincronizada",
+		"meses":   n,
+	})
+}
+
+// parseMesBase lee el parámetro opcional ?base=AAAA-MM. Si es inválido responde
+// 400 y devuelve ok=false.
+func parseMesBase(ctx *gin.Context) (*time.Time, bool) {
+	v := ctx.Query("base")
+	if v == "" {
+		return nil, true
+	}
+	t, err := time.Parse("2006-01", v)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Mes base inválido (use AAAA-MM)"})
+		return nil, false
+	}
+	return &t, true
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReporteProgramadoController struct {
+	service *services.ReporteProgramadoService
+}
+
+func NewReporteProgramadoController() *ReporteProgramadoController {
+	return &ReporteProgramadoController{
+		service: services.NewReporteProgramadoService(),
+	}
+}
+
+// GET /api/reportes/programados
+func (c *ReporteProgramadoController) Listar(ctx *gin.Context) {
+	programaciones, err := c.service.Listar()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener reportes programados"})
+		return
+	}
+	ctx.JSON(http.StatusOK, programaciones)
+}
+
+// POST /api/reportes/programados
+// Body: {"nombre","reporte":"cierre_diario|cobranza_alquileres","formato":"pdf|xlsx",
+// "cron":"0 22 * * *","periodo":"actual|anterior","destinatarios":["a@b.com"]}
+func (c *ReporteProgramadoController) Crear(ctx *gin.Context) {
+	var req models.ProgramacionReporteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	p, err := c.service.Crear(req, ctx.GetUint("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Reporte programado creado", "programacion": p})
+}
+
+// PUT /api/reportes/programados/:id
+func (c *ReporteProgramadoController) Actualizar(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	var req models.ProgramacionReporteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	p, err := c.service.Actualizar(uint(id), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reporte programado actualizado", "programacion": p})
+}
+
+// DELETE /api/reportes/programados/:id
+func (c *ReporteProgramadoController) Eliminar(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	if err := c.service.Eliminar(uint(id)); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Reporte programado eliminado"})
+}
+
+// POST /api/reportes/programados/:id/enviar — ejecuta el envío en el momento.
+func (c *ReporteProgramadoController) EnviarAhora(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	envio, err := c.service.EnviarAhora(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Envío procesado", "envio": envio})
+}
+
+// GET /api/reportes/envios?programacion_id=3&limite=50
+func (c *ReporteProgramadoController) GetEnvios(ctx *gin.Context) {
+	var programacionID uint
+	if v := ctx.Query("programacion_id"); v != "" {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			programacionID = uint(id)
+		}
+	}
+	limite, _ := strconv.Atoi(ctx.Query("limite"))
+
+	envios, err := c.service.GetEnvios(programacionID, limite)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener envíos"})
+		return
+	}
+	ctx.JSON(http.StatusOK, envios)
+}
+
+// POST /api/reportes/envios/:id/reintentar
+func (c *ReporteProgramadoController) ReintentarEnvio(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+
+	envio, err := c.service.ReintentarEnvio(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Envío procesado", "envio": envio})
+}
+
+// GET /api/reportes/descargar?reporte=cierre_diario&formato=pdf&fecha=2025-03-10
+// Para cobranza_alquileres la fecha puede ser AAAA-MM.
+func (c *ReporteProgramadoController) Descargar(ctx *gin.Context) {
+	reporte := ctx.Query("reporte")
+	formato := ctx.DefaultQuery("formato", "pdf")
+
+	fecha := time.Now()
+	if v := ctx.Query("fecha"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01", v, time.Local)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida (use AAAA-MM-DD o AAAA-MM)"})
+			return
+		}
+		fecha = t
+	}
+
+	nombre, contentType, datos, err := c.service.GenerarArchivo(reporte, formato, fecha)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", nombre))
+	ctx.Data(http.StatusOK, contentType, datos)
+}
@@ -179,6 +179,12 @@ func runMigrations(db *gorm.DB) error {
 		&models.Movement{},
 		&models.SpecificIncome{},
 		&models.SpecificExpense{},
+		&models.SaldoGlobalDiario{},
+		&models.IPCMensual{},
+		&models.IndiceValor{},
+		&models.Cotizacion{},
+		&models.ProgramacionReporte{},
+		&models.EnvioReporte{},
 	}
 
 	log.Println("Ejecutando migraciones...")
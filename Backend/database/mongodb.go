@@ -17,6 +17,25 @@ var MongoDB *mongo.Database
 var MongoClient *mongo.Client
 
 const CollectionPropiedades = "propiedades"
+const CollectionPeriodosAlquiler = "periodos_alquiler"
+const CollectionContratos = "contratos"
+const CollectionConfiguracionAlquileres = "configuracion_alquileres"
+const CollectionPersonas = "personas"
+const CollectionRecibos = "recibos"
+const CollectionContadores = "contadores"
+const CollectionPropietarios = "propietarios"
+const CollectionGastosPropiedad = "gastos_propiedad"
+const CollectionLiquidaciones = "liquidaciones_propietario"
+const CollectionHistorialEstados = "historial_estados_periodo"
+const CollectionNotificaciones = "notificaciones"
+const CollectionDocumentos = "documentos"
+const CollectionOperacionesCaja = "operaciones_caja"
+const CollectionEdificios = "edificios"
+const CollectionGastosEdificio = "gastos_edificio"
+const CollectionHistorialOcupacion = "historial_ocupacion"
+const CollectionReclamos = "reclamos"
+const CollectionHistorialPropiedades = "historial_propiedades"
+const CollectionHistorialMontos = "historial_montos"
 
 // InitMongoDB inicializa la conexión a MongoDB
 func InitMongoDB() {
@@ -92,6 +111,295 @@ func createMongoIndexes() {
 	} else {
 		utils.Logger.Info("Índices de MongoDB creados/verificados")
 	}
+
+	// Libro de períodos: un documento por propiedad + año + mes
+	periodos := MongoDB.Collection(CollectionPeriodosAlquiler)
+	_, err = periodos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "anio", Value: 1}, {Key: "mes", Value: 1}},
+			Options: options.Index().SetName("idx_periodo_unico").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "estado", Value: 1}, {Key: "anio", Value: 1}, {Key: "mes", Value: 1}},
+			Options: options.Index().SetName("idx_periodo_estado"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de períodos de alquiler", zap.Error(err))
+	}
+
+	// Contratos: historial por propiedad y un único contrato vigente por propiedad
+	contratos := MongoDB.Collection(CollectionContratos)
+	_, err = contratos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "fecha_inicio", Value: -1}},
+			Options: options.Index().SetName("idx_contrato_propiedad"),
+		},
+		{
+			Keys: bson.D{{Key: "propiedad_id", Value: 1}},
+			Options: options.Index().SetName("idx_contrato_vigente_unico").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"estado": "vigente"}),
+		},
+		{
+			Keys:    bson.D{{Key: "estado", Value: 1}, {Key: "fecha_fin", Value: 1}},
+			Options: options.Index().SetName("idx_contrato_estado"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de contratos", zap.Error(err))
+	}
+	_, err = contratos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "inquilino_id", Value: 1}},
+			Options: options.Index().SetName("idx_contrato_inquilino"),
+		},
+		{
+			Keys:    bson.D{{Key: "garantes_ids", Value: 1}},
+			Options: options.Index().SetName("idx_contrato_garantes"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de contratos por persona", zap.Error(err))
+	}
+
+	// Padrón de inquilinos y garantes: documento único cuando se cargó
+	personas := MongoDB.Collection(CollectionPersonas)
+	_, err = personas.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "documento", Value: 1}},
+			Options: options.Index().SetName("idx_persona_documento").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"documento": bson.M{"$gt": ""}}),
+		},
+		{
+			Keys:    bson.D{{Key: "nombre", Value: 1}},
+			Options: options.Index().SetName("idx_persona_nombre"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de personas", zap.Error(err))
+	}
+
+	// Recibos: el número es único dentro de cada punto de venta
+	recibos := MongoDB.Collection(CollectionRecibos)
+	_, err = recibos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "punto_venta", Value: 1}, {Key: "numero", Value: 1}},
+			Options: options.Index().SetName("idx_recibo_numero").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "fecha_emision", Value: -1}},
+			Options: options.Index().SetName("idx_recibo_propiedad"),
+		},
+		{
+			Keys:    bson.D{{Key: "cobro_id", Value: 1}},
+			Options: options.Index().SetName("idx_recibo_cobro"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de recibos", zap.Error(err))
+	}
+
+	// Propiedades administradas por cuenta de terceros
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "propietario_id", Value: 1}},
+		Options: options.Index().SetName("idx_propiedad_propietario"),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índice de propietario en propiedades", zap.Error(err))
+	}
+	propietarios := MongoDB.Collection(CollectionPropietarios)
+	_, err = propietarios.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "documento", Value: 1}},
+		Options: options.Index().SetName("idx_propietario_documento").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"documento": bson.M{"$gt": ""}}),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de propietarios", zap.Error(err))
+	}
+
+	// Gastos de propiedades y liquidaciones a propietarios
+	gastos := MongoDB.Collection(CollectionGastosPropiedad)
+	_, err = gastos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "fecha", Value: -1}},
+			Options: options.Index().SetName("idx_gasto_propiedad"),
+		},
+		{
+			Keys:    bson.D{{Key: "propietario_id", Value: 1}, {Key: "liquidacion_id", Value: 1}},
+			Options: options.Index().SetName("idx_gasto_liquidacion"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de gastos", zap.Error(err))
+	}
+	liquidaciones := MongoDB.Collection(CollectionLiquidaciones)
+	_, err = liquidaciones.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "propietario_id", Value: 1}, {Key: "anio", Value: -1}, {Key: "mes", Value: -1}},
+			Options: options.Index().SetName("idx_liquidacion_propietario"),
+		},
+		{
+			Keys:    bson.D{{Key: "items.cobro_id", Value: 1}},
+			Options: options.Index().SetName("idx_liquidacion_cobro"),
+		},
+		{
+			// Una sola liquidación pendiente de pago por propietario
+			Keys: bson.D{{Key: "propietario_id", Value: 1}},
+			Options: options.Index().SetName("idx_liquidacion_pendiente_unica").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"estado": "pendiente"}),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de liquidaciones", zap.Error(err))
+	}
+
+	historial := MongoDB.Collection(CollectionHistorialEstados)
+	_, err = historial.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "periodo_id", Value: 1}, {Key: "fecha", Value: 1}},
+			Options: options.Index().SetName("idx_historial_periodo"),
+		},
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "fecha", Value: -1}},
+			Options: options.Index().SetName("idx_historial_propiedad"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices del historial de estados", zap.Error(err))
+	}
+
+	notificaciones := MongoDB.Collection(CollectionNotificaciones)
+	// La clave pasó a ser única (un registro por aviso y canal): se reemplaza el
+	// índice anterior
+	if specs, err := notificaciones.Indexes().ListSpecifications(ctx); err == nil {
+		for _, spec := range specs {
+			if spec.Name == "idx_notificacion_clave" && (spec.Unique == nil || !*spec.Unique) {
+				if _, err := notificaciones.Indexes().DropOne(ctx, spec.Name); err != nil {
+					utils.Logger.Warn("Error al reemplazar el índice de claves de avisos", zap.Error(err))
+				}
+			}
+		}
+	}
+	_, err = notificaciones.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "clave", Value: 1}},
+		Options: options.Index().SetName("idx_notificacion_clave").SetUnique(true),
+	})
+	if err != nil {
+		// Falla si quedaron registros repetidos de antes de la cola de reintentos
+		utils.Logger.Warn("Error al crear el índice único de claves de avisos", zap.Error(err))
+	}
+	_, err = notificaciones.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "fecha", Value: -1}},
+			Options: options.Index().SetName("idx_notificacion_propiedad"),
+		},
+		{
+			Keys:    bson.D{{Key: "persona_id", Value: 1}, {Key: "fecha", Value: -1}},
+			Options: options.Index().SetName("idx_notificacion_persona"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de notificaciones", zap.Error(err))
+	}
+
+	documentos := MongoDB.Collection(CollectionDocumentos)
+	_, err = documentos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_documento_propiedad"),
+		},
+		{
+			Keys:    bson.D{{Key: "contrato_id", Value: 1}},
+			Options: options.Index().SetName("idx_documento_contrato").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "vencimiento", Value: 1}},
+			Options: options.Index().SetName("idx_documento_vencimiento").SetSparse(true),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de documentos", zap.Error(err))
+	}
+
+	operaciones := MongoDB.Collection(CollectionOperacionesCaja)
+	_, err = operaciones.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "estado", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("idx_operacion_caja_estado"),
+		},
+		{
+			Keys:    bson.D{{Key: "cobro_id", Value: 1}},
+			Options: options.Index().SetName("idx_operacion_caja_cobro").SetSparse(true),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de operaciones de caja", zap.Error(err))
+	}
+
+	// Edificios: unidades y gastos comunes
+	_, err = coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "edificio_id", Value: 1}},
+		Options: options.Index().SetName("idx_propiedad_edificio").SetSparse(true),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índice de edificio en propiedades", zap.Error(err))
+	}
+	gastosEdificio := MongoDB.Collection(CollectionGastosEdificio)
+	_, err = gastosEdificio.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "edificio_id", Value: 1}, {Key: "fecha", Value: -1}},
+		Options: options.Index().SetName("idx_gasto_edificio"),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de gastos de edificio", zap.Error(err))
+	}
+	_, err = gastos.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "gasto_edificio_id", Value: 1}},
+		Options: options.Index().SetName("idx_gasto_parte_edificio").SetSparse(true),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índice de gastos comunes", zap.Error(err))
+	}
+
+	ocupacion := MongoDB.Collection(CollectionHistorialOcupacion)
+	_, err = ocupacion.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "desde", Value: 1}},
+		Options: options.Index().SetName("idx_ocupacion_propiedad"),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices del historial de ocupación", zap.Error(err))
+	}
+
+	reclamos := MongoDB.Collection(CollectionReclamos)
+	_, err = reclamos.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_reclamo_propiedad"),
+		},
+		{
+			Keys:    bson.D{{Key: "estado", Value: 1}},
+			Options: options.Index().SetName("idx_reclamo_estado"),
+		},
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de reclamos", zap.Error(err))
+	}
+
+	cambiosPropiedad := MongoDB.Collection(CollectionHistorialPropiedades)
+	_, err = cambiosPropiedad.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "fecha", Value: -1}},
+		Options: options.Index().SetName("idx_historial_propiedad"),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices del historial de propiedades", zap.Error(err))
+	}
+	montos := MongoDB.Collection(CollectionHistorialMontos)
+	_, err = montos.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "propiedad_id", Value: 1}, {Key: "vigente_desde", Value: 1}},
+		Options: options.Index().SetName("idx_monto_propiedad"),
+	})
+	if err != nil {
+		utils.Logger.Warn("Error al crear índices de la historia de alquileres", zap.Error(err))
+	}
 }
 
 // CloseMongoDB cierra la conexión a MongoDB de forma segura
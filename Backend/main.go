@@ -47,11 +47,33 @@ func main() {
 	database.InitDB()
 	utils.Logger.Info("Base de datos MySQL inicializada correctamente")
 
-	// 4b. Inicializar MongoDB (alquileres)
+	// 4a. Snapshots diarios de la caja global
+	cajaGlobalService := services.NewCajaGlobalService()
+	cajaGlobalService.Start()
+	defer cajaGlobalService.Stop()
+
+	// 4b. Copia local de la serie IPC (reportes en pesos reales)
+	inflacionService := services.NewInflacionService()
+	inflacionService.Start()
+	defer inflacionService.Stop()
+
+	// 4c. Inicializar MongoDB (alquileres)
 	database.InitMongoDB()
 	utils.Logger.Info("MongoDB inicializado correctamente")
 	defer database.CloseMongoDB()
 
+	// 4d. Programador de tareas, libro de períodos de alquiler y envío de reportes
+	// (después de Mongo: el reporte de cobranza lee las propiedades)
+	scheduler := services.GetScheduler()
+	services.NewAlquilerService().Start()
+	services.NewMoraService().Start()
+	services.NewNotificacionService().Start()
+	services.NewCotizacionService().Start()
+	services.NewOperacionCajaService().Start()
+	services.NewReporteProgramadoService().Start()
+	scheduler.Start()
+	defer scheduler.Stop()
+
 	// 5. Configurar rutas con todos los middlewares de seguridad
 	router := routes.SetupRoutes(cfg)
 
@@ -38,6 +38,9 @@ const (
 	PermOpenGlobalArco Permission = "arco:open:global" // NUEVO: Arco global
 	PermViewGlobalCaja Permission = "arco:view:global" // NUEVO: Ver caja global
 
+	// Regenerar los snapshots diarios de la caja global
+	PermManageGlobalCaja Permission = "arco:manage:global"
+
 	// Permisos administrativos
 	PermManageUsers    Permission = "admin:users"
 	PermManageRoles    Permission = "admin:roles"
@@ -45,6 +48,7 @@ const (
 	PermViewReports    Permission = "admin:reports"
 	PermViewOwnReports Permission = "admin:reports:own" // NUEVO: Solo sus reportes
 	PermViewAllReports Permission = "admin:reports:all" // NUEVO: Todos los reportes
+	PermManageReports  Permission = "admin:reports:manage"
 	PermManageBackups  Permission = "admin:backups"
 	PermManageSecrets  Permission = "admin:secrets"
 
@@ -96,12 +100,14 @@ var rolePermissionsMap = map[string][]Permission{
 		PermCloseArco,
 		PermReadArco,
 		PermViewGlobalCaja,   // Ver caja global
+		PermManageGlobalCaja, // Regenerar snapshots de la caja global
 		PermManageUsers,      // Crear/editar/eliminar usuarios
 		PermManageRoles,      // Crear/editar/eliminar roles
 		PermManageConcepts,   // Crear/editar/eliminar conceptos
 		PermViewReports,
 		PermViewOwnReports,
 		PermViewAllReports,   // Ver TODOS los reportes
+		PermManageReports,    // Sincronizar índices y programar reportes
 		PermManageBackups,
 		PermManageSecrets,
 		PermViewLogs,
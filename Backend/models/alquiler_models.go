@@ -16,13 +16,21 @@ const (
 	Atraso2Estado   EstadoPago = "late_2"
 )
 
-// PagoMes representa el estado de pago de un mes específico
+// PagoMes representa el estado de pago de un mes específico.
+// Es la vista de 12 meses de un año que se arma a partir de PeriodoAlquiler.
 type PagoMes struct {
 	Mes        int        `bson:"mes" json:"mes"`
 	Estado     EstadoPago `bson:"estado" json:"estado"`
 	Monto      float64    `bson:"monto" json:"monto"`
 	FechaPago  *time.Time `bson:"fecha_pago,omitempty" json:"fecha_pago,omitempty"`
 	MovementID *uint      `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+
+	ContratoID *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+
+	// Cobrado es lo cobrado en el mes (alquiler + punitorios) y Saldo lo que falta
+	Cobrado       float64 `bson:"-" json:"cobrado"`
+	Saldo         float64 `bson:"-" json:"saldo"`
+	CantidadPagos int     `bson:"-" json:"cantidad_pagos"`
 }
 
 // Propiedad es el documento principal almacenado en MongoDB.
@@ -33,6 +41,13 @@ type Propiedad struct {
 	AlquilerMensual float64            `bson:"alquiler_mensual" json:"alquiler_mensual"`
 	Ocupada         bool               `bson:"ocupada" json:"ocupada"`
 
+	// ContratoVigenteID apunta al contrato en curso. Inquilino, AlquilerMensual y
+	// los datos de actualización son una copia de ese contrato (ver Contrato).
+	ContratoVigenteID *primitive.ObjectID `bson:"contrato_vigente_id,omitempty" json:"contrato_vigente_id,omitempty"`
+	// PropietarioID es el dueño cuando la propiedad se administra por cuenta de
+	// terceros (nil = propiedad propia)
+	PropietarioID *primitive.ObjectID `bson:"propietario_id,omitempty" json:"propietario_id,omitempty"`
+
 	// ── Modo pesos (contrato con actualización) ──────────────────────────────
 	IndiceInflacion         float64    `bson:"indice_inflacion" json:"indice_inflacion"`
 	FechaActualizacion      *time.Time `bson:"fecha_actualizacion,omitempty" json:"fecha_actualizacion,omitempty"`
@@ -40,35 +55,205 @@ type Propiedad struct {
 	FrecuenciaActualizacion int        `bson:"frecuencia_actualizacion" json:"frecuencia_actualizacion"`
 	// PosponerHasta: si el gestor pospone la notificación, no se muestra hasta esta fecha
 	PosponerHasta           *time.Time `bson:"posponer_hasta,omitempty" json:"posponer_hasta,omitempty"`
+	// TipoIndice es el índice con que se actualiza el alquiler (ver IndiceIPC);
+	// vacío en documentos anteriores = IPC
+	TipoIndice string `bson:"tipo_indice,omitempty" json:"tipo_indice,omitempty"`
+
+	// ── Vencimiento y mora (vacío = reglas generales, ver ReglasMora) ───────
+	DiaVencimiento int  `bson:"dia_vencimiento,omitempty" json:"dia_vencimiento,omitempty"`
+	DiasGracia     *int `bson:"dias_gracia,omitempty" json:"dias_gracia,omitempty"`
+
+	// ── Edificio (nil = propiedad independiente) ─────────────────────────────
+	// Unidad identifica la propiedad dentro del edificio ("3° B"). Coeficiente es
+	// su participación en los gastos comunes (0 en todas = partes iguales).
+	EdificioID  *primitive.ObjectID `bson:"edificio_id,omitempty" json:"edificio_id,omitempty"`
+	Unidad      string              `bson:"unidad,omitempty" json:"unidad,omitempty"`
+	Coeficiente float64             `bson:"coeficiente,omitempty" json:"coeficiente,omitempty"`
 
 	// Campos legacy
 	MesInicio int `bson:"mes_inicio" json:"mes_inicio"`
 
 	// ── Modo dólares ─────────────────────────────────────────────────────────
 	// Cuando PagaEnDolares=true, MontoDolares es el valor fijo en USD.
-	// AlquilerMensual se actualiza manualmente con la cotización del mes.
+	// AlquilerMensual se recalcula con la cotización del día (ver Cotizacion).
 	// Las actualizaciones por inflación no aplican.
 	PagaEnDolares bool    `bson:"paga_en_dolares" json:"paga_en_dolares"`
 	MontoDolares  float64 `bson:"monto_dolares" json:"monto_dolares"`
 
+	// TipoCotizacion es el dólar con que se pasa a pesos (vacío = oficial)
+	TipoCotizacion string `bson:"tipo_cotizacion,omitempty" json:"tipo_cotizacion,omitempty"`
+
 	// ── Imágenes ─────────────────────────────────────────────────────────────
-	// Almacenadas como data-URLs base64 (formato: "data:image/jpeg;base64,...")
+	// URLs de las imágenes guardadas en el almacén de archivos
+	// (/api/alquileres/imagenes/<id>). Al crear o modificar la propiedad se
+	// aceptan data-URLs base64, que se guardan y se reemplazan por su URL.
 	Imagenes []string `bson:"imagenes" json:"imagenes"`
 
 	// ── Campos generales ─────────────────────────────────────────────────────
-	Anio      int                    `bson:"anio" json:"anio"`
-	Pagos     []PagoMes              `bson:"pagos" json:"pagos"`
+	// Anio es el año de alta de la propiedad (primer año con períodos).
+	Anio int `bson:"anio" json:"anio"`
+	// Pagos no se persiste: es la vista del año AnioPagos armada desde el libro
+	// de períodos (colección periodos_alquiler).
+	Pagos     []PagoMes              `bson:"-" json:"pagos"`
+	AnioPagos int                    `bson:"-" json:"anio_pagos"`
 	Metadata  map[string]interface{} `bson:"metadata" json:"metadata"`
 	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time              `bson:"updated_at" json:"updated_at"`
 	CreatedBy uint                   `bson:"created_by" json:"created_by"`
 }
 
+// PeriodoAlquiler es un mes (año + mes) del libro de pagos de una propiedad.
+// El libro es abierto: cada año se generan los 12 períodos del año siguiente.
+type PeriodoAlquiler struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PropiedadID primitive.ObjectID `bson:"propiedad_id" json:"propiedad_id"`
+	Anio        int                `bson:"anio" json:"anio"`
+	Mes         int                `bson:"mes" json:"mes"` // 0-11, igual que PagoMes
+	Estado      EstadoPago         `bson:"estado" json:"estado"`
+	Monto       float64            `bson:"monto" json:"monto"`
+	FechaPago   *time.Time         `bson:"fecha_pago,omitempty" json:"fecha_pago,omitempty"`
+	MovementID  *uint              `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// ContratoID es el contrato al que corresponde el período (vacío si la
+	// propiedad estaba desocupada en ese mes)
+	ContratoID *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+
+	// ── Cobros parciales y punitorios ────────────────────────────────────────
+	// Monto es el alquiler del mes. Cada cobro se imputa primero a punitorios y
+	// después al alquiler; el período pasa a "paid" cuando el saldo llega a cero.
+	Pagado             float64 `bson:"pagado" json:"pagado"`
+	PunitorioDevengado float64 `bson:"punitorio_devengado" json:"punitorio_devengado"`
+	PunitorioPagado    float64 `bson:"punitorio_pagado" json:"punitorio_pagado"`
+	// InteresHasta es el último día con punitorios diarios ya liquidados
+	InteresHasta *time.Time     `bson:"interes_hasta,omitempty" json:"interes_hasta,omitempty"`
+	Cobros       []CobroPeriodo `bson:"cobros,omitempty" json:"cobros,omitempty"`
+
+	// ── Alquiler en dólares ──────────────────────────────────────────────────
+	// Monto es MontoDolares por la cotización (venta) vigente al vencimiento,
+	// o al día si todavía no venció. Se recalcula hasta el primer cobro.
+	MontoDolares    float64    `bson:"monto_dolares,omitempty" json:"monto_dolares,omitempty"`
+	Cotizacion      float64    `bson:"cotizacion,omitempty" json:"cotizacion,omitempty"`
+	TipoCotizacion  string     `bson:"tipo_cotizacion,omitempty" json:"tipo_cotizacion,omitempty"`
+	FechaCotizacion *time.Time `bson:"fecha_cotizacion,omitempty" json:"fecha_cotizacion,omitempty"`
+}
+
+// CobroPeriodo es un pago (total o parcial) de un período. Cada cobro genera su
+// propio movimiento de caja.
+type CobroPeriodo struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Fecha     time.Time          `bson:"fecha" json:"fecha"`
+	Monto     float64            `bson:"monto" json:"monto"`
+	Capital   float64            `bson:"capital" json:"capital"`     // imputado al alquiler
+	Punitorio float64            `bson:"punitorio" json:"punitorio"` // imputado a punitorios
+	// PunitorioDevengado son los punitorios liquidados al momento de este cobro
+	PunitorioDevengado float64 `bson:"punitorio_devengado" json:"punitorio_devengado"`
+	DiasAtraso         int     `bson:"dias_atraso" json:"dias_atraso"`
+	Condonado          bool    `bson:"condonado" json:"condonado"`
+	// InteresDesde es el InteresHasta anterior, para poder revertir el cobro
+	InteresDesde  *time.Time `bson:"interes_desde,omitempty" json:"-"`
+	MovementID    *uint      `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+	RegistradoPor uint       `bson:"registrado_por" json:"registrado_por"`
+
+	MedioPago string              `bson:"medio_pago,omitempty" json:"medio_pago,omitempty"`
+	ReciboID  *primitive.ObjectID `bson:"recibo_id,omitempty" json:"recibo_id,omitempty"`
+
+	// Cobro en dólares: Monto es el equivalente en pesos a la Cotizacion usada
+	MontoDolares   float64 `bson:"monto_dolares,omitempty" json:"monto_dolares,omitempty"`
+	Cotizacion     float64 `bson:"cotizacion,omitempty" json:"cotizacion,omitempty"`
+	TipoCotizacion string  `bson:"tipo_cotizacion,omitempty" json:"tipo_cotizacion,omitempty"`
+}
+
+// Indice devuelve la posición absoluta del período (anio*12 + mes) para comparar meses.
+func (p PeriodoAlquiler) Indice() int {
+	return p.Anio*12 + p.Mes
+}
+
+// SaldoCapital es lo que falta pagar del alquiler del mes.
+func (p PeriodoAlquiler) SaldoCapital() float64 {
+	if p.Estado == PagadoEstado || p.Pagado >= p.Monto {
+		return 0
+	}
+	return p.Monto - p.Pagado
+}
+
+// SaldoPunitorio son los punitorios liquidados y todavía no cobrados.
+func (p PeriodoAlquiler) SaldoPunitorio() float64 {
+	if p.PunitorioDevengado <= p.PunitorioPagado {
+		return 0
+	}
+	return p.PunitorioDevengado - p.PunitorioPagado
+}
+
+// Saldo es el total adeudado del período a la última liquidación.
+func (p PeriodoAlquiler) Saldo() float64 {
+	return p.SaldoCapital() + p.SaldoPunitorio()
+}
+
+// Cobrado es el total cobrado del período (alquiler + punitorios).
+func (p PeriodoAlquiler) Cobrado() float64 {
+	return p.Pagado + p.PunitorioPagado
+}
+
 // RegistrarPagoRequest es el body para marcar un mes como pagado
 type RegistrarPagoRequest struct {
 	// Mes usa omitempty para que mes=0 (Enero) no falle la validación required
 	Mes   int     `json:"mes" binding:"min=0,max=11"`
-	Monto float64 `json:"monto" binding:"required,gt=0"`
+	Monto float64 `json:"monto" binding:"omitempty,gt=0"`
+	// Anio del período; si se omite se usa el año en curso
+	Anio int `json:"anio" binding:"omitempty,min=2000,max=2100"`
+	// Monto puede ser parcial: se imputa primero a punitorios y luego al alquiler.
+	// CondonarPunitorio no liquida los punitorios devengados hasta hoy.
+	CondonarPunitorio bool `json:"condonar_punitorio"`
+	// MedioPago se imprime en el recibo (por defecto "efectivo")
+	MedioPago string `json:"medio_pago" binding:"omitempty,oneof=efectivo transferencia cheque otro"`
+	// Cobro en dólares (solo propiedades en dólares): Monto se calcula con la
+	// Cotizacion indicada o, si se omite, con la del día
+	MontoDolares float64 `json:"monto_dolares" binding:"omitempty,gt=0"`
+	Cotizacion   float64 `json:"cotizacion" binding:"omitempty,gt=0"`
+
+	// ArcoID es la caja abierta que recibe el cobro; si se omite, la caja
+	// abierta de quien lo registra. Las transferencias también se imputan a una
+	// caja: el sistema no lleva bóvedas ni cuentas bancarias.
+	ArcoID uint `json:"arco_id"`
+}
+
+// Tipos de punitorio por pago fuera de término
+const (
+	PunitorioDiario = "diario" // TasaDiaria % por día sobre el alquiler adeudado
+	PunitorioFijo   = "fijo"   // MontoFijo una vez por período
+)
+
+// PoliticaPunitorio define los intereses por mora. Hay una política general
+// (colección configuracion_alquileres) y cada contrato puede tener la suya.
+// Los punitorios corren desde el primer día de mora (vencimiento y días de
+// gracia de ReglasMora o de la propiedad).
+type PoliticaPunitorio struct {
+	Activo     bool    `bson:"activo" json:"activo"`
+	Tipo       string  `bson:"tipo" json:"tipo" binding:"required,oneof=diario fijo"`
+	TasaDiaria float64 `bson:"tasa_diaria" json:"tasa_diaria" binding:"gte=0,lte=10"`
+	MontoFijo  float64 `bson:"monto_fijo" json:"monto_fijo" binding:"gte=0"`
+	// TopePct limita los punitorios del período a un % del alquiler (0 = sin tope)
+	TopePct   float64   `bson:"tope_pct" json:"tope_pct" binding:"gte=0"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	UpdatedBy uint      `bson:"updated_by" json:"updated_by"`
+}
+
+// LiquidacionPeriodo es el detalle de lo que hay que cobrar hoy por un período
+type LiquidacionPeriodo struct {
+	Anio               int                `json:"anio"`
+	Mes                int                `json:"mes"`
+	Vencimiento        time.Time          `json:"vencimiento"`
+	Alquiler           float64            `json:"alquiler"`
+	Pagado             float64            `json:"pagado"`
+	SaldoAlquiler      float64            `json:"saldo_alquiler"`
+	PunitorioPendiente float64            `json:"punitorio_pendiente"` // liquidado en cobros anteriores
+	PunitorioNuevo     float64            `json:"punitorio_nuevo"`     // devengado desde el último cobro
+	DiasAtraso         int                `json:"dias_atraso"`
+	Total              float64            `json:"total"`
+	Politica           *PoliticaPunitorio `json:"politica"`
+	Cobros             []CobroPeriodo     `json:"cobros"`
 }
 
 // CrearPropiedadRequest es el body para crear una propiedad
@@ -83,12 +268,25 @@ type CrearPropiedadRequest struct {
 	// Cada cuántos meses se actualiza (mínimo 3, 0 = sin actualización automática)
 	FrecuenciaActualizacion int        `json:"frecuencia_actualizacion"`
 	// Modo dólares
-	PagaEnDolares bool    `json:"paga_en_dolares"`
-	MontoDolares  float64 `json:"monto_dolares"`
+	PagaEnDolares  bool    `json:"paga_en_dolares"`
+	MontoDolares   float64 `json:"monto_dolares"`
+	TipoCotizacion string  `json:"tipo_cotizacion" binding:"omitempty,oneof=oficial mep blue"`
 	// Imágenes
 	Imagenes []string `json:"imagenes"`
 	// Metadata libre
 	Metadata map[string]interface{} `json:"metadata"`
+	// Propietario (vacío = propiedad propia)
+	PropietarioID string `json:"propietario_id"`
+	// Índice de actualización (vacío = IPC)
+	TipoIndice string `json:"tipo_indice" binding:"omitempty,oneof=ipc icl cer uva cac ninguno"`
+	// Vencimiento propio (0 / nil = reglas generales de mora)
+	DiaVencimiento int  `json:"dia_vencimiento" binding:"omitempty,min=1,max=28"`
+	DiasGracia     *int `json:"dias_gracia" binding:"omitempty,gte=0,lte=60"`
+
+	// Edificio al que pertenece como unidad (vacío = propiedad independiente)
+	EdificioID  string  `json:"edificio_id"`
+	Unidad      string  `json:"unidad"`
+	Coeficiente float64 `json:"coeficiente" binding:"gte=0"`
 }
 
 // ActualizarPropiedadRequest es el body para modificar una propiedad
@@ -102,12 +300,25 @@ type ActualizarPropiedadRequest struct {
 	FechaActualizacion      *time.Time `json:"fecha_actualizacion"`
 	FrecuenciaActualizacion *int       `json:"frecuencia_actualizacion"`
 	// Modo dólares
-	PagaEnDolares *bool    `json:"paga_en_dolares"`
-	MontoDolares  *float64 `json:"monto_dolares"`
+	PagaEnDolares  *bool    `json:"paga_en_dolares"`
+	MontoDolares   *float64 `json:"monto_dolares"`
+	TipoCotizacion *string  `json:"tipo_cotizacion" binding:"omitempty,oneof=oficial mep blue"`
 	// Imágenes
 	Imagenes *[]string `json:"imagenes"`
 	// Metadata libre (merge de campos)
 	Metadata map[string]interface{} `json:"metadata"`
+	// Propietario ("" = pasa a ser propiedad propia)
+	PropietarioID *string `json:"propietario_id"`
+	// Índice de actualización
+	TipoIndice *string `json:"tipo_indice" binding:"omitempty,oneof=ipc icl cer uva cac ninguno"`
+	// Vencimiento propio (0 / -1 = volver a las reglas generales de mora)
+	DiaVencimiento *int `json:"dia_vencimiento" binding:"omitempty,min=0,max=28"`
+	DiasGracia     *int `json:"dias_gracia" binding:"omitempty,gte=-1,lte=60"`
+
+	// Edificio ("" = deja de ser unidad de un edificio)
+	EdificioID  *string  `json:"edificio_id"`
+	Unidad      *string  `json:"unidad"`
+	Coeficiente *float64 `json:"coeficiente" binding:"omitempty,gte=0"`
 }
 
 // ── Tipos para el sistema de notificación de actualización de alquiler ─────────────
@@ -125,6 +336,49 @@ type DetalleInflacion struct {
 	Fuente        string         `json:"fuente"`
 	FechaConsulta time.Time      `json:"fecha_consulta"`
 	Error         string         `json:"error,omitempty"` // si hubo error al consultar la API
+
+	// Indice es el índice aplicado y Coeficiente el factor sobre el monto actual.
+	// En índices de nivel (ICL, CER, UVA, CAC) se informan los valores usados.
+	Indice          string     `json:"indice"`
+	Coeficiente     float64    `json:"coeficiente"`
+	ValorDesde      float64    `json:"valor_desde,omitempty"`
+	ValorHasta      float64    `json:"valor_hasta,omitempty"`
+	FechaValorDesde *time.Time `json:"fecha_valor_desde,omitempty"`
+	FechaValorHasta *time.Time `json:"fecha_valor_hasta,omitempty"`
+
+	// Frescura de la copia local: última sincronización de los datos usados y
+	// si alguno fue cargado o corregido a mano
+	ActualizadoEn *time.Time `json:"actualizado_en,omitempty"`
+	Manual        bool       `json:"manual,omitempty"`
+}
+
+// IndiceDisponible describe un índice de actualización que puede elegir un contrato
+type IndiceDisponible struct {
+	Codigo string `json:"codigo"`
+	Nombre string `json:"nombre"`
+	Fuente string `json:"fuente"`
+	Tipo   string `json:"tipo"` // variacion_mensual | nivel
+
+	// Estado de la copia local de la serie
+	Automatico    bool       `json:"automatico"` // false = solo carga manual
+	Cantidad      int64      `json:"cantidad"`
+	Manuales      int64      `json:"manuales"`
+	PrimerDato    *time.Time `json:"primer_dato,omitempty"`
+	UltimoDato    *time.Time `json:"ultimo_dato,omitempty"`
+	ActualizadoEn *time.Time `json:"actualizado_en,omitempty"`
+}
+
+// CargarValorIndiceRequest es el body para cargar o corregir un valor a mano.
+// Fecha: AAAA-MM-DD (o AAAA-MM para el IPC); Valor: % mensual (IPC) o valor del índice.
+type CargarValorIndiceRequest struct {
+	Fecha string  `json:"fecha" binding:"required"`
+	Valor float64 `json:"valor" binding:"required"`
+}
+
+// ResultadoImportacionIndice resume la carga de un CSV de valores de un índice
+type ResultadoImportacionIndice struct {
+	Cargados int      `json:"cargados"`
+	Errores  []string `json:"errores"`
 }
 
 // PropiedadActualizacion es la respuesta del endpoint de actualizaciones pendientes
@@ -140,6 +394,10 @@ type ActualizarMontoRequest struct {
 	NuevoMonto             float64    `json:"nuevo_monto" binding:"required,gt=0"`
 	NuevaFechaActualizacion *time.Time `json:"nueva_fecha_actualizacion"`
 	Notas                  string     `json:"notas"`
+
+	// VigenteDesde es el mes desde el que rige el nuevo monto (por defecto, el
+	// mes en curso); los períodos sin cobros desde ese mes toman el nuevo monto
+	VigenteDesde *time.Time `json:"vigente_desde"`
 }
 
 // PosponerRequest es el body para posponer la notificación de una propiedad
@@ -157,4 +415,16 @@ type ResumenAlquileres struct {
 	TotalPropiedades       int     `json:"total_propiedades"`
 	PagosAtrasados         int     `json:"pagos_atrasados"`
 	PropiedadesConAtraso   int     `json:"propiedades_con_atraso"`
+
+	// Rentabilidad del año: cobrado menos gastos a cargo del propietario o la administración
+	IngresosCobrados float64                 `json:"ingresos_cobrados"`
+	GastosTotales    float64                 `json:"gastos_totales"`
+	Rentabilidad     float64                 `json:"rentabilidad"`
+	PorPropiedad     []RentabilidadPropiedad `json:"por_propiedad"`
+
+	// Documentos (seguros, garantías, etc.) vencidos o que vencen en los próximos 30 días
+	DocumentosPorVencer []VencimientoDocumento `json:"documentos_por_vencer"`
+
+	// Ocupación del año según el historial (TasaOcupacion es la foto de hoy)
+	OcupacionHistorica MetricasOcupacion `json:"ocupacion_historica"`
 }
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operaciones pendientes sobre la caja (MySQL) que acompañan a un cobro de
+// alquiler registrado en MongoDB
+const (
+	OpCrearMovimiento  = "crear_movimiento"
+	OpAnularMovimiento = "anular_movimiento"
+)
+
+// Estados de una operación de caja
+const (
+	OpPendiente = "pendiente"
+	OpAplicada  = "aplicada"
+	OpCancelada = "cancelada" // el cobro se revirtió antes de aplicarla
+	OpRevision  = "revision"  // falta la caja destino o la elegida se cerró
+)
+
+// OperacionCaja es una entrada del outbox de caja: el cambio en MySQL que debe
+// acompañar a un cobro (o a su reversión). Se escribe antes de tocar el período
+// y se reintenta hasta aplicarse, así Mongo y la caja terminan coincidiendo.
+type OperacionCaja struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Tipo        string             `bson:"tipo" json:"tipo"`
+	Estado      string             `bson:"estado" json:"estado"`
+	PropiedadID primitive.ObjectID `bson:"propiedad_id" json:"propiedad_id"`
+	PeriodoID   primitive.ObjectID `bson:"periodo_id" json:"periodo_id"`
+	// CobroID es el cobro del período; vacío en pagos anteriores a los cobros parciales
+	CobroID *primitive.ObjectID `bson:"cobro_id,omitempty" json:"cobro_id,omitempty"`
+	Anio    int                 `bson:"anio" json:"anio"`
+	Mes     int                 `bson:"mes" json:"mes"`
+
+	// Datos del movimiento a crear (o el movimiento a anular)
+	Referencia string    `bson:"referencia,omitempty" json:"referencia,omitempty"` // reference_id del movimiento
+	Monto      float64   `bson:"monto,omitempty" json:"monto,omitempty"`
+	Detalle    string    `bson:"detalle,omitempty" json:"detalle,omitempty"`
+	Fecha      time.Time `bson:"fecha" json:"fecha"`
+	MovementID *uint     `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+	UsuarioID  uint      `bson:"usuario_id" json:"usuario_id"`
+	// ArcoID es la caja destino elegida (0 = la caja abierta del usuario)
+	ArcoID uint `bson:"arco_id,omitempty" json:"arco_id,omitempty"`
+
+	Intentos    int        `bson:"intentos" json:"intentos"`
+	UltimoError string     `bson:"ultimo_error,omitempty" json:"ultimo_error,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	AplicadaEn  *time.Time `bson:"aplicada_en,omitempty" json:"aplicada_en,omitempty"`
+}
+
+// ResultadoOperacionesCaja resume un procesamiento del outbox de caja
+type ResultadoOperacionesCaja struct {
+	Procesadas int      `json:"procesadas"`
+	Aplicadas  int      `json:"aplicadas"`
+	Canceladas int      `json:"canceladas"`
+	Pendientes int      `json:"pendientes"`
+	Errores    []string `json:"errores,omitempty"`
+}
+
+// Tipos de diferencia entre los cobros de alquiler y los movimientos de caja
+const (
+	DifSinMovimiento       = "cobro_sin_movimiento" // cobro sin movimiento asociado
+	DifMovimientoEliminado = "movimiento_eliminado" // el movimiento no existe o está anulado
+	DifMontoDistinto       = "monto_distinto"       // el movimiento no coincide con el cobro (solo se informa)
+	DifMovimientoHuerfano  = "movimiento_sin_cobro" // movimiento de alquiler sin cobro en Mongo
+	DifOperacionPendiente  = "operacion_pendiente"  // hay una operación del outbox sin aplicar
+)
+
+// DiferenciaCaja es una inconsistencia entre un cobro y la caja
+type DiferenciaCaja struct {
+	Tipo        string              `json:"tipo"`
+	PropiedadID *primitive.ObjectID `json:"propiedad_id,omitempty"`
+	Direccion   string              `json:"direccion,omitempty"`
+	PeriodoID   *primitive.ObjectID `json:"periodo_id,omitempty"`
+	CobroID     *primitive.ObjectID `json:"cobro_id,omitempty"`
+	Anio        int                 `json:"anio,omitempty"`
+	Mes         int                 `json:"mes"`
+	MontoCobro  float64             `json:"monto_cobro,omitempty"`
+	MovementID  *uint               `json:"movement_id,omitempty"`
+	MontoCaja   float64             `json:"monto_caja,omitempty"`
+	Detalle     string              `json:"detalle"`
+	// Reparable indica si /reparar la corrige (los movimientos de alquiler
+	// cargados a mano en la caja, las diferencias de monto y las operaciones
+	// sin caja destino solo se informan)
+	Reparable bool `json:"reparable"`
+}
+
+// ReporteConciliacion lista las diferencias entre cobros y caja de un rango de fechas
+type ReporteConciliacion struct {
+	Desde                time.Time        `json:"desde"`
+	Hasta                time.Time        `json:"hasta"`
+	CobrosRevisados      int              `json:"cobros_revisados"`
+	MovimientosRevisados int              `json:"movimientos_revisados"`
+	Diferencias          []DiferenciaCaja `json:"diferencias"`
+	Reparadas            int              `json:"reparadas,omitempty"`
+	Errores              []string         `json:"errores,omitempty"`
+}
+
+// RepararConciliacionRequest es el body para reparar diferencias. Sin fechas
+// se usan los últimos 90 días; sin tipos se reparan todas las reparables.
+type RepararConciliacionRequest struct {
+	Desde string   `json:"desde"` // AAAA-MM-DD
+	Hasta string   `json:"hasta"` // AAAA-MM-DD, inclusive
+	Tipos []string `json:"tipos" binding:"omitempty,dive,oneof=cobro_sin_movimiento movimiento_eliminado monto_distinto movimiento_sin_cobro operacion_pendiente"`
+	// ArcoID es la caja que recibe los movimientos que se vuelvan a crear (0 =
+	// la caja abierta de quien registró cada cobro)
+	ArcoID uint `json:"arco_id"`
+}
+
+// ReasignarOperacionRequest es el body para cambiar la caja destino de una operación
+type ReasignarOperacionRequest struct {
+	ArcoID uint `json:"arco_id" binding:"required"`
+}
+
+// CajaDestino es una caja abierta que puede recibir los cobros de alquiler
+type CajaDestino struct {
+	ArcoID        uint      `json:"arco_id"`
+	Responsable   string    `json:"responsable"`
+	OwnerID       uint      `json:"owner_id"`
+	Turno         string    `json:"turno"`
+	FechaApertura time.Time `json:"fecha_apertura"`
+	Propia        bool      `json:"propia"` // es la caja de quien consulta
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EstadoContrato define el ciclo de vida de un contrato de alquiler
+type EstadoContrato string
+
+const (
+	ContratoVigente    EstadoContrato = "vigente"
+	ContratoFinalizado EstadoContrato = "finalizado"
+	ContratoRescindido EstadoContrato = "rescindido"
+)
+
+// Índices de actualización admitidos en un contrato
+const (
+	IndiceIPC     = "ipc"
+	IndiceICL     = "icl"
+	IndiceCER     = "cer"
+	IndiceUVA     = "uva"
+	IndiceCAC     = "cac"
+	IndiceNinguno = "ninguno"
+)
+
+// Contrato es una locación de una propiedad a un inquilino (colección contratos).
+// Una propiedad tiene a lo sumo un contrato vigente; los anteriores quedan como
+// historial y conservan sus períodos de pago (PeriodoAlquiler.ContratoID).
+type Contrato struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PropiedadID primitive.ObjectID `bson:"propiedad_id" json:"propiedad_id"`
+	Inquilino   string             `bson:"inquilino" json:"inquilino"`
+	FechaInicio time.Time          `bson:"fecha_inicio" json:"fecha_inicio"`
+	FechaFin    time.Time          `bson:"fecha_fin" json:"fecha_fin"`
+	// InquilinoID es la ficha del inquilino en el padrón de personas
+	// (Inquilino es una copia de su nombre)
+	InquilinoID *primitive.ObjectID `bson:"inquilino_id,omitempty" json:"inquilino_id,omitempty"`
+
+	// ── Montos y actualización ───────────────────────────────────────────────
+	MontoInicial float64 `bson:"monto_inicial" json:"monto_inicial"`
+	// MontoActual es el alquiler mensual vigente luego de las actualizaciones
+	MontoActual             float64    `bson:"monto_actual" json:"monto_actual"`
+	TipoIndice              string     `bson:"tipo_indice" json:"tipo_indice"`
+	FrecuenciaActualizacion int        `bson:"frecuencia_actualizacion" json:"frecuencia_actualizacion"`
+	FechaActualizacion      *time.Time `bson:"fecha_actualizacion,omitempty" json:"fecha_actualizacion,omitempty"`
+	PagaEnDolares           bool       `bson:"paga_en_dolares" json:"paga_en_dolares"`
+	MontoDolares            float64    `bson:"monto_dolares" json:"monto_dolares"`
+	// TipoCotizacion: dólar con que se pasa a pesos (oficial, mep, blue)
+	TipoCotizacion string `bson:"tipo_cotizacion,omitempty" json:"tipo_cotizacion,omitempty"`
+
+	// ── Garantías ────────────────────────────────────────────────────────────
+	Deposito float64  `bson:"deposito" json:"deposito"`
+	Garantes []string `bson:"garantes" json:"garantes"`
+	// GarantesIDs son las fichas de los garantes (Garantes copia sus nombres)
+	GarantesIDs []primitive.ObjectID `bson:"garantes_ids,omitempty" json:"garantes_ids,omitempty"`
+	// Punitorio reemplaza la política general de punitorios para este contrato
+	Punitorio *PoliticaPunitorio `bson:"punitorio,omitempty" json:"punitorio,omitempty"`
+	// ComisionPct es el porcentaje de administración que se descuenta al
+	// propietario sobre lo cobrado
+	ComisionPct float64 `bson:"comision_pct" json:"comision_pct"`
+
+	// ── Estado ───────────────────────────────────────────────────────────────
+	Estado             EstadoContrato `bson:"estado" json:"estado"`
+	FechaFinalizacion  *time.Time     `bson:"fecha_finalizacion,omitempty" json:"fecha_finalizacion,omitempty"`
+	MotivoFinalizacion string         `bson:"motivo_finalizacion,omitempty" json:"motivo_finalizacion,omitempty"`
+
+	Notas     string    `bson:"notas" json:"notas"`
+	CreatedBy uint      `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ContratoDetalle es el contrato con su libro de períodos y totales
+type ContratoDetalle struct {
+	Contrato
+	Periodos       []PeriodoAlquiler `json:"periodos"`
+	TotalPagado    float64           `json:"total_pagado"`
+	TotalAdeudado  float64           `json:"total_adeudado"`
+	MesesAdeudados int               `json:"meses_adeudados"`
+}
+
+// CrearContratoRequest es el body para iniciar un contrato sobre una propiedad
+type CrearContratoRequest struct {
+	// Inquilino del padrón (InquilinoID) o, si no existe, solo el nombre:
+	// en ese caso se busca o se crea la ficha con ese nombre
+	InquilinoID  string    `json:"inquilino_id"`
+	Inquilino    string    `json:"inquilino" binding:"required_without=InquilinoID"`
+	FechaInicio  time.Time `json:"fecha_inicio" binding:"required"`
+	FechaFin     time.Time `json:"fecha_fin" binding:"required"`
+	MontoInicial float64   `json:"monto_inicial" binding:"required,gt=0"`
+	TipoIndice   string    `json:"tipo_indice" binding:"omitempty,oneof=ipc icl cer uva cac ninguno"`
+	// Cada cuántos meses se actualiza (mínimo 3, 0 = sin actualización automática)
+	FrecuenciaActualizacion int      `json:"frecuencia_actualizacion"`
+	PagaEnDolares           bool     `json:"paga_en_dolares"`
+	MontoDolares            float64  `json:"monto_dolares"`
+	TipoCotizacion          string   `json:"tipo_cotizacion" binding:"omitempty,oneof=oficial mep blue"`
+	Deposito                float64  `json:"deposito" binding:"gte=0"`
+	Garantes                []string `json:"garantes"`
+	Notas                   string   `json:"notas"`
+
+	Punitorio *PoliticaPunitorio `json:"punitorio"`
+	// GarantesIDs: fichas del padrón (se suman a Garantes)
+	GarantesIDs []string `json:"garantes_ids"`
+	// ComisionPct: comisión de administración (propiedades de terceros)
+	ComisionPct float64 `json:"comision_pct" binding:"gte=0,lte=100"`
+}
+
+// ActualizarContratoRequest es el body para modificar datos de un contrato
+type ActualizarContratoRequest struct {
+	FechaFin   *time.Time `json:"fecha_fin"`
+	TipoIndice *string    `json:"tipo_indice" binding:"omitempty,oneof=ipc icl cer uva cac ninguno"`
+	Deposito   *float64   `json:"deposito" binding:"omitempty,gte=0"`
+	Garantes   *[]string  `json:"garantes"`
+	Notas      *string    `json:"notas"`
+
+	Punitorio *PoliticaPunitorio `json:"punitorio"`
+	// QuitarPunitorio vuelve a aplicar la política general
+	QuitarPunitorio bool `json:"quitar_punitorio"`
+
+	// Vincular el contrato con fichas del padrón
+	InquilinoID *string   `json:"inquilino_id"`
+	GarantesIDs *[]string `json:"garantes_ids"`
+
+	ComisionPct *float64 `json:"comision_pct" binding:"omitempty,gte=0,lte=100"`
+
+	// Condiciones económicas; las del contrato vigente se copian a la propiedad.
+	// MontoActual corrige el alquiler (las actualizaciones por índice van por
+	// ActualizarMonto).
+	MontoActual             *float64   `json:"monto_actual" binding:"omitempty,gt=0"`
+	FrecuenciaActualizacion *int       `json:"frecuencia_actualizacion" binding:"omitempty,gte=0"`
+	FechaActualizacion      *time.Time `json:"fecha_actualizacion"`
+	PagaEnDolares           *bool      `json:"paga_en_dolares"`
+	MontoDolares            *float64   `json:"monto_dolares" binding:"omitempty,gte=0"`
+	TipoCotizacion          *string    `json:"tipo_cotizacion" binding:"omitempty,oneof=oficial mep blue"`
+}
+
+// FinalizarContratoRequest es el body para cerrar un contrato vigente
+type FinalizarContratoRequest struct {
+	// Estado final: "finalizado" (fin de plazo) o "rescindido" (anticipado)
+	Estado EstadoContrato `json:"estado" binding:"required,oneof=finalizado rescindido"`
+	// Fecha efectiva de entrega; si se omite se usa la fecha actual
+	Fecha  *time.Time `json:"fecha"`
+	Motivo string     `json:"motivo"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Tipos de cotización del dólar
+const (
+	DolarOficial = "oficial"
+	DolarMEP     = "mep"
+	DolarBlue    = "blue"
+)
+
+// CargarCotizacionRequest es el body para cargar o corregir la cotización de un día
+type CargarCotizacionRequest struct {
+	Fecha  string  `json:"fecha" binding:"required"` // AAAA-MM-DD o DD/MM/AAAA
+	Compra float64 `json:"compra" binding:"gte=0"`
+	Venta  float64 `json:"venta" binding:"required,gt=0"`
+}
+
+// EstadoCotizacion es la última cotización guardada de un tipo de dólar
+type EstadoCotizacion struct {
+	Tipo          string      `json:"tipo"`
+	Nombre        string      `json:"nombre"`
+	Fuente        string      `json:"fuente"`
+	Ultima        *Cotizacion `json:"ultima,omitempty"`
+	Cantidad      int64       `json:"cantidad"`
+	ActualizadoEn *time.Time  `json:"actualizado_en,omitempty"`
+}
+
+// ResultadoMontosDolares resume un recálculo de los períodos en dólares
+type ResultadoMontosDolares struct {
+	Propiedades int      `json:"propiedades"`
+	Periodos    int      `json:"periodos"`
+	Errores     []string `json:"errores"`
+}
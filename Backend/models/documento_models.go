@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tipos de documento adjunto
+const (
+	DocContratoFirmado = "contrato_firmado"
+	DocInventario      = "inventario"
+	DocSeguro          = "seguro"
+	DocGarantia        = "garantia"
+	DocOtro            = "otro"
+)
+
+// Documento es un archivo adjunto (PDF o imagen) de una propiedad y,
+// opcionalmente, de uno de sus contratos. El contenido vive en el almacén de
+// archivos; el documento guarda solo los datos para listarlo y sus alertas.
+type Documento struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	PropiedadID primitive.ObjectID  `bson:"propiedad_id" json:"propiedad_id"`
+	ContratoID  *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+	Tipo        string              `bson:"tipo" json:"tipo"`
+	Descripcion string              `bson:"descripcion,omitempty" json:"descripcion,omitempty"`
+	// Vencimiento es el último día de validez (pólizas, garantías); nil = no vence
+	Vencimiento *time.Time `bson:"vencimiento,omitempty" json:"vencimiento,omitempty"`
+
+	// Archivo en el almacén
+	ArchivoID   string `bson:"archivo_id" json:"-"`
+	Nombre      string `bson:"nombre" json:"nombre"`
+	ContentType string `bson:"content_type" json:"content_type"`
+	Tamanio     int64  `bson:"tamanio" json:"tamanio"`
+
+	CreatedBy uint      `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// SubirDocumentoRequest son los campos del formulario multipart que acompañan
+// al archivo (campo "archivo")
+type SubirDocumentoRequest struct {
+	Tipo        string `form:"tipo" binding:"required,oneof=contrato_firmado inventario seguro garantia otro"`
+	Descripcion string `form:"descripcion"`
+	Vencimiento string `form:"vencimiento"` // AAAA-MM-DD o DD/MM/AAAA
+	ContratoID  string `form:"contrato_id"`
+}
+
+// ActualizarDocumentoRequest corrige los datos de un documento (no el archivo).
+// Vencimiento vacío quita el vencimiento.
+type ActualizarDocumentoRequest struct {
+	Tipo        *string `json:"tipo" binding:"omitempty,oneof=contrato_firmado inventario seguro garantia otro"`
+	Descripcion *string `json:"descripcion"`
+	Vencimiento *string `json:"vencimiento"`
+}
+
+// VencimientoDocumento es un documento vencido o por vencer, con los datos de
+// su propiedad para mostrarlo en el tablero
+type VencimientoDocumento struct {
+	Documento
+	Direccion     string `json:"direccion"`
+	DiasRestantes int    `json:"dias_restantes"` // negativo = vencido
+}
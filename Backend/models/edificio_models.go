@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Edificio agrupa propiedades que son unidades de un mismo edificio o complejo
+// (colección edificios). Cada unidad es una Propiedad con EdificioID.
+type Edificio struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Nombre    string             `bson:"nombre" json:"nombre"`
+	Direccion string             `bson:"direccion" json:"direccion"`
+	Notas     string             `bson:"notas" json:"notas"`
+	CreatedBy uint               `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+
+	// CantidadUnidades no se persiste: propiedades asignadas al edificio
+	CantidadUnidades int `bson:"-" json:"cantidad_unidades"`
+}
+
+// EdificioRequest es el body para crear o modificar un edificio
+type EdificioRequest struct {
+	Nombre    string `json:"nombre" binding:"required"`
+	Direccion string `json:"direccion"`
+	Notas     string `json:"notas"`
+}
+
+// EdificioDetalle es la ficha del edificio con sus unidades
+type EdificioDetalle struct {
+	Edificio
+	Propiedades []Propiedad `json:"propiedades"`
+}
+
+// ParteGastoEdificio es lo que le toca a una unidad de un gasto común
+type ParteGastoEdificio struct {
+	PropiedadID primitive.ObjectID `bson:"propiedad_id" json:"propiedad_id"`
+	Direccion   string             `bson:"direccion" json:"direccion"`
+	Unidad      string             `bson:"unidad" json:"unidad"`
+	Coeficiente float64            `bson:"coeficiente" json:"coeficiente"`
+	Monto       float64            `bson:"monto" json:"monto"`
+	// GastoID es el gasto de la propiedad que registra la parte
+	GastoID primitive.ObjectID `bson:"gasto_id" json:"gasto_id"`
+}
+
+// GastoEdificio es un gasto común del edificio (colección gastos_edificio),
+// prorrateado entre las unidades según su coeficiente. Cada parte se registra
+// como GastoPropiedad de la unidad, así se liquida y suma en la rentabilidad
+// igual que un gasto propio.
+type GastoEdificio struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EdificioID  primitive.ObjectID `bson:"edificio_id" json:"edificio_id"`
+	Fecha       time.Time          `bson:"fecha" json:"fecha"`
+	Categoria   string             `bson:"categoria" json:"categoria"`
+	Descripcion string             `bson:"descripcion" json:"descripcion"`
+	Monto       float64            `bson:"monto" json:"monto"`
+	// ACargoDe indicado al cargarlo (vacío = el que corresponde a cada unidad)
+	ACargoDe  string               `bson:"a_cargo_de,omitempty" json:"a_cargo_de,omitempty"`
+	Prorrateo []ParteGastoEdificio `bson:"prorrateo" json:"prorrateo"`
+	// MovementID es el Egreso de caja por el total (ver GastoPropiedad)
+	MovementID        *uint     `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+	MovementVinculado bool      `bson:"movement_vinculado,omitempty" json:"movement_vinculado,omitempty"`
+	CreatedBy         uint      `bson:"created_by" json:"created_by"`
+	CreatedAt         time.Time `bson:"created_at" json:"created_at"`
+}
+
+// UnidadEdificio es el resultado del año de una unidad del edificio
+type UnidadEdificio struct {
+	RentabilidadPropiedad
+	Unidad          string  `json:"unidad"`
+	Coeficiente     float64 `json:"coeficiente"`
+	Ocupada         bool    `json:"ocupada"`
+	Inquilino       string  `json:"inquilino"`
+	AlquilerMensual float64 `json:"alquiler_mensual"`
+	Deuda           float64 `json:"deuda"`
+}
+
+// ResumenEdificio son la ocupación y los ingresos del año de un edificio
+type ResumenEdificio struct {
+	Edificio
+	Anio                   int     `json:"anio"`
+	TotalUnidades          int     `json:"total_unidades"`
+	UnidadesOcupadas       int     `json:"unidades_ocupadas"`
+	TasaOcupacion          float64 `json:"tasa_ocupacion"` // años cerrados: por días ocupados según el historial
+	IngresoAnualProyectado float64 `json:"ingreso_anual_proyectado"`
+	DeudaTotal             float64 `json:"deuda_total"`
+
+	// Cobrado menos gastos a cargo del propietario o la administración (propios
+	// de cada unidad más su parte de los gastos comunes)
+	IngresosCobrados float64 `json:"ingresos_cobrados"`
+	GastosTotales    float64 `json:"gastos_totales"`
+	Resultado        float64 `json:"resultado"`
+	// GastosComunes es el total de los gastos del edificio cargados en el año
+	GastosComunes float64 `json:"gastos_comunes"`
+
+	Unidades []UnidadEdificio `json:"unidades"`
+}
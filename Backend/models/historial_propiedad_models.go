@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operaciones que modifican una propiedad (CambioPropiedad.Operacion)
+const (
+	OperacionEdicion            = "edicion"
+	OperacionActualizacionMonto = "actualizacion_monto"
+	OperacionPosponer           = "posponer"
+	OperacionEliminarMetadata   = "eliminar_metadata"
+	OperacionImagenes           = "imagenes"
+	OperacionEliminarPropiedad  = "eliminacion"
+	OperacionAlta               = "alta"
+	OperacionInicioContrato     = "inicio_contrato"
+	OperacionFinContrato        = "fin_contrato"
+	OperacionNombreInquilino    = "nombre_inquilino"  // el inquilino cambió de nombre en el padrón
+	OperacionEliminarEdificio   = "eliminar_edificio" // la unidad pasó a ser independiente
+)
+
+// CambioPropiedad es una entrada del registro de cambios de una propiedad
+// (colección historial_propiedades). Solo se agregan entradas: no se editan
+// ni se borran, aunque la propiedad se elimine.
+type CambioPropiedad struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PropiedadID primitive.ObjectID `bson:"propiedad_id" json:"propiedad_id"`
+	// Campo con el nombre guardado en la base; los de Metadata como "metadata.<clave>"
+	Campo     string      `bson:"campo" json:"campo"`
+	Anterior  interface{} `bson:"anterior" json:"anterior"` // nil si el campo no existía
+	Nuevo     interface{} `bson:"nuevo" json:"nuevo"`       // nil si el campo se quitó
+	Operacion string      `bson:"operacion" json:"operacion"`
+	UsuarioID uint        `bson:"usuario_id" json:"usuario_id"`
+	Fecha     time.Time   `bson:"fecha" json:"fecha"`
+}
+
+// Orígenes de un cambio de alquiler (MontoAlquiler.Origen)
+const (
+	OrigenMontoAlta          = "alta"
+	OrigenMontoActualizacion = "actualizacion" // actualización por índice
+	OrigenMontoEdicion       = "edicion"       // editado desde la propiedad
+	OrigenMontoContrato      = "contrato"      // inicio de un contrato
+	OrigenMontoMigracion     = "migracion"
+)
+
+// MontoAlquiler es un valor del alquiler mensual de una propiedad con la fecha
+// desde la que rige (colección historial_montos). Los períodos toman el monto
+// vigente en su mes.
+type MontoAlquiler struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PropiedadID   primitive.ObjectID `bson:"propiedad_id" json:"propiedad_id"`
+	Monto         float64            `bson:"monto" json:"monto"`
+	MontoAnterior float64            `bson:"monto_anterior" json:"monto_anterior"`
+	// VigenteDesde es el primer día del mes desde el que rige
+	VigenteDesde time.Time           `bson:"vigente_desde" json:"vigente_desde"`
+	Origen       string              `bson:"origen" json:"origen"`
+	ContratoID   *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+	Notas        string              `bson:"notas,omitempty" json:"notas,omitempty"`
+	UsuarioID    uint                `bson:"usuario_id" json:"usuario_id"`
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+}
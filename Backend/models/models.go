@@ -103,6 +103,13 @@ type Arco struct {
 	Fecha         time.Time  `gorm:"not null" json:"fecha"`
 	SaldoInicial  float64    `gorm:"type:decimal(15,2);default:0" json:"saldo_inicial"` // Saldo con el que comienza el arco
 	SaldoFinal    float64    `gorm:"type:decimal(15,2);default:0" json:"saldo_final"`   // Saldo con el que termina el arco
+
+	// Arqueo al cierre: efectivo contado (antes del retiro) y diferencia contra el saldo esperado.
+	// Diferencia > 0 es sobrante, < 0 es faltante. Nil si se cerró sin contar.
+	TotalContado     *float64 `gorm:"type:decimal(15,2)" json:"total_contado,omitempty"`
+	Diferencia       *float64 `gorm:"type:decimal(15,2)" json:"diferencia,omitempty"`
+	CierreAutomatico bool     `gorm:"default:false" json:"cierre_automatico"` // Cerrado al abrir otro arco del mismo turno
+
 	Usuario       User       `gorm:"foreignKey:CreatedBy" json:"usuario,omitempty"`
 	Owner         User       `gorm:"foreignKey:OwnerID" json:"owner,omitempty"` // NUEVO: Relación con el dueño
 	Movimientos   []Movement `gorm:"foreignKey:ArcoID" json:"movimientos,omitempty"`
@@ -145,3 +152,123 @@ type VistaSaldoArqueo struct {
 	TotalRetiros  float64    `gorm:"column:total_retiros" json:"total_retiros"`
 	SaldoTotal    float64    `gorm:"column:saldo_total" json:"saldo_total"`
 }
+
+// SaldoGlobalDiario es la foto de cierre del día de la caja global consolidada.
+// Se escribe al final de cada día para acelerar las consultas históricas.
+type SaldoGlobalDiario struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Fecha         time.Time `gorm:"type:date;not null;uniqueIndex" json:"fecha"`
+	SaldoTotal    float64   `gorm:"type:decimal(15,2);not null;default:0" json:"saldo_total"`
+	TotalIngresos float64   `gorm:"type:decimal(15,2);not null;default:0" json:"total_ingresos"` // Movimientos del día
+	TotalEgresos  float64   `gorm:"type:decimal(15,2);not null;default:0" json:"total_egresos"`
+	TotalRetiros  float64   `gorm:"type:decimal(15,2);not null;default:0" json:"total_retiros"`
+	CajasAbiertas int       `gorm:"not null;default:0" json:"cajas_abiertas"`
+	CajasCerradas int       `gorm:"not null;default:0" json:"cajas_cerradas"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Retirado es lo retirado de las cajas hasta el cierre del día (incluido en SaldoTotal)
+	Retirado float64 `gorm:"type:decimal(15,2);not null;default:0" json:"retirado"`
+}
+
+// SaldoCajaUsuario es el saldo de la caja personal de un usuario en un momento dado.
+type SaldoCajaUsuario struct {
+	OwnerID   uint    `json:"owner_id"`
+	OwnerName string  `json:"owner_name"`
+	ArcoID    uint    `json:"arco_id"`
+	Activo    bool    `json:"activo"` // true si el arco estaba abierto en ese momento
+	Saldo     float64 `json:"saldo"`
+}
+
+// SaldoGlobal es el saldo consolidado de la empresa en un momento dado:
+// cajas abiertas con sus movimientos hasta ese instante, cajas cerradas
+// con su SaldoFinal (que ya descuenta los retiros y se arrastra al arco
+// siguiente) y lo retirado de las cajas, que sigue siendo de la empresa.
+type SaldoGlobal struct {
+	Fecha         time.Time          `json:"fecha"`
+	SaldoTotal    float64            `json:"saldo_total"`
+	TotalIngresos float64            `json:"total_ingresos"` // Movimientos del día de Fecha
+	TotalEgresos  float64            `json:"total_egresos"`
+	TotalRetiros  float64            `json:"total_retiros"`
+	CajasAbiertas int                `json:"cajas_abiertas"`
+	CajasCerradas int                `json:"cajas_cerradas"`
+	Fuente        string             `json:"fuente"` // "calculado" o "snapshot"
+	Detalle       []SaldoCajaUsuario `json:"detalle,omitempty"`
+
+	// Retirado son los retiros de caja (RetiroCaja) hasta Fecha: pasan de un
+	// arco a la caja global, así que no cambian el saldo de la empresa
+	Retirado float64 `json:"retirado"`
+}
+
+// IPCMensual es la copia local de la serie mensual de IPC (INDEC vía ArgentinaDatos).
+// Los reportes en pesos reales se calculan con esta tabla, sin consultar la API externa.
+type IPCMensual struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Periodo       time.Time `gorm:"type:date;not null;uniqueIndex" json:"periodo"` // Primer día del mes
+	Pct           float64   `gorm:"type:decimal(8,2);not null" json:"pct"`         // Variación mensual: 2.4 = 2.4%
+	Fuente        string    `gorm:"size:100;not null" json:"fuente"`
+	ActualizadoEn time.Time `gorm:"not null" json:"actualizado_en"`
+
+	// Manual: cargado o corregido por un administrador; la sincronización no lo pisa
+	Manual     bool  `gorm:"not null;default:false" json:"manual"`
+	CargadoPor *uint `json:"cargado_por,omitempty"`
+}
+
+// IndiceValor es la copia local de los índices de nivel que actualizan
+// contratos (ICL, CER, UVA, CAC): un valor por fecha de publicación.
+type IndiceValor struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Indice        string    `gorm:"size:10;not null;uniqueIndex:idx_indice_fecha" json:"indice"`
+	Fecha         time.Time `gorm:"type:date;not null;uniqueIndex:idx_indice_fecha" json:"fecha"`
+	Valor         float64   `gorm:"type:decimal(18,6);not null" json:"valor"`
+	Fuente        string    `gorm:"size:100;not null" json:"fuente"`
+	ActualizadoEn time.Time `gorm:"not null" json:"actualizado_en"`
+	Manual        bool      `gorm:"not null;default:false" json:"manual"`
+	CargadoPor    *uint     `json:"cargado_por,omitempty"`
+}
+
+// Cotizacion es la copia local de la cotización diaria del dólar (oficial,
+// MEP o blue) con que se calculan en pesos los alquileres en dólares.
+type Cotizacion struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Tipo          string    `gorm:"size:10;not null;uniqueIndex:idx_cotizacion_fecha" json:"tipo"`
+	Fecha         time.Time `gorm:"type:date;not null;uniqueIndex:idx_cotizacion_fecha" json:"fecha"`
+	Compra        float64   `gorm:"type:decimal(18,4);not null" json:"compra"`
+	Venta         float64   `gorm:"type:decimal(18,4);not null" json:"venta"`
+	Fuente        string    `gorm:"size:100;not null" json:"fuente"`
+	ActualizadoEn time.Time `gorm:"not null" json:"actualizado_en"`
+	Manual        bool      `gorm:"not null;default:false" json:"manual"`
+	CargadoPor    *uint     `json:"cargado_por,omitempty"`
+}
+
+// ProgramacionReporte define un reporte que se envía por correo según una expresión cron.
+type ProgramacionReporte struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Nombre        string     `gorm:"size:150;not null" json:"nombre"`
+	Reporte       string     `gorm:"type:enum('cierre_diario','cobranza_alquileres');not null" json:"reporte"`
+	Formato       string     `gorm:"type:enum('pdf','xlsx');not null;default:'pdf'" json:"formato"`
+	Cron          string     `gorm:"size:100;not null" json:"cron"` // Formato estándar de 5 campos: "0 22 * * *"
+	Periodo       string     `gorm:"type:enum('actual','anterior');not null;default:'actual'" json:"periodo"`
+	Destinatarios string     `gorm:"type:text;not null" json:"destinatarios"` // Emails separados por coma
+	Activo        bool       `gorm:"default:true" json:"activo"`
+	UltimoEnvio   *time.Time `json:"ultimo_envio,omitempty"`
+	CreatedBy     uint       `gorm:"not null" json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// EnvioReporte es el registro de cada envío de un reporte programado.
+// Los envíos fallidos se reintentan hasta agotar los intentos.
+type EnvioReporte struct {
+	ID              uint                `gorm:"primaryKey;autoIncrement" json:"id"`
+	ProgramacionID  uint                `gorm:"not null;index" json:"programacion_id"`
+	FechaReferencia time.Time           `gorm:"not null" json:"fecha_referencia"` // Día o mes que cubre el reporte
+	Destinatarios   string              `gorm:"type:text;not null" json:"destinatarios"`
+	Archivo         string              `gorm:"size:200" json:"archivo"`
+	Estado          string              `gorm:"type:enum('pendiente','enviando','enviado','fallido');not null;default:'pendiente';index" json:"estado"`
+	Intentos        int                 `gorm:"default:0" json:"intentos"`
+	ProximoIntento  *time.Time          `json:"proximo_intento,omitempty"`
+	Error           string              `gorm:"type:text" json:"error,omitempty"`
+	EnviadoEn       *time.Time          `json:"enviado_en,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Programacion    ProgramacionReporte `gorm:"foreignKey:ProgramacionID" json:"programacion,omitempty"`
+}
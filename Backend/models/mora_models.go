@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NivelMora es un umbral de atraso: desde DiasDesde días después del
+// vencimiento (más los días de gracia) el período pasa al Estado indicado.
+type NivelMora struct {
+	Estado    EstadoPago `bson:"estado" json:"estado" binding:"required,oneof=late_1 late_2"`
+	DiasDesde int        `bson:"dias_desde" json:"dias_desde" binding:"min=1"`
+	// Nombre es la etiqueta que se muestra para el estado ("Atraso leve", ...)
+	Nombre string `bson:"nombre" json:"nombre"`
+}
+
+// ReglasMora es la configuración general de morosidad (documento "mora" de
+// configuracion_alquileres). Cada propiedad puede tener su propio día de
+// vencimiento y días de gracia.
+type ReglasMora struct {
+	// DiaVencimiento: día del mes en que vence el alquiler del período
+	DiaVencimiento int `bson:"dia_vencimiento" json:"dia_vencimiento" binding:"min=1,max=28"`
+	// DiasGracia: días después del vencimiento antes de contar el atraso
+	DiasGracia int         `bson:"dias_gracia" json:"dias_gracia" binding:"gte=0,lte=60"`
+	Niveles    []NivelMora `bson:"niveles" json:"niveles" binding:"required,min=1,max=2,dive"`
+	// Cron: cuándo corre la actualización automática ("min hora dia mes dia_semana")
+	Cron string `bson:"cron" json:"cron" binding:"required"`
+
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	UpdatedBy uint      `bson:"updated_by" json:"updated_by"`
+}
+
+// Orígenes de un cambio de estado de un período
+const (
+	OrigenMoraProgramada = "programado" // corrida automática del Scheduler
+	OrigenMoraManual     = "manual"     // POST /api/alquileres/actualizar-morosos
+	OrigenPago           = "pago"
+	OrigenReversionPago  = "reversion_pago"
+)
+
+// TransicionEstado registra un cambio de estado de un período de alquiler
+// (colección historial_estados_periodo).
+type TransicionEstado struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	PeriodoID      primitive.ObjectID  `bson:"periodo_id" json:"periodo_id"`
+	PropiedadID    primitive.ObjectID  `bson:"propiedad_id" json:"propiedad_id"`
+	ContratoID     *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+	Anio           int                 `bson:"anio" json:"anio"`
+	Mes            int                 `bson:"mes" json:"mes"`
+	EstadoAnterior EstadoPago          `bson:"estado_anterior" json:"estado_anterior"`
+	EstadoNuevo    EstadoPago          `bson:"estado_nuevo" json:"estado_nuevo"`
+	// DiasAtraso desde el vencimiento con gracia, al momento del cambio
+	DiasAtraso int       `bson:"dias_atraso" json:"dias_atraso"`
+	Origen     string    `bson:"origen" json:"origen"`
+	UsuarioID  uint      `bson:"usuario_id,omitempty" json:"usuario_id,omitempty"`
+	Fecha      time.Time `bson:"fecha" json:"fecha"`
+}
+
+// ResultadoMora resume una corrida de actualización de estados de morosidad
+type ResultadoMora struct {
+	Revisados    int `json:"revisados"`
+	Actualizados int `json:"actualizados"`
+}
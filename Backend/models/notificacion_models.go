@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TipoNotificacion es el motivo de un aviso al inquilino
+type TipoNotificacion string
+
+const (
+	NotifRecordatorio  TipoNotificacion = "recordatorio_vencimiento"
+	NotifMora          TipoNotificacion = "aviso_mora"
+	NotifActualizacion TipoNotificacion = "aviso_actualizacion"
+)
+
+// Canales de envío de avisos
+const (
+	CanalEmail    = "email"
+	CanalWhatsApp = "whatsapp"
+	CanalSMS      = "sms"
+)
+
+// Estados de un envío
+const (
+	NotifEnviando = "enviando" // registrado antes de enviar: reserva la clave del aviso
+	NotifEnviada  = "enviada"
+	NotifError    = "error"
+	NotifOmitida  = "omitida" // el inquilino no tiene el dato de contacto del canal
+)
+
+// PlantillaNotificacion es el texto de un aviso. Admite los campos
+// {{.Inquilino}}, {{.Direccion}}, {{.Periodo}}, {{.Monto}}, {{.Saldo}},
+// {{.Vencimiento}}, {{.DiasAtraso}}, {{.Estado}}, {{.MontoAnterior}},
+// {{.MontoNuevo}}, {{.VigenteDesde}} y {{.Emisor}}.
+type PlantillaNotificacion struct {
+	Asunto string `bson:"asunto" json:"asunto"` // solo email
+	Cuerpo string `bson:"cuerpo" json:"cuerpo" binding:"required"`
+}
+
+// ConfigNotificaciones es la configuración de avisos a inquilinos (documento
+// "notificaciones" de configuracion_alquileres).
+type ConfigNotificaciones struct {
+	Activo  bool     `bson:"activo" json:"activo"`
+	Canales []string `bson:"canales" json:"canales" binding:"required,min=1,dive,oneof=email whatsapp sms"`
+	// DiasAntes: cuántos días antes del vencimiento se envía el recordatorio
+	DiasAntes int `bson:"dias_antes" json:"dias_antes" binding:"gte=0,lte=28"`
+	// Cron: cuándo se revisan los recordatorios ("min hora dia mes dia_semana")
+	Cron       string                                     `bson:"cron" json:"cron" binding:"required"`
+	Plantillas map[TipoNotificacion]PlantillaNotificacion `bson:"plantillas" json:"plantillas"`
+
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	UpdatedBy uint      `bson:"updated_by" json:"updated_by"`
+}
+
+// Notificacion es un envío a un inquilino (colección notificaciones): el
+// registro de lo enviado por cada canal.
+type Notificacion struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Tipo        TipoNotificacion    `bson:"tipo" json:"tipo"`
+	Canal       string              `bson:"canal" json:"canal"`
+	PersonaID   *primitive.ObjectID `bson:"persona_id,omitempty" json:"persona_id,omitempty"`
+	Inquilino   string              `bson:"inquilino" json:"inquilino"`
+	Destino     string              `bson:"destino" json:"destino"` // email o teléfono
+	PropiedadID primitive.ObjectID  `bson:"propiedad_id" json:"propiedad_id"`
+	Direccion   string              `bson:"direccion" json:"direccion"`
+	ContratoID  *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+	Anio        int                 `bson:"anio,omitempty" json:"anio,omitempty"`
+	Mes         int                 `bson:"mes,omitempty" json:"mes,omitempty"`
+	Asunto      string              `bson:"asunto,omitempty" json:"asunto,omitempty"`
+	Cuerpo      string              `bson:"cuerpo" json:"cuerpo"`
+	Estado      string              `bson:"estado" json:"estado"`
+	Error       string              `bson:"error,omitempty" json:"error,omitempty"`
+	// Clave identifica el aviso (tipo, período, estado...) para no repetirlo
+	Clave string    `bson:"clave" json:"-"`
+	Fecha time.Time `bson:"fecha" json:"fecha"`
+
+	// Intentos cuenta los reintentos de un envío con error (ver ReintentarFallidos)
+	Intentos int `bson:"intentos" json:"intentos"`
+}
+
+// FiltroNotificaciones son los filtros del registro de envíos
+type FiltroNotificaciones struct {
+	PropiedadID string
+	PersonaID   string
+	Tipo        string
+	Estado      string
+	Limite      int64
+}
+
+// ResultadoRecordatorios resume una corrida de recordatorios de vencimiento
+type ResultadoRecordatorios struct {
+	Periodos int `json:"periodos"`
+	Enviados int `json:"enviados"`
+	Errores  int `json:"errores"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TramoOcupacion es un período continuo en que la propiedad estuvo ocupada o
+// desocupada (colección historial_ocupacion). El tramo en curso no tiene Hasta.
+type TramoOcupacion struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PropiedadID primitive.ObjectID `bson:"propiedad_id" json:"propiedad_id"`
+	Ocupada     bool               `bson:"ocupada" json:"ocupada"`
+	Desde       time.Time          `bson:"desde" json:"desde"`
+	Hasta       *time.Time         `bson:"hasta,omitempty" json:"hasta,omitempty"` // exclusivo
+	// Motivo del cambio: inicio de contrato, fin de plazo, rescisión, refacción, etc.
+	Motivo     string              `bson:"motivo" json:"motivo"`
+	ContratoID *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+	Inquilino  string              `bson:"inquilino,omitempty" json:"inquilino,omitempty"`
+	// AlquilerMensual es el alquiler del contrato (ocupada) o el de referencia
+	// para estimar lo que se dejó de cobrar (desocupada)
+	AlquilerMensual float64   `bson:"alquiler_mensual" json:"alquiler_mensual"`
+	CreatedAt       time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `bson:"updated_at" json:"updated_at"`
+
+	// Calculados al consultar
+	Dias           int     `bson:"-" json:"dias"`
+	IngresoPerdido float64 `bson:"-" json:"ingreso_perdido,omitempty"`
+}
+
+// ActualizarTramoRequest corrige el motivo o el alquiler de referencia de un tramo
+type ActualizarTramoRequest struct {
+	Motivo          *string  `json:"motivo"`
+	AlquilerMensual *float64 `json:"alquiler_mensual" binding:"omitempty,gte=0"`
+}
+
+// MetricasOcupacion resume la ocupación de un rango de fechas. Los días se
+// cuentan desde el alta de cada propiedad.
+type MetricasOcupacion struct {
+	DiasOcupada    int     `json:"dias_ocupada"`
+	DiasDesocupada int     `json:"dias_desocupada"`
+	TasaOcupacion  float64 `json:"tasa_ocupacion"` // % de días ocupada
+	// Vacancias que transcurrieron (total o parcialmente) en el rango; la
+	// duración es la de toda la vacancia (la abierta, hasta hoy)
+	Vacancias            int     `json:"vacancias"`
+	VacanciaPromedioDias float64 `json:"vacancia_promedio_dias"`
+	VacanciaMaximaDias   int     `json:"vacancia_maxima_dias"`
+	// IngresoPerdido estima el alquiler no cobrado en los días desocupados del rango
+	IngresoPerdido float64 `json:"ingreso_perdido"`
+}
+
+// HistorialOcupacion es la línea de tiempo de ocupación de una propiedad
+type HistorialOcupacion struct {
+	PropiedadID primitive.ObjectID `json:"propiedad_id"`
+	Direccion   string             `json:"direccion"`
+	Ocupada     bool               `json:"ocupada"`
+	// DiasEnEstado es la antigüedad del tramo en curso
+	DiasEnEstado int              `json:"dias_en_estado"`
+	Tramos       []TramoOcupacion `json:"tramos"`
+	MetricasOcupacion
+}
+
+// ReporteOcupacion son las métricas de ocupación de todas las propiedades en un rango
+type ReporteOcupacion struct {
+	Desde time.Time `json:"desde"`
+	Hasta time.Time `json:"hasta"`
+	MetricasOcupacion
+	PorPropiedad []OcupacionPropiedad `json:"por_propiedad"`
+}
+
+// OcupacionPropiedad son las métricas de una propiedad en el rango del reporte
+type OcupacionPropiedad struct {
+	PropiedadID primitive.ObjectID `json:"propiedad_id"`
+	Direccion   string             `json:"direccion"`
+	Ocupada     bool               `json:"ocupada"`
+	MetricasOcupacion
+}
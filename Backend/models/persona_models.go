@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles de una persona en los contratos
+const (
+	RolInquilino = "inquilino"
+	RolGarante   = "garante"
+)
+
+// Persona es un inquilino o garante del padrón (colección personas). El rol
+// surge de los contratos: la misma persona puede ser inquilino en uno y
+// garante en otro.
+type Persona struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Nombre string             `bson:"nombre" json:"nombre"`
+	// Documento: DNI o CUIT/CUIL solo con dígitos (vacío si no se cargó)
+	TipoDocumento string    `bson:"tipo_documento" json:"tipo_documento"`
+	Documento     string    `bson:"documento" json:"documento"`
+	Telefono      string    `bson:"telefono" json:"telefono"`
+	Email         string    `bson:"email" json:"email"`
+	Domicilio     string    `bson:"domicilio" json:"domicilio"`
+	Notas         string    `bson:"notas" json:"notas"`
+	CreatedBy     uint      `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// PersonaRequest es el body para crear o modificar una persona
+type PersonaRequest struct {
+	Nombre        string `json:"nombre" binding:"required"`
+	TipoDocumento string `json:"tipo_documento" binding:"omitempty,oneof=dni cuit cuil pasaporte"`
+	Documento     string `json:"documento"`
+	Telefono      string `json:"telefono"`
+	Email         string `json:"email" binding:"omitempty,email"`
+	Domicilio     string `json:"domicilio"`
+	Notas         string `json:"notas"`
+}
+
+// ContratoPersona es un contrato en el que participó una persona
+type ContratoPersona struct {
+	Rol       string   `json:"rol"`
+	Direccion string   `json:"direccion"`
+	Contrato  Contrato `json:"contrato"`
+}
+
+// PagoPersona es un cobro de alquiler de un contrato en el que la persona es inquilino
+type PagoPersona struct {
+	ContratoID  primitive.ObjectID `json:"contrato_id"`
+	PropiedadID primitive.ObjectID `json:"propiedad_id"`
+	Direccion   string             `json:"direccion"`
+	Anio        int                `json:"anio"`
+	Mes         int                `json:"mes"`
+	Fecha       *time.Time         `json:"fecha,omitempty"`
+	Monto       float64            `json:"monto"`
+	Punitorio   float64            `json:"punitorio"`
+	MovementID  *uint              `json:"movement_id,omitempty"`
+}
+
+// PersonaDetalle es la ficha de una persona con sus contratos e historial de pagos
+type PersonaDetalle struct {
+	Persona
+	Roles          []string          `json:"roles"`
+	Contratos      []ContratoPersona `json:"contratos"`
+	Pagos          []PagoPersona     `json:"pagos"`
+	TotalPagado    float64           `json:"total_pagado"`
+	TotalAdeudado  float64           `json:"total_adeudado"`
+	MesesAdeudados int               `json:"meses_adeudados"`
+}
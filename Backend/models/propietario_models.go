@@ -0,0 +1,193 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Propietario es el dueño de una propiedad administrada por terceros
+// (colección propietarios). Las propiedades sin propietario son propias.
+type Propietario struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Nombre        string             `bson:"nombre" json:"nombre"`
+	TipoDocumento string             `bson:"tipo_documento" json:"tipo_documento"`
+	Documento     string             `bson:"documento" json:"documento"`
+	Telefono      string             `bson:"telefono" json:"telefono"`
+	Email         string             `bson:"email" json:"email"`
+	Domicilio     string             `bson:"domicilio" json:"domicilio"`
+	// Datos para transferirle la liquidación
+	CBU   string `bson:"cbu" json:"cbu"`
+	Alias string `bson:"alias" json:"alias"`
+	// ComisionPct es la comisión que toman por defecto sus contratos nuevos
+	ComisionPct float64   `bson:"comision_pct" json:"comision_pct"`
+	Notas       string    `bson:"notas" json:"notas"`
+	CreatedBy   uint      `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// PropietarioRequest es el body para crear o modificar un propietario
+type PropietarioRequest struct {
+	Nombre        string `json:"nombre" binding:"required"`
+	TipoDocumento string `json:"tipo_documento" binding:"omitempty,oneof=dni cuit cuil pasaporte"`
+	Documento     string `json:"documento"`
+	Telefono      string `json:"telefono"`
+	Email         string `json:"email" binding:"omitempty,email"`
+	Domicilio     string `json:"domicilio"`
+	CBU           string `json:"cbu" binding:"omitempty,len=22,numeric"`
+	Alias         string `json:"alias"`
+	Notas         string `json:"notas"`
+
+	ComisionPct float64 `json:"comision_pct" binding:"gte=0,lte=100"`
+}
+
+// PropietarioDetalle es la ficha del propietario con sus propiedades
+type PropietarioDetalle struct {
+	Propietario
+	Propiedades []Propiedad `json:"propiedades"`
+}
+
+// Categorías de gastos de una propiedad
+const (
+	GastoReparacion = "reparacion"
+	GastoABL        = "abl"
+	GastoExpensas   = "expensas"
+	GastoImpuestos  = "impuestos"
+	GastoServicios  = "servicios"
+	GastoSeguro     = "seguro"
+	GastoOtro       = "otro"
+)
+
+// Quién soporta un gasto
+const (
+	GastoACargoPropietario    = "propietario"
+	GastoACargoInquilino      = "inquilino"
+	GastoACargoAdministracion = "administracion"
+)
+
+// GastoPropiedad es un costo de una propiedad (colección gastos_propiedad).
+// Los que están a cargo del propietario se descuentan en su próxima
+// liquidación; los del inquilino solo se registran para recuperarlos.
+type GastoPropiedad struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	PropiedadID   primitive.ObjectID  `bson:"propiedad_id" json:"propiedad_id"`
+	PropietarioID *primitive.ObjectID `bson:"propietario_id,omitempty" json:"propietario_id,omitempty"`
+	Fecha         time.Time           `bson:"fecha" json:"fecha"`
+	Categoria     string              `bson:"categoria" json:"categoria"`
+	Descripcion   string              `bson:"descripcion" json:"descripcion"`
+	Monto         float64             `bson:"monto" json:"monto"`
+	// ACargoDe: propietario, inquilino o administracion (vacío en gastos
+	// anteriores = propietario)
+	ACargoDe string `bson:"a_cargo_de,omitempty" json:"a_cargo_de"`
+	// MovementID es el Egreso de caja con el que se pagó (si se registró en caja).
+	// MovementVinculado indica que el Egreso se cargó aparte y solo se vinculó:
+	// al borrar el gasto no se anula.
+	MovementID        *uint `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+	MovementVinculado bool  `bson:"movement_vinculado,omitempty" json:"movement_vinculado,omitempty"`
+	// LiquidacionID es la liquidación que lo descontó (nil = pendiente)
+	LiquidacionID *primitive.ObjectID `bson:"liquidacion_id,omitempty" json:"liquidacion_id,omitempty"`
+	CreatedBy     uint                `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+
+	// GastoEdificioID indica que es la parte de la unidad en un gasto común
+	// del edificio: se modifica o elimina desde el gasto del edificio
+	GastoEdificioID *primitive.ObjectID `bson:"gasto_edificio_id,omitempty" json:"gasto_edificio_id,omitempty"`
+}
+
+// CrearGastoRequest es el body para cargar un gasto de una propiedad
+type CrearGastoRequest struct {
+	Categoria   string     `json:"categoria" binding:"required,oneof=reparacion abl expensas impuestos servicios seguro otro"`
+	Descripcion string     `json:"descripcion"`
+	Monto       float64    `json:"monto" binding:"required,gt=0"`
+	Fecha       *time.Time `json:"fecha"`
+	// ACargoDe por defecto: propietario si la propiedad es de terceros,
+	// administracion si es propia
+	ACargoDe string `json:"a_cargo_de" binding:"omitempty,oneof=propietario inquilino administracion"`
+	// RegistrarEnCaja crea el Egreso en la caja por el pago del gasto;
+	// MovementID vincula en cambio un Egreso ya cargado
+	RegistrarEnCaja bool  `json:"registrar_en_caja"`
+	MovementID      *uint `json:"movement_id"`
+}
+
+// ActualizarGastoRequest es el body para corregir un gasto no liquidado
+type ActualizarGastoRequest struct {
+	Categoria   *string    `json:"categoria" binding:"omitempty,oneof=reparacion abl expensas impuestos servicios seguro otro"`
+	Descripcion *string    `json:"descripcion"`
+	Monto       *float64   `json:"monto" binding:"omitempty,gt=0"`
+	Fecha       *time.Time `json:"fecha"`
+	ACargoDe    *string    `json:"a_cargo_de" binding:"omitempty,oneof=propietario inquilino administracion"`
+	// MovementID vincula un Egreso de caja (0 = desvincular)
+	MovementID *uint `json:"movement_id"`
+}
+
+// RentabilidadPropiedad es el resultado de una propiedad en el año: lo cobrado
+// menos los gastos que no recaen sobre el inquilino.
+type RentabilidadPropiedad struct {
+	PropiedadID     primitive.ObjectID `json:"propiedad_id"`
+	Direccion       string             `json:"direccion"`
+	Ingresos        float64            `json:"ingresos"`
+	Gastos          float64            `json:"gastos"`
+	GastosInquilino float64            `json:"gastos_inquilino"`
+	Resultado       float64            `json:"resultado"`
+	// Margen: Resultado / Ingresos en % (0 si no hubo ingresos)
+	Margen float64 `json:"margen"`
+}
+
+// EstadoLiquidacion define el ciclo de una liquidación a propietario
+type EstadoLiquidacion string
+
+const (
+	LiquidacionPendiente EstadoLiquidacion = "pendiente"
+	LiquidacionPagada    EstadoLiquidacion = "pagada"
+	LiquidacionAnulada   EstadoLiquidacion = "anulada"
+)
+
+// ItemLiquidacion es un cobro de alquiler incluido en una liquidación
+type ItemLiquidacion struct {
+	CobroID     primitive.ObjectID  `bson:"cobro_id" json:"cobro_id"`
+	PropiedadID primitive.ObjectID  `bson:"propiedad_id" json:"propiedad_id"`
+	Direccion   string              `bson:"direccion" json:"direccion"`
+	ContratoID  *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+	Inquilino   string              `bson:"inquilino" json:"inquilino"`
+	Anio        int                 `bson:"anio" json:"anio"`
+	Mes         int                 `bson:"mes" json:"mes"`
+	Fecha       time.Time           `bson:"fecha" json:"fecha"`
+	Monto       float64             `bson:"monto" json:"monto"`
+	ComisionPct float64             `bson:"comision_pct" json:"comision_pct"`
+	Comision    float64             `bson:"comision" json:"comision"`
+}
+
+// LiquidacionPropietario es la rendición mensual a un propietario (colección
+// liquidaciones_propietario): alquileres cobrados menos comisión y gastos.
+// Un saldo negativo (gastos mayores a lo cobrado) pasa a la liquidación siguiente.
+type LiquidacionPropietario struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PropietarioID primitive.ObjectID `bson:"propietario_id" json:"propietario_id"`
+	Propietario   string             `bson:"propietario" json:"propietario"`
+	Anio          int                `bson:"anio" json:"anio"`
+	Mes           int                `bson:"mes" json:"mes"` // 0-11
+
+	Items  []ItemLiquidacion `bson:"items" json:"items"`
+	Gastos []GastoPropiedad  `bson:"gastos" json:"gastos"`
+
+	TotalCobrado  float64 `bson:"total_cobrado" json:"total_cobrado"`
+	TotalComision float64 `bson:"total_comision" json:"total_comision"`
+	TotalGastos   float64 `bson:"total_gastos" json:"total_gastos"`
+	SaldoAnterior float64 `bson:"saldo_anterior" json:"saldo_anterior"`
+	Neto          float64 `bson:"neto" json:"neto"`
+
+	Estado     EstadoLiquidacion `bson:"estado" json:"estado"`
+	MovementID *uint             `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+	PagadaEn   *time.Time        `bson:"pagada_en,omitempty" json:"pagada_en,omitempty"`
+	PagadaPor  uint              `bson:"pagada_por,omitempty" json:"pagada_por,omitempty"`
+	AnuladaEn  *time.Time        `bson:"anulada_en,omitempty" json:"anulada_en,omitempty"`
+	CreatedBy  uint              `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time         `bson:"created_at" json:"created_at"`
+}
+
+// GenerarLiquidacionRequest es el body para liquidar un mes a un propietario
+type GenerarLiquidacionRequest struct {
+	Anio int `json:"anio" binding:"required,min=2000,max=2100"`
+	Mes  int `json:"mes" binding:"min=0,max=11"`
+}
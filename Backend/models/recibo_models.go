@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EstadoRecibo: un recibo emitido solo se anula (nunca se borra ni se renumera)
+type EstadoRecibo string
+
+const (
+	ReciboEmitido EstadoRecibo = "emitido"
+	ReciboAnulado EstadoRecibo = "anulado"
+)
+
+// Medios de pago admitidos en un cobro de alquiler
+const (
+	MedioEfectivo      = "efectivo"
+	MedioTransferencia = "transferencia"
+	MedioCheque        = "cheque"
+	MedioOtro          = "otro"
+)
+
+// Recibo es el comprobante entregado al inquilino por un cobro de alquiler
+// (colección recibos). Guarda una copia de los datos impresos para que la
+// reimpresión sea idéntica aunque cambien la propiedad o el contrato.
+type Recibo struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// Numeración propia: punto de venta + número correlativo (0001-00000123)
+	PuntoVenta       int    `bson:"punto_venta" json:"punto_venta"`
+	Numero           int64  `bson:"numero" json:"numero"`
+	NumeroFormateado string `bson:"numero_formateado" json:"numero_formateado"`
+
+	// ── Qué se cobró ─────────────────────────────────────────────────────────
+	PropiedadID primitive.ObjectID  `bson:"propiedad_id" json:"propiedad_id"`
+	Direccion   string              `bson:"direccion" json:"direccion"`
+	ContratoID  *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+	InquilinoID *primitive.ObjectID `bson:"inquilino_id,omitempty" json:"inquilino_id,omitempty"`
+	Inquilino   string              `bson:"inquilino" json:"inquilino"`
+	PeriodoID   primitive.ObjectID  `bson:"periodo_id" json:"periodo_id"`
+	CobroID     primitive.ObjectID  `bson:"cobro_id" json:"cobro_id"`
+	Anio        int                 `bson:"anio" json:"anio"`
+	Mes         int                 `bson:"mes" json:"mes"` // 0-11
+
+	// ── Importes ─────────────────────────────────────────────────────────────
+	Monto         float64 `bson:"monto" json:"monto"`
+	Capital       float64 `bson:"capital" json:"capital"`
+	Punitorio     float64 `bson:"punitorio" json:"punitorio"`
+	MontoEnLetras string  `bson:"monto_en_letras" json:"monto_en_letras"`
+	MedioPago     string  `bson:"medio_pago" json:"medio_pago"`
+	// Parcial indica que el cobro no saldó el período
+	Parcial bool `bson:"parcial" json:"parcial"`
+
+	// ── Emisión ──────────────────────────────────────────────────────────────
+	Emisor          string    `bson:"emisor" json:"emisor"`
+	EmisorCUIT      string    `bson:"emisor_cuit,omitempty" json:"emisor_cuit,omitempty"`
+	EmisorDomicilio string    `bson:"emisor_domicilio,omitempty" json:"emisor_domicilio,omitempty"`
+	EmitidoPor      uint      `bson:"emitido_por" json:"emitido_por"`
+	FechaEmision    time.Time `bson:"fecha_emision" json:"fecha_emision"`
+	MovementID      *uint     `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+	// Impresiones cuenta las reimpresiones; con alguna, el PDF se marca DUPLICADO
+	Impresiones int `bson:"impresiones" json:"impresiones"`
+
+	// ── Anulación ────────────────────────────────────────────────────────────
+	Estado          EstadoRecibo `bson:"estado" json:"estado"`
+	AnuladoEn       *time.Time   `bson:"anulado_en,omitempty" json:"anulado_en,omitempty"`
+	AnuladoPor      uint         `bson:"anulado_por,omitempty" json:"anulado_por,omitempty"`
+	MotivoAnulacion string       `bson:"motivo_anulacion,omitempty" json:"motivo_anulacion,omitempty"`
+}
+
+// FiltroRecibos son los filtros del listado de recibos (todos opcionales)
+type FiltroRecibos struct {
+	PropiedadID string
+	InquilinoID string
+	Estado      string
+	Desde       *time.Time
+	Hasta       *time.Time
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Prioridades de un reclamo de mantenimiento
+const (
+	PrioridadBaja    = "baja"
+	PrioridadMedia   = "media"
+	PrioridadAlta    = "alta"
+	PrioridadUrgente = "urgente"
+)
+
+// Estados de un reclamo de mantenimiento
+const (
+	ReclamoAbierto   = "abierto"
+	ReclamoAsignado  = "asignado" // con proveedor asignado
+	ReclamoEnCurso   = "en_curso"
+	ReclamoResuelto  = "resuelto"
+	ReclamoCerrado   = "cerrado" // resuelto y conforme: no admite más cambios
+	ReclamoCancelado = "cancelado"
+)
+
+// TransicionesReclamo son los cambios de estado permitidos
+var TransicionesReclamo = map[string][]string{
+	ReclamoAbierto:   {ReclamoAsignado, ReclamoEnCurso, ReclamoCancelado},
+	ReclamoAsignado:  {ReclamoAbierto, ReclamoEnCurso, ReclamoCancelado},
+	ReclamoEnCurso:   {ReclamoAsignado, ReclamoResuelto, ReclamoCancelado},
+	ReclamoResuelto:  {ReclamoEnCurso, ReclamoCerrado},
+	ReclamoCerrado:   {},
+	ReclamoCancelado: {ReclamoAbierto},
+}
+
+// EstadosReclamoPendientes son los reclamos que siguen sin resolver
+var EstadosReclamoPendientes = []string{ReclamoAbierto, ReclamoAsignado, ReclamoEnCurso}
+
+// CambioEstadoReclamo es una entrada del historial de un reclamo
+type CambioEstadoReclamo struct {
+	Estado    string    `bson:"estado" json:"estado"`
+	Fecha     time.Time `bson:"fecha" json:"fecha"`
+	UsuarioID uint      `bson:"usuario_id" json:"usuario_id"`
+	Nota      string    `bson:"nota,omitempty" json:"nota,omitempty"`
+}
+
+// Reclamo es un pedido de mantenimiento de una propiedad (colección reclamos):
+// una pérdida, un artefacto roto, etc.
+type Reclamo struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Numero      int64               `bson:"numero" json:"numero"`
+	PropiedadID primitive.ObjectID  `bson:"propiedad_id" json:"propiedad_id"`
+	Direccion   string              `bson:"direccion" json:"direccion"`
+	ContratoID  *primitive.ObjectID `bson:"contrato_id,omitempty" json:"contrato_id,omitempty"`
+
+	// Quién lo informó (por defecto, el inquilino del contrato vigente)
+	ReportadoPor string              `bson:"reportado_por" json:"reportado_por"`
+	Telefono     string              `bson:"telefono" json:"telefono"`
+	PersonaID    *primitive.ObjectID `bson:"persona_id,omitempty" json:"persona_id,omitempty"`
+
+	Titulo      string `bson:"titulo" json:"titulo"`
+	Descripcion string `bson:"descripcion" json:"descripcion"`
+	// URLs de las fotos en el almacén de archivos (ver Propiedad.Imagenes)
+	Imagenes  []string `bson:"imagenes" json:"imagenes"`
+	Prioridad string   `bson:"prioridad" json:"prioridad"`
+	Estado    string   `bson:"estado" json:"estado"`
+
+	// Proveedor asignado (plomero, gasista, service, etc.)
+	Proveedor         string `bson:"proveedor,omitempty" json:"proveedor,omitempty"`
+	ProveedorTelefono string `bson:"proveedor_telefono,omitempty" json:"proveedor_telefono,omitempty"`
+
+	// Costo es el presupuesto o, una vez pagado, el monto del gasto. El pago se
+	// registra como GastoPropiedad (GastoID) con su Egreso de caja (MovementID).
+	Costo      float64             `bson:"costo" json:"costo"`
+	GastoID    *primitive.ObjectID `bson:"gasto_id,omitempty" json:"gasto_id,omitempty"`
+	MovementID *uint               `bson:"movement_id,omitempty" json:"movement_id,omitempty"`
+
+	Historial       []CambioEstadoReclamo `bson:"historial" json:"historial"`
+	FechaResolucion *time.Time            `bson:"fecha_resolucion,omitempty" json:"fecha_resolucion,omitempty"`
+	CreatedBy       uint                  `bson:"created_by" json:"created_by"`
+	CreatedAt       time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `bson:"updated_at" json:"updated_at"`
+}
+
+// CrearReclamoRequest es el body para cargar un reclamo de una propiedad
+type CrearReclamoRequest struct {
+	Titulo       string   `json:"titulo" binding:"required"`
+	Descripcion  string   `json:"descripcion"`
+	Prioridad    string   `json:"prioridad" binding:"omitempty,oneof=baja media alta urgente"`
+	ReportadoPor string   `json:"reportado_por"`
+	Telefono     string   `json:"telefono"`
+	PersonaID    string   `json:"persona_id"`
+	Imagenes     []string `json:"imagenes"` // URLs o data-URLs base64
+	// Proveedor: si se indica, el reclamo queda asignado
+	Proveedor         string  `json:"proveedor"`
+	ProveedorTelefono string  `json:"proveedor_telefono"`
+	Costo             float64 `json:"costo" binding:"gte=0"`
+}
+
+// ActualizarReclamoRequest es el body para modificar un reclamo
+type ActualizarReclamoRequest struct {
+	Titulo            *string   `json:"titulo"`
+	Descripcion       *string   `json:"descripcion"`
+	Prioridad         *string   `json:"prioridad" binding:"omitempty,oneof=baja media alta urgente"`
+	ReportadoPor      *string   `json:"reportado_por"`
+	Telefono          *string   `json:"telefono"`
+	Imagenes          *[]string `json:"imagenes"`
+	Proveedor         *string   `json:"proveedor"`
+	ProveedorTelefono *string   `json:"proveedor_telefono"`
+	Costo             *float64  `json:"costo" binding:"omitempty,gte=0"`
+}
+
+// CambiarEstadoReclamoRequest es el body para avanzar un reclamo en su flujo
+type CambiarEstadoReclamoRequest struct {
+	Estado string `json:"estado" binding:"required,oneof=abierto asignado en_curso resuelto cerrado cancelado"`
+	Nota   string `json:"nota"`
+}
+
+// PagarReclamoRequest registra el pago del arreglo como gasto de la propiedad.
+// RegistrarEnCaja crea el Egreso; MovementID vincula uno ya cargado.
+type PagarReclamoRequest struct {
+	Monto           float64    `json:"monto" binding:"required,gt=0"`
+	Fecha           *time.Time `json:"fecha"`
+	ACargoDe        string     `json:"a_cargo_de" binding:"omitempty,oneof=propietario inquilino administracion"`
+	RegistrarEnCaja bool       `json:"registrar_en_caja"`
+	MovementID      *uint      `json:"movement_id"`
+}
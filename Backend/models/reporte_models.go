@@ -0,0 +1,157 @@
+package models
+
+import "time"
+
+// MontosReporte agrupa los totales de un período por tipo de movimiento.
+type MontosReporte struct {
+	Ingresos float64 `json:"ingresos"`
+	Egresos  float64 `json:"egresos"`
+	Retiros  float64 `json:"retiros"`
+	Neto     float64 `json:"neto"` // Ingresos - Egresos - Retiros
+}
+
+// ResumenMensual son los totales de un mes, en pesos nominales y, si se pidió,
+// en pesos reales (constantes del mes base).
+type ResumenMensual struct {
+	Periodo     string         `json:"periodo"` // "2025-03"
+	Nominal     MontosReporte  `json:"nominal"`
+	Real        *MontosReporte `json:"real,omitempty"`
+	FactorIPC   float64        `json:"factor_ipc,omitempty"`   // Multiplicador nominal -> real
+	IPCEstimado bool           `json:"ipc_estimado,omitempty"` // true si no hay IPC publicado para el mes
+}
+
+// ReporteMensual es el reporte agregado mes a mes de un rango.
+type ReporteMensual struct {
+	Desde            string           `json:"desde"`
+	Hasta            string           `json:"hasta"`
+	BaseReal         string           `json:"base_real,omitempty"` // Mes base de los pesos reales ("2025-06")
+	Meses            []ResumenMensual `json:"meses"`
+	TotalNominal     MontosReporte    `json:"total_nominal"`
+	TotalReal        *MontosReporte   `json:"total_real,omitempty"`
+	FuenteIPC        string           `json:"fuente_ipc,omitempty"`
+	IPCActualizado   *time.Time       `json:"ipc_actualizado,omitempty"`
+	UltimoPeriodoIPC string           `json:"ultimo_periodo_ipc,omitempty"`
+}
+
+// VariacionMensual compara un mes con el mismo mes del año anterior.
+// Los porcentajes son nil cuando el año anterior no tuvo movimientos.
+type VariacionMensual struct {
+	Mes                int      `json:"mes"` // 1-12
+	IngresosNominalPct *float64 `json:"ingresos_nominal_pct"`
+	IngresosRealPct    *float64 `json:"ingresos_real_pct"`
+	EgresosNominalPct  *float64 `json:"egresos_nominal_pct"`
+	EgresosRealPct     *float64 `json:"egresos_real_pct"`
+}
+
+// ComparativoInteranual compara un año con el anterior en pesos nominales y reales.
+type ComparativoInteranual struct {
+	Anio        int                `json:"anio"`
+	BaseReal    string             `json:"base_real"`
+	Actual      *ReporteMensual    `json:"actual"`
+	Anterior    *ReporteMensual    `json:"anterior"`
+	Variaciones []VariacionMensual `json:"variaciones"`
+}
+
+// ProgramacionReporteRequest es el body para crear o editar un reporte programado.
+type ProgramacionReporteRequest struct {
+	Nombre        string   `json:"nombre" binding:"required"`
+	Reporte       string   `json:"reporte" binding:"required,oneof=cierre_diario cobranza_alquileres"`
+	Formato       string   `json:"formato" binding:"required,oneof=pdf xlsx"`
+	Cron          string   `json:"cron" binding:"required"`
+	Periodo       string   `json:"periodo" binding:"omitempty,oneof=actual anterior"`
+	Destinatarios []string `json:"destinatarios" binding:"required,min=1,dive,email"`
+	Activo        *bool    `json:"activo"`
+}
+
+// DesempenoCajero resume el comportamiento de un usuario en sus arcos del período.
+// Faltantes y sobrantes solo se cuentan en arcos cerrados con conteo de efectivo.
+type DesempenoCajero struct {
+	OwnerID               uint              `json:"owner_id"`
+	Nombre                string            `json:"nombre"`
+	Email                 string            `json:"email"`
+	CantidadArcos         int               `json:"cantidad_arcos"`
+	ArcosCerrados         int               `json:"arcos_cerrados"`
+	DuracionPromedioMin   float64           `json:"duracion_promedio_min"`
+	ArcosConConteo        int               `json:"arcos_con_conteo"`
+	ArcosConFaltante      int               `json:"arcos_con_faltante"`
+	ArcosConSobrante      int               `json:"arcos_con_sobrante"`
+	TotalFaltante         float64           `json:"total_faltante"`
+	TotalSobrante         float64           `json:"total_sobrante"`
+	FaltantePromedio      float64           `json:"faltante_promedio"` // Por arco con conteo
+	SobrantePromedio      float64           `json:"sobrante_promedio"` // Por arco con conteo
+	DiferenciaNeta        float64           `json:"diferencia_neta"`
+	CierresAutomaticos    int               `json:"cierres_automaticos"`
+	PctCierresAutomaticos float64           `json:"pct_cierres_automaticos"` // Sobre arcos cerrados
+	MovimientosEliminados int               `json:"movimientos_eliminados"`  // Eliminados por el usuario (DeletedBy)
+	Tendencia             []TendenciaCajero `json:"tendencia"`
+}
+
+// TendenciaCajero son los indicadores de un cajero en un mes.
+type TendenciaCajero struct {
+	Periodo               string  `json:"periodo"` // "2006-01"
+	CantidadArcos         int     `json:"cantidad_arcos"`
+	DuracionPromedioMin   float64 `json:"duracion_promedio_min"`
+	ArcosConConteo        int     `json:"arcos_con_conteo"`
+	TotalFaltante         float64 `json:"total_faltante"`
+	TotalSobrante         float64 `json:"total_sobrante"`
+	CierresAutomaticos    int     `json:"cierres_automaticos"`
+	MovimientosEliminados int     `json:"movimientos_eliminados"`
+}
+
+// ReporteCajeros es el reporte de discrepancias y desempeño por usuario.
+type ReporteCajeros struct {
+	Desde   string            `json:"desde"`
+	Hasta   string            `json:"hasta"`
+	Cajeros []DesempenoCajero `json:"cajeros"`
+}
+
+// ProyeccionFlujo es la proyección de ingresos y egresos de caja de los próximos meses.
+type ProyeccionFlujo struct {
+	Desde               string                `json:"desde"` // Primer mes proyectado (el actual)
+	Meses               int                   `json:"meses"`
+	MesesHistoria       int                   `json:"meses_historia"`        // Meses usados para promediar egresos
+	InflacionMensualPct float64               `json:"inflacion_mensual_pct"` // Supuesto para aumentos y egresos
+	InflacionEstimada   bool                  `json:"inflacion_estimada"`    // true si no hay IPC local y se asumió 0
+	SaldoInicial        float64               `json:"saldo_inicial"`         // Saldo global actual
+	Periodos            []PeriodoProyeccion   `json:"periodos"`
+	Propiedades         []ProyeccionPropiedad `json:"propiedades"`
+	Conceptos           []ProyeccionConcepto  `json:"conceptos"`
+	Totales             PeriodoProyeccion     `json:"totales"`
+}
+
+// PeriodoProyeccion son los flujos esperados de un mes.
+// En el mes actual solo se proyecta lo que falta cobrar/gastar.
+type PeriodoProyeccion struct {
+	Periodo             string  `json:"periodo"`
+	AlquileresContrato  float64 `json:"alquileres_contrato"` // Sin ponderar por historial
+	AlquileresEsperados float64 `json:"alquileres_esperados"`
+	SaldoVencido        float64 `json:"saldo_vencido"` // Deuda de meses anteriores, incluida en el mes actual
+	OtrosIngresos       float64 `json:"otros_ingresos"`
+	Egresos             float64 `json:"egresos"`
+	Neto                float64 `json:"neto"`
+	SaldoProyectado     float64 `json:"saldo_proyectado"`
+	AumentosProgramados int     `json:"aumentos_programados"` // Propiedades que actualizan monto ese mes
+}
+
+// ProyeccionPropiedad detalla el cobro esperado de una propiedad.
+type ProyeccionPropiedad struct {
+	ID                string    `json:"id"`
+	Direccion         string    `json:"direccion"`
+	Inquilino         string    `json:"inquilino"`
+	MontoActual       float64   `json:"monto_actual"`
+	SaldoVencido      float64   `json:"saldo_vencido"`      // Adeudado de meses anteriores
+	ProbabilidadCobro float64   `json:"probabilidad_cobro"` // 0..1 según meses pagados
+	HistorialPropio   bool      `json:"historial_propio"`   // false si se usó el promedio de la cartera
+	Montos            []float64 `json:"montos"`             // Saldo a cobrar por período (0 si ya pagó)
+	Aumentos          []string  `json:"aumentos"`           // Períodos con actualización por IPC
+}
+
+// ProyeccionConcepto es el promedio mensual histórico de un concepto.
+type ProyeccionConcepto struct {
+	ConceptID           uint    `json:"concept_id"`
+	Nombre              string  `json:"nombre"`
+	Tipo                string  `json:"tipo"`             // Ingreso o Egreso
+	PromedioMensual     float64 `json:"promedio_mensual"` // En pesos del último mes completo
+	MesesConMovimiento  int     `json:"meses_con_movimiento"`
+	RegistradoMesActual float64 `json:"registrado_mes_actual"`
+}
@@ -49,6 +49,23 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 	arcoController := controllers.NewArcoController()
 	adminController := controllers.NewAdminController()
 	alquilerController := controllers.NewAlquilerController()
+	contratoController := controllers.NewContratoController()
+	personaController := controllers.NewPersonaController()
+	reciboController := controllers.NewReciboController()
+	propietarioController := controllers.NewPropietarioController()
+	indiceController := controllers.NewIndiceController()
+	notificacionController := controllers.NewNotificacionController()
+	cotizacionController := controllers.NewCotizacionController()
+	imagenController := controllers.NewImagenController()
+	documentoController := controllers.NewDocumentoController()
+	edificioController := controllers.NewEdificioController()
+	ocupacionController := controllers.NewOcupacionController()
+	reclamoController := controllers.NewReclamoController()
+	historialPropiedadController := controllers.NewHistorialPropiedadController()
+	conciliacionController := controllers.NewConciliacionController()
+	cajaGlobalController := controllers.NewCajaGlobalController()
+	reporteController := controllers.NewReporteController()
+	reporteProgramadoController := controllers.NewReporteProgramadoController()
 
 	// Archivos estáticos
 	r.Static("/css", "./Front/css")
@@ -174,6 +191,20 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 			movementController.GetGlobalMovements,
 		)
 
+		// Caja global histórica (saldo consolidado en cualquier fecha) - SOLO Admin General
+		protected.GET("/api/caja-global/saldo",
+			middleware.RequirePermission(middleware.PermViewGlobalCaja),
+			cajaGlobalController.GetSaldo,
+		)
+		protected.GET("/api/caja-global/historial",
+			middleware.RequirePermission(middleware.PermViewGlobalCaja),
+			cajaGlobalController.GetHistorial,
+		)
+		protected.POST("/api/caja-global/snapshot",
+			middleware.RequirePermission(middleware.PermManageGlobalCaja),
+			cajaGlobalController.RegenerarSnapshot,
+		)
+
 		// Eliminar movimientos - SOLO Supervisor y Admin General
 		protected.DELETE("/api/movimientos/:movement_id",
 			middleware.RequirePermission(middleware.PermDeleteMovement),
@@ -195,6 +226,62 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 			controllers.MostrarPaginaReporteGlobal,
 		)
 
+		// Reportes agregados (nominales y en pesos reales por IPC)
+		protected.GET("/api/reportes/mensual",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			reporteController.GetResumenMensual,
+		)
+		protected.GET("/api/reportes/interanual",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			reporteController.GetComparativoInteranual,
+		)
+		protected.GET("/api/reportes/cajeros",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			reporteController.GetDesempenoCajeros,
+		)
+		protected.GET("/api/reportes/proyeccion",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			reporteController.GetProyeccion,
+		)
+		protected.POST("/api/reportes/ipc/sincronizar",
+			middleware.RequirePermission(middleware.PermManageReports),
+			reporteController.SincronizarIPC,
+		)
+
+		// Reportes programados por correo (cierre diario, cobranza de alquileres)
+		protected.GET("/api/reportes/programados",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			reporteProgramadoController.Listar,
+		)
+		protected.POST("/api/reportes/programados",
+			middleware.RequirePermission(middleware.PermManageReports),
+			reporteProgramadoController.Crear,
+		)
+		protected.PUT("/api/reportes/programados/:id",
+			middleware.RequirePermission(middleware.PermManageReports),
+			reporteProgramadoController.Actualizar,
+		)
+		protected.DELETE("/api/reportes/programados/:id",
+			middleware.RequirePermission(middleware.PermManageReports),
+			reporteProgramadoController.Eliminar,
+		)
+		protected.POST("/api/reportes/programados/:id/enviar",
+			middleware.RequirePermission(middleware.PermManageReports),
+			reporteProgramadoController.EnviarAhora,
+		)
+		protected.GET("/api/reportes/envios",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			reporteProgramadoController.GetEnvios,
+		)
+		protected.POST("/api/reportes/envios/:id/reintentar",
+			middleware.RequirePermission(middleware.PermManageReports),
+			reporteProgramadoController.ReintentarEnvio,
+		)
+		protected.GET("/api/reportes/descargar",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			reporteProgramadoController.Descargar,
+		)
+
 		// =========================================================
 		// MÓDULO DE ALQUILERES
 		// Ruta oculta — accesible solo para Gestor de Alquileres y Admin General
@@ -215,6 +302,10 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 			middleware.RequirePermission(middleware.PermViewAlquileres),
 			alquilerController.GetPropiedadByID,
 		)
+		protected.GET("/api/alquileres/propiedades/:id/periodos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			alquilerController.GetPeriodos,
+		)
 		protected.POST("/api/alquileres/propiedades",
 			middleware.RequirePermission(middleware.PermManageAlquileres),
 			alquilerController.CrearPropiedad,
@@ -232,6 +323,116 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 			alquilerController.EliminarMetadataField,
 		)
 
+		// Registro de cambios e historia del alquiler de cada propiedad
+		protected.GET("/api/alquileres/propiedades/:id/historial",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			historialPropiedadController.Cambios,
+		)
+		protected.GET("/api/alquileres/propiedades/:id/montos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			historialPropiedadController.Montos,
+		)
+
+		// Imágenes de propiedades (almacén de archivos, con miniatura para listados)
+		protected.GET("/api/alquileres/imagenes/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			imagenController.Servir,
+		)
+		protected.GET("/api/alquileres/imagenes/:id/miniatura",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			imagenController.Servir,
+		)
+		protected.POST("/api/alquileres/propiedades/:id/imagenes",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			imagenController.Subir,
+		)
+		protected.DELETE("/api/alquileres/propiedades/:id/imagenes/:imagen",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			imagenController.Quitar,
+		)
+
+		// Documentos adjuntos de propiedades y contratos (con vencimiento)
+		protected.GET("/api/alquileres/propiedades/:id/documentos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			documentoController.ListarPorPropiedad,
+		)
+		protected.POST("/api/alquileres/propiedades/:id/documentos",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			documentoController.Subir,
+		)
+		protected.GET("/api/alquileres/contratos/:id/documentos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			documentoController.ListarPorContrato,
+		)
+		protected.GET("/api/alquileres/documentos/vencimientos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			documentoController.Vencimientos,
+		)
+		protected.GET("/api/alquileres/documentos/:id/archivo",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			documentoController.Archivo,
+		)
+		protected.PUT("/api/alquileres/documentos/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			documentoController.Actualizar,
+		)
+		protected.DELETE("/api/alquileres/documentos/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			documentoController.Eliminar,
+		)
+
+		// Historial de ocupación y métricas de vacancia
+		protected.GET("/api/alquileres/propiedades/:id/ocupacion",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			ocupacionController.Historial,
+		)
+		protected.GET("/api/alquileres/ocupacion",
+			middleware.RequirePermission(middleware.PermViewAlquilerReport),
+			ocupacionController.Reporte,
+		)
+		protected.PUT("/api/alquileres/ocupacion/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			ocupacionController.ActualizarTramo,
+		)
+
+		// Reclamos de mantenimiento
+		protected.GET("/api/alquileres/reclamos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			reclamoController.Listar,
+		)
+		protected.GET("/api/alquileres/propiedades/:id/reclamos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			reclamoController.ListarPorPropiedad,
+		)
+		protected.POST("/api/alquileres/propiedades/:id/reclamos",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			reclamoController.Crear,
+		)
+		protected.GET("/api/alquileres/reclamos/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			reclamoController.GetByID,
+		)
+		protected.PUT("/api/alquileres/reclamos/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			reclamoController.Actualizar,
+		)
+		protected.DELETE("/api/alquileres/reclamos/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			reclamoController.Eliminar,
+		)
+		protected.POST("/api/alquileres/reclamos/:id/estado",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			reclamoController.CambiarEstado,
+		)
+		protected.POST("/api/alquileres/reclamos/:id/pago",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			reclamoController.Pagar,
+		)
+		protected.DELETE("/api/alquileres/reclamos/:id/pago",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			reclamoController.AnularPago,
+		)
+
 		// API Pagos
 		protected.POST("/api/alquileres/propiedades/:id/pago",
 			middleware.RequirePermission(middleware.PermRegistrarPago),
@@ -241,6 +442,56 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 			middleware.RequirePermission(middleware.PermManageAlquileres),
 			alquilerController.DeshacerPago,
 		)
+		protected.GET("/api/alquileres/propiedades/:id/pago/:mes/liquidacion",
+			middleware.RequirePermission(middleware.PermRegistrarPago),
+			alquilerController.GetLiquidacion,
+		)
+		protected.GET("/api/alquileres/propiedades/:id/pago/:mes/historial",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			alquilerController.GetHistorialPeriodo,
+		)
+		protected.GET("/api/alquileres/cajas-destino",
+			middleware.RequirePermission(middleware.PermRegistrarPago),
+			alquilerController.GetCajasDestino,
+		)
+
+		// Política general de punitorios (cada contrato puede tener la suya)
+		protected.GET("/api/alquileres/punitorios",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			alquilerController.GetPoliticaPunitorio,
+		)
+		protected.PUT("/api/alquileres/punitorios",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			alquilerController.GuardarPoliticaPunitorio,
+		)
+
+		// Reglas generales de mora (cada propiedad puede tener su vencimiento y gracia)
+		protected.GET("/api/alquileres/mora/reglas",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			alquilerController.GetReglasMora,
+		)
+		protected.PUT("/api/alquileres/mora/reglas",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			alquilerController.GuardarReglasMora,
+		)
+
+		// Avisos a inquilinos: vencimiento, atraso y actualización del alquiler
+		protected.GET("/api/alquileres/notificaciones/config",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			notificacionController.GetConfig,
+		)
+		protected.PUT("/api/alquileres/notificaciones/config",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			notificacionController.GuardarConfig,
+		)
+		protected.GET("/api/alquileres/notificaciones",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			notificacionController.Listar,
+		)
+		protected.POST("/api/alquileres/notificaciones/recordatorios",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			notificacionController.EnviarRecordatorios,
+		)
 
 		// API Resumen / Reportes (solo Admin General)
 		protected.GET("/api/alquileres/resumen",
@@ -256,11 +507,89 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 			alquilerController.ActualizarMorosos,
 		)
 
-		// Notificaciones de actualización de monto por IPC
+		// Notificaciones de actualización de monto por índice (IPC, ICL, CER, UVA, CAC)
 		protected.GET("/api/alquileres/actualizaciones-pendientes",
 			middleware.RequirePermission(middleware.PermViewAlquileres),
 			alquilerController.GetActualizacionesPendientes,
 		)
+
+		// Índices de actualización: copia local, sincronización y carga manual
+		protected.GET("/api/alquileres/indices",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			indiceController.Listar,
+		)
+		protected.GET("/api/alquileres/indices/:codigo/valores",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			indiceController.ListarValores,
+		)
+		protected.POST("/api/alquileres/indices/:codigo/sincronizar",
+			middleware.RequirePermission(middleware.PermManageReports),
+			indiceController.Sincronizar,
+		)
+		protected.PUT("/api/alquileres/indices/:codigo/valores",
+			middleware.RequirePermission(middleware.PermManageReports),
+			indiceController.CargarValor,
+		)
+		protected.DELETE("/api/alquileres/indices/:codigo/valores",
+			middleware.RequirePermission(middleware.PermManageReports),
+			indiceController.EliminarValor,
+		)
+		protected.POST("/api/alquileres/indices/:codigo/importar",
+			middleware.RequirePermission(middleware.PermManageReports),
+			indiceController.ImportarCSV,
+		)
+
+		// Cotización del dólar para alquileres en dólares (oficial, mep, blue)
+		protected.GET("/api/alquileres/cotizaciones",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			cotizacionController.Estado,
+		)
+		protected.POST("/api/alquileres/cotizaciones/recalcular",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			cotizacionController.Recalcular,
+		)
+		protected.GET("/api/alquileres/cotizaciones/:tipo",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			cotizacionController.Listar,
+		)
+		protected.GET("/api/alquileres/cotizaciones/:tipo/vigente",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			cotizacionController.Vigente,
+		)
+		protected.POST("/api/alquileres/cotizaciones/:tipo/sincronizar",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			cotizacionController.Sincronizar,
+		)
+		protected.PUT("/api/alquileres/cotizaciones/:tipo",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			cotizacionController.Cargar,
+		)
+		protected.DELETE("/api/alquileres/cotizaciones/:tipo",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			cotizacionController.Eliminar,
+		)
+
+		// Consistencia entre cobros (MongoDB) y caja (MySQL): outbox y conciliación
+		protected.GET("/api/alquileres/conciliacion-caja",
+			middleware.RequirePermission(middleware.PermViewAlquilerReport),
+			conciliacionController.Reporte,
+		)
+		protected.POST("/api/alquileres/conciliacion-caja/reparar",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			conciliacionController.Reparar,
+		)
+		protected.GET("/api/alquileres/operaciones-caja",
+			middleware.RequirePermission(middleware.PermViewAlquilerReport),
+			conciliacionController.ListarOperaciones,
+		)
+		protected.POST("/api/alquileres/operaciones-caja/procesar",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			conciliacionController.ProcesarOperaciones,
+		)
+		protected.PUT("/api/alquileres/operaciones-caja/:id/caja",
+			middleware.RequirePermission(middleware.PermViewAllReports),
+			conciliacionController.ReasignarOperacion,
+		)
 		protected.PUT("/api/alquileres/propiedades/:id/actualizar-monto",
 			middleware.RequirePermission(middleware.PermManageAlquileres),
 			alquilerController.ActualizarMonto,
@@ -270,6 +599,180 @@ func SetupRoutes(cfg *config.Config) *gin.Engine {
 			alquilerController.PosponerActualizacion,
 		)
 
+		// Contratos (historial de locaciones por propiedad)
+		protected.GET("/api/alquileres/contratos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			contratoController.Listar,
+		)
+		protected.GET("/api/alquileres/contratos/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			contratoController.GetDetalle,
+		)
+		protected.GET("/api/alquileres/propiedades/:id/contratos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			contratoController.ListarPorPropiedad,
+		)
+		protected.POST("/api/alquileres/propiedades/:id/contratos",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			contratoController.Crear,
+		)
+		protected.PUT("/api/alquileres/contratos/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			contratoController.Actualizar,
+		)
+		protected.PUT("/api/alquileres/contratos/:id/actualizar-monto",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			contratoController.ActualizarMonto,
+		)
+		protected.POST("/api/alquileres/contratos/:id/finalizar",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			contratoController.Finalizar,
+		)
+
+		// Padrón de inquilinos y garantes
+		protected.GET("/api/alquileres/personas",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			personaController.Listar,
+		)
+		protected.GET("/api/alquileres/personas/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			personaController.GetDetalle,
+		)
+		protected.POST("/api/alquileres/personas",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			personaController.Crear,
+		)
+		protected.PUT("/api/alquileres/personas/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			personaController.Actualizar,
+		)
+		protected.DELETE("/api/alquileres/personas/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			personaController.Eliminar,
+		)
+
+		// Recibos de alquiler (se emiten al registrar cada cobro)
+		protected.GET("/api/alquileres/recibos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			reciboController.Listar,
+		)
+		protected.GET("/api/alquileres/recibos/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			reciboController.GetByID,
+		)
+		protected.GET("/api/alquileres/recibos/:id/pdf",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			reciboController.DescargarPDF,
+		)
+		protected.POST("/api/alquileres/recibos/:id/reimprimir",
+			middleware.RequirePermission(middleware.PermRegistrarPago),
+			reciboController.Reimprimir,
+		)
+
+		// Propietarios, gastos y liquidaciones (propiedades de terceros)
+		protected.GET("/api/alquileres/propietarios",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			propietarioController.Listar,
+		)
+		protected.GET("/api/alquileres/propietarios/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			propietarioController.GetDetalle,
+		)
+		protected.POST("/api/alquileres/propietarios",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.Crear,
+		)
+		protected.PUT("/api/alquileres/propietarios/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.Actualizar,
+		)
+		protected.DELETE("/api/alquileres/propietarios/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.Eliminar,
+		)
+		protected.GET("/api/alquileres/propiedades/:id/gastos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			propietarioController.ListarGastos,
+		)
+		protected.POST("/api/alquileres/propiedades/:id/gastos",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.CrearGasto,
+		)
+		protected.PUT("/api/alquileres/gastos/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.ActualizarGasto,
+		)
+		protected.DELETE("/api/alquileres/gastos/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.EliminarGasto,
+		)
+		protected.GET("/api/alquileres/propietarios/:id/liquidacion",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			propietarioController.PrevisualizarLiquidacion,
+		)
+		protected.GET("/api/alquileres/propietarios/:id/liquidaciones",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			propietarioController.ListarLiquidaciones,
+		)
+		protected.POST("/api/alquileres/propietarios/:id/liquidaciones",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.GenerarLiquidacion,
+		)
+		protected.GET("/api/alquileres/liquidaciones/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			propietarioController.GetLiquidacion,
+		)
+		protected.GET("/api/alquileres/liquidaciones/:id/pdf",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			propietarioController.DescargarLiquidacion,
+		)
+		protected.POST("/api/alquileres/liquidaciones/:id/pagar",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.PagarLiquidacion,
+		)
+		protected.POST("/api/alquileres/liquidaciones/:id/anular",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			propietarioController.AnularLiquidacion,
+		)
+
+		// Edificios: unidades y gastos comunes prorrateados
+		protected.GET("/api/alquileres/edificios",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			edificioController.Listar,
+		)
+		protected.GET("/api/alquileres/edificios/:id",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			edificioController.GetDetalle,
+		)
+		protected.GET("/api/alquileres/edificios/:id/resumen",
+			middleware.RequirePermission(middleware.PermViewAlquilerReport),
+			edificioController.GetResumen,
+		)
+		protected.POST("/api/alquileres/edificios",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			edificioController.Crear,
+		)
+		protected.PUT("/api/alquileres/edificios/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			edificioController.Actualizar,
+		)
+		protected.DELETE("/api/alquileres/edificios/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			edificioController.Eliminar,
+		)
+		protected.GET("/api/alquileres/edificios/:id/gastos",
+			middleware.RequirePermission(middleware.PermViewAlquileres),
+			edificioController.ListarGastos,
+		)
+		protected.POST("/api/alquileres/edificios/:id/gastos",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			edificioController.CrearGasto,
+		)
+		protected.DELETE("/api/alquileres/gastos-edificio/:id",
+			middleware.RequirePermission(middleware.PermManageAlquileres),
+			edificioController.EliminarGasto,
+		)
+
 		// =========================================================
 		// AUTH
 		// =========================================================
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"caja-fuerte/config"
+	"caja-fuerte/database"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrArchivoNoEncontrado indica que el archivo pedido no existe en el almacén.
+var ErrArchivoNoEncontrado = errors.New("archivo no encontrado")
+
+// ArchivoInfo describe un archivo guardado.
+type ArchivoInfo struct {
+	ID          string
+	Nombre      string
+	ContentType string
+	Tamanio     int64
+	Creado      time.Time
+}
+
+// AlmacenArchivos guarda archivos binarios fuera de los documentos de Mongo.
+// El ID lo elige quien guarda y no cambia: el contenido de un ID es inmutable.
+type AlmacenArchivos interface {
+	Guardar(id, nombre, contentType string, datos []byte) error
+	Abrir(id string) (io.ReadCloser, *ArchivoInfo, error)
+	Eliminar(id string) error
+}
+
+var (
+	almacenOnce sync.Once
+	almacen     AlmacenArchivos
+)
+
+// GetAlmacen devuelve el almacén configurado en ALMACENAMIENTO_TIPO.
+func GetAlmacen() AlmacenArchivos {
+	almacenOnce.Do(func() {
+		cfg := config.AppConfig
+		if strings.ToLower(cfg.AlmacenamientoTipo) == "disco" {
+			almacen = &almacenDisco{dir: cfg.AlmacenamientoDir}
+			return
+		}
+		almacen = &almacenGridFS{}
+	})
+	return almacen
+}
+
+// idArchivoValido evita IDs que salgan de la carpeta del almacén en disco.
+var idArchivoValido = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,100}$`)
+
+// ─── GridFS ─────────────────────────────────────────────────────────────────
+
+// almacenGridFS guarda los archivos en el bucket "archivos" de MongoDB.
+type almacenGridFS struct{}
+
+// bucket abre el bucket para una sola operación: los plazos de lectura y
+// escritura son del bucket y no se pueden compartir entre pedidos concurrentes.
+func (a *almacenGridFS) bucket() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(database.MongoDB, options.GridFSBucket().SetName("archivos"))
+}
+
+func (a *almacenGridFS) Guardar(id, nombre, contentType string, datos []byte) error {
+	if !idArchivoValido.MatchString(id) {
+		return errors.New("ID de archivo inválido")
+	}
+	bucket, err := a.bucket()
+	if err != nil {
+		return err
+	}
+	if err := bucket.SetWriteDeadline(time.Now().Add(30 * time.Second)); err != nil {
+		return err
+	}
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	return bucket.UploadFromStreamWithID(id, nombre, bytes.NewReader(datos), opts)
+}
+
+func (a *almacenGridFS) Abrir(id string) (io.ReadCloser, *ArchivoInfo, error) {
+	bucket, err := a.bucket()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := bucket.SetReadDeadline(time.Now().Add(30 * time.Second)); err != nil {
+		return nil, nil, err
+	}
+	stream, err := bucket.OpenDownloadStream(id)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, nil, ErrArchivoNoEncontrado
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	f := stream.GetFile()
+	var meta struct {
+		ContentType string `bson:"content_type"`
+	}
+	if f.Metadata != nil {
+		_ = bson.Unmarshal(f.Metadata, &meta)
+	}
+	return stream, &ArchivoInfo{
+		ID:          id,
+		Nombre:      f.Name,
+		ContentType: meta.ContentType,
+		Tamanio:     f.Length,
+		Creado:      f.UploadDate,
+	}, nil
+}
+
+func (a *almacenGridFS) Eliminar(id string) error {
+	bucket, err := a.bucket()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err = bucket.DeleteContext(ctx, id)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return ErrArchivoNoEncontrado
+	}
+	return err
+}
+
+// ─── Disco ──────────────────────────────────────────────────────────────────
+
+// almacenDisco guarda cada archivo como <dir>/<id> junto con <id>.json (nombre
+// y tipo de contenido). Sirve para un volumen local o un bucket montado.
+type almacenDisco struct {
+	dir string
+}
+
+func (a *almacenDisco) rutas(id string) (string, string, error) {
+	if !idArchivoValido.MatchString(id) {
+		return "", "", errors.New("ID de archivo inválido")
+	}
+	ruta := filepath.Join(a.dir, id)
+	return ruta, ruta + ".json", nil
+}
+
+func (a *almacenDisco) Guardar(id, nombre, contentType string, datos []byte) error {
+	ruta, rutaMeta, err := a.rutas(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.dir, 0o750); err != nil {
+		return err
+	}
+	meta, err := json.Marshal(ArchivoInfo{ID: id, Nombre: nombre, ContentType: contentType,
+		Tamanio: int64(len(datos)), Creado: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ruta, datos, 0o640); err != nil {
+		return err
+	}
+	return os.WriteFile(rutaMeta, meta, 0o640)
+}
+
+func (a *almacenDisco) Abrir(id string) (io.ReadCloser, *ArchivoInfo, error) {
+	ruta, rutaMeta, err := a.rutas(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrArchivoNoEncontrado
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info := &ArchivoInfo{ID: id}
+	if meta, err := os.ReadFile(rutaMeta); err == nil {
+		_ = json.Unmarshal(meta, info)
+	}
+	if st, err := f.Stat(); err == nil {
+		info.Tamanio = st.Size()
+		if info.Creado.IsZero() {
+			info.Creado = st.ModTime()
+		}
+	}
+	return f, info, nil
+}
+
+func (a *almacenDisco) Eliminar(id string) error {
+	ruta, rutaMeta, err := a.rutas(id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(ruta)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrArchivoNoEncontrado
+	}
+	if err != nil {
+		return err
+	}
+	os.Remove(rutaMeta)
+	return nil
+}
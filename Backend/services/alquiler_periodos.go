@@ -0,0 +1,323 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Libro de períodos de alquiler (colección periodos_alquiler)
+// Cada propiedad tiene un documento por año + mes. Propiedad.Pagos es solo la
+// vista de 12 meses de un año armada a partir de estos documentos.
+// ─────────────────────────────────────────────────────────────────────────────
+
+// asegurarPeriodos crea (si faltan) los 12 períodos del año para la propiedad
+// y los asocia al contrato vigente. Cada período toma el alquiler vigente en su
+// mes según la historia de montos. Los existentes no se modifican.
+func (s *AlquilerService) asegurarPeriodos(ctx context.Context, prop *models.Propiedad, anio int) error {
+	montos, err := NewHistorialPropiedadService().montosDe(ctx, prop.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	ops := make([]mongo.WriteModel, 0, 12)
+	for mes := 0; mes < 12; mes++ {
+		ops = append(ops, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"propiedad_id": prop.ID, "anio": anio, "mes": mes}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{
+				"estado":     string(models.PendienteEstado),
+				"monto":      montoVigente(montos, anio, mes, prop.AlquilerMensual),
+				"created_at": now,
+				"updated_at": now,
+			}}).
+			SetUpsert(true))
+	}
+	if _, err := s.periodos.BulkWrite(ctx, ops, options.BulkWrite().SetOrdered(false)); err != nil {
+		return err
+	}
+	if prop.ContratoVigenteID != nil {
+		return NewContratoService().vincularVigente(ctx, *prop.ContratoVigenteID)
+	}
+	return nil
+}
+
+// getPeriodo devuelve un período puntual, creando los del año si todavía no existen.
+func (s *AlquilerService) getPeriodo(ctx context.Context, prop *models.Propiedad, anio, mes int) (*models.PeriodoAlquiler, error) {
+	if err := s.asegurarPeriodos(ctx, prop, anio); err != nil {
+		return nil, err
+	}
+	var periodo models.PeriodoAlquiler
+	err := s.periodos.FindOne(ctx, bson.M{"propiedad_id": prop.ID, "anio": anio, "mes": mes}).Decode(&periodo)
+	if err != nil {
+		return nil, err
+	}
+	return &periodo, nil
+}
+
+// listarPeriodos devuelve los períodos que cumplen el filtro, ordenados cronológicamente.
+func (s *AlquilerService) listarPeriodos(ctx context.Context, filter bson.M) ([]models.PeriodoAlquiler, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "anio", Value: 1}, {Key: "mes", Value: 1}})
+	cursor, err := s.periodos.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	periodos := []models.PeriodoAlquiler{}
+	if err := cursor.All(ctx, &periodos); err != nil {
+		return nil, err
+	}
+	return periodos, nil
+}
+
+// cargarPagos completa la vista Pagos (12 meses del año) de cada propiedad.
+// Los meses sin período (años anteriores al alta) se muestran como pendientes.
+func (s *AlquilerService) cargarPagos(ctx context.Context, props []models.Propiedad, anio int) error {
+	if len(props) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, len(props))
+	for i, p := range props {
+		ids[i] = p.ID
+	}
+
+	periodos, err := s.listarPeriodos(ctx, bson.M{"propiedad_id": bson.M{"$in": ids}, "anio": anio})
+	if err != nil {
+		return err
+	}
+	porPropiedad := map[primitive.ObjectID][]models.PeriodoAlquiler{}
+	for _, p := range periodos {
+		porPropiedad[p.PropiedadID] = append(porPropiedad[p.PropiedadID], p)
+	}
+
+	for i := range props {
+		props[i].Pagos = vistaPagos(porPropiedad[props[i].ID], props[i].AlquilerMensual)
+		props[i].AnioPagos = anio
+	}
+	return nil
+}
+
+// GetPeriodos devuelve el libro completo de períodos de una propiedad.
+func (s *AlquilerService) GetPeriodos(propID string) ([]models.PeriodoAlquiler, error) {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.listarPeriodos(ctx, bson.M{"propiedad_id": objID})
+}
+
+// AsegurarPeriodosVigentes genera los períodos del año en curso para todas las
+// propiedades y, a partir de diciembre, también los del año siguiente.
+// Es idempotente: se ejecuta al iniciar y todos los días desde el Scheduler.
+func (s *AlquilerService) AsegurarPeriodosVigentes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var props []models.Propiedad
+	if err := cursor.All(ctx, &props); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	anios := []int{now.Year()}
+	if now.Month() == time.December {
+		anios = append(anios, now.Year()+1)
+	}
+
+	for i := range props {
+		for _, anio := range anios {
+			if anio < props[i].Anio {
+				continue
+			}
+			if err := s.asegurarPeriodos(ctx, &props[i], anio); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MigrarPagosLegacy pasa el arreglo fijo `pagos` (12 meses del campo `anio`)
+// de los documentos antiguos al libro de períodos y lo elimina del documento.
+// También completa `pagado` en los períodos pagados antes de los cobros parciales.
+// Es idempotente: un período ya migrado no se pisa.
+func (s *AlquilerService) MigrarPagosLegacy() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	type legacy struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Anio  int                `bson:"anio"`
+		Pagos []models.PagoMes   `bson:"pagos"`
+	}
+
+	cursor, err := s.coll.Find(ctx, bson.M{"pagos": bson.M{"$exists": true}})
+	if err != nil {
+		return 0, err
+	}
+	var docs []legacy
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+
+	migradas := 0
+	for _, d := range docs {
+		anio := d.Anio
+		if anio == 0 {
+			anio = d.ID.Timestamp().Year()
+		}
+
+		ops := make([]mongo.WriteModel, 0, len(d.Pagos))
+		for _, p := range d.Pagos {
+			if p.Mes < 0 || p.Mes > 11 {
+				continue
+			}
+			campos := bson.M{
+				"estado":     string(p.Estado),
+				"monto":      p.Monto,
+				"created_at": time.Now(),
+				"updated_at": time.Now(),
+			}
+			if p.Estado == models.PagadoEstado {
+				campos["pagado"] = p.Monto
+			}
+			if p.FechaPago != nil {
+				campos["fecha_pago"] = *p.FechaPago
+			}
+			if p.MovementID != nil {
+				campos["movement_id"] = *p.MovementID
+			}
+			ops = append(ops, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"propiedad_id": d.ID, "anio": anio, "mes": p.Mes}).
+				SetUpdate(bson.M{"$setOnInsert": campos}).
+				SetUpsert(true))
+		}
+		if len(ops) > 0 {
+			if _, err := s.periodos.BulkWrite(ctx, ops, options.BulkWrite().SetOrdered(false)); err != nil {
+				return migradas, err
+			}
+		}
+
+		if _, err := s.coll.UpdateOne(ctx, bson.M{"_id": d.ID}, bson.M{
+			"$unset": bson.M{"pagos": ""},
+			"$set":   bson.M{"anio": anio},
+		}); err != nil {
+			return migradas, err
+		}
+		migradas++
+	}
+
+	if migradas > 0 {
+		utils.Logger.Info("Pagos de alquiler migrados al libro de períodos", zap.Int("propiedades", migradas))
+	}
+
+	// Períodos pagados antes de los cobros parciales: un único pago por el total
+	if _, err := s.periodos.UpdateMany(ctx,
+		bson.M{"estado": string(models.PagadoEstado), "pagado": bson.M{"$exists": false}},
+		bson.A{bson.M{"$set": bson.M{"pagado": "$monto"}}},
+	); err != nil {
+		return migradas, err
+	}
+	return migradas, nil
+}
+
+// Start programa la generación diaria de períodos (que crea los del año
+// siguiente durante diciembre) y lanza en segundo plano las migraciones de
+// documentos antiguos y la generación de los períodos vigentes, para no
+// demorar el arranque del servidor.
+func (s *AlquilerService) Start() {
+	if _, err := GetScheduler().Programar("periodos-alquiler", "10 0 * * *", func() {
+		if err := s.AsegurarPeriodosVigentes(); err != nil {
+			utils.Logger.Error("Error generando períodos de alquiler", zap.Error(err))
+		}
+	}); err != nil {
+		utils.Logger.Error("Error programando la generación de períodos", zap.Error(err))
+	}
+
+	go s.migrar()
+}
+
+// migrar pasa los documentos antiguos (pagos, contratos, personas, historial de
+// ocupación, historia de alquileres e imágenes) al modelo actual y genera los
+// períodos vigentes. Cada migración solo toma lo que todavía no migró.
+func (s *AlquilerService) migrar() {
+	inicio := time.Now()
+	if _, err := s.MigrarPagosLegacy(); err != nil {
+		utils.Logger.Error("Error migrando pagos de alquiler al libro de períodos", zap.Error(err))
+	}
+	if _, err := NewContratoService().MigrarContratosLegacy(); err != nil {
+		utils.Logger.Error("Error generando contratos de propiedades existentes", zap.Error(err))
+	}
+	if _, err := NewPersonaService().MigrarPersonasLegacy(); err != nil {
+		utils.Logger.Error("Error vinculando contratos al padrón de personas", zap.Error(err))
+	}
+	if _, err := NewOcupacionService().MigrarOcupacionLegacy(); err != nil {
+		utils.Logger.Error("Error generando el historial de ocupación", zap.Error(err))
+	}
+	if _, err := NewHistorialPropiedadService().MigrarMontosLegacy(); err != nil {
+		utils.Logger.Error("Error generando la historia de alquileres", zap.Error(err))
+	}
+	if n, err := NewImagenService().MigrarImagenesBase64(); err != nil {
+		utils.Logger.Error("Error migrando imágenes de propiedades al almacén de archivos", zap.Error(err))
+	} else if n > 0 {
+		utils.Logger.Info("Imágenes de propiedades migradas al almacén de archivos", zap.Int("propiedades", n))
+	}
+	if err := s.AsegurarPeriodosVigentes(); err != nil {
+		utils.Logger.Error("Error generando períodos de alquiler", zap.Error(err))
+	}
+	utils.Logger.Info("Migraciones de alquileres finalizadas", zap.Duration("duracion", time.Since(inicio)))
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+// vistaPagos arma los 12 PagoMes de un año a partir de sus períodos.
+func vistaPagos(periodos []models.PeriodoAlquiler, montoDefault float64) []models.PagoMes {
+	pagos := make([]models.PagoMes, 12)
+	for i := range pagos {
+		pagos[i] = models.PagoMes{Mes: i, Estado: models.PendienteEstado, Monto: montoDefault, Saldo: montoDefault}
+	}
+	for _, p := range periodos {
+		if p.Mes < 0 || p.Mes > 11 {
+			continue
+		}
+		pagos[p.Mes] = models.PagoMes{
+			Mes:        p.Mes,
+			Estado:     p.Estado,
+			Monto:      p.Monto,
+			FechaPago:  p.FechaPago,
+			MovementID: p.MovementID,
+			ContratoID: p.ContratoID,
+
+			Cobrado:       roundDos(p.Cobrado()),
+			Saldo:         roundDos(p.Saldo()),
+			CantidadPagos: len(p.Cobros),
+		}
+	}
+	return pagos
+}
+
+// validarAnioPeriodo verifica que el año esté dentro del libro de la propiedad.
+func validarAnioPeriodo(prop *models.Propiedad, anio int) error {
+	if anio < prop.Anio {
+		return errors.New("el año es anterior al alta de la propiedad")
+	}
+	if anio > time.Now().Year()+1 {
+		return errors.New("no se pueden registrar pagos con más de un año de anticipación")
+	}
+	return nil
+}
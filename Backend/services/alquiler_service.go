@@ -3,26 +3,33 @@ package services
 import (
 	"caja-fuerte/database"
 	"caja-fuerte/models"
+	"caja-fuerte/utils"
 	"context"
 	"errors"
 	"log"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/gorm"
 )
 
 // AlquilerService maneja la lógica de negocio del módulo de alquileres.
 // Las propiedades se almacenan en MongoDB; los pagos impactan en MySQL (caja).
 type AlquilerService struct {
-	coll *mongo.Collection
+	coll     *mongo.Collection
+	periodos *mongo.Collection
 }
 
 func NewAlquilerService() *AlquilerService {
 	return &AlquilerService{
-		coll: database.MongoDB.Collection(database.CollectionPropiedades),
+		coll:     database.MongoDB.Collection(database.CollectionPropiedades),
+		periodos: database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
 	}
 }
 
@@ -30,28 +37,20 @@ func NewAlquilerService() *AlquilerService {
 // CRUD Propiedades
 // ─────────────────────────────────────────────────────────────────────────────
 
-// CrearPropiedad crea una nueva propiedad en MongoDB inicializando 12 meses en "pending".
+// CrearPropiedad crea una nueva propiedad en MongoDB e inicializa los 12
+// períodos del año en curso en "pending". Si se carga ocupada se genera su contrato.
 func (s *AlquilerService) CrearPropiedad(req models.CrearPropiedadRequest, createdBy uint) (*models.Propiedad, error) {
 	anio := time.Now().Year()
 
-	// Inicializar 12 meses en pendiente
-	pagos := make([]models.PagoMes, 12)
-	for i := range pagos {
-		pagos[i] = models.PagoMes{
-			Mes:    i,
-			Estado: models.PendienteEstado,
-			Monto:  req.AlquilerMensual,
-		}
-	}
-
 	meta := req.Metadata
 	if meta == nil {
 		meta = map[string]interface{}{}
 	}
 
-	imagenes := req.Imagenes
-	if imagenes == nil {
-		imagenes = []string{}
+	// Las imágenes enviadas como data-URL se guardan en el almacén de archivos
+	imagenes, err := NewImagenService().resolverImagenes(req.Imagenes)
+	if err != nil {
+		return nil, err
 	}
 
 	frecuencia := req.FrecuenciaActualizacion
@@ -68,39 +67,88 @@ func (s *AlquilerService) CrearPropiedad(req models.CrearPropiedadRequest, creat
 		IndiceInflacion:         req.IndiceInflacion,
 		FechaActualizacion:      req.FechaActualizacion,
 		FrecuenciaActualizacion: frecuencia,
+		TipoIndice:              req.TipoIndice,
+		DiaVencimiento:          req.DiaVencimiento,
+		DiasGracia:              req.DiasGracia,
 		PagaEnDolares:           req.PagaEnDolares,
 		MontoDolares:            req.MontoDolares,
+		TipoCotizacion:          req.TipoCotizacion,
 		Imagenes:                imagenes,
 		Anio:                    anio,
-		Pagos:                   pagos,
 		Metadata:                meta,
 		CreatedAt:               time.Now(),
 		UpdatedAt:               time.Now(),
 		CreatedBy:               createdBy,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	_, err := s.coll.InsertOne(ctx, prop)
+	propietarioID, err := NewPropietarioService().resolverID(ctx, req.PropietarioID)
 	if err != nil {
+		NewImagenService().eliminar(imagenes)
+		return nil, err
+	}
+	prop.PropietarioID = propietarioID
+	if prop.EdificioID, err = NewEdificioService().resolverID(ctx, req.EdificioID); err != nil {
+		NewImagenService().eliminar(imagenes)
 		return nil, err
 	}
+	if prop.EdificioID != nil {
+		prop.Unidad = strings.TrimSpace(req.Unidad)
+		prop.Coeficiente = req.Coeficiente
+	}
 
-	return &prop, nil
+	_, err = s.coll.InsertOne(ctx, prop)
+	if err != nil {
+		NewImagenService().eliminar(imagenes)
+		return nil, err
+	}
+
+	historial := NewHistorialPropiedadService()
+	if err := historial.registrarCambios(ctx, &models.Propiedad{ID: prop.ID}, &prop, models.OperacionAlta, createdBy); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo registrar el alta de %s: %v", prop.ID.Hex(), err)
+	}
+	// El alquiler del alta rige desde el inicio del libro de períodos
+	if err := historial.registrarMonto(ctx, &models.MontoAlquiler{
+		PropiedadID:  prop.ID,
+		Monto:        prop.AlquilerMensual,
+		VigenteDesde: time.Date(anio, time.January, 1, 0, 0, 0, 0, time.Local),
+		Origen:       models.OrigenMontoAlta,
+		UsuarioID:    createdBy,
+	}); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo registrar el alquiler de %s: %v", prop.ID.Hex(), err)
+	}
+	if err := s.asegurarPeriodos(ctx, &prop, anio); err != nil {
+		return nil, err
+	}
+	// Desocupada desde el alta; si se carga ocupada, el contrato abre el historial
+	if !prop.Ocupada {
+		if err := NewOcupacionService().registrar(ctx, prop.ID, false, prop.CreatedAt, "Alta de la propiedad", nil, "", prop.AlquilerMensual); err != nil {
+			log.Printf("[ALQUILER] Advertencia: no se pudo registrar la ocupación de %s: %v", prop.ID.Hex(), err)
+		}
+	}
+	// Si se carga ocupada, el inquilino queda registrado en un contrato vigente
+	if err := NewContratoService().sincronizarConPropiedad(ctx, &prop, createdBy); err != nil {
+		return nil, err
+	}
+	return s.conPagos(&prop, anio)
 }
 
-// GetPropiedades devuelve propiedades con filtros opcionales.
-func (s *AlquilerService) GetPropiedades(busqueda, filtroEstado string, anio int) ([]models.Propiedad, error) {
+// GetPropiedades devuelve propiedades con filtros opcionales (edificioID vacío =
+// todas). Los pagos (y el filtro por estado) corresponden al año indicado, o al
+// año en curso si anio es 0.
+func (s *AlquilerService) GetPropiedades(busqueda, filtroEstado, edificioID string, anio int) ([]models.Propiedad, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{}
-
-	if anio > 0 {
-		filter["anio"] = anio
+	if anio == 0 {
+		anio = time.Now().Year()
 	}
 
+	// Propiedades dadas de alta hasta ese año (el libro de períodos empieza en el alta)
+	filter := bson.M{"anio": bson.M{"$lte": anio}}
+
 	// Filtro de búsqueda por texto (dirección o inquilino)
 	if busqueda != "" {
 		filter["$or"] = bson.A{
@@ -109,16 +157,18 @@ func (s *AlquilerService) GetPropiedades(busqueda, filtroEstado string, anio int
 		}
 	}
 
-	// Filtro por estado de pagos
+	// Unidades de un edificio
+	if edificioID != "" {
+		objID, err := primitive.ObjectIDFromHex(edificioID)
+		if err != nil {
+			return nil, errors.New("ID de edificio inválido")
+		}
+		filter["edificio_id"] = objID
+	}
+
 	switch filtroEstado {
 	case "aldia":
-		// Todas las propiedades donde NINGÚN pago es diferente a "paid"
-		filter["pagos"] = bson.M{"$not": bson.M{"$elemMatch": bson.M{"estado": bson.M{"$ne": "paid"}}}}
 		filter["ocupada"] = true
-	case "atraso1":
-		filter["pagos"] = bson.M{"$elemMatch": bson.M{"estado": bson.M{"$in": bson.A{"late_1", "late_2"}}}}
-	case "atraso2":
-		filter["pagos"] = bson.M{"$elemMatch": bson.M{"estado": "late_2"}}
 	case "desocupadas":
 		filter["ocupada"] = false
 	}
@@ -136,11 +186,44 @@ func (s *AlquilerService) GetPropiedades(busqueda, filtroEstado string, anio int
 		return nil, err
 	}
 
+	if err := s.cargarPagos(ctx, propiedades, anio); err != nil {
+		return nil, err
+	}
+
+	// Filtro por estado de pagos del año (sobre la vista de 12 meses)
+	switch filtroEstado {
+	case "aldia", "atraso1", "atraso2":
+		filtradas := []models.Propiedad{}
+		for _, p := range propiedades {
+			if cumpleFiltroEstado(p.Pagos, filtroEstado) {
+				filtradas = append(filtradas, p)
+			}
+		}
+		propiedades = filtradas
+	}
+
 	return propiedades, nil
 }
 
-// GetPropiedadByID obtiene una propiedad por su ObjectID.
+// GetPropiedadByID obtiene una propiedad por su ObjectID con los pagos del año en curso.
 func (s *AlquilerService) GetPropiedadByID(id string) (*models.Propiedad, error) {
+	return s.GetPropiedadAnio(id, time.Now().Year())
+}
+
+// GetPropiedadAnio obtiene una propiedad con la vista de pagos del año indicado.
+func (s *AlquilerService) GetPropiedadAnio(id string, anio int) (*models.Propiedad, error) {
+	if anio == 0 {
+		anio = time.Now().Year()
+	}
+	prop, err := s.getPropiedad(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.conPagos(prop, anio)
+}
+
+// getPropiedad obtiene el documento de la propiedad sin la vista de pagos.
+func (s *AlquilerService) getPropiedad(id string) (*models.Propiedad, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("ID inválido")
@@ -160,29 +243,60 @@ func (s *AlquilerService) GetPropiedadByID(id string) (*models.Propiedad, error)
 	return &prop, nil
 }
 
-// ActualizarPropiedad actualiza los campos enviados de una propiedad.
-func (s *AlquilerService) ActualizarPropiedad(id string, req models.ActualizarPropiedadRequest) (*models.Propiedad, error) {
+// ActualizarPropiedad actualiza los campos enviados de una propiedad. La
+// tenencia se refleja en los contratos (ver ContratoService.sincronizarConPropiedad):
+// mientras el contrato vigente sigue, las condiciones económicas enviadas
+// (monto, índice, dólares) se aplican al contrato, que las copia a la propiedad.
+// Cada campo modificado queda en el historial de la propiedad.
+func (s *AlquilerService) ActualizarPropiedad(id string, req models.ActualizarPropiedadRequest, userID uint) (*models.Propiedad, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("ID inválido")
 	}
 
+	// Más margen que el resto: puede guardar imágenes nuevas en el almacén
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Versión anterior, para el contrato, el historial y las imágenes que se quitan
+	var antes models.Propiedad
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&antes); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("propiedad no encontrada")
+		}
+		return nil, err
+	}
+	anteriores := antes.Imagenes
+
+	// El contrato vigente sigue si la propiedad no se desocupa ni cambia de inquilino
+	contratoSigue := antes.ContratoVigenteID != nil &&
+		(req.Ocupada == nil || *req.Ocupada) &&
+		(req.Inquilino == nil || mismoInquilino(*req.Inquilino, antes.Inquilino))
+
 	updates := bson.M{"updated_at": time.Now()}
+	// Condiciones económicas: con contrato vigente van al contrato
+	economicas := updates
+	var creq models.ActualizarContratoRequest
+	if contratoSigue {
+		economicas = bson.M{}
+	}
 
 	if req.Direccion != nil {
 		updates["direccion"] = *req.Direccion
 	}
-	if req.Inquilino != nil {
+	if req.Inquilino != nil && !contratoSigue {
 		updates["inquilino"] = *req.Inquilino
 	}
 	if req.AlquilerMensual != nil {
-		updates["alquiler_mensual"] = *req.AlquilerMensual
+		economicas["alquiler_mensual"] = *req.AlquilerMensual
+		creq.MontoActual = req.AlquilerMensual
 	}
 	if req.IndiceInflacion != nil {
 		updates["indice_inflacion"] = *req.IndiceInflacion
 	}
 	if req.FechaActualizacion != nil {
-		updates["fecha_actualizacion"] = *req.FechaActualizacion
+		economicas["fecha_actualizacion"] = *req.FechaActualizacion
+		creq.FechaActualizacion = req.FechaActualizacion
 	}
 	if req.Ocupada != nil {
 		updates["ocupada"] = *req.Ocupada
@@ -192,16 +306,40 @@ func (s *AlquilerService) ActualizarPropiedad(id string, req models.ActualizarPr
 		if f > 0 && f < 3 {
 			f = 3
 		}
-		updates["frecuencia_actualizacion"] = f
+		economicas["frecuencia_actualizacion"] = f
+		creq.FrecuenciaActualizacion = &f
+	}
+	if req.TipoIndice != nil {
+		economicas["tipo_indice"] = *req.TipoIndice
+		creq.TipoIndice = req.TipoIndice
+	}
+	// Vencimiento propio: 0 / -1 vuelven a las reglas generales de mora
+	unset := bson.M{}
+	if req.DiaVencimiento != nil {
+		if *req.DiaVencimiento == 0 {
+			unset["dia_vencimiento"] = ""
+		} else {
+			updates["dia_vencimiento"] = *req.DiaVencimiento
+		}
+	}
+	if req.DiasGracia != nil {
+		if *req.DiasGracia < 0 {
+			unset["dias_gracia"] = ""
+		} else {
+			updates["dias_gracia"] = *req.DiasGracia
+		}
 	}
 	if req.PagaEnDolares != nil {
-		updates["paga_en_dolares"] = *req.PagaEnDolares
+		economicas["paga_en_dolares"] = *req.PagaEnDolares
+		creq.PagaEnDolares = req.PagaEnDolares
 	}
 	if req.MontoDolares != nil {
-		updates["monto_dolares"] = *req.MontoDolares
+		economicas["monto_dolares"] = *req.MontoDolares
+		creq.MontoDolares = req.MontoDolares
 	}
-	if req.Imagenes != nil {
-		updates["imagenes"] = *req.Imagenes
+	if req.TipoCotizacion != nil {
+		economicas["tipo_cotizacion"] = *req.TipoCotizacion
+		creq.TipoCotizacion = req.TipoCotizacion
 	}
 	// Metadata: merge de campos (no reemplaza todo el mapa, solo los keys enviados)
 	if req.Metadata != nil {
@@ -210,28 +348,106 @@ func (s *AlquilerService) ActualizarPropiedad(id string, req models.ActualizarPr
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	update := bson.M{"$set": updates}
+	if req.PropietarioID != nil {
+		propietarioID, err := NewPropietarioService().resolverID(ctx, *req.PropietarioID)
+		if err != nil {
+			return nil, err
+		}
+		if propietarioID != nil {
+			updates["propietario_id"] = *propietarioID
+		} else {
+			unset["propietario_id"] = ""
+		}
+	}
+	if req.EdificioID != nil {
+		edificioID, err := NewEdificioService().resolverID(ctx, *req.EdificioID)
+		if err != nil {
+			return nil, err
+		}
+		if edificioID != nil {
+			updates["edificio_id"] = *edificioID
+		} else {
+			unset["edificio_id"] = ""
+			unset["unidad"] = ""
+			unset["coeficiente"] = ""
+		}
+	}
+	if req.EdificioID == nil || *req.EdificioID != "" {
+		if req.Unidad != nil {
+			updates["unidad"] = strings.TrimSpace(*req.Unidad)
+		}
+		if req.Coeficiente != nil {
+			updates["coeficiente"] = *req.Coeficiente
+		}
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	// Imágenes: las nuevas (data-URL) van al almacén y las que se quitan se borran
+	imagenes := NewImagenService()
+	if req.Imagenes != nil {
+		refs, err := imagenes.resolverImagenes(*req.Imagenes)
+		if err != nil {
+			return nil, err
+		}
+		updates["imagenes"] = refs
+	}
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updated models.Propiedad
 	err = s.coll.FindOneAndUpdate(ctx,
 		bson.M{"_id": objID},
-		bson.M{"$set": updates},
+		update,
 		opts,
 	).Decode(&updated)
 
 	if err != nil {
+		if refs, ok := updates["imagenes"].([]string); ok {
+			imagenes.eliminar(imagenesQuitadas(refs, anteriores))
+		}
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, errors.New("propiedad no encontrada")
 		}
 		return nil, err
 	}
-	return &updated, nil
+	if req.Imagenes != nil {
+		imagenes.eliminar(imagenesQuitadas(anteriores, updated.Imagenes))
+	}
+	historial := NewHistorialPropiedadService()
+	if err := historial.registrarCambios(ctx, &antes, &updated, models.OperacionEdicion, userID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo registrar el historial de %s: %v", id, err)
+	}
+	if updated.AlquilerMensual != antes.AlquilerMensual {
+		if err := historial.registrarMonto(ctx, &models.MontoAlquiler{
+			PropiedadID:   updated.ID,
+			Monto:         updated.AlquilerMensual,
+			MontoAnterior: antes.AlquilerMensual,
+			VigenteDesde:  time.Now(),
+			Origen:        models.OrigenMontoEdicion,
+			ContratoID:    updated.ContratoVigenteID,
+			UsuarioID:     userID,
+		}); err != nil {
+			log.Printf("[ALQUILER] Advertencia: no se pudo registrar el alquiler de %s: %v", id, err)
+		}
+	}
+	contratos := NewContratoService()
+	if contratoSigue {
+		if len(economicas) > 0 {
+			if _, err := contratos.Actualizar(antes.ContratoVigenteID.Hex(), creq, userID); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := contratos.sincronizarConPropiedad(ctx, &updated, userID); err != nil {
+		return nil, err
+	}
+	return s.GetPropiedadByID(id)
 }
 
-// EliminarPropiedad elimina una propiedad de MongoDB.
-func (s *AlquilerService) EliminarPropiedad(id string) error {
+// EliminarPropiedad elimina una propiedad de MongoDB. Su registro de cambios
+// se conserva, con la baja como última entrada.
+func (s *AlquilerService) EliminarPropiedad(id string, userID uint) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("ID inválido")
@@ -240,18 +456,49 @@ func (s *AlquilerService) EliminarPropiedad(id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	result, err := s.coll.DeleteOne(ctx, bson.M{"_id": objID})
+	var eliminada models.Propiedad
+	err = s.coll.FindOneAndDelete(ctx, bson.M{"_id": objID},
+		options.FindOneAndDelete().SetProjection(bson.M{"imagenes": 1, "direccion": 1})).Decode(&eliminada)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errors.New("propiedad no encontrada")
+	}
 	if err != nil {
 		return err
 	}
-	if result.DeletedCount == 0 {
-		return errors.New("propiedad no encontrada")
+	NewImagenService().eliminar(eliminada.Imagenes)
+	if _, err := s.periodos.DeleteMany(ctx, bson.M{"propiedad_id": objID}); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudieron eliminar los períodos de %s: %v", id, err)
+	}
+	if _, err := database.MongoDB.Collection(database.CollectionContratos).DeleteMany(ctx, bson.M{"propiedad_id": objID}); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudieron eliminar los contratos de %s: %v", id, err)
+	}
+	// Los gastos ya liquidados quedan como respaldo de la liquidación
+	gastos := database.MongoDB.Collection(database.CollectionGastosPropiedad)
+	if _, err := gastos.DeleteMany(ctx, bson.M{"propiedad_id": objID, "liquidacion_id": bson.M{"$exists": false}}); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudieron eliminar los gastos de %s: %v", id, err)
+	}
+	if err := NewDocumentoService().eliminarPorPropiedad(ctx, objID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudieron eliminar los documentos de %s: %v", id, err)
+	}
+	if err := NewOcupacionService().eliminarPorPropiedad(ctx, objID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo eliminar el historial de ocupación de %s: %v", id, err)
+	}
+	if err := NewReclamoService().eliminarPorPropiedad(ctx, objID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudieron eliminar los reclamos de %s: %v", id, err)
+	}
+	historial := NewHistorialPropiedadService()
+	if err := historial.eliminarMontosPorPropiedad(ctx, objID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo eliminar la historia del alquiler de %s: %v", id, err)
+	}
+	if err := historial.registrar(ctx, objID, models.OperacionEliminarPropiedad, userID,
+		models.CambioPropiedad{Campo: "direccion", Anterior: eliminada.Direccion}); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo registrar la baja de %s: %v", id, err)
 	}
 	return nil
 }
 
 // EliminarMetadataField elimina un campo específico del mapa Metadata.
-func (s *AlquilerService) EliminarMetadataField(id, campo string) (*models.Propiedad, error) {
+func (s *AlquilerService) EliminarMetadataField(id, campo string, userID uint) (*models.Propiedad, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("ID inválido")
@@ -260,196 +507,491 @@ func (s *AlquilerService) EliminarMetadataField(id, campo string) (*models.Propi
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	// Se lee la versión anterior para dejar el valor quitado en el historial
+	now := time.Now()
 	var updated models.Propiedad
 	err = s.coll.FindOneAndUpdate(ctx,
 		bson.M{"_id": objID},
 		bson.M{
 			"$unset": bson.M{"metadata." + campo: ""},
-			"$set":   bson.M{"updated_at": time.Now()},
+			"$set":   bson.M{"updated_at": now},
 		},
-		opts,
 	).Decode(&updated)
 
 	if err != nil {
 		return nil, err
 	}
-	return &updated, nil
+	if anterior, ok := updated.Metadata[campo]; ok {
+		delete(updated.Metadata, campo)
+		if err := NewHistorialPropiedadService().registrar(ctx, objID, models.OperacionEliminarMetadata, userID,
+			models.CambioPropiedad{Campo: "metadata." + campo, Anterior: anterior}); err != nil {
+			log.Printf("[ALQUILER] Advertencia: no se pudo registrar el historial de %s: %v", id, err)
+		}
+	}
+	updated.UpdatedAt = now
+	return s.conPagos(&updated, time.Now().Year())
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
 // Pagos (MongoDB + MySQL)
 // ─────────────────────────────────────────────────────────────────────────────
 
-// RegistrarPago marca un mes como pagado en MongoDB y crea un Movimiento de
-// tipo "Ingreso" en la caja del Administrador General (MySQL).
-func (s *AlquilerService) RegistrarPago(propID string, req models.RegistrarPagoRequest, registradoPor uint) (*models.Propiedad, error) {
+// RegistrarPago registra un cobro (total o parcial) de un período (año + mes).
+// Liquida los punitorios a la fecha según la política del contrato, imputa el
+// cobro primero a punitorios y luego al alquiler, y crea un Movimiento de tipo
+// "Ingreso" por el monto cobrado. El período pasa a "paid" cuando el saldo es cero.
+// Cada cobro emite un recibo numerado para el inquilino.
+func (s *AlquilerService) RegistrarPago(propID string, req models.RegistrarPagoRequest, registradoPor uint) (*models.Propiedad, *models.Recibo, error) {
 	// 1. Obtener la propiedad
-	prop, err := s.GetPropiedadByID(propID)
+	prop, err := s.getPropiedad(propID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if !prop.Ocupada {
-		return nil, errors.New("la propiedad está desocupada, no se puede registrar un pago")
+		return nil, nil, errors.New("la propiedad está desocupada, no se puede registrar un pago")
 	}
 
 	if req.Mes < 0 || req.Mes > 11 {
-		return nil, errors.New("mes inválido (debe estar entre 0 y 11)")
+		return nil, nil, errors.New("mes inválido (debe estar entre 0 y 11)")
+	}
+	if req.Anio == 0 {
+		req.Anio = time.Now().Year()
+	}
+	if req.MedioPago == "" {
+		req.MedioPago = models.MedioEfectivo
+	}
+	if err := validarAnioPeriodo(prop, req.Anio); err != nil {
+		return nil, nil, err
 	}
 
-	if prop.Pagos[req.Mes].Estado == models.PagadoEstado {
-		return nil, errors.New("este mes ya está marcado como pagado")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	periodo, err := s.getPeriodo(ctx, prop, req.Anio, req.Mes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if periodo.Estado == models.PagadoEstado {
+		return nil, nil, errors.New("este mes ya está marcado como pagado")
 	}
 
-	// 2. Crear el movimiento en MySQL (caja del admin)
-	movID, err := s.crearMovimientoAlquiler(prop, req, registradoPor)
+	// La caja que recibe el cobro tiene que estar abierta: sin caja no se registra
+	arco, err := arcoDestino(req.ArcoID, registradoPor)
 	if err != nil {
-		log.Printf("[ALQUILER] Advertencia: no se pudo crear movimiento en caja: %v", err)
-		// Continuamos - el pago se registra en Mongo aunque no haya arco abierto
+		return nil, nil, err
 	}
 
-	// 3. Actualizar estado en MongoDB
+	// Cobro en dólares: se pasa a pesos con la cotización indicada o la del día.
+	// El primer cobro fija el monto del período con esa misma cotización.
 	now := time.Now()
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	set := bson.M{}
+	tipoCotizacion := ""
+	if req.MontoDolares > 0 {
+		if !prop.PagaEnDolares {
+			return nil, nil, errors.New("la propiedad no alquila en dólares")
+		}
+		tipoCotizacion = tipoCotizacionDe(prop)
+		req.Monto, req.Cotizacion, err = NewCotizacionService().montoEnPesos(tipoCotizacion, req.MontoDolares, req.Cotizacion, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		dolares := periodo.MontoDolares
+		if dolares == 0 {
+			dolares = prop.MontoDolares
+		}
+		if len(periodo.Cobros) == 0 && dolares > 0 {
+			periodo.Monto = roundDos(dolares * req.Cotizacion)
+			set["monto"] = periodo.Monto
+			set["monto_dolares"] = dolares
+			set["cotizacion"] = req.Cotizacion
+			set["tipo_cotizacion"] = tipoCotizacion
+			set["fecha_cotizacion"] = inicioDia(now)
+		}
+	}
+	if req.Monto <= 0 {
+		return nil, nil, errors.New("debe indicar el monto del pago")
+	}
 
-	objID := prop.ID
+	// 2. Liquidar punitorios e imputar el cobro
+	pol, err := NewPunitorioService().politicaPara(ctx, periodo.ContratoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reglas, err := NewMoraService().reglas(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	punitorioNuevo, diasAtraso := calcularPunitorio(pol, inicioMora(reglas, *prop, periodo.Anio, periodo.Mes), periodo, now)
+	if req.CondonarPunitorio {
+		punitorioNuevo = 0
+	}
 
-	updateFields := bson.M{
-		"updated_at":                   now,
-		"pagos." + intToStr(req.Mes) + ".estado":     string(models.PagadoEstado),
-		"pagos." + intToStr(req.Mes) + ".monto":      req.Monto,
-		"pagos." + intToStr(req.Mes) + ".fecha_pago": now,
+	saldoPunitorio := roundDos(periodo.SaldoPunitorio() + punitorioNuevo)
+	saldo := roundDos(periodo.SaldoCapital() + saldoPunitorio)
+	if req.Monto > saldo+0.005 {
+		return nil, nil, errors.New("el monto supera el saldo del período ($ " + utils.FormatMonto(saldo) + ")")
 	}
-	if movID != nil {
-		updateFields["pagos."+intToStr(req.Mes)+".movement_id"] = *movID
+	aPunitorio := math.Min(req.Monto, saldoPunitorio)
+	aCapital := roundDos(req.Monto - aPunitorio)
+	saldada := saldo-req.Monto <= 0.005
+
+	// 3. Armar el movimiento de caja del cobro
+	nota := ""
+	if !saldada {
+		nota = "pago parcial"
+	}
+	if aPunitorio > 0 {
+		if nota != "" {
+			nota += ", "
+		}
+		nota += "incluye punitorios $ " + utils.FormatMonto(aPunitorio)
+	}
+	if req.MontoDolares > 0 {
+		if nota != "" {
+			nota += ", "
+		}
+		nota += "USD " + utils.FormatMonto(req.MontoDolares) + " a $ " + utils.FormatMonto(req.Cotizacion)
+	}
+
+	// 4. Registrar en el outbox la operación de caja antes de tocar el período:
+	// si MySQL falla se reintenta hasta crear el movimiento en la caja elegida
+	cobroID := primitive.NewObjectID()
+	op := &models.OperacionCaja{
+		Tipo:        models.OpCrearMovimiento,
+		PropiedadID: prop.ID,
+		PeriodoID:   periodo.ID,
+		CobroID:     &cobroID,
+		Anio:        req.Anio,
+		Mes:         req.Mes,
+		Referencia:  referenciaCobro(cobroID),
+		Monto:       req.Monto,
+		Detalle:     detalleMovimientoAlquiler(prop, req.Anio, req.Mes, nota),
+		Fecha:       now,
+		UsuarioID:   registradoPor,
+		ArcoID:      arco.ID,
+	}
+	ops := NewOperacionCajaService()
+	if err := ops.encolar(ctx, op); err != nil {
+		return nil, nil, err
+	}
+
+	// 5. Actualizar el período en MongoDB
+	cobro := models.CobroPeriodo{
+		ID:                 cobroID,
+		Fecha:              now,
+		Monto:              req.Monto,
+		Capital:            aCapital,
+		Punitorio:          aPunitorio,
+		PunitorioDevengado: punitorioNuevo,
+		DiasAtraso:         diasAtraso,
+		Condonado:          req.CondonarPunitorio && diasAtraso > 0,
+		InteresDesde:       periodo.InteresHasta,
+		RegistradoPor:      registradoPor,
+		MedioPago:          req.MedioPago,
+	}
+	if req.MontoDolares > 0 {
+		cobro.MontoDolares = req.MontoDolares
+		cobro.Cotizacion = req.Cotizacion
+		cobro.TipoCotizacion = tipoCotizacion
+	}
+	set["fecha_pago"] = now
+	set["updated_at"] = now
+	if saldada {
+		set["estado"] = string(models.PagadoEstado)
+	}
+	if diasAtraso > 0 {
+		// Los punitorios diarios quedan liquidados (o condonados) hasta hoy
+		set["interes_hasta"] = inicioDia(now)
+	}
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"cobros": cobro},
+		"$inc": bson.M{
+			"pagado":              aCapital,
+			"punitorio_devengado": punitorioNuevo,
+			"punitorio_pagado":    aPunitorio,
+		},
+	}
+	// El filtro descarta el cobro si otro cobro o reversión tocó el período desde
+	// que se leyó: lo imputado se calculó sobre ese saldo
+	res, err := s.periodos.UpdateOne(ctx, filtroPeriodoLeido(periodo), update)
+	if err != nil {
+		ops.cancelar(ctx, op.ID)
+		return nil, nil, err
+	}
+	if res.MatchedCount == 0 {
+		ops.cancelar(ctx, op.ID)
+		return nil, nil, ErrPeriodoModificado
+	}
+	s.coll.UpdateOne(ctx, bson.M{"_id": prop.ID}, bson.M{"$set": bson.M{"updated_at": now}})
+	if saldada {
+		NewMoraService().registrarTransicion(ctx, periodo, models.PagadoEstado, diasAtraso, models.OrigenPago, registradoPor)
 	}
 
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	var updated models.Propiedad
-	err = s.coll.FindOneAndUpdate(ctx,
-		bson.M{"_id": objID},
-		bson.M{"$set": updateFields},
-		opts,
-	).Decode(&updated)
+	// 6. Crear el movimiento en MySQL (caja del admin). Si falla, el cobro queda
+	// registrado y la operación pendiente se reintenta en segundo plano.
+	if movID, err := ops.aplicar(ctx, op); err != nil {
+		log.Printf("[ALQUILER] Advertencia: movimiento de caja pendiente para el cobro %s: %v", cobroID.Hex(), err)
+	} else {
+		cobro.MovementID = movID
+	}
 
+	// 7. Emitir el recibo (si falla, el cobro queda registrado igual)
+	recibo, err := NewReciboService().emitir(ctx, prop, periodo, cobro, saldada)
 	if err != nil {
-		return nil, err
+		log.Printf("[ALQUILER] Advertencia: no se pudo emitir el recibo: %v", err)
+	} else {
+		s.periodos.UpdateOne(ctx,
+			bson.M{"_id": periodo.ID, "cobros._id": cobro.ID},
+			bson.M{"$set": bson.M{"cobros.$.recibo_id": recibo.ID}})
 	}
-	return &updated, nil
+
+	actualizada, err := s.conPagos(prop, req.Anio)
+	if err != nil {
+		return nil, nil, err
+	}
+	return actualizada, recibo, nil
 }
 
-// DeshacerPago revierte el pago de un mes: actualiza MongoDB y elimina el movimiento de MySQL.
-func (s *AlquilerService) DeshacerPago(propID string, mes int, userID uint) (*models.Propiedad, error) {
-	prop, err := s.GetPropiedadByID(propID)
+// GetLiquidacion devuelve cuánto hay que cobrar hoy por un período: saldo del
+// alquiler, punitorios pendientes y los que se liquidarían con el próximo cobro.
+func (s *AlquilerService) GetLiquidacion(propID string, anio, mes int) (*models.LiquidacionPeriodo, error) {
+	prop, err := s.getPropiedad(propID)
 	if err != nil {
 		return nil, err
 	}
-
 	if mes < 0 || mes > 11 {
 		return nil, errors.New("mes inválido")
 	}
+	if anio == 0 {
+		anio = time.Now().Year()
+	}
+	if err := validarAnioPeriodo(prop, anio); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	periodo, err := s.getPeriodo(ctx, prop, anio, mes)
+	if err != nil {
+		return nil, err
+	}
+	pol, err := NewPunitorioService().politicaPara(ctx, periodo.ContratoID)
+	if err != nil {
+		return nil, err
+	}
+	reglas, err := NewMoraService().reglas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nuevo, dias := calcularPunitorio(pol, inicioMora(reglas, *prop, anio, mes), periodo, time.Now())
+
+	cobros := periodo.Cobros
+	if cobros == nil {
+		cobros = []models.CobroPeriodo{}
+	}
+	return &models.LiquidacionPeriodo{
+		Anio:               anio,
+		Mes:                mes,
+		Vencimiento:        vencimientoPago(reglas, *prop, anio, mes),
+		Alquiler:           periodo.Monto,
+		Pagado:             roundDos(periodo.Pagado),
+		SaldoAlquiler:      roundDos(periodo.SaldoCapital()),
+		PunitorioPendiente: roundDos(periodo.SaldoPunitorio()),
+		PunitorioNuevo:     nuevo,
+		DiasAtraso:         dias,
+		Total:              roundDos(periodo.Saldo() + nuevo),
+		Politica:           pol,
+		Cobros:             cobros,
+	}, nil
+}
 
-	if prop.Pagos[mes].Estado != models.PagadoEstado {
-		return nil, errors.New("este mes no está pagado")
+// DeshacerPago revierte el último cobro de un período: elimina su movimiento de
+// MySQL, descuenta lo imputado (incluidos los punitorios liquidados en ese cobro)
+// y anula su recibo.
+func (s *AlquilerService) DeshacerPago(propID string, anio, mes int, userID uint) (*models.Propiedad, error) {
+	prop, err := s.getPropiedad(propID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Eliminar movimiento de MySQL si existe
-	movID := prop.Pagos[mes].MovementID
-	if movID != nil {
-		ms := NewMovementService()
-		if err := ms.SoftDeleteMovement(*movID, userID); err != nil {
-			log.Printf("[ALQUILER] Advertencia: no se pudo eliminar movimiento %d de MySQL: %v", *movID, err)
-		}
+	if mes < 0 || mes > 11 {
+		return nil, errors.New("mes inválido")
+	}
+	if anio == 0 {
+		anio = time.Now().Year()
 	}
 
-	// Actualizar MongoDB
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	updateFields := bson.M{
-		"updated_at": time.Now(),
-		"pagos." + intToStr(mes) + ".estado":      string(models.PendienteEstado),
-		"pagos." + intToStr(mes) + ".fecha_pago":  nil,
-		"pagos." + intToStr(mes) + ".movement_id": nil,
+	var periodo models.PeriodoAlquiler
+	err = s.periodos.FindOne(ctx, bson.M{"propiedad_id": prop.ID, "anio": anio, "mes": mes}).Decode(&periodo)
+	if err != nil || (len(periodo.Cobros) == 0 && periodo.Estado != models.PagadoEstado) {
+		return nil, errors.New("este mes no tiene pagos registrados")
 	}
 
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	var updated models.Propiedad
-	err = s.coll.FindOneAndUpdate(ctx,
-		bson.M{"_id": prop.ID},
-		bson.M{"$set": updateFields},
-		opts,
-	).Decode(&updated)
+	now := time.Now()
+	var update bson.M
+	var movID *uint
+	var revertido *models.CobroPeriodo
 
+	// Si el período deja de estar pagado vuelve al estado de mora que le corresponde hoy
+	mora := NewMoraService()
+	estadoImpago, diasAtraso, err := mora.estadoPeriodo(ctx, prop, anio, mes)
 	if err != nil {
 		return nil, err
 	}
-	return &updated, nil
-}
 
-// ActualizarEstadosMorosos recalcula el estado de los pagos pendientes y los
-// marca como late_1 o late_2 según los meses de atraso acumulados.
-// Se puede llamar periódicamente (ej: cron).
-func (s *AlquilerService) ActualizarEstadosMorosos() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	if len(periodo.Cobros) == 0 {
+		// Pago anterior a los cobros parciales: un único pago por el total
+		movID = periodo.MovementID
+		update = bson.M{
+			"$set":   bson.M{"estado": string(estadoImpago), "pagado": 0.0, "updated_at": now},
+			"$unset": bson.M{"fecha_pago": "", "movement_id": ""},
+		}
+	} else {
+		ultimo := periodo.Cobros[len(periodo.Cobros)-1]
+		if liquidado, err := NewLiquidacionService().cobroLiquidado(ctx, ultimo.ID); err != nil {
+			return nil, err
+		} else if liquidado {
+			return nil, errors.New("el cobro ya se rindió al propietario; anule la liquidación antes de revertirlo")
+		}
+		movID = ultimo.MovementID
+		revertido = &ultimo
 
-	mesActual := int(time.Now().Month()) - 1 // 0-based
+		set := bson.M{"updated_at": now}
+		unset := bson.M{}
+		if periodo.Estado == models.PagadoEstado {
+			set["estado"] = string(estadoImpago)
+		}
+		if ultimo.InteresDesde != nil {
+			set["interes_hasta"] = *ultimo.InteresDesde
+		} else {
+			unset["interes_hasta"] = ""
+		}
+		if len(periodo.Cobros) > 1 {
+			anterior := periodo.Cobros[len(periodo.Cobros)-2]
+			set["fecha_pago"] = anterior.Fecha
+			if anterior.MovementID != nil {
+				set["movement_id"] = *anterior.MovementID
+			} else {
+				unset["movement_id"] = ""
+			}
+		} else {
+			unset["fecha_pago"] = ""
+			unset["movement_id"] = ""
+		}
 
-	cursor, err := s.coll.Find(ctx, bson.M{"ocupada": true})
-	if err != nil {
-		return err
+		update = bson.M{
+			"$set": set,
+			"$pop": bson.M{"cobros": 1},
+			"$inc": bson.M{
+				"pagado":              -ultimo.Capital,
+				"punitorio_devengado": -ultimo.PunitorioDevengado,
+				"punitorio_pagado":    -ultimo.Punitorio,
+			},
+		}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
 	}
-	defer cursor.Close(ctx)
 
-	var props []models.Propiedad
-	if err := cursor.All(ctx, &props); err != nil {
-		return err
+	// La anulación del movimiento de MySQL pasa por el outbox: se registra antes
+	// de revertir el período y se reintenta hasta aplicarse
+	ops := NewOperacionCajaService()
+	var op *models.OperacionCaja
+	if movID != nil {
+		op = &models.OperacionCaja{
+			Tipo:        models.OpAnularMovimiento,
+			PropiedadID: prop.ID,
+			PeriodoID:   periodo.ID,
+			Anio:        anio,
+			Mes:         mes,
+			Fecha:       now,
+			MovementID:  movID,
+			UsuarioID:   userID,
+		}
+		if revertido != nil {
+			op.CobroID = &revertido.ID
+		}
+		if err := ops.encolar(ctx, op); err != nil {
+			return nil, err
+		}
 	}
 
-	for _, prop := range props {
-		updates := bson.M{"updated_at": time.Now()}
-		changed := false
+	// Actualizar MongoDB, solo si el período sigue como se leyó (otra reversión
+	// concurrente sacaría un segundo cobro con el $pop)
+	res, err := s.periodos.UpdateOne(ctx, filtroPeriodoLeido(&periodo), update)
+	if err == nil && res.MatchedCount == 0 {
+		err = ErrPeriodoModificado
+	}
+	if err != nil {
+		if op != nil {
+			ops.cancelar(ctx, op.ID)
+		}
+		return nil, err
+	}
+	if op != nil {
+		if _, err := ops.aplicar(ctx, op); err != nil {
+			log.Printf("[ALQUILER] Advertencia: anulación del movimiento %d pendiente: %v", *movID, err)
+		}
+	} else if revertido != nil {
+		// El movimiento del cobro todavía no se había creado: ya no hace falta
+		ops.cancelarCreacion(ctx, revertido.ID)
+	}
+	s.coll.UpdateOne(ctx, bson.M{"_id": prop.ID}, bson.M{"$set": bson.M{"updated_at": now}})
+	if periodo.Estado == models.PagadoEstado {
+		mora.registrarTransicion(ctx, &periodo, estadoImpago, diasAtraso, models.OrigenReversionPago, userID)
+	}
 
-		for i := 0; i < mesActual; i++ {
-			pago := prop.Pagos[i]
-			if pago.Estado == models.PagadoEstado {
-				continue
-			}
+	if revertido != nil {
+		if err := NewReciboService().anular(ctx, *revertido, userID, "pago revertido"); err != nil {
+			log.Printf("[ALQUILER] Advertencia: no se pudo anular el recibo del cobro %s: %v", revertido.ID.Hex(), err)
+		}
+	}
 
-			mesesAtraso := mesActual - i
-			var nuevoEstado models.EstadoPago
-			switch {
-			case mesesAtraso >= 2:
-				nuevoEstado = models.Atraso2Estado
-			case mesesAtraso == 1:
-				nuevoEstado = models.Atraso1Estado
-			default:
-				nuevoEstado = models.PendienteEstado
-			}
+	return s.conPagos(prop, anio)
+}
 
-			if pago.Estado != nuevoEstado {
-				updates["pagos."+intToStr(i)+".estado"] = string(nuevoEstado)
-				changed = true
-			}
-		}
+// ErrPeriodoModificado indica que otra operación cambió el período mientras se
+// registraba o revertía un cobro.
+var ErrPeriodoModificado = errors.New("el período fue modificado por otra operación, vuelva a intentarlo")
 
-		if changed {
-			updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			_, _ = s.coll.UpdateOne(updateCtx, bson.M{"_id": prop.ID}, bson.M{"$set": updates})
-			cancel()
-		}
+// filtroPeriodoLeido coincide con el período solo si no cambió desde que se
+// leyó: toda escritura de cobros actualiza updated_at y la cantidad de cobros.
+func filtroPeriodoLeido(p *models.PeriodoAlquiler) bson.M {
+	filtro := bson.M{"_id": p.ID, "updated_at": p.UpdatedAt}
+	if len(p.Cobros) == 0 {
+		filtro["cobros.0"] = bson.M{"$exists": false}
+	} else {
+		filtro["cobros"] = bson.M{"$size": len(p.Cobros)}
 	}
-	return nil
+	return filtro
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
 // Resumen / Reportes
 // ─────────────────────────────────────────────────────────────────────────────
 
-// GetResumen calcula los KPIs del módulo de alquileres.
+// calcularRentabilidad completa el resultado de una propiedad a partir de lo
+// cobrado (ya sumado en rent.Ingresos) y sus gastos. Los gastos a cargo del
+// inquilino se informan pero no restan.
+func calcularRentabilidad(rent *models.RentabilidadPropiedad, g totalesGasto) {
+	rent.Ingresos = roundDos(rent.Ingresos)
+	rent.Gastos = roundDos(g.Gastos)
+	rent.GastosInquilino = roundDos(g.GastosInquilino)
+	rent.Resultado = roundDos(rent.Ingresos - rent.Gastos)
+	if rent.Ingresos > 0 {
+		rent.Margen = roundDos(rent.Resultado / rent.Ingresos * 100)
+	}
+}
+
+// GetResumen calcula los KPIs del módulo de alquileres para los períodos del año,
+// incluida la rentabilidad por propiedad (lo cobrado menos los gastos del año) y
+// la ocupación del año según el historial.
 func (s *AlquilerService) GetResumen(anio int) (*models.ResumenAlquileres, error) {
 	if anio == 0 {
 		anio = time.Now().Year()
@@ -458,7 +1000,7 @@ func (s *AlquilerService) GetResumen(anio int) (*models.ResumenAlquileres, error
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := s.coll.Find(ctx, bson.M{"anio": anio})
+	cursor, err := s.coll.Find(ctx, bson.M{"anio": bson.M{"$lte": anio}})
 	if err != nil {
 		return nil, err
 	}
@@ -468,9 +1010,20 @@ func (s *AlquilerService) GetResumen(anio int) (*models.ResumenAlquileres, error
 	if err := cursor.All(ctx, &props); err != nil {
 		return nil, err
 	}
+	if err := s.cargarPagos(ctx, props, anio); err != nil {
+		return nil, err
+	}
+
+	// Gastos del año por propiedad para la rentabilidad
+	desde := time.Date(anio, time.January, 1, 0, 0, 0, 0, time.Local)
+	gastos, err := NewGastoService().totalesPorPropiedad(ctx, desde, desde.AddDate(1, 0, 0))
+	if err != nil {
+		return nil, err
+	}
 
 	resumen := &models.ResumenAlquileres{
 		TotalPropiedades: len(props),
+		PorPropiedad:     []models.RentabilidadPropiedad{},
 	}
 
 	propiedadesConAtraso := map[primitive.ObjectID]bool{}
@@ -481,9 +1034,11 @@ func (s *AlquilerService) GetResumen(anio int) (*models.ResumenAlquileres, error
 			resumen.IngresoAnualProyectado += p.AlquilerMensual * 12
 		}
 
+		rent := models.RentabilidadPropiedad{PropiedadID: p.ID, Direccion: p.Direccion}
 		for _, pago := range p.Pagos {
+			rent.Ingresos += pago.Cobrado
 			if pago.Estado != models.PagadoEstado {
-				resumen.DeudaTotal += p.AlquilerMensual
+				resumen.DeudaTotal += pago.Saldo
 				resumen.MesesPendientesTotal++
 			}
 			if pago.Estado == models.Atraso1Estado || pago.Estado == models.Atraso2Estado {
@@ -491,9 +1046,29 @@ func (s *AlquilerService) GetResumen(anio int) (*models.ResumenAlquileres, error
 				propiedadesConAtraso[p.ID] = true
 			}
 		}
+
+		calcularRentabilidad(&rent, gastos[p.ID])
+		resumen.IngresosCobrados += rent.Ingresos
+		resumen.GastosTotales += rent.Gastos
+		resumen.PorPropiedad = append(resumen.PorPropiedad, rent)
 	}
 
 	resumen.PropiedadesConAtraso = len(propiedadesConAtraso)
+	// Ocupación histórica: del 1° de enero a hoy (o a fin de año si ya terminó)
+	hastaOcupacion := desde.AddDate(1, 0, 0)
+	if hoy := finDeHoy(); hoy.Before(hastaOcupacion) {
+		hastaOcupacion = hoy
+	}
+	if resumen.OcupacionHistorica, err = NewOcupacionService().metricasRango(ctx, desde, hastaOcupacion); err != nil {
+		return nil, err
+	}
+	resumen.DocumentosPorVencer, err = NewDocumentoService().vencimientos(ctx, diasAlertaDocumentos)
+	if err != nil {
+		return nil, err
+	}
+	resumen.IngresosCobrados = roundDos(resumen.IngresosCobrados)
+	resumen.GastosTotales = roundDos(resumen.GastosTotales)
+	resumen.Rentabilidad = roundDos(resumen.IngresosCobrados - resumen.GastosTotales)
 
 	if resumen.TotalPropiedades > 0 {
 		resumen.TasaOcupacion = float64(resumen.PropiedadesOcupadas) / float64(resumen.TotalPropiedades) * 100
@@ -503,8 +1078,9 @@ func (s *AlquilerService) GetResumen(anio int) (*models.ResumenAlquileres, error
 }
 
 // GetMovimientosAlquiler devuelve los movimientos MySQL relacionados a alquileres
-// filtrados por período (dia, mes, anio).
-func (s *AlquilerService) GetMovimientosAlquiler(periodo string) (interface{}, error) {
+// filtrados por período (dia, mes, anio). Si base no es nil también devuelve el
+// total expresado en pesos constantes de ese mes (IPC local).
+func (s *AlquilerService) GetMovimientosAlquiler(periodo string, base *time.Time) (interface{}, error) {
 	now := time.Now()
 	var desde, hasta time.Time
 
@@ -530,6 +1106,9 @@ func (s *AlquilerService) GetMovimientosAlquiler(periodo string) (interface{}, e
 		Periodo     string      `json:"periodo"`
 		Desde       time.Time   `json:"desde"`
 		Hasta       time.Time   `json:"hasta"`
+		// Pesos reales (solo si se pidió un mes base)
+		TotalMontoReal *float64 `json:"total_monto_real,omitempty"`
+		BaseReal       string   `json:"base_real,omitempty"`
 	}
 
 	var movimientos []models.Movement
@@ -552,87 +1131,207 @@ func (s *AlquilerService) GetMovimientosAlquiler(periodo string) (interface{}, e
 		total += m.Amount
 	}
 
-	return Resultado{
+	resultado := Resultado{
 		Movimientos: movimientos,
 		TotalMonto:  total,
 		Cantidad:    len(movimientos),
 		Periodo:     periodo,
 		Desde:       desde,
 		Hasta:       hasta,
-	}, nil
+	}
+
+	if base != nil {
+		indice, err := NewInflacionService().GetIndicePrecios()
+		if err != nil {
+			return nil, err
+		}
+		var totalReal float64
+		for _, m := range movimientos {
+			factor, _ := indice.FactorReal(m.MovementDate, *base)
+			totalReal += m.Amount * factor
+		}
+		totalReal = roundDos(totalReal)
+		resultado.TotalMontoReal = &totalReal
+		resultado.BaseReal = claveMes(*base)
+	}
+
+	return resultado, nil
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
 // Helpers privados
 // ─────────────────────────────────────────────────────────────────────────────
 
-// crearMovimientoAlquiler crea un movimiento de tipo Ingreso en la caja del admin.
-// Devuelve el movement_id creado o nil si no se pudo crear.
-func (s *AlquilerService) crearMovimientoAlquiler(prop *models.Propiedad, req models.RegistrarPagoRequest, registradoPor uint) (*uint, error) {
-	// Buscar el arco activo del usuario que registra (gestor o admin)
-	var arcoID uint
-	var arco models.Arco
+// detalleMovimientoAlquiler arma el detalle del movimiento de caja de un cobro
+// del período. `nota` se agrega al final (ej: "pago parcial").
+func detalleMovimientoAlquiler(prop *models.Propiedad, anio, mes int, nota string) string {
+	details := "Alquiler " + nombreMes(time.Month(mes+1)) + " " + strconv.Itoa(anio) + " - " + prop.Direccion
+	if prop.Inquilino != "" {
+		details += " (" + prop.Inquilino + ")"
+	}
+	if nota != "" {
+		details += " - " + nota
+	}
+	return details
+}
 
-	// Primero intentar el arco activo del usuario que registra
-	err := database.DB.Where("owner_id = ? AND activo = ?", registradoPor, true).First(&arco).Error
-	if err != nil {
-		// Si no tiene arco propio, buscar cualquier arco activo de un admin
-		var adminUser models.User
-		var adminRole models.Role
-		if err2 := database.DB.Where("role_name = ?", "Administrador General").First(&adminRole).Error; err2 == nil {
-			if err3 := database.DB.Where("role_id = ? AND is_active = ?", adminRole.RoleID, true).First(&adminUser).Error; err3 == nil {
-				database.DB.Where("owner_id = ? AND activo = ?", adminUser.UserID, true).First(&arco)
-			}
+// crearMovimientoCaja registra un Ingreso o Egreso del módulo de alquileres en
+// la caja abierta del usuario.
+func crearMovimientoCaja(tipo string, conceptID uint, monto float64, details string, registradoPor uint) (*uint, error) {
+	return crearMovimientoCajaRef(tipo, conceptID, monto, details, registradoPor, 0, "", time.Now())
+}
+
+var (
+	// ErrSinCajaDestino indica que un proceso automático no puede elegir la caja
+	// del movimiento: no hay usuario ni caja indicada.
+	ErrSinCajaDestino = errors.New("indique la caja destino")
+	// ErrCajaDestinoCerrada indica que la caja elegida ya no recibe movimientos.
+	ErrCajaDestinoCerrada = errors.New("la caja destino está cerrada")
+)
+
+// arcoDestino devuelve la caja que recibe un movimiento del módulo: la indicada
+// (debe estar abierta y no ser global) o, si no se indica, la caja abierta del
+// usuario. No hay caja por defecto: si no está abierta, el movimiento no se registra.
+func arcoDestino(arcoID, userID uint) (*models.Arco, error) {
+	var arco models.Arco
+	if arcoID != 0 {
+		if err := database.DB.First(&arco, arcoID).Error; err != nil || arco.IsGlobal {
+			return nil, errors.New("la caja destino no existe")
 		}
+		if !arco.Activo {
+			return nil, ErrCajaDestinoCerrada
+		}
+		return &arco, nil
+	}
+	if userID == 0 {
+		return nil, ErrSinCajaDestino
 	}
+	err := database.DB.Where("owner_id = ? AND is_global = ? AND activo = ?", userID, false, true).First(&arco).Error
+	if err != nil {
+		return nil, errors.New("no tiene una caja abierta; ábrala o indique la caja destino")
+	}
+	return &arco, nil
+}
 
-	if arco.ID == 0 {
-		return nil, errors.New("no hay ningún arco activo disponible")
+// CajasDestino lista las cajas abiertas que pueden recibir cobros, primero la
+// del usuario. Las globales no reciben movimientos.
+func (s *AlquilerService) CajasDestino(userID uint) ([]models.CajaDestino, error) {
+	var arcos []models.Arco
+	if err := database.DB.Preload("Owner").Where("activo = ? AND is_global = ?", true, false).
+		Order("fecha_apertura DESC").Find(&arcos).Error; err != nil {
+		return nil, err
 	}
-	arcoID = arco.ID
+	cajas := make([]models.CajaDestino, 0, len(arcos))
+	for _, a := range arcos {
+		c := models.CajaDestino{
+			ArcoID:        a.ID,
+			Responsable:   a.Owner.FullName,
+			OwnerID:       a.OwnerID,
+			Turno:         a.Turno,
+			FechaApertura: a.FechaApertura,
+			Propia:        a.OwnerID == userID,
+		}
+		if c.Propia {
+			cajas = append([]models.CajaDestino{c}, cajas...)
+		} else {
+			cajas = append(cajas, c)
+		}
+	}
+	return cajas, nil
+}
 
-	// Buscar o crear concepto "Alquiler"
-	conceptID := s.getOrCreateAlquilerConcept(registradoPor)
-	if conceptID == 0 {
-		return nil, errors.New("no se pudo obtener el concepto de alquiler")
+// crearMovimientoCajaRef es crearMovimientoCaja en la caja arcoID (0 = la del
+// usuario, ver arcoDestino), con un reference_id fijo (vacío = se genera) y la
+// fecha del movimiento. Con referencia es idempotente: si ya existe un
+// movimiento con ella, aunque esté anulado, devuelve ese.
+func crearMovimientoCajaRef(tipo string, conceptID uint, monto float64, details string, registradoPor, arcoID uint, referencia string, fecha time.Time) (*uint, error) {
+	if referencia != "" {
+		if id, ok := movimientoPorReferencia(referencia); ok {
+			return &id, nil
+		}
 	}
 
-	// Generar referenceID
-	ms := NewMovementService()
-	refID, err := ms.generateReferenceID(database.DB, registradoPor)
+	arco, err := arcoDestino(arcoID, registradoPor)
 	if err != nil {
 		return nil, err
 	}
+	if registradoPor == 0 {
+		// Procesos automáticos (conciliación): el movimiento queda a nombre del dueño del arco
+		registradoPor = arco.OwnerID
+	}
 
-	nombreMes := []string{"Enero", "Febrero", "Marzo", "Abril", "Mayo", "Junio",
-		"Julio", "Agosto", "Septiembre", "Octubre", "Noviembre", "Diciembre"}
-	details := "Alquiler " + nombreMes[req.Mes] + " - " + prop.Direccion
-	if prop.Inquilino != "" {
-		details += " (" + prop.Inquilino + ")"
+	// Generar referenceID
+	refID := referencia
+	if refID == "" {
+		ms := NewMovementService()
+		refID, err = ms.generateReferenceID(database.DB, registradoPor)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	movement := models.Movement{
 		ReferenceID:  refID,
-		MovementType: "Ingreso",
-		MovementDate: time.Now(),
-		Amount:       req.Monto,
-		Shift:        "M",
+		MovementType: tipo,
+		MovementDate: fecha,
+		Amount:       monto,
+		Shift:        arco.Turno,
 		ConceptID:    conceptID,
 		Details:      details,
 		CreatedBy:    registradoPor,
-		ArcoID:       arcoID,
+		ArcoID:       arco.ID,
 	}
 
-	if err := database.DB.Create(&movement).Error; err != nil {
+	// El movimiento y su detalle (ingreso o egreso) se crean juntos
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&movement).Error; err != nil {
+			return err
+		}
+		if tipo == "Egreso" {
+			return tx.Create(&models.SpecificExpense{MovementID: movement.MovementID}).Error
+		}
+		return tx.Create(&models.SpecificIncome{MovementID: movement.MovementID}).Error
+	})
+	if err != nil {
+		// Otro intento concurrente pudo crearlo con la misma referencia
+		if referencia != "" {
+			if id, ok := movimientoPorReferencia(referencia); ok {
+				return &id, nil
+			}
+		}
 		return nil, err
 	}
 
-	income := models.SpecificIncome{MovementID: movement.MovementID}
-	if err := database.DB.Create(&income).Error; err != nil {
-		log.Printf("[ALQUILER] Advertencia: no se pudo crear SpecificIncome: %v", err)
+	return &movement.MovementID, nil
+}
+
+// movimientoPorReferencia busca un movimiento (incluidos los anulados) por su reference_id.
+func movimientoPorReferencia(referencia string) (uint, bool) {
+	var mov models.Movement
+	if err := database.DB.Unscoped().Select("movement_id").Where("reference_id = ?", referencia).First(&mov).Error; err != nil {
+		return 0, false
 	}
+	return mov.MovementID, true
+}
 
-	return &movement.MovementID, nil
+// getOrCreateConcepto busca un concepto por nombre exacto o lo crea con el tipo indicado.
+func getOrCreateConcepto(nombre, tipo string, createdBy uint) uint {
+	var concept models.ConceptType
+	if err := database.DB.Where("concept_name = ?", nombre).First(&concept).Error; err == nil {
+		return concept.ConceptID
+	}
+
+	newConcept := models.ConceptType{
+		ConceptName:             nombre,
+		MovementTypeAssociation: tipo,
+		IsActive:                true,
+		CreatedBy:               &createdBy,
+		CreatedAt:               time.Now(),
+	}
+	if err := database.DB.Create(&newConcept).Error; err != nil {
+		return 0
+	}
+	return newConcept.ConceptID
 }
 
 // getAlquilerConceptID busca el ID del concepto de alquiler.
@@ -667,9 +1366,45 @@ func (s *AlquilerService) getOrCreateAlquilerConcept(createdBy uint) uint {
 	return newConcept.ConceptID
 }
 
-// intToStr convierte un int a string (para construir paths de bson).
-func intToStr(n int) string {
-	return []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}[n]
+// conPagos completa la vista de pagos del año indicado en la propiedad.
+func (s *AlquilerService) conPagos(prop *models.Propiedad, anio int) (*models.Propiedad, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	props := []models.Propiedad{*prop}
+	if err := s.cargarPagos(ctx, props, anio); err != nil {
+		return nil, err
+	}
+	return &props[0], nil
+}
+
+// cumpleFiltroEstado aplica los filtros de estado de la tabla sobre los 12 meses.
+func cumpleFiltroEstado(pagos []models.PagoMes, filtro string) bool {
+	switch filtro {
+	case "aldia":
+		// NINGÚN pago es diferente a "paid"
+		for _, p := range pagos {
+			if p.Estado != models.PagadoEstado {
+				return false
+			}
+		}
+		return true
+	case "atraso1":
+		for _, p := range pagos {
+			if p.Estado == models.Atraso1Estado || p.Estado == models.Atraso2Estado {
+				return true
+			}
+		}
+		return false
+	case "atraso2":
+		for _, p := range pagos {
+			if p.Estado == models.Atraso2Estado {
+				return true
+			}
+		}
+		return false
+	}
+	return true
 }
 
 // ─────────────────────────────────────────────────────────────────────────────
@@ -677,20 +1412,22 @@ func intToStr(n int) string {
 // ─────────────────────────────────────────────────────────────────────────────
 
 // GetActualizacionesPendientes devuelve las propiedades en pesos cuya fecha de
-// actualización ya pasó y no están pospuestas, junto con el cálculo de IPC.
+// actualización ya pasó y no están pospuestas, junto con el coeficiente del
+// índice que usa cada una (IPC, ICL, CER, UVA o CAC).
 func (s *AlquilerService) GetActualizacionesPendientes() ([]models.PropiedadActualizacion, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
 	now := time.Now()
 
-	// Propiedades: en pesos, ocupadas, con frecuencia ≥3 meses configurada,
+	// Propiedades: en pesos, ocupadas, con índice y frecuencia ≥3 meses configurados,
 	// cuya fecha de actualización ya llegó y no están pospuestas (o posponimiento expirado)
 	filter := bson.M{
 		"paga_en_dolares":          false,
 		"ocupada":                  true,
 		"frecuencia_actualizacion": bson.M{"$gte": 3},
 		"fecha_actualizacion":      bson.M{"$ne": nil, "$lte": now},
+		"tipo_indice":              bson.M{"$ne": models.IndiceNinguno},
 		"$or": bson.A{
 			bson.M{"posponer_hasta": nil},
 			bson.M{"posponer_hasta": bson.M{"$exists": false}},
@@ -713,23 +1450,39 @@ func (s *AlquilerService) GetActualizacionesPendientes() ([]models.PropiedadActu
 		return []models.PropiedadActualizacion{}, nil
 	}
 
-	inflSvc := NewInflacionService()
+	// Rango total de fechas, para descargar cada índice una sola vez
+	var minDesde, maxHasta time.Time
+	for _, prop := range props {
+		if prop.FechaActualizacion == nil || prop.FrecuenciaActualizacion < 3 {
+			continue
+		}
+		desde := prop.FechaActualizacion.AddDate(0, -prop.FrecuenciaActualizacion, 0)
+		if minDesde.IsZero() || desde.Before(minDesde) {
+			minDesde = desde
+		}
+		if prop.FechaActualizacion.After(maxHasta) {
+			maxHasta = *prop.FechaActualizacion
+		}
+	}
+	calc := newCalculadoraIndices(minDesde, maxHasta)
 	var resultado []models.PropiedadActualizacion
 
 	for _, prop := range props {
 		if prop.FechaActualizacion == nil || prop.FrecuenciaActualizacion < 3 {
 			continue
 		}
+		indice := prop.TipoIndice
+		if indice == "" {
+			indice = models.IndiceIPC
+		}
 
 		// Período: desde (fecha_actualizacion - frecuencia_meses) hasta fecha_actualizacion
 		desde := prop.FechaActualizacion.AddDate(0, -prop.FrecuenciaActualizacion, 0)
 		hasta := *prop.FechaActualizacion
 
-		detalle := inflSvc.ObtenerAcumulado(desde, hasta)
+		detalle := calc.calcular(indice, desde, hasta)
 
-		montoRec := prop.AlquilerMensual * (1.0 + detalle.AcumuladoPct/100.0)
-		// Redondear a 2 decimales
-		montoRec = float64(int(montoRec*100+0.5)) / 100
+		montoRec := roundDos(prop.AlquilerMensual * detalle.Coeficiente)
 
 		resultado = append(resultado, models.PropiedadActualizacion{
 			Propiedad:        prop,
@@ -743,63 +1496,98 @@ func (s *AlquilerService) GetActualizacionesPendientes() ([]models.PropiedadActu
 }
 
 // ActualizarMonto actualiza el monto mensual de una propiedad y avanza la
-// fecha de actualización al siguiente período.
-func (s *AlquilerService) ActualizarMonto(id string, req models.ActualizarMontoRequest) (*models.Propiedad, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		return nil, errors.New("ID inválido")
-	}
-
-	// Primero obtenemos la propiedad para calcular la nueva fecha si no se envió
+// fecha de actualización al siguiente período. Con contrato vigente la
+// actualización es del contrato (ContratoService.ActualizarMonto), que la
+// copia a la propiedad.
+func (s *AlquilerService) ActualizarMonto(id string, req models.ActualizarMontoRequest, userID uint) (*models.Propiedad, error) {
 	prop, err := s.GetPropiedadByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if prop.ContratoVigenteID != nil {
+		if _, err := NewContratoService().ActualizarMonto(prop.ContratoVigenteID.Hex(), req, userID); err != nil {
+			return nil, err
+		}
+		return s.GetPropiedadByID(id)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if req.NuevaFechaActualizacion == nil && prop.FechaActualizacion != nil && prop.FrecuenciaActualizacion >= 3 {
+		// Avanzar automáticamente al siguiente período
+		nuevaFecha := prop.FechaActualizacion.AddDate(0, prop.FrecuenciaActualizacion, 0)
+		req.NuevaFechaActualizacion = &nuevaFecha
+	}
+	if _, err := s.aplicarMonto(ctx, prop, nil, req, userID); err != nil {
+		return nil, err
+	}
+	return s.GetPropiedadByID(id)
+}
+
+// aplicarMonto guarda en la propiedad el monto y la fecha de actualización
+// (ya calculada) de req. Con contratoID solo se aplica si ese contrato sigue
+// vigente. El nuevo monto queda en la historia del alquiler desde
+// req.VigenteDesde (por defecto, el mes en curso) y se aplica a los períodos
+// sin cobros desde ese mes.
+func (s *AlquilerService) aplicarMonto(ctx context.Context, prop *models.Propiedad, contratoID *primitive.ObjectID, req models.ActualizarMontoRequest, userID uint) (*models.Propiedad, error) {
 	updates := bson.M{
 		"alquiler_mensual": req.NuevoMonto,
 		"updated_at":       time.Now(),
 		"posponer_hasta":   nil, // limpiar cualquier posponimiento
 	}
-
-	// Calcular la nueva fecha de actualización
 	if req.NuevaFechaActualizacion != nil {
 		updates["fecha_actualizacion"] = *req.NuevaFechaActualizacion
-	} else if prop.FechaActualizacion != nil && prop.FrecuenciaActualizacion >= 3 {
-		// Avanzar automáticamente al siguiente período
-		nuevaFecha := prop.FechaActualizacion.AddDate(0, prop.FrecuenciaActualizacion, 0)
-		updates["fecha_actualizacion"] = nuevaFecha
 	}
 
 	// Guardar notas en metadata si se enviaron
 	if req.Notas != "" {
 		updates["metadata.ultima_actualizacion_notas"] = req.Notas
 		updates["metadata.ultima_actualizacion_fecha"] = time.Now().Format("02/01/2006")
-		updates["metadata.monto_anterior"] = prop.AlquilerMensual
 	}
 
+	filtro := bson.M{"_id": prop.ID}
+	if contratoID != nil {
+		filtro["contrato_vigente_id"] = *contratoID
+	}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updated models.Propiedad
-	err = s.coll.FindOneAndUpdate(ctx,
-		bson.M{"_id": objID},
-		bson.M{"$set": updates},
-		opts,
-	).Decode(&updated)
-
+	err := s.coll.FindOneAndUpdate(ctx, filtro, bson.M{"$set": updates}, opts).Decode(&updated)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, errors.New("propiedad no encontrada")
 		}
 		return nil, err
 	}
+
+	historial := NewHistorialPropiedadService()
+	if err := historial.registrarCambios(ctx, prop, &updated, models.OperacionActualizacionMonto, userID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo registrar el historial de %s: %v", prop.ID.Hex(), err)
+	}
+	vigenteDesde := time.Now()
+	if req.VigenteDesde != nil {
+		vigenteDesde = *req.VigenteDesde
+	}
+	if updated.AlquilerMensual != prop.AlquilerMensual {
+		if err := historial.registrarMonto(ctx, &models.MontoAlquiler{
+			PropiedadID:   updated.ID,
+			Monto:         updated.AlquilerMensual,
+			MontoAnterior: prop.AlquilerMensual,
+			VigenteDesde:  vigenteDesde,
+			Origen:        models.OrigenMontoActualizacion,
+			ContratoID:    updated.ContratoVigenteID,
+			Notas:         req.Notas,
+			UsuarioID:     userID,
+		}); err != nil {
+			log.Printf("[ALQUILER] Advertencia: no se pudo registrar el alquiler de %s: %v", prop.ID.Hex(), err)
+		}
+	}
+	go NewNotificacionService().AvisarActualizacion(updated, prop.AlquilerMensual, vigenteDesde)
 	return &updated, nil
 }
 
 // PosponerActualizacion pospone la notificación de actualización hasta una fecha elegida.
-func (s *AlquilerService) PosponerActualizacion(id string, hasta time.Time) (*models.Propiedad, error) {
+func (s *AlquilerService) PosponerActualizacion(id string, hasta time.Time, userID uint) (*models.Propiedad, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("ID inválido")
@@ -808,16 +1596,16 @@ func (s *AlquilerService) PosponerActualizacion(id string, hasta time.Time) (*mo
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	var updated models.Propiedad
+	// Se lee la versión anterior para el historial
+	now := time.Now()
+	var antes models.Propiedad
 	err = s.coll.FindOneAndUpdate(ctx,
 		bson.M{"_id": objID},
 		bson.M{"$set": bson.M{
 			"posponer_hasta": hasta,
-			"updated_at":     time.Now(),
+			"updated_at":     now,
 		}},
-		opts,
-	).Decode(&updated)
+	).Decode(&antes)
 
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -825,5 +1613,11 @@ func (s *AlquilerService) PosponerActualizacion(id string, hasta time.Time) (*mo
 		}
 		return nil, err
 	}
-	return &updated, nil
+	updated := antes
+	updated.PosponerHasta = &hasta
+	updated.UpdatedAt = now
+	if err := NewHistorialPropiedadService().registrarCambios(ctx, &antes, &updated, models.OperacionPosponer, userID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo registrar el historial de %s: %v", id, err)
+	}
+	return s.conPagos(&updated, time.Now().Year())
 }
@@ -34,6 +34,7 @@ func (s *ArcoService) AbrirArco(userID uint, turno string, isGlobal bool) (*mode
 		arcoAbierto.FechaCierre = &now
 		arcoAbierto.HoraCierre = &now
 		arcoAbierto.Activo = false
+		arcoAbierto.CierreAutomatico = true
 		// Calcular y guardar saldo final al cerrar
 		saldoFinal, errSaldo := calcularSaldoFinal(database.DB, arcoAbierto.ID, arcoAbierto.SaldoInicial)
 		if errSaldo == nil {
@@ -114,7 +115,9 @@ func (s *ArcoService) CerrarArco(arcoID uint, userID uint) (*models.Arco, error)
 
 // CerrarArcoConRetiro cierra el arco y opcionalmente crea un movimiento tipo RetiroCaja
 // con el monto especificado, todo dentro de una transacción para mantener consistencia.
-func (s *ArcoService) CerrarArcoConRetiro(arcoID uint, userID uint, retiroAmount float64) (*models.Arco, error) {
+// Si se informa totalContado (efectivo contado antes del retiro) se registra junto
+// con la diferencia contra el saldo esperado.
+func (s *ArcoService) CerrarArcoConRetiro(arcoID uint, userID uint, retiroAmount float64, totalContado *float64) (*models.Arco, error) {
 	var resultArco models.Arco
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		var arco models.Arco
@@ -198,6 +201,13 @@ func (s *ArcoService) CerrarArcoConRetiro(arcoID uint, userID uint, retiroAmount
 			arco.SaldoFinal = saldoFinal
 		}
 
+		// El conteo se hace antes del retiro: se compara contra saldo final + retiro
+		if totalContado != nil {
+			diferencia := roundDos(*totalContado - (arco.SaldoFinal + retiroAmount))
+			arco.TotalContado = totalContado
+			arco.Diferencia = &diferencia
+		}
+
 		if err := tx.Save(&arco).Error; err != nil {
 			return err
 		}
@@ -321,7 +331,7 @@ func (s *ArcoService) GetSaldoArcoUsuario(userID uint, isGlobal bool) (*models.V
 	var err error
 
 	if isGlobal {
-		// NUEVA LÓGICA: La caja global es la suma de TODAS las cajas activas de TODOS los usuarios
+		// La caja global es la suma de las cajas activas más el saldo final de las cajas cerradas
 		log.Printf("[ARCO] Calculando caja GLOBAL (suma de todas las cajas)")
 
 		// Sumar los saldos de todas las cajas personales activas
@@ -351,25 +361,29 @@ func (s *ArcoService) GetSaldoArcoUsuario(userID uint, isGlobal bool) (*models.V
 			return nil, err
 		}
 
-		if globalSum.CajasActivas == 0 {
-			return nil, errors.New("No hay cajas personales activas en el sistema")
+		// El dinero de las cajas ya cerradas (su SaldoFinal) también es de la empresa:
+		// se suma al saldo inicial para que la caja global no quede en cero cuando todos cierran.
+		consolidado, err := NewCajaGlobalService().GetSaldoEnFecha(time.Now())
+		if err != nil {
+			return nil, err
 		}
+		saldoCerradas := consolidado.SaldoTotal - globalSum.SaldoTotal
 
 		// Crear un objeto VistaSaldoArqueo virtual para la caja global
 		saldo = models.VistaSaldoArqueo{
 			ArqueoID:      0, // ID virtual para caja global
 			OwnerID:       0, // Sin dueño específico (representa a todos)
 			IsGlobal:      true,
-			Activo:        true,
-			SaldoInicial:  globalSum.SaldoInicial,
+			Activo:        globalSum.CajasActivas > 0,
+			SaldoInicial:  globalSum.SaldoInicial + saldoCerradas,
 			TotalIngresos: globalSum.TotalIngresos,
 			TotalEgresos:  globalSum.TotalEgresos,
 			TotalRetiros:  globalSum.TotalRetiros,
-			SaldoTotal:    globalSum.SaldoTotal,
+			SaldoTotal:    consolidado.SaldoTotal,
 		}
 
-		log.Printf("[ARCO] Caja GLOBAL calculada - Cajas activas: %d, Saldo Total: %.2f",
-			globalSum.CajasActivas, globalSum.SaldoTotal)
+		log.Printf("[ARCO] Caja GLOBAL calculada - Cajas activas: %d, Cajas cerradas: %d, Saldo Total: %.2f",
+			globalSum.CajasActivas, consolidado.CajasCerradas, saldo.SaldoTotal)
 
 	} else {
 		// Caja personal del usuario
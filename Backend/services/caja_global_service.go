@@ -0,0 +1,334 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CajaGlobalService calcula el saldo consolidado de la empresa en cualquier
+// momento y mantiene la tabla de snapshots diarios.
+type CajaGlobalService struct {
+	stopChan chan bool
+}
+
+func NewCajaGlobalService() *CajaGlobalService {
+	return &CajaGlobalService{
+		stopChan: make(chan bool),
+	}
+}
+
+// GetSaldoEnFecha calcula el saldo global en el instante t:
+//   - cada arco abierto en t (un usuario puede tener uno por turno) aporta
+//     saldo_inicial + movimientos hasta t, igual que la vista de arqueos;
+//   - los usuarios sin arco abierto en t aportan el SaldoFinal de su último
+//     arco cerrado, que es el saldo que se arrastra al siguiente arco;
+//   - los retiros de caja hasta t se suman aparte: son una transferencia de
+//     un arco a la caja global, no una salida de dinero.
+//
+// Los movimientos eliminados después de t se consideran vigentes en t.
+func (s *CajaGlobalService) GetSaldoEnFecha(t time.Time) (*models.SaldoGlobal, error) {
+	abiertosEnT := database.DB.Model(&models.Arco{}).
+		Select("id").
+		Where("is_global = ? AND fecha_apertura <= ? AND (fecha_cierre IS NULL OR fecha_cierre > ?)", false, t, t)
+
+	var arcos []models.Arco
+	err := database.DB.Preload("Owner").Where("id IN (?)", abiertosEnT).Find(&arcos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	conCajaAbierta := []uint{0}
+	for _, a := range arcos {
+		conCajaAbierta = append(conCajaAbierta, a.OwnerID)
+	}
+	ultimosCerrados := database.DB.Model(&models.Arco{}).
+		Select("MAX(id)").
+		Where("is_global = ? AND fecha_cierre <= ? AND owner_id NOT IN ?", false, t, conCajaAbierta).
+		Group("owner_id")
+	var cerrados []models.Arco
+	if err := database.DB.Preload("Owner").Where("id IN (?)", ultimosCerrados).Find(&cerrados).Error; err != nil {
+		return nil, err
+	}
+	arcos = append(arcos, cerrados...)
+
+	saldo := &models.SaldoGlobal{
+		Fecha:   t,
+		Fuente:  "calculado",
+		Detalle: []models.SaldoCajaUsuario{},
+	}
+
+	// Arcos abiertos en t: necesitan sumar sus movimientos hasta t
+	var abiertos []uint
+	for _, a := range arcos {
+		if a.FechaCierre == nil || a.FechaCierre.After(t) {
+			abiertos = append(abiertos, a.ID)
+		}
+	}
+
+	netos := map[uint]float64{}
+	if len(abiertos) > 0 {
+		type netoArco struct {
+			ArcoID uint
+			Neto   float64
+		}
+		var rows []netoArco
+		err := database.DB.Raw(`
+			SELECT arco_id,
+				COALESCE(SUM(CASE WHEN movement_type = 'Ingreso' THEN amount ELSE -amount END), 0) AS neto
+			FROM movements
+			WHERE arco_id IN ? AND movement_date <= ?
+				AND (deleted_at IS NULL OR deleted_at > ?)
+			GROUP BY arco_id`, abiertos, t, t).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			netos[r.ArcoID] = r.Neto
+		}
+	}
+
+	var retirado float64
+	err = database.DB.Raw(`
+		SELECT COALESCE(SUM(amount), 0)
+		FROM movements
+		WHERE movement_type = 'RetiroCaja' AND movement_date <= ?
+			AND (deleted_at IS NULL OR deleted_at > ?)`, t, t).Scan(&retirado).Error
+	if err != nil {
+		return nil, err
+	}
+	consolidar(saldo, arcos, netos, retirado, t)
+
+	// Totales del día de t (hasta t)
+	desde := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	type totalesDia struct {
+		Ingresos float64
+		Egresos  float64
+		Retiros  float64
+	}
+	var tot totalesDia
+	err = database.DB.Raw(`
+		SELECT
+			COALESCE(SUM(CASE WHEN movement_type = 'Ingreso' THEN amount ELSE 0 END),0) AS ingresos,
+			COALESCE(SUM(CASE WHEN movement_type = 'Egreso' THEN amount ELSE 0 END),0) AS egresos,
+			COALESCE(SUM(CASE WHEN movement_type = 'RetiroCaja' THEN amount ELSE 0 END),0) AS retiros
+		FROM movements
+		WHERE movement_date >= ? AND movement_date <= ?
+			AND (deleted_at IS NULL OR deleted_at > ?)`, desde, t, t).Scan(&tot).Error
+	if err != nil {
+		return nil, err
+	}
+	saldo.TotalIngresos = tot.Ingresos
+	saldo.TotalEgresos = tot.Egresos
+	saldo.TotalRetiros = tot.Retiros
+
+	return saldo, nil
+}
+
+// GetSaldoDia devuelve el saldo global al cierre del día indicado.
+// Si existe snapshot para ese día se usa directamente; si no, se calcula.
+func (s *CajaGlobalService) GetSaldoDia(dia time.Time) (*models.SaldoGlobal, error) {
+	inicio := time.Date(dia.Year(), dia.Month(), dia.Day(), 0, 0, 0, 0, dia.Location())
+
+	var snap models.SaldoGlobalDiario
+	err := database.DB.Where("fecha = ?", inicio.Format("2006-01-02")).First(&snap).Error
+	if err == nil {
+		return snapshotASaldo(snap, finDelDia(inicio)), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	t := finDelDia(inicio)
+	if t.After(time.Now()) {
+		t = time.Now()
+	}
+	return s.GetSaldoEnFecha(t)
+}
+
+// GetHistorial devuelve el saldo de cierre de cada día del rango [desde, hasta].
+// Los días sin snapshot se calculan en el momento.
+func (s *CajaGlobalService) GetHistorial(desde, hasta time.Time) ([]models.SaldoGlobal, error) {
+	desde = time.Date(desde.Year(), desde.Month(), desde.Day(), 0, 0, 0, 0, desde.Location())
+	hasta = time.Date(hasta.Year(), hasta.Month(), hasta.Day(), 0, 0, 0, 0, hasta.Location())
+	if hasta.Before(desde) {
+		return nil, errors.New("el rango de fechas es inválido")
+	}
+	if hasta.Sub(desde) > 366*24*time.Hour {
+		return nil, errors.New("el rango no puede superar un año")
+	}
+
+	var snaps []models.SaldoGlobalDiario
+	if err := database.DB.Where("fecha >= ? AND fecha <= ?", desde.Format("2006-01-02"), hasta.Format("2006-01-02")).
+		Find(&snaps).Error; err != nil {
+		return nil, err
+	}
+	porDia := map[string]models.SaldoGlobalDiario{}
+	for _, sn := range snaps {
+		porDia[sn.Fecha.Format("2006-01-02")] = sn
+	}
+
+	var resultado []models.SaldoGlobal
+	for d := desde; !d.After(hasta); d = d.AddDate(0, 0, 1) {
+		if sn, ok := porDia[d.Format("2006-01-02")]; ok {
+			resultado = append(resultado, *snapshotASaldo(sn, finDelDia(d)))
+			continue
+		}
+		if d.After(time.Now()) {
+			break
+		}
+		saldo, err := s.GetSaldoDia(d)
+		if err != nil {
+			return nil, err
+		}
+		saldo.Detalle = nil
+		resultado = append(resultado, *saldo)
+	}
+	return resultado, nil
+}
+
+// GuardarSnapshot calcula y guarda (o reemplaza) el snapshot de cierre del día indicado.
+func (s *CajaGlobalService) GuardarSnapshot(dia time.Time) (*models.SaldoGlobalDiario, error) {
+	inicio := time.Date(dia.Year(), dia.Month(), dia.Day(), 0, 0, 0, 0, dia.Location())
+	saldo, err := s.GetSaldoEnFecha(finDelDia(inicio))
+	if err != nil {
+		return nil, err
+	}
+
+	snap := models.SaldoGlobalDiario{
+		Fecha:         inicio,
+		SaldoTotal:    saldo.SaldoTotal,
+		TotalIngresos: saldo.TotalIngresos,
+		TotalEgresos:  saldo.TotalEgresos,
+		TotalRetiros:  saldo.TotalRetiros,
+		CajasAbiertas: saldo.CajasAbiertas,
+		CajasCerradas: saldo.CajasCerradas,
+		CreatedAt:     time.Now(),
+		Retirado:      saldo.Retirado,
+	}
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "fecha"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"saldo_total", "total_ingresos", "total_egresos", "total_retiros",
+			"cajas_abiertas", "cajas_cerradas", "created_at", "retirado",
+		}),
+	}).Create(&snap).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// Start inicia la escritura automática del snapshot al final de cada día.
+// Al arrancar completa los días anteriores que hayan quedado sin snapshot.
+func (s *CajaGlobalService) Start() {
+	go func() {
+		s.completarSnapshotsFaltantes(30)
+
+		for {
+			ahora := time.Now()
+			// Apenas pasada la medianoche, cuando el día anterior ya no admite movimientos
+			proximo := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 5, 0, ahora.Location()).AddDate(0, 0, 1)
+			dia := proximo.AddDate(0, 0, -1)
+
+			select {
+			case <-time.After(time.Until(proximo)):
+				if _, err := s.GuardarSnapshot(dia); err != nil {
+					utils.Logger.Error("Error guardando snapshot de caja global", zap.Error(err))
+				} else {
+					utils.Logger.Info("Snapshot de caja global guardado",
+						zap.String("fecha", dia.Format("2006-01-02")),
+					)
+				}
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	utils.Logger.Info("Snapshots diarios de caja global activados")
+}
+
+// Stop detiene la escritura automática de snapshots.
+func (s *CajaGlobalService) Stop() {
+	close(s.stopChan)
+}
+
+// completarSnapshotsFaltantes genera los snapshots de los últimos `dias` días
+// (sin incluir hoy) que no existan, por ejemplo tras una caída del servidor.
+func (s *CajaGlobalService) completarSnapshotsFaltantes(dias int) {
+	hoy := time.Now()
+	hoy = time.Date(hoy.Year(), hoy.Month(), hoy.Day(), 0, 0, 0, 0, hoy.Location())
+
+	var primerArco models.Arco
+	if err := database.DB.Order("fecha_apertura ASC").First(&primerArco).Error; err != nil {
+		return // todavía no hay arcos
+	}
+
+	for d := hoy.AddDate(0, 0, -dias); d.Before(hoy); d = d.AddDate(0, 0, 1) {
+		if finDelDia(d).Before(primerArco.FechaApertura) {
+			continue
+		}
+		var count int64
+		database.DB.Model(&models.SaldoGlobalDiario{}).Where("fecha = ?", d.Format("2006-01-02")).Count(&count)
+		if count > 0 {
+			continue
+		}
+		if _, err := s.GuardarSnapshot(d); err != nil {
+			utils.Logger.Warn("No se pudo completar snapshot de caja global",
+				zap.String("fecha", d.Format("2006-01-02")),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+// consolidar suma al saldo cada arco (los abiertos en t con sus movimientos
+// netos, los cerrados con su SaldoFinal) y lo retirado de las cajas hasta t.
+func consolidar(saldo *models.SaldoGlobal, arcos []models.Arco, netos map[uint]float64, retirado float64, t time.Time) {
+	for _, a := range arcos {
+		item := models.SaldoCajaUsuario{
+			OwnerID:   a.OwnerID,
+			OwnerName: a.Owner.FullName,
+			ArcoID:    a.ID,
+		}
+		if a.FechaCierre == nil || a.FechaCierre.After(t) {
+			item.Activo = true
+			item.Saldo = a.SaldoInicial + netos[a.ID]
+			saldo.CajasAbiertas++
+		} else {
+			item.Saldo = a.SaldoFinal
+			saldo.CajasCerradas++
+		}
+		saldo.SaldoTotal += item.Saldo
+		saldo.Detalle = append(saldo.Detalle, item)
+	}
+	saldo.Retirado = roundDos(retirado)
+	saldo.SaldoTotal = roundDos(saldo.SaldoTotal + saldo.Retirado)
+}
+
+func finDelDia(d time.Time) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 23, 59, 59, 999999999, d.Location())
+}
+
+func snapshotASaldo(sn models.SaldoGlobalDiario, fecha time.Time) *models.SaldoGlobal {
+	return &models.SaldoGlobal{
+		Fecha:         fecha,
+		SaldoTotal:    sn.SaldoTotal,
+		TotalIngresos: sn.TotalIngresos,
+		TotalEgresos:  sn.TotalEgresos,
+		TotalRetiros:  sn.TotalRetiros,
+		CajasAbiertas: sn.CajasAbiertas,
+		CajasCerradas: sn.CajasCerradas,
+		Retirado:      sn.Retirado,
+		Fuente:        "snapshot",
+	}
+}
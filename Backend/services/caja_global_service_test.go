@@ -0,0 +1,48 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+	"time"
+)
+
+func TestConsolidar(t *testing.T) {
+	ahora := time.Date(2026, time.March, 10, 18, 0, 0, 0, time.Local)
+	cierre, despues := ahora.Add(-4*time.Hour), ahora.Add(time.Hour)
+	abierto := models.Arco{ID: 1, OwnerID: 10, SaldoInicial: 5000}
+	cerrado := models.Arco{ID: 2, OwnerID: 20, SaldoInicial: 2000, SaldoFinal: 700, FechaCierre: &cierre}
+	cerradoDespues := models.Arco{ID: 3, OwnerID: 30, SaldoInicial: 1000, FechaCierre: &despues}
+
+	casos := []struct {
+		nombre       string
+		arcos        []models.Arco
+		netos        map[uint]float64
+		retirado     float64
+		wantTotal    float64
+		wantAbiertas int
+		wantCerradas int
+	}{
+		{"sin retiros", []models.Arco{abierto}, map[uint]float64{1: 1500.5}, 0, 6500.5, 1, 0},
+		// El arco cerrado retiró 300 al cerrar (SaldoFinal 700): la empresa sigue teniendo 1000
+		{"retiro al cerrar", []models.Arco{cerrado}, nil, 300, 1000, 0, 1},
+		// Retiro de 800 en un arco abierto: su neto baja y lo retirado lo compensa
+		{"retiro con el arco abierto", []models.Arco{abierto}, map[uint]float64{1: 1200 - 800}, 800, 6200, 1, 0},
+		{"un arco cerrado después de t cuenta como abierto", []models.Arco{abierto, cerradoDespues}, map[uint]float64{1: -250, 3: 100}, 0, 5850, 2, 0},
+		{"faltante", []models.Arco{abierto}, map[uint]float64{1: -5000.01}, 0, -0.01, 1, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			saldo := &models.SaldoGlobal{}
+			consolidar(saldo, c.arcos, c.netos, c.retirado, ahora)
+			if saldo.SaldoTotal != c.wantTotal || saldo.Retirado != c.retirado {
+				t.Errorf("total/retirado = %v/%v, want %v/%v", saldo.SaldoTotal, saldo.Retirado, c.wantTotal, c.retirado)
+			}
+			if saldo.CajasAbiertas != c.wantAbiertas || saldo.CajasCerradas != c.wantCerradas {
+				t.Errorf("abiertas/cerradas = %d/%d, want %d/%d", saldo.CajasAbiertas, saldo.CajasCerradas, c.wantAbiertas, c.wantCerradas)
+			}
+			if len(saldo.Detalle) != len(c.arcos) {
+				t.Errorf("detalle = %d cajas, want %d", len(saldo.Detalle), len(c.arcos))
+			}
+		})
+	}
+}
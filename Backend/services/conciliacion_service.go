@@ -0,0 +1,460 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// diasConciliacion es el rango que revisa la conciliación programada (y la
+// manual si no se indican fechas).
+const diasConciliacion = 90
+
+// ConciliacionService compara los cobros de alquiler (MongoDB) con los
+// movimientos de caja (MySQL) y corrige las diferencias.
+type ConciliacionService struct {
+	periodos *mongo.Collection
+	props    *mongo.Collection
+	ops      *OperacionCajaService
+}
+
+func NewConciliacionService() *ConciliacionService {
+	return &ConciliacionService{
+		periodos: database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
+		props:    database.MongoDB.Collection(database.CollectionPropiedades),
+		ops:      NewOperacionCajaService(),
+	}
+}
+
+// diferencia agrega a DiferenciaCaja lo necesario para repararla.
+type diferencia struct {
+	models.DiferenciaCaja
+	fecha     time.Time
+	usuarioID uint
+	prop      *models.Propiedad
+	op        *models.OperacionCaja
+	anulado   bool // el movimiento existe pero está anulado
+}
+
+// cobroConciliado es un cobro (o un pago anterior a los cobros parciales) con
+// movimiento asociado.
+type cobroConciliado struct {
+	periodo *models.PeriodoAlquiler
+	cobroID *primitive.ObjectID
+	monto   float64
+	fecha   time.Time
+	usuario uint
+}
+
+// Conciliar lista las diferencias de los cobros del rango [desde, hasta).
+func (s *ConciliacionService) Conciliar(desde, hasta time.Time) (*models.ReporteConciliacion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	rep, difs, err := s.analizar(ctx, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range difs {
+		rep.Diferencias = append(rep.Diferencias, d.DiferenciaCaja)
+	}
+	return rep, nil
+}
+
+// Reparar corrige las diferencias reparables de los tipos indicados (vacío =
+// todos) y devuelve las que quedan. Los movimientos que se vuelven a crear van
+// a la caja arcoID (0 = la caja abierta de quien registró cada cobro).
+func (s *ConciliacionService) Reparar(desde, hasta time.Time, tipos []string, arcoID, userID uint) (*models.ReporteConciliacion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	incluir := map[string]bool{}
+	for _, t := range tipos {
+		incluir[t] = true
+	}
+
+	rep, difs, err := s.analizar(ctx, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range difs {
+		if !d.Reparable || (len(incluir) > 0 && !incluir[d.Tipo]) {
+			rep.Diferencias = append(rep.Diferencias, d.DiferenciaCaja)
+			continue
+		}
+		if err := s.reparar(ctx, d, arcoID, userID); err != nil {
+			rep.Errores = append(rep.Errores, d.Tipo+" "+d.Detalle+": "+err.Error())
+			rep.Diferencias = append(rep.Diferencias, d.DiferenciaCaja)
+			continue
+		}
+		rep.Reparadas++
+	}
+	return rep, nil
+}
+
+// conciliacionProgramada repara cada noche las diferencias seguras (cobros sin
+// movimiento y operaciones pendientes) y registra las que requieren revisión.
+func (s *ConciliacionService) conciliacionProgramada() {
+	hasta := inicioDia(time.Now()).AddDate(0, 0, 1)
+	rep, err := s.Reparar(hasta.AddDate(0, 0, -diasConciliacion), hasta,
+		[]string{models.DifSinMovimiento, models.DifOperacionPendiente}, 0, 0)
+	if err != nil {
+		utils.Logger.Error("Error en la conciliación de cobros y caja", zap.Error(err))
+		return
+	}
+	registrar := utils.Logger.Info
+	if len(rep.Diferencias) > 0 || len(rep.Errores) > 0 {
+		registrar = utils.Logger.Warn
+	}
+	registrar("Conciliación de cobros y caja",
+		zap.Int("cobros", rep.CobrosRevisados), zap.Int("reparadas", rep.Reparadas),
+		zap.Int("pendientes_de_revision", len(rep.Diferencias)), zap.Int("errores", len(rep.Errores)))
+}
+
+// analizar arma el reporte (sin diferencias) y la lista de diferencias.
+func (s *ConciliacionService) analizar(ctx context.Context, desde, hasta time.Time) (*models.ReporteConciliacion, []diferencia, error) {
+	if !hasta.After(desde) {
+		return nil, nil, errors.New("el rango de fechas es inválido")
+	}
+	rep := &models.ReporteConciliacion{Desde: desde, Hasta: hasta, Diferencias: []models.DiferenciaCaja{}}
+	rango := bson.M{"$gte": desde, "$lt": hasta}
+
+	// Operaciones del outbox sin aplicar (las recién registradas se están aplicando)
+	cursor, err := s.ops.coll.Find(ctx, bson.M{
+		"estado":     bson.M{"$in": bson.A{models.OpPendiente, models.OpRevision}},
+		"created_at": bson.M{"$lt": time.Now().Add(-esperaOperacionCaja)},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var pendientes []models.OperacionCaja
+	if err := cursor.All(ctx, &pendientes); err != nil {
+		return nil, nil, err
+	}
+	conOperacion := map[primitive.ObjectID]bool{}
+	refsPendientes := map[string]bool{}
+	var difs []diferencia
+	for i := range pendientes {
+		op := &pendientes[i]
+		if op.CobroID != nil && op.Tipo == models.OpCrearMovimiento {
+			conOperacion[*op.CobroID] = true
+		}
+		if op.Referencia != "" {
+			refsPendientes[op.Referencia] = true
+		}
+		d := diferencia{op: op}
+		d.Tipo = models.DifOperacionPendiente
+		d.PropiedadID = &op.PropiedadID
+		d.PeriodoID = &op.PeriodoID
+		d.CobroID = op.CobroID
+		d.Anio, d.Mes = op.Anio, op.Mes
+		d.MontoCobro = op.Monto
+		d.MovementID = op.MovementID
+		d.Detalle = strings.ReplaceAll(op.Tipo, "_", " ") + " pendiente (" + strconv.Itoa(op.Intentos) + " intentos)"
+		if op.Estado == models.OpRevision {
+			d.Detalle = strings.ReplaceAll(op.Tipo, "_", " ") + " en revisión: indique la caja destino"
+		} else if op.UltimoError != "" {
+			d.Detalle += ": " + op.UltimoError
+		}
+		d.Reparable = op.Estado == models.OpPendiente
+		difs = append(difs, d)
+	}
+
+	// Cobros del rango y pagos anteriores a los cobros parciales
+	cursor, err = s.periodos.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"cobros.fecha": rango},
+		bson.M{"cobros": bson.M{"$exists": false}, "estado": models.PagadoEstado, "fecha_pago": rango},
+	}})
+	if err != nil {
+		return nil, nil, err
+	}
+	var periodos []models.PeriodoAlquiler
+	if err := cursor.All(ctx, &periodos); err != nil {
+		return nil, nil, err
+	}
+
+	props, err := s.propiedades(ctx, periodos)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conMovimiento := map[uint]cobroConciliado{}
+	sinMovimiento := func(per *models.PeriodoAlquiler, cobroID *primitive.ObjectID, monto float64, fecha time.Time, usuario uint) {
+		d := diferencia{fecha: fecha, usuarioID: usuario, prop: props[per.PropiedadID]}
+		d.Tipo = models.DifSinMovimiento
+		d.PropiedadID = &per.PropiedadID
+		d.PeriodoID = &per.ID
+		d.CobroID = cobroID
+		d.Anio, d.Mes = per.Anio, per.Mes
+		d.MontoCobro = monto
+		d.Detalle = "El cobro no tiene movimiento de caja"
+		d.Reparable = d.prop != nil
+		difs = append(difs, d)
+	}
+	for i := range periodos {
+		per := &periodos[i]
+		if len(per.Cobros) == 0 {
+			rep.CobrosRevisados++
+			monto := per.Pagado
+			if monto == 0 {
+				monto = per.Monto
+			}
+			c := cobroConciliado{periodo: per, monto: monto, fecha: *per.FechaPago}
+			if per.MovementID == nil {
+				sinMovimiento(per, nil, c.monto, c.fecha, 0)
+			} else {
+				conMovimiento[*per.MovementID] = c
+			}
+			continue
+		}
+		for j := range per.Cobros {
+			cobro := &per.Cobros[j]
+			if cobro.Fecha.Before(desde) || !cobro.Fecha.Before(hasta) {
+				continue
+			}
+			rep.CobrosRevisados++
+			switch {
+			case cobro.MovementID != nil:
+				conMovimiento[*cobro.MovementID] = cobroConciliado{periodo: per, cobroID: &cobro.ID,
+					monto: cobro.Monto, fecha: cobro.Fecha, usuario: cobro.RegistradoPor}
+			case !conOperacion[cobro.ID]:
+				sinMovimiento(per, &cobro.ID, cobro.Monto, cobro.Fecha, cobro.RegistradoPor)
+			}
+		}
+	}
+
+	// Movimientos vinculados: deben existir, estar vigentes y coincidir en monto
+	ids := make([]uint, 0, len(conMovimiento))
+	for id := range conMovimiento {
+		ids = append(ids, id)
+	}
+	movs := map[uint]models.Movement{}
+	if len(ids) > 0 {
+		var lista []models.Movement
+		if err := database.DB.Unscoped().Where("movement_id IN ?", ids).Find(&lista).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, m := range lista {
+			movs[m.MovementID] = m
+		}
+	}
+	for id, c := range conMovimiento {
+		movID := id
+		d := diferencia{fecha: c.fecha, usuarioID: c.usuario, prop: props[c.periodo.PropiedadID]}
+		d.PropiedadID = &c.periodo.PropiedadID
+		d.PeriodoID = &c.periodo.ID
+		d.CobroID = c.cobroID
+		d.Anio, d.Mes = c.periodo.Anio, c.periodo.Mes
+		d.MontoCobro = c.monto
+		d.MovementID = &movID
+		mov, ok := movs[id]
+		switch {
+		case !ok || mov.DeletedAt.Valid:
+			d.Tipo = models.DifMovimientoEliminado
+			d.anulado = ok
+			d.Detalle = "El movimiento " + strconv.FormatUint(uint64(id), 10) + " no existe en la caja"
+			if ok {
+				d.Detalle = "El movimiento " + strconv.FormatUint(uint64(id), 10) + " está anulado"
+			}
+			d.Reparable = ok || d.prop != nil
+		case math.Abs(mov.Amount-c.monto) > 0.005:
+			d.Tipo = models.DifMontoDistinto
+			d.MontoCaja = mov.Amount
+			// El movimiento puede ser de una caja ya cerrada: se corrige a mano
+			d.Detalle = "La caja registra $ " + utils.FormatMonto(mov.Amount) + " y el cobro $ " + utils.FormatMonto(c.monto)
+		default:
+			continue
+		}
+		difs = append(difs, d)
+	}
+
+	// Movimientos de alquiler del rango que ningún cobro referencia
+	huerfanos, revisados, err := s.movimientosSinCobro(ctx, desde, hasta, conMovimiento, refsPendientes)
+	if err != nil {
+		return nil, nil, err
+	}
+	rep.MovimientosRevisados = revisados
+	difs = append(difs, huerfanos...)
+
+	for i := range difs {
+		if difs[i].prop == nil && difs[i].PropiedadID != nil {
+			difs[i].prop = props[*difs[i].PropiedadID]
+		}
+		if difs[i].prop != nil {
+			difs[i].PropiedadID = &difs[i].prop.ID
+			difs[i].Direccion = difs[i].prop.Direccion
+		}
+	}
+	return rep, difs, nil
+}
+
+// propiedades carga la dirección e inquilino de las propiedades de los períodos.
+func (s *ConciliacionService) propiedades(ctx context.Context, periodos []models.PeriodoAlquiler) (map[primitive.ObjectID]*models.Propiedad, error) {
+	res := map[primitive.ObjectID]*models.Propiedad{}
+	if len(periodos) == 0 {
+		return res, nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(periodos))
+	for _, p := range periodos {
+		ids = append(ids, p.PropiedadID)
+	}
+	cursor, err := s.props.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"direccion": 1, "inquilino": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var props []models.Propiedad
+	if err := cursor.All(ctx, &props); err != nil {
+		return nil, err
+	}
+	for i := range props {
+		res[props[i].ID] = &props[i]
+	}
+	return res, nil
+}
+
+// movimientosSinCobro busca los Ingresos vigentes del concepto de alquiler del
+// rango que no están vinculados a ningún cobro. Solo los creados por el módulo
+// (referencia ALQ-) se pueden anular automáticamente.
+func (s *ConciliacionService) movimientosSinCobro(ctx context.Context, desde, hasta time.Time, vinculados map[uint]cobroConciliado, refsPendientes map[string]bool) ([]diferencia, int, error) {
+	conceptID := NewAlquilerService().getAlquilerConceptID()
+	if conceptID == 0 {
+		return nil, 0, nil
+	}
+	var movs []models.Movement
+	err := database.DB.Where("concept_id = ? AND movement_type = ? AND movement_date >= ? AND movement_date < ?",
+		conceptID, "Ingreso", desde, hasta).Find(&movs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var candidatos []models.Movement
+	var ids []uint
+	for _, m := range movs {
+		if _, ok := vinculados[m.MovementID]; ok || refsPendientes[m.ReferenceID] {
+			continue
+		}
+		candidatos = append(candidatos, m)
+		ids = append(ids, m.MovementID)
+	}
+	if len(candidatos) == 0 {
+		return nil, len(movs), nil
+	}
+
+	// Pueden ser de cobros fuera del rango
+	cursor, err := s.periodos.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"movement_id": bson.M{"$in": ids}},
+		bson.M{"cobros.movement_id": bson.M{"$in": ids}},
+	}}, options.Find().SetProjection(bson.M{"movement_id": 1, "cobros.movement_id": 1}))
+	if err != nil {
+		return nil, 0, err
+	}
+	var referencian []models.PeriodoAlquiler
+	if err := cursor.All(ctx, &referencian); err != nil {
+		return nil, 0, err
+	}
+	usados := map[uint]bool{}
+	for _, p := range referencian {
+		if p.MovementID != nil {
+			usados[*p.MovementID] = true
+		}
+		for _, c := range p.Cobros {
+			if c.MovementID != nil {
+				usados[*c.MovementID] = true
+			}
+		}
+	}
+
+	var difs []diferencia
+	for _, m := range candidatos {
+		if usados[m.MovementID] {
+			continue
+		}
+		movID := m.MovementID
+		d := diferencia{fecha: m.MovementDate}
+		d.Tipo = models.DifMovimientoHuerfano
+		d.MovementID = &movID
+		d.MontoCaja = m.Amount
+		d.Detalle = m.Details
+		d.Reparable = strings.HasPrefix(m.ReferenceID, "ALQ-")
+		difs = append(difs, d)
+	}
+	return difs, len(movs), nil
+}
+
+// reparar corrige una diferencia.
+func (s *ConciliacionService) reparar(ctx context.Context, d diferencia, arcoID, userID uint) error {
+	switch d.Tipo {
+	case models.DifOperacionPendiente:
+		_, err := s.ops.aplicar(ctx, d.op)
+		return err
+
+	case models.DifSinMovimiento:
+		return s.crearMovimiento(ctx, d, arcoID, "")
+
+	case models.DifMovimientoEliminado:
+		if d.anulado {
+			return database.DB.Unscoped().Model(&models.Movement{}).Where("movement_id = ?", *d.MovementID).
+				Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil}).Error
+		}
+		if d.CobroID == nil {
+			// El movimiento del período se reemplaza por uno nuevo
+			if _, err := s.periodos.UpdateOne(ctx, bson.M{"_id": *d.PeriodoID},
+				bson.M{"$unset": bson.M{"movement_id": ""}}); err != nil {
+				return err
+			}
+		}
+		// La referencia del cobro ya la usó el movimiento que se borró
+		return s.crearMovimiento(ctx, d, arcoID, "-"+strconv.FormatInt(time.Now().Unix(), 36))
+
+	case models.DifMovimientoHuerfano:
+		return NewMovementService().SoftDeleteMovement(*d.MovementID, userID)
+	}
+	return errors.New("diferencia no reparable")
+}
+
+// crearMovimiento registra y aplica la creación del movimiento de un cobro.
+func (s *ConciliacionService) crearMovimiento(ctx context.Context, d diferencia, arcoID uint, sufijo string) error {
+	if d.prop == nil {
+		return errors.New("la propiedad del cobro no existe")
+	}
+	if arcoID == 0 && d.usuarioID == 0 {
+		// Pago anterior al registro de cobros: la operación nunca podría aplicarse
+		return ErrSinCajaDestino
+	}
+	referencia := "ALQ-P-" + d.PeriodoID.Hex()
+	if d.CobroID != nil {
+		referencia = referenciaCobro(*d.CobroID)
+	}
+	op := &models.OperacionCaja{
+		Tipo:        models.OpCrearMovimiento,
+		PropiedadID: d.prop.ID,
+		PeriodoID:   *d.PeriodoID,
+		CobroID:     d.CobroID,
+		Anio:        d.Anio,
+		Mes:         d.Mes,
+		Referencia:  referencia + sufijo,
+		Monto:       d.MontoCobro,
+		Detalle:     detalleMovimientoAlquiler(d.prop, d.Anio, d.Mes, "conciliación"),
+		Fecha:       d.fecha,
+		UsuarioID:   d.usuarioID,
+		ArcoID:      arcoID,
+	}
+	if err := s.ops.encolar(ctx, op); err != nil {
+		return err
+	}
+	_, err := s.ops.aplicar(ctx, op)
+	return err
+}
@@ -0,0 +1,729 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ContratoService maneja los contratos de alquiler (colección contratos).
+// La propiedad guarda una copia de los datos del contrato vigente
+// (inquilino, monto, actualización) para que el listado no tenga que unirlos.
+type ContratoService struct {
+	coll     *mongo.Collection
+	props    *mongo.Collection
+	periodos *mongo.Collection
+}
+
+func NewContratoService() *ContratoService {
+	return &ContratoService{
+		coll:     database.MongoDB.Collection(database.CollectionContratos),
+		props:    database.MongoDB.Collection(database.CollectionPropiedades),
+		periodos: database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
+	}
+}
+
+// Plazo por defecto de los contratos generados a partir de una propiedad
+const plazoContratoAnios = 2
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CRUD Contratos
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Crear inicia un contrato sobre una propiedad sin contrato vigente. La
+// propiedad pasa a ocupada con los datos del contrato y los períodos desde
+// el inicio quedan asociados a él.
+func (s *ContratoService) Crear(propID string, req models.CrearContratoRequest, createdBy uint) (*models.Contrato, error) {
+	prop, err := NewAlquilerService().getPropiedad(propID)
+	if err != nil {
+		return nil, err
+	}
+	if prop.ContratoVigenteID != nil {
+		return nil, errors.New("la propiedad ya tiene un contrato vigente, finalícelo antes de crear uno nuevo")
+	}
+	if !req.FechaFin.After(req.FechaInicio) {
+		return nil, errors.New("la fecha de fin debe ser posterior a la de inicio")
+	}
+
+	frecuencia := req.FrecuenciaActualizacion
+	if frecuencia > 0 && frecuencia < 3 {
+		frecuencia = 3 // mínimo 3 meses
+	}
+	tipoIndice := req.TipoIndice
+	if tipoIndice == "" {
+		tipoIndice = models.IndiceNinguno
+		if frecuencia >= 3 && !req.PagaEnDolares {
+			tipoIndice = models.IndiceIPC
+		}
+	}
+	var fechaActualizacion *time.Time
+	if frecuencia >= 3 && !req.PagaEnDolares {
+		f := req.FechaInicio.AddDate(0, frecuencia, 0)
+		fechaActualizacion = &f
+	}
+	garantes := req.Garantes
+	if garantes == nil {
+		garantes = []string{}
+	}
+	if req.Punitorio != nil {
+		if err := validarPolitica(req.Punitorio); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &models.Contrato{
+		PropiedadID:             prop.ID,
+		Inquilino:               req.Inquilino,
+		FechaInicio:             req.FechaInicio,
+		FechaFin:                req.FechaFin,
+		MontoInicial:            req.MontoInicial,
+		MontoActual:             req.MontoInicial,
+		TipoIndice:              tipoIndice,
+		FrecuenciaActualizacion: frecuencia,
+		FechaActualizacion:      fechaActualizacion,
+		PagaEnDolares:           req.PagaEnDolares,
+		MontoDolares:            req.MontoDolares,
+		TipoCotizacion:          req.TipoCotizacion,
+		Deposito:                req.Deposito,
+		Garantes:                garantes,
+		Punitorio:               req.Punitorio,
+		ComisionPct:             req.ComisionPct,
+		Notas:                   req.Notas,
+		CreatedBy:               createdBy,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	// Fichas del padrón de inquilino y garantes (por ID o por nombre)
+	if err := NewPersonaService().vincularContrato(ctx, c, req.InquilinoID, req.GarantesIDs); err != nil {
+		return nil, err
+	}
+	if err := s.iniciar(ctx, prop, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Listar devuelve los contratos filtrados por propiedad y/o estado, del más reciente al más antiguo.
+func (s *ContratoService) Listar(propID, estado string) ([]models.Contrato, error) {
+	filter := bson.M{}
+	if propID != "" {
+		objID, err := primitive.ObjectIDFromHex(propID)
+		if err != nil {
+			return nil, errors.New("ID de propiedad inválido")
+		}
+		filter["propiedad_id"] = objID
+	}
+	if estado != "" {
+		filter["estado"] = estado
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "fecha_inicio", Value: -1}})
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	contratos := []models.Contrato{}
+	if err := cursor.All(ctx, &contratos); err != nil {
+		return nil, err
+	}
+	return contratos, nil
+}
+
+// GetDetalle devuelve un contrato con sus períodos y lo pagado / adeudado a la fecha.
+func (s *ContratoService) GetDetalle(id string) (*models.ContratoDetalle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := s.getContrato(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	periodos, err := NewAlquilerService().listarPeriodos(ctx, bson.M{"contrato_id": c.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	detalle := &models.ContratoDetalle{Contrato: *c, Periodos: periodos}
+	indiceActual := indiceMes(time.Now())
+	for _, p := range periodos {
+		detalle.TotalPagado += p.Cobrado()
+		if p.Estado != models.PagadoEstado && p.Indice() < indiceActual {
+			detalle.TotalAdeudado += p.Saldo()
+			detalle.MesesAdeudados++
+		}
+	}
+	detalle.TotalPagado = roundDos(detalle.TotalPagado)
+	detalle.TotalAdeudado = roundDos(detalle.TotalAdeudado)
+	return detalle, nil
+}
+
+// Actualizar modifica los datos de un contrato: plazo, garantías, política de
+// punitorios, comisión, notas y condiciones económicas. En el contrato vigente
+// los datos que la propiedad replica (inquilino, monto, índice, dólares) se
+// copian a ella. Las actualizaciones por índice van por ActualizarMonto.
+func (s *ContratoService) Actualizar(id string, req models.ActualizarContratoRequest, userID uint) (*models.Contrato, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := s.getContrato(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{"updated_at": time.Now()}
+	// Copia en la propiedad (solo si el contrato está vigente)
+	copia := bson.M{}
+	if req.FechaFin != nil {
+		if !req.FechaFin.After(c.FechaInicio) {
+			return nil, errors.New("la fecha de fin debe ser posterior a la de inicio")
+		}
+		updates["fecha_fin"] = *req.FechaFin
+	}
+	if req.TipoIndice != nil {
+		updates["tipo_indice"] = *req.TipoIndice
+		copia["tipo_indice"] = *req.TipoIndice
+	}
+	if req.MontoActual != nil {
+		updates["monto_actual"] = *req.MontoActual
+		copia["alquiler_mensual"] = *req.MontoActual
+	}
+	if req.FrecuenciaActualizacion != nil {
+		f := *req.FrecuenciaActualizacion
+		if f > 0 && f < 3 {
+			f = 3 // mínimo 3 meses
+		}
+		updates["frecuencia_actualizacion"] = f
+		copia["frecuencia_actualizacion"] = f
+	}
+	if req.FechaActualizacion != nil {
+		updates["fecha_actualizacion"] = *req.FechaActualizacion
+		copia["fecha_actualizacion"] = *req.FechaActualizacion
+	}
+	if req.PagaEnDolares != nil {
+		updates["paga_en_dolares"] = *req.PagaEnDolares
+		copia["paga_en_dolares"] = *req.PagaEnDolares
+	}
+	if req.MontoDolares != nil {
+		updates["monto_dolares"] = *req.MontoDolares
+		copia["monto_dolares"] = *req.MontoDolares
+	}
+	if req.TipoCotizacion != nil {
+		updates["tipo_cotizacion"] = *req.TipoCotizacion
+		copia["tipo_cotizacion"] = *req.TipoCotizacion
+	}
+	if req.Deposito != nil {
+		updates["deposito"] = *req.Deposito
+	}
+	if req.Notas != nil {
+		updates["notas"] = *req.Notas
+	}
+	if req.ComisionPct != nil {
+		updates["comision_pct"] = *req.ComisionPct
+	}
+	if req.InquilinoID != nil || req.GarantesIDs != nil || req.Garantes != nil {
+		inquilinoID := ""
+		if req.InquilinoID != nil {
+			inquilinoID = *req.InquilinoID
+		}
+		var garantesIDs []string
+		if req.GarantesIDs != nil {
+			garantesIDs = *req.GarantesIDs
+			if req.Garantes == nil {
+				c.Garantes = nil // las fichas reemplazan a los garantes actuales
+			}
+		} else if req.Garantes == nil && len(c.GarantesIDs) > 0 {
+			// Sin cambios en los garantes: se conservan sus fichas
+			for _, g := range c.GarantesIDs {
+				garantesIDs = append(garantesIDs, g.Hex())
+			}
+			c.Garantes = nil
+		}
+		if req.Garantes != nil {
+			c.Garantes = *req.Garantes
+		}
+		if err := NewPersonaService().vincularContrato(ctx, c, inquilinoID, garantesIDs); err != nil {
+			return nil, err
+		}
+		updates["garantes"] = c.Garantes
+		updates["garantes_ids"] = c.GarantesIDs
+		if c.InquilinoID != nil {
+			updates["inquilino_id"] = *c.InquilinoID
+		}
+		if req.InquilinoID != nil {
+			updates["inquilino"] = c.Inquilino
+			copia["inquilino"] = c.Inquilino
+		}
+	}
+	if req.Punitorio != nil {
+		if err := validarPolitica(req.Punitorio); err != nil {
+			return nil, err
+		}
+		updates["punitorio"] = *req.Punitorio
+	}
+	update := bson.M{"$set": updates}
+	if req.QuitarPunitorio {
+		update["$unset"] = bson.M{"punitorio": ""}
+		delete(updates, "punitorio")
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Contrato
+	if err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": c.ID}, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+
+	if c.Estado == models.ContratoVigente && len(copia) > 0 {
+		antes, err := s.copiarAPropiedad(ctx, &updated, copia, models.OperacionEdicion, userID)
+		if err != nil {
+			return nil, err
+		}
+		if antes != nil && updated.MontoActual != antes.AlquilerMensual {
+			if err := NewHistorialPropiedadService().registrarMonto(ctx, &models.MontoAlquiler{
+				PropiedadID:   updated.PropiedadID,
+				Monto:         updated.MontoActual,
+				MontoAnterior: antes.AlquilerMensual,
+				VigenteDesde:  time.Now(),
+				Origen:        models.OrigenMontoEdicion,
+				ContratoID:    &updated.ID,
+				UsuarioID:     userID,
+			}); err != nil {
+				utils.Logger.Warn("No se pudo registrar el alquiler del contrato",
+					zap.String("contrato", updated.ID.Hex()), zap.Error(err))
+			}
+		}
+	}
+	return &updated, nil
+}
+
+// ActualizarMonto aplica una actualización por índice al contrato vigente:
+// cambia su monto, avanza la fecha de actualización al siguiente período y
+// copia ambos a la propiedad (ver AlquilerService.aplicarMonto).
+func (s *ContratoService) ActualizarMonto(id string, req models.ActualizarMontoRequest, userID uint) (*models.Contrato, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := s.getContrato(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Estado != models.ContratoVigente {
+		return nil, errors.New("el contrato no está vigente")
+	}
+	prop, err := NewAlquilerService().getPropiedad(c.PropiedadID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{"monto_actual": req.NuevoMonto, "updated_at": time.Now()}
+	if req.NuevaFechaActualizacion == nil && c.FechaActualizacion != nil && c.FrecuenciaActualizacion >= 3 {
+		nuevaFecha := c.FechaActualizacion.AddDate(0, c.FrecuenciaActualizacion, 0)
+		req.NuevaFechaActualizacion = &nuevaFecha
+	}
+	if req.NuevaFechaActualizacion != nil {
+		updates["fecha_actualizacion"] = *req.NuevaFechaActualizacion
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Contrato
+	if err := s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": c.ID, "estado": models.ContratoVigente},
+		bson.M{"$set": updates},
+		opts,
+	).Decode(&updated); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("el contrato no está vigente")
+		}
+		return nil, err
+	}
+
+	if _, err := NewAlquilerService().aplicarMonto(ctx, prop, &updated.ID, req, userID); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Finalizar cierra un contrato vigente (fin de plazo o rescisión). La propiedad
+// queda desocupada y los meses impagos posteriores a la entrega dejan de
+// pertenecer al contrato; la deuda anterior sigue asociada a él.
+func (s *ContratoService) Finalizar(id string, req models.FinalizarContratoRequest, userID uint) (*models.Contrato, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, err := s.getContrato(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Estado != models.ContratoVigente {
+		return nil, errors.New("el contrato no está vigente")
+	}
+
+	fecha := time.Now()
+	if req.Fecha != nil {
+		fecha = *req.Fecha
+	}
+	if fecha.Before(c.FechaInicio) {
+		return nil, errors.New("la fecha de finalización es anterior al inicio del contrato")
+	}
+
+	if err := s.finalizar(ctx, c, req.Estado, fecha, req.Motivo, userID); err != nil {
+		return nil, err
+	}
+	return s.getContrato(ctx, id)
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Sincronización con la propiedad
+// ─────────────────────────────────────────────────────────────────────────────
+
+// sincronizarConPropiedad mantiene el contrato vigente alineado con la tenencia
+// de la propiedad luego de crearla o editarla desde el formulario de propiedades:
+//   - ocupada sin contrato vigente: se genera un contrato con los datos cargados
+//   - desocupada con contrato vigente: el contrato se da por finalizado hoy
+//   - otro inquilino: el contrato vigente se finaliza hoy y el nuevo empieza el
+//     mes siguiente (el mes en curso sigue siendo del inquilino anterior)
+//
+// Las condiciones económicas no se copian: son del contrato (ver Actualizar).
+func (s *ContratoService) sincronizarConPropiedad(ctx context.Context, prop *models.Propiedad, userID uint) error {
+	switch {
+	case prop.Ocupada && prop.ContratoVigenteID == nil:
+		return s.crearDesdePropiedad(ctx, prop, inicioMes(time.Now()))
+
+	case prop.ContratoVigenteID == nil:
+		return nil
+	}
+
+	var c models.Contrato
+	if err := s.coll.FindOne(ctx, bson.M{"_id": *prop.ContratoVigenteID}).Decode(&c); err != nil {
+		return err
+	}
+	if !prop.Ocupada {
+		return s.finalizar(ctx, &c, models.ContratoFinalizado, time.Now(), "Propiedad marcada como desocupada", userID)
+	}
+	if mismoInquilino(prop.Inquilino, c.Inquilino) {
+		return nil
+	}
+	if err := s.finalizar(ctx, &c, models.ContratoFinalizado, time.Now(), "Cambio de inquilino", userID); err != nil {
+		return err
+	}
+	prop.ContratoVigenteID = nil
+	return s.crearDesdePropiedad(ctx, prop, inicioMes(time.Now().AddDate(0, 1, 0)))
+}
+
+// mismoInquilino indica si dos nombres de inquilino son la misma persona
+// (sin distinguir mayúsculas ni espacios de los extremos). Un nombre vacío no
+// cambia el inquilino.
+func mismoInquilino(a, b string) bool {
+	a = strings.TrimSpace(a)
+	return a == "" || strings.EqualFold(a, strings.TrimSpace(b))
+}
+
+// copiarAPropiedad replica en la propiedad los datos del contrato vigente
+// (solo si sigue siéndolo) y registra los cambios en su historial. Devuelve la
+// propiedad anterior, o nil si el contrato ya no es el vigente.
+func (s *ContratoService) copiarAPropiedad(ctx context.Context, c *models.Contrato, set bson.M, operacion string, userID uint) (*models.Propiedad, error) {
+	set["updated_at"] = time.Now()
+	return NewHistorialPropiedadService().actualizarPropiedad(ctx,
+		bson.M{"_id": c.PropiedadID, "contrato_vigente_id": c.ID}, bson.M{"$set": set}, operacion, userID)
+}
+
+// crearDesdePropiedad genera un contrato vigente con los datos de tenencia de la
+// propiedad (altas desde el formulario de propiedades y documentos anteriores a contratos).
+func (s *ContratoService) crearDesdePropiedad(ctx context.Context, prop *models.Propiedad, inicio time.Time) error {
+	tipoIndice := models.IndiceNinguno
+	if prop.FrecuenciaActualizacion >= 3 && !prop.PagaEnDolares {
+		tipoIndice = models.IndiceIPC
+		if prop.TipoIndice != "" {
+			tipoIndice = prop.TipoIndice
+		}
+	}
+	c := &models.Contrato{
+		PropiedadID:             prop.ID,
+		Inquilino:               prop.Inquilino,
+		FechaInicio:             inicio,
+		FechaFin:                inicio.AddDate(plazoContratoAnios, 0, 0),
+		MontoInicial:            prop.AlquilerMensual,
+		MontoActual:             prop.AlquilerMensual,
+		TipoIndice:              tipoIndice,
+		FrecuenciaActualizacion: prop.FrecuenciaActualizacion,
+		FechaActualizacion:      prop.FechaActualizacion,
+		PagaEnDolares:           prop.PagaEnDolares,
+		MontoDolares:            prop.MontoDolares,
+		TipoCotizacion:          prop.TipoCotizacion,
+		Garantes:                []string{},
+		Notas:                   "Generado a partir de los datos de la propiedad",
+		CreatedBy:               prop.CreatedBy,
+	}
+	return s.iniciar(ctx, prop, c)
+}
+
+// iniciar guarda el contrato como vigente, copia sus datos a la propiedad y
+// asocia los períodos desde el inicio.
+func (s *ContratoService) iniciar(ctx context.Context, prop *models.Propiedad, c *models.Contrato) error {
+	if c.InquilinoID == nil {
+		if err := NewPersonaService().vincularContrato(ctx, c, "", nil); err != nil {
+			return err
+		}
+	}
+
+	// Sin comisión propia, el contrato toma la del propietario de la propiedad
+	if c.ComisionPct == 0 && prop.PropietarioID != nil {
+		c.ComisionPct = NewPropietarioService().comisionPorDefecto(ctx, *prop.PropietarioID)
+	}
+
+	now := time.Now()
+	c.ID = primitive.NewObjectID()
+	c.Estado = models.ContratoVigente
+	c.CreatedAt = now
+	c.UpdatedAt = now
+
+	if _, err := s.coll.InsertOne(ctx, c); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("la propiedad ya tiene un contrato vigente")
+		}
+		return err
+	}
+
+	set := bson.M{
+		"ocupada":                  true,
+		"inquilino":                c.Inquilino,
+		"alquiler_mensual":         c.MontoActual,
+		"frecuencia_actualizacion": c.FrecuenciaActualizacion,
+		"paga_en_dolares":          c.PagaEnDolares,
+		"monto_dolares":            c.MontoDolares,
+		"tipo_indice":              c.TipoIndice,
+		"contrato_vigente_id":      c.ID,
+		"posponer_hasta":           nil,
+		"updated_at":               now,
+	}
+	if c.FechaActualizacion != nil {
+		set["fecha_actualizacion"] = *c.FechaActualizacion
+	}
+	if c.TipoCotizacion != "" {
+		set["tipo_cotizacion"] = c.TipoCotizacion
+	}
+	// El libro de períodos empieza en el alta: un contrato retroactivo lo extiende
+	if prop.Anio == 0 || c.FechaInicio.Year() < prop.Anio {
+		prop.Anio = c.FechaInicio.Year()
+		set["anio"] = prop.Anio
+	}
+	if _, err := NewHistorialPropiedadService().actualizarPropiedad(ctx, bson.M{"_id": prop.ID},
+		bson.M{"$set": set}, models.OperacionInicioContrato, c.CreatedBy); err != nil {
+		// Sin la propiedad actualizada el contrato no puede quedar vigente
+		if _, errDel := s.coll.DeleteOne(ctx, bson.M{"_id": c.ID}); errDel != nil {
+			utils.Logger.Warn("No se pudo descartar el contrato sin propiedad actualizada",
+				zap.String("contrato", c.ID.Hex()), zap.Error(errDel))
+		}
+		return err
+	}
+
+	NewOcupacionService().registrarInicioContrato(ctx, c)
+
+	// Un contrato con otro monto lo deja en la historia del alquiler desde su inicio
+	if c.MontoActual != prop.AlquilerMensual {
+		if err := NewHistorialPropiedadService().registrarMonto(ctx, &models.MontoAlquiler{
+			PropiedadID:   prop.ID,
+			Monto:         c.MontoActual,
+			MontoAnterior: prop.AlquilerMensual,
+			VigenteDesde:  c.FechaInicio,
+			Origen:        models.OrigenMontoContrato,
+			ContratoID:    &c.ID,
+			UsuarioID:     c.CreatedBy,
+		}); err != nil {
+			utils.Logger.Warn("No se pudo registrar el alquiler del contrato",
+				zap.String("contrato", c.ID.Hex()), zap.Error(err))
+		}
+	}
+
+	prop.ContratoVigenteID = &c.ID
+	prop.AlquilerMensual = c.MontoActual
+	alquileres := NewAlquilerService()
+	for anio := c.FechaInicio.Year(); anio <= now.Year(); anio++ {
+		if err := alquileres.asegurarPeriodos(ctx, prop, anio); err != nil {
+			return err
+		}
+	}
+	return s.vincularPeriodos(ctx, c)
+}
+
+// finalizar marca el contrato como terminado y libera la propiedad.
+func (s *ContratoService) finalizar(ctx context.Context, c *models.Contrato, estado models.EstadoContrato, fecha time.Time, motivo string, userID uint) error {
+	now := time.Now()
+	set := bson.M{
+		"estado":             estado,
+		"fecha_finalizacion": fecha,
+		"updated_at":         now,
+	}
+	if motivo != "" {
+		set["motivo_finalizacion"] = motivo
+	}
+	if _, err := s.coll.UpdateOne(ctx, bson.M{"_id": c.ID}, bson.M{"$set": set}); err != nil {
+		return err
+	}
+
+	antes, err := NewHistorialPropiedadService().actualizarPropiedad(ctx,
+		bson.M{"_id": c.PropiedadID, "contrato_vigente_id": c.ID},
+		bson.M{
+			"$set":   bson.M{"ocupada": false, "inquilino": "", "updated_at": now},
+			"$unset": bson.M{"contrato_vigente_id": "", "posponer_hasta": ""},
+		},
+		models.OperacionFinContrato, userID,
+	)
+	if err != nil {
+		s.revertirFinalizacion(ctx, c)
+		return err
+	}
+	if antes != nil {
+		NewOcupacionService().registrarFinContrato(ctx, c, estado, fecha, motivo)
+	}
+
+	// Los meses impagos posteriores a la entrega ya no son deuda del inquilino
+	_, err = s.periodos.UpdateMany(ctx,
+		bson.M{
+			"contrato_id": c.ID,
+			"estado":      bson.M{"$ne": string(models.PagadoEstado)},
+			"$expr":       bson.M{"$gt": bson.A{exprIndicePeriodo, indiceMes(fecha)}},
+		},
+		bson.M{
+			"$set":   bson.M{"estado": string(models.PendienteEstado), "updated_at": now},
+			"$unset": bson.M{"contrato_id": ""},
+		},
+	)
+	return err
+}
+
+// revertirFinalizacion devuelve el contrato al estado que tenía antes de
+// finalizar, cuando no se pudo liberar la propiedad.
+func (s *ContratoService) revertirFinalizacion(ctx context.Context, c *models.Contrato) {
+	set := bson.M{"estado": c.Estado, "updated_at": c.UpdatedAt}
+	unset := bson.M{}
+	if c.FechaFinalizacion != nil {
+		set["fecha_finalizacion"] = *c.FechaFinalizacion
+	} else {
+		unset["fecha_finalizacion"] = ""
+	}
+	if c.MotivoFinalizacion != "" {
+		set["motivo_finalizacion"] = c.MotivoFinalizacion
+	} else {
+		unset["motivo_finalizacion"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if _, err := s.coll.UpdateOne(ctx, bson.M{"_id": c.ID}, update); err != nil {
+		utils.Logger.Warn("No se pudo revertir la finalización del contrato",
+			zap.String("contrato", c.ID.Hex()), zap.Error(err))
+	}
+}
+
+// vincularPeriodos asocia al contrato los períodos sin contrato de su vigencia.
+// Un contrato vigente no tiene límite superior (el inquilino puede seguir
+// después del vencimiento hasta que se finalice).
+func (s *ContratoService) vincularPeriodos(ctx context.Context, c *models.Contrato) error {
+	rango := bson.A{bson.M{"$gte": bson.A{exprIndicePeriodo, indiceMes(c.FechaInicio)}}}
+	if c.Estado != models.ContratoVigente && c.FechaFinalizacion != nil {
+		rango = append(rango, bson.M{"$lte": bson.A{exprIndicePeriodo, indiceMes(*c.FechaFinalizacion)}})
+	}
+
+	_, err := s.periodos.UpdateMany(ctx,
+		bson.M{
+			"propiedad_id": c.PropiedadID,
+			"contrato_id":  bson.M{"$exists": false},
+			"$expr":        bson.M{"$and": rango},
+		},
+		bson.M{"$set": bson.M{"contrato_id": c.ID}},
+	)
+	return err
+}
+
+// vincularVigente asocia los períodos nuevos al contrato vigente de la propiedad.
+func (s *ContratoService) vincularVigente(ctx context.Context, contratoID primitive.ObjectID) error {
+	var c models.Contrato
+	if err := s.coll.FindOne(ctx, bson.M{"_id": contratoID}).Decode(&c); err != nil {
+		return err
+	}
+	return s.vincularPeriodos(ctx, &c)
+}
+
+// MigrarContratosLegacy genera un contrato vigente para cada propiedad ocupada
+// cargada antes de existir los contratos. El inicio es el 1° de enero del año
+// de alta para que abarque todo el libro de períodos existente.
+func (s *ContratoService) MigrarContratosLegacy() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := s.props.Find(ctx, bson.M{"ocupada": true, "contrato_vigente_id": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	var props []models.Propiedad
+	if err := cursor.All(ctx, &props); err != nil {
+		return 0, err
+	}
+
+	migradas := 0
+	for i := range props {
+		p := &props[i]
+		inicio := inicioMes(p.CreatedAt)
+		if p.Anio > 0 {
+			inicio = time.Date(p.Anio, time.January, 1, 0, 0, 0, 0, time.Local)
+		}
+		if err := s.crearDesdePropiedad(ctx, p, inicio); err != nil {
+			return migradas, err
+		}
+		migradas++
+	}
+
+	if migradas > 0 {
+		utils.Logger.Info("Contratos generados para propiedades existentes", zap.Int("propiedades", migradas))
+	}
+	return migradas, nil
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+func (s *ContratoService) getContrato(ctx context.Context, id string) (*models.Contrato, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	var c models.Contrato
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&c); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("contrato no encontrado")
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// exprIndicePeriodo es anio*12 + mes en una expresión de agregación ($expr).
+var exprIndicePeriodo = bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{"$anio", 12}}, "$mes"}}
+
+// indiceMes devuelve la posición absoluta del mes de t (igual que PeriodoAlquiler.Indice).
+func indiceMes(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// inicioMes devuelve el primer día del mes de t a las 00:00 (hora local).
+func inicioMes(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,470 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ─── Proveedores ────────────────────────────────────────────────────────────
+
+// ProveedorCotizacion descarga la serie diaria de un tipo de dólar.
+type ProveedorCotizacion interface {
+	Fuente() string
+	Descargar(tipo string, desde time.Time) ([]models.Cotizacion, error)
+}
+
+// proveedorCotizaciones es la fuente automática de cotizaciones
+var proveedorCotizaciones ProveedorCotizacion = proveedorArgentinaDatos{}
+
+// nombresCotizacion son los tipos de dólar admitidos
+var nombresCotizacion = map[string]string{
+	models.DolarOficial: "Dólar oficial (BNA)",
+	models.DolarMEP:     "Dólar MEP (bolsa)",
+	models.DolarBlue:    "Dólar blue",
+}
+
+const argentinaDatosURL = "https://api.argentinadatos.com/v1/cotizaciones/dolares/%s"
+
+// proveedorArgentinaDatos usa la API pública de ArgentinaDatos, que devuelve
+// la serie histórica completa de cada casa.
+type proveedorArgentinaDatos struct{}
+
+func (proveedorArgentinaDatos) Fuente() string { return "ArgentinaDatos" }
+
+func (proveedorArgentinaDatos) Descargar(tipo string, desde time.Time) ([]models.Cotizacion, error) {
+	casa := tipo
+	if tipo == models.DolarMEP {
+		casa = "bolsa"
+	}
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Get(fmt.Sprintf(argentinaDatosURL, casa))
+	if err != nil {
+		return nil, fmt.Errorf("error de conexión con ArgentinaDatos: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ArgentinaDatos respondió con status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo respuesta: %w", err)
+	}
+
+	var raw []struct {
+		Fecha  string  `json:"fecha"`
+		Compra float64 `json:"compra"`
+		Venta  float64 `json:"venta"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("error parseando JSON: %w", err)
+	}
+	res := make([]models.Cotizacion, 0, len(raw))
+	for _, r := range raw {
+		fecha, err := time.Parse("2006-01-02", r.Fecha)
+		if err != nil || fecha.Before(desde) || r.Venta <= 0 {
+			continue
+		}
+		res = append(res, models.Cotizacion{Tipo: tipo, Fecha: fecha, Compra: r.Compra, Venta: r.Venta})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Fecha.Before(res[j].Fecha) })
+	return res, nil
+}
+
+// ─── Servicio ───────────────────────────────────────────────────────────────
+
+// CotizacionService guarda la cotización diaria del dólar (tabla cotizacions)
+// y calcula en pesos los períodos de las propiedades que alquilan en dólares.
+type CotizacionService struct {
+	props    *mongo.Collection
+	periodos *mongo.Collection
+}
+
+func NewCotizacionService() *CotizacionService {
+	return &CotizacionService{
+		props:    database.MongoDB.Collection(database.CollectionPropiedades),
+		periodos: database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
+	}
+}
+
+// inicioSeriesCotizacion es desde dónde se guardan las cotizaciones la primera vez
+var inicioSeriesCotizacion = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func validarTipoCotizacion(tipo string) error {
+	if _, ok := nombresCotizacion[tipo]; !ok {
+		return fmt.Errorf("tipo de cotización desconocido: %s (oficial, mep o blue)", tipo)
+	}
+	return nil
+}
+
+// tipoCotizacionDe es el dólar con que se pasa a pesos el alquiler de la propiedad.
+func tipoCotizacionDe(prop *models.Propiedad) string {
+	if prop.TipoCotizacion != "" {
+		return prop.TipoCotizacion
+	}
+	return models.DolarOficial
+}
+
+// Start sincroniza las cotizaciones y recalcula los montos en pesos al iniciar
+// y todos los días a las 19:00 (después del cierre cambiario).
+func (s *CotizacionService) Start() {
+	go s.sincronizarYRecalcular()
+	if _, err := GetScheduler().Programar("cotizaciones-dolar", "0 19 * * *", s.sincronizarYRecalcular); err != nil {
+		utils.Logger.Error("Error programando la actualización de cotizaciones", zap.Error(err))
+	}
+}
+
+func (s *CotizacionService) sincronizarYRecalcular() {
+	if errores := s.SincronizarTodas(); len(errores) > 0 {
+		utils.Logger.Warn("Sincronización de cotizaciones incompleta", zap.Int("con_error", len(errores)))
+	}
+	res, err := s.ActualizarMontosDolares()
+	if err != nil {
+		utils.Logger.Error("Error recalculando alquileres en dólares", zap.Error(err))
+		return
+	}
+	utils.Logger.Info("Alquileres en dólares recalculados",
+		zap.Int("propiedades", res.Propiedades), zap.Int("periodos", res.Periodos), zap.Int("errores", len(res.Errores)))
+}
+
+// Sincronizar actualiza la copia local del tipo de dólar con la fuente
+// automática. Las cotizaciones cargadas a mano no se pisan.
+func (s *CotizacionService) Sincronizar(tipo string) (int, error) {
+	if err := validarTipoCotizacion(tipo); err != nil {
+		return 0, err
+	}
+
+	// Se vuelve a pedir un margen antes del último dato por si hubo correcciones
+	desde := inicioSeriesCotizacion
+	var ultima models.Cotizacion
+	if err := database.DB.Where("tipo = ? AND manual = ?", tipo, false).
+		Order("fecha DESC").First(&ultima).Error; err == nil {
+		desde = ultima.Fecha.AddDate(0, 0, -10)
+	}
+
+	datos, err := proveedorCotizaciones.Descargar(tipo, desde)
+	if err != nil {
+		return 0, err
+	}
+
+	var manuales []time.Time
+	if err := database.DB.Model(&models.Cotizacion{}).
+		Where("tipo = ? AND manual = ? AND fecha >= ?", tipo, true, desde).
+		Pluck("fecha", &manuales).Error; err != nil {
+		return 0, err
+	}
+	esManual := map[string]bool{}
+	for _, f := range manuales {
+		esManual[f.Format("2006-01-02")] = true
+	}
+
+	now := time.Now()
+	registros := make([]models.Cotizacion, 0, len(datos))
+	for _, d := range datos {
+		if esManual[d.Fecha.Format("2006-01-02")] {
+			continue
+		}
+		d.Fuente = proveedorCotizaciones.Fuente()
+		d.ActualizadoEn = now
+		registros = append(registros, d)
+	}
+	if len(registros) == 0 {
+		return 0, nil
+	}
+
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tipo"}, {Name: "fecha"}},
+		DoUpdates: clause.AssignmentColumns([]string{"compra", "venta", "fuente", "actualizado_en"}),
+	}).CreateInBatches(registros, 500).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(registros), nil
+}
+
+// SincronizarTodas actualiza los tres tipos de dólar y devuelve el error de
+// cada uno que falló.
+func (s *CotizacionService) SincronizarTodas() map[string]error {
+	errores := map[string]error{}
+	for tipo := range nombresCotizacion {
+		n, err := s.Sincronizar(tipo)
+		if err != nil {
+			errores[tipo] = err
+			utils.Logger.Warn("No se pudo sincronizar la cotización",
+				zap.String("tipo", tipo), zap.Error(err))
+			continue
+		}
+		utils.Logger.Info("Cotización sincronizada", zap.String("tipo", tipo), zap.Int("valores", n))
+	}
+	return errores
+}
+
+// ─── Consulta ───────────────────────────────────────────────────────────────
+
+// Vigente devuelve la última cotización publicada hasta la fecha indicada
+// (fines de semana y feriados toman la del último día hábil).
+func (s *CotizacionService) Vigente(tipo string, fecha time.Time) (*models.Cotizacion, error) {
+	if err := validarTipoCotizacion(tipo); err != nil {
+		return nil, err
+	}
+	dia := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, time.UTC)
+	var c models.Cotizacion
+	err := database.DB.Where("tipo = ? AND fecha <= ?", tipo, dia).Order("fecha DESC").First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("no hay cotización del dólar %s al %s", tipo, fecha.Format("02/01/2006"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Estado devuelve la última cotización guardada de cada tipo de dólar.
+func (s *CotizacionService) Estado() ([]models.EstadoCotizacion, error) {
+	res := make([]models.EstadoCotizacion, 0, len(nombresCotizacion))
+	for tipo, nombre := range nombresCotizacion {
+		item := models.EstadoCotizacion{Tipo: tipo, Nombre: nombre, Fuente: proveedorCotizaciones.Fuente()}
+
+		var estado struct {
+			Cantidad      int64
+			ActualizadoEn *time.Time
+		}
+		if err := database.DB.Model(&models.Cotizacion{}).Where("tipo = ?", tipo).
+			Select("COUNT(*) AS cantidad, MAX(actualizado_en) AS actualizado_en").
+			Scan(&estado).Error; err != nil {
+			return nil, err
+		}
+		item.Cantidad = estado.Cantidad
+		item.ActualizadoEn = estado.ActualizadoEn
+
+		var ultima models.Cotizacion
+		err := database.DB.Where("tipo = ?", tipo).Order("fecha DESC").First(&ultima).Error
+		if err == nil {
+			item.Ultima = &ultima
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Tipo < res[j].Tipo })
+	return res, nil
+}
+
+// Listar devuelve las cotizaciones guardadas de un tipo entre dos fechas.
+func (s *CotizacionService) Listar(tipo string, desde, hasta time.Time) ([]models.Cotizacion, error) {
+	if err := validarTipoCotizacion(tipo); err != nil {
+		return nil, err
+	}
+	cotizaciones := []models.Cotizacion{}
+	err := database.DB.Where("tipo = ? AND fecha >= ? AND fecha <= ?", tipo, desde, hasta).
+		Order("fecha ASC").Find(&cotizaciones).Error
+	return cotizaciones, err
+}
+
+// ─── Carga manual ───────────────────────────────────────────────────────────
+
+// Cargar carga o corrige la cotización de un día. Queda marcada como manual y
+// las sincronizaciones posteriores no la modifican.
+func (s *CotizacionService) Cargar(tipo string, req models.CargarCotizacionRequest, userID uint) (*models.Cotizacion, error) {
+	if err := validarTipoCotizacion(tipo); err != nil {
+		return nil, err
+	}
+	fecha, err := parseFechaValor(req.Fecha)
+	if err != nil {
+		return nil, err
+	}
+	if req.Venta <= 0 {
+		return nil, errors.New("la cotización de venta debe ser mayor a cero")
+	}
+	c := models.Cotizacion{
+		Tipo:          tipo,
+		Fecha:         fecha,
+		Compra:        req.Compra,
+		Venta:         req.Venta,
+		Fuente:        fuenteManual,
+		ActualizadoEn: time.Now(),
+		Manual:        true,
+		CargadoPor:    &userID,
+	}
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tipo"}, {Name: "fecha"}},
+		DoUpdates: clause.AssignmentColumns([]string{"compra", "venta", "fuente", "actualizado_en", "manual", "cargado_por"}),
+	}).Create(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Eliminar borra la cotización de un día. La próxima sincronización vuelve a
+// traer el dato publicado.
+func (s *CotizacionService) Eliminar(tipo, fecha string) error {
+	if err := validarTipoCotizacion(tipo); err != nil {
+		return err
+	}
+	f, err := parseFechaValor(fecha)
+	if err != nil {
+		return err
+	}
+	res := database.DB.Where("tipo = ? AND fecha = ?", tipo, f).Delete(&models.Cotizacion{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("no hay una cotización cargada para esa fecha")
+	}
+	return nil
+}
+
+// ─── Alquileres en dólares ──────────────────────────────────────────────────
+
+// ActualizarMontosDolares pasa a pesos los períodos impagos y sin cobros de
+// las propiedades en dólares, hasta el mes en curso: MontoDolares por la
+// cotización (venta) vigente al vencimiento (vencimientoPago: sin los días de
+// gracia, generales ni de la propiedad), o la de hoy si todavía no venció.
+// También actualiza AlquilerMensual con la cotización del día.
+func (s *CotizacionService) ActualizarMontosDolares() (*models.ResultadoMontosDolares, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	res := &models.ResultadoMontosDolares{Errores: []string{}}
+	reglas, err := NewMoraService().reglas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.props.Find(ctx, bson.M{
+		"ocupada":         true,
+		"paga_en_dolares": true,
+		"monto_dolares":   bson.M{"$gt": 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var enDolares []models.Propiedad
+	if err := cursor.All(ctx, &enDolares); err != nil {
+		return nil, err
+	}
+	if len(enDolares) == 0 {
+		return res, nil
+	}
+	props := make(map[primitive.ObjectID]*models.Propiedad, len(enDolares))
+	ids := make([]primitive.ObjectID, len(enDolares))
+	for i := range enDolares {
+		props[enDolares[i].ID] = &enDolares[i]
+		ids[i] = enDolares[i].ID
+	}
+
+	// Cotizaciones ya consultadas en la corrida, por tipo y día
+	cache := map[string]*models.Cotizacion{}
+	vigente := func(tipo string, fecha time.Time) (*models.Cotizacion, error) {
+		clave := tipo + fecha.Format("2006-01-02")
+		if c, ok := cache[clave]; ok {
+			return c, nil
+		}
+		c, err := s.Vigente(tipo, fecha)
+		if err != nil {
+			return nil, err
+		}
+		cache[clave] = c
+		return c, nil
+	}
+
+	now := time.Now()
+	hoy := inicioDia(now)
+	periodos, err := NewAlquilerService().listarPeriodos(ctx, bson.M{
+		"propiedad_id": bson.M{"$in": ids},
+		"estado":       bson.M{"$ne": string(models.PagadoEstado)},
+		"cobros.0":     bson.M{"$exists": false},
+		"$expr":        bson.M{"$lte": bson.A{exprIndicePeriodo, indiceMes(now)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range periodos {
+		prop := props[p.PropiedadID]
+		tipo := tipoCotizacionDe(prop)
+		fecha := vencimientoPago(reglas, *prop, p.Anio, p.Mes)
+		if fecha.After(hoy) {
+			fecha = hoy
+		}
+		c, err := vigente(tipo, fecha)
+		if err != nil {
+			res.Errores = append(res.Errores, prop.Direccion+": "+err.Error())
+			continue
+		}
+		monto := roundDos(prop.MontoDolares * c.Venta)
+		if p.Monto == monto && p.Cotizacion == c.Venta && p.MontoDolares == prop.MontoDolares {
+			continue
+		}
+		// Un cobro registrado durante la corrida congela el monto del período
+		r, err := s.periodos.UpdateOne(ctx,
+			bson.M{"_id": p.ID, "cobros.0": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{
+				"monto":            monto,
+				"monto_dolares":    prop.MontoDolares,
+				"cotizacion":       c.Venta,
+				"tipo_cotizacion":  tipo,
+				"fecha_cotizacion": c.Fecha,
+				"updated_at":       now,
+			}})
+		if err != nil {
+			return nil, err
+		}
+		if r.ModifiedCount > 0 {
+			res.Periodos++
+		}
+	}
+
+	// Monto en pesos del día en la propiedad; el contrato conserva el pactado
+	// (MontoDolares), no se reescribe con cada cotización. Es una estimación
+	// diaria, no un cambio del alquiler: no pasa por el historial de la
+	// propiedad ni por la historia del alquiler.
+	for _, prop := range props {
+		c, err := vigente(tipoCotizacionDe(prop), hoy)
+		if err != nil {
+			res.Errores = append(res.Errores, prop.Direccion+": "+err.Error())
+			continue
+		}
+		monto := roundDos(prop.MontoDolares * c.Venta)
+		if prop.AlquilerMensual == monto {
+			continue
+		}
+		if _, err := s.props.UpdateOne(ctx, bson.M{"_id": prop.ID},
+			bson.M{"$set": bson.M{"alquiler_mensual": monto, "updated_at": now}}); err != nil {
+			return nil, err
+		}
+		prop.AlquilerMensual = monto
+		res.Propiedades++
+	}
+	return res, nil
+}
+
+// montoEnPesos pasa a pesos un cobro en dólares con la cotización indicada o,
+// si es cero, con la vigente del día. Devuelve el monto y la cotización usada.
+func (s *CotizacionService) montoEnPesos(tipo string, dolares, cotizacion float64, fecha time.Time) (float64, float64, error) {
+	if cotizacion <= 0 {
+		c, err := s.Vigente(tipo, fecha)
+		if err != nil {
+			return 0, 0, err
+		}
+		cotizacion = c.Venta
+	}
+	return roundDos(dolares * cotizacion), cotizacion, nil
+}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	maxBytesDocumento = 20 << 20 // 20 MB por documento
+	// diasAlertaDocumentos es la anticipación con que el tablero avisa los vencimientos
+	diasAlertaDocumentos = 30
+)
+
+// Tipos de contenido aceptados para los documentos (se detectan por el
+// contenido, no por la extensión)
+var tiposDocumento = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+}
+
+// DocumentoService maneja los documentos adjuntos de propiedades y contratos
+// (colección documentos; el contenido se guarda en el almacén de archivos).
+type DocumentoService struct {
+	coll      *mongo.Collection
+	props     *mongo.Collection
+	contratos *mongo.Collection
+	almacen   AlmacenArchivos
+}
+
+func NewDocumentoService() *DocumentoService {
+	return &DocumentoService{
+		coll:      database.MongoDB.Collection(database.CollectionDocumentos),
+		props:     database.MongoDB.Collection(database.CollectionPropiedades),
+		contratos: database.MongoDB.Collection(database.CollectionContratos),
+		almacen:   GetAlmacen(),
+	}
+}
+
+// parseVencimiento interpreta la fecha de vencimiento ("" = no vence).
+func parseVencimiento(s string) (*time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	t, err := parseFechaValor(s)
+	if err != nil {
+		return nil, err
+	}
+	v := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	return &v, nil
+}
+
+// Subir guarda el archivo en el almacén y registra el documento de la propiedad.
+func (s *DocumentoService) Subir(propID string, req models.SubirDocumentoRequest, nombre string, datos []byte, userID uint) (*models.Documento, error) {
+	if len(datos) == 0 {
+		return nil, errors.New("el archivo está vacío")
+	}
+	if len(datos) > maxBytesDocumento {
+		return nil, fmt.Errorf("el archivo supera el máximo de %d MB", maxBytesDocumento>>20)
+	}
+	contentType := http.DetectContentType(datos)
+	if !tiposDocumento[contentType] {
+		return nil, errors.New("solo se aceptan documentos PDF o imágenes (JPEG, PNG, GIF o WebP)")
+	}
+	vencimiento, err := parseVencimiento(req.Vencimiento)
+	if err != nil {
+		return nil, err
+	}
+
+	prop, err := NewAlquilerService().getPropiedad(propID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var contratoID *primitive.ObjectID
+	if req.ContratoID != "" {
+		c, err := NewContratoService().getContrato(ctx, req.ContratoID)
+		if err != nil {
+			return nil, err
+		}
+		if c.PropiedadID != prop.ID {
+			return nil, errors.New("el contrato no pertenece a la propiedad")
+		}
+		contratoID = &c.ID
+	}
+
+	now := time.Now()
+	doc := &models.Documento{
+		ID:          primitive.NewObjectID(),
+		PropiedadID: prop.ID,
+		ContratoID:  contratoID,
+		Tipo:        req.Tipo,
+		Descripcion: strings.TrimSpace(req.Descripcion),
+		Vencimiento: vencimiento,
+		Nombre:      nombre,
+		ContentType: contentType,
+		Tamanio:     int64(len(datos)),
+		CreatedBy:   userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	doc.ArchivoID = "doc-" + doc.ID.Hex()
+	if doc.Nombre == "" {
+		doc.Nombre = doc.ArchivoID
+	}
+
+	if err := s.almacen.Guardar(doc.ArchivoID, doc.Nombre, contentType, datos); err != nil {
+		return nil, err
+	}
+	if _, err := s.coll.InsertOne(ctx, doc); err != nil {
+		s.eliminarArchivo(doc.ArchivoID)
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ListarPorPropiedad devuelve los documentos de la propiedad (incluidos los de
+// sus contratos), los más recientes primero.
+func (s *DocumentoService) ListarPorPropiedad(propID string) ([]models.Documento, error) {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	return s.listar(bson.M{"propiedad_id": objID})
+}
+
+// ListarPorContrato devuelve los documentos de un contrato.
+func (s *DocumentoService) ListarPorContrato(contratoID string) ([]models.Documento, error) {
+	objID, err := primitive.ObjectIDFromHex(contratoID)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	return s.listar(bson.M{"contrato_id": objID})
+}
+
+func (s *DocumentoService) listar(filter bson.M) ([]models.Documento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	docs := []models.Documento{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// Actualizar corrige el tipo, la descripción o el vencimiento de un documento.
+func (s *DocumentoService) Actualizar(id string, req models.ActualizarDocumentoRequest) (*models.Documento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc, err := s.getDocumento(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	unset := bson.M{}
+	if req.Tipo != nil {
+		set["tipo"] = *req.Tipo
+	}
+	if req.Descripcion != nil {
+		set["descripcion"] = strings.TrimSpace(*req.Descripcion)
+	}
+	if req.Vencimiento != nil {
+		v, err := parseVencimiento(*req.Vencimiento)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			unset["vencimiento"] = ""
+		} else {
+			set["vencimiento"] = *v
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var actualizado models.Documento
+	err = s.coll.FindOneAndUpdate(ctx, bson.M{"_id": doc.ID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&actualizado)
+	if err != nil {
+		return nil, err
+	}
+	return &actualizado, nil
+}
+
+// Eliminar borra el documento y su archivo.
+func (s *DocumentoService) Eliminar(id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc models.Documento
+	err = s.coll.FindOneAndDelete(ctx, bson.M{"_id": objID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return errors.New("documento no encontrado")
+	}
+	if err != nil {
+		return err
+	}
+	s.eliminarArchivo(doc.ArchivoID)
+	return nil
+}
+
+// eliminarPorPropiedad borra los documentos de una propiedad eliminada.
+func (s *DocumentoService) eliminarPorPropiedad(ctx context.Context, propID primitive.ObjectID) error {
+	cursor, err := s.coll.Find(ctx, bson.M{"propiedad_id": propID},
+		options.Find().SetProjection(bson.M{"archivo_id": 1}))
+	if err != nil {
+		return err
+	}
+	var docs []models.Documento
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+	if _, err := s.coll.DeleteMany(ctx, bson.M{"propiedad_id": propID}); err != nil {
+		return err
+	}
+	for _, d := range docs {
+		s.eliminarArchivo(d.ArchivoID)
+	}
+	return nil
+}
+
+// eliminarArchivo borra el contenido del almacén. Los errores solo se
+// registran: el documento ya no lo referencia.
+func (s *DocumentoService) eliminarArchivo(archivoID string) {
+	if err := s.almacen.Eliminar(archivoID); err != nil && !errors.Is(err, ErrArchivoNoEncontrado) {
+		utils.Logger.Warn("No se pudo eliminar el archivo del documento", zap.String("archivo", archivoID), zap.Error(err))
+	}
+}
+
+// Abrir devuelve el documento y el contenido de su archivo.
+func (s *DocumentoService) Abrir(id string) (*models.Documento, io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	doc, err := s.getDocumento(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, _, err := s.almacen.Abrir(doc.ArchivoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, r, nil
+}
+
+// Vencimientos devuelve los documentos vencidos o que vencen en los próximos
+// `dias` días, los más urgentes primero.
+func (s *DocumentoService) Vencimientos(dias int) ([]models.VencimientoDocumento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.vencimientos(ctx, dias)
+}
+
+// vencimientos considera solo el último vencimiento de cada tipo de documento
+// por propiedad y contrato: una póliza renovada deja de alertar por la anterior.
+// Los documentos de contratos que ya no están vigentes no alertan.
+func (s *DocumentoService) vencimientos(ctx context.Context, dias int) ([]models.VencimientoDocumento, error) {
+	cursor, err := s.coll.Find(ctx, bson.M{"vencimiento": bson.M{"$ne": nil}},
+		options.Find().SetSort(bson.D{{Key: "vencimiento", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []models.Documento
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	vigentes, err := s.contratosVigentes(ctx, docs)
+	if err != nil {
+		return nil, err
+	}
+
+	hoy := inicioDia(time.Now())
+	limite := hoy.AddDate(0, 0, dias)
+	vistos := map[string]bool{}
+	res := []models.VencimientoDocumento{}
+	propIDs := []primitive.ObjectID{}
+	for _, d := range docs {
+		if d.ContratoID != nil && !vigentes[*d.ContratoID] {
+			continue
+		}
+		clave := d.PropiedadID.Hex() + "/" + d.Tipo
+		if d.ContratoID != nil {
+			clave += "/" + d.ContratoID.Hex()
+		}
+		// Ordenados por vencimiento descendente: el primero de cada clave es el vigente
+		if vistos[clave] {
+			continue
+		}
+		vistos[clave] = true
+		if d.Vencimiento.After(limite) {
+			continue
+		}
+		res = append(res, models.VencimientoDocumento{
+			Documento:     d,
+			DiasRestantes: diasEntre(hoy, inicioDia(*d.Vencimiento)),
+		})
+		propIDs = append(propIDs, d.PropiedadID)
+	}
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	// Dirección de cada propiedad para mostrar la alerta
+	pcur, err := s.props.Find(ctx, bson.M{"_id": bson.M{"$in": propIDs}},
+		options.Find().SetProjection(bson.M{"direccion": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var props []models.Propiedad
+	if err := pcur.All(ctx, &props); err != nil {
+		return nil, err
+	}
+	direcciones := make(map[primitive.ObjectID]string, len(props))
+	for _, p := range props {
+		direcciones[p.ID] = p.Direccion
+	}
+	for i := range res {
+		res[i].Direccion = direcciones[res[i].PropiedadID]
+	}
+
+	// Más urgentes primero
+	sort.SliceStable(res, func(i, j int) bool { return res[i].DiasRestantes < res[j].DiasRestantes })
+	return res, nil
+}
+
+// contratosVigentes indica cuáles de los contratos de los documentos siguen vigentes.
+func (s *DocumentoService) contratosVigentes(ctx context.Context, docs []models.Documento) (map[primitive.ObjectID]bool, error) {
+	ids := []primitive.ObjectID{}
+	for _, d := range docs {
+		if d.ContratoID != nil {
+			ids = append(ids, *d.ContratoID)
+		}
+	}
+	vigentes := map[primitive.ObjectID]bool{}
+	if len(ids) == 0 {
+		return vigentes, nil
+	}
+	valores, err := s.contratos.Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": ids}, "estado": models.ContratoVigente})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range valores {
+		if id, ok := v.(primitive.ObjectID); ok {
+			vigentes[id] = true
+		}
+	}
+	return vigentes, nil
+}
+
+func (s *DocumentoService) getDocumento(ctx context.Context, id string) (*models.Documento, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	var d models.Documento
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("documento no encontrado")
+		}
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,573 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EdificioService maneja los edificios o complejos (colección edificios), sus
+// unidades (propiedades con edificio_id) y los gastos comunes que se
+// prorratean entre ellas (colección gastos_edificio).
+type EdificioService struct {
+	coll   *mongo.Collection
+	props  *mongo.Collection
+	gastos *mongo.Collection
+	partes *mongo.Collection
+}
+
+func NewEdificioService() *EdificioService {
+	return &EdificioService{
+		coll:   database.MongoDB.Collection(database.CollectionEdificios),
+		props:  database.MongoDB.Collection(database.CollectionPropiedades),
+		gastos: database.MongoDB.Collection(database.CollectionGastosEdificio),
+		partes: database.MongoDB.Collection(database.CollectionGastosPropiedad),
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CRUD Edificios
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Listar devuelve los edificios por nombre con la cantidad de unidades.
+func (s *EdificioService) Listar() ([]models.Edificio, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "nombre", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	edificios := []models.Edificio{}
+	if err := cursor.All(ctx, &edificios); err != nil {
+		return nil, err
+	}
+
+	cursor, err = s.props.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"edificio_id": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$edificio_id", "unidades": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var conteos []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Unidades int                `bson:"unidades"`
+	}
+	if err := cursor.All(ctx, &conteos); err != nil {
+		return nil, err
+	}
+	unidades := map[primitive.ObjectID]int{}
+	for _, c := range conteos {
+		unidades[c.ID] = c.Unidades
+	}
+	for i := range edificios {
+		edificios[i].CantidadUnidades = unidades[edificios[i].ID]
+	}
+	return edificios, nil
+}
+
+// GetDetalle devuelve el edificio con sus unidades.
+func (s *EdificioService) GetDetalle(id string) (*models.EdificioDetalle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e, err := s.getEdificio(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	props, err := s.unidades(ctx, e.ID, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	e.CantidadUnidades = len(props)
+	return &models.EdificioDetalle{Edificio: *e, Propiedades: props}, nil
+}
+
+// Crear da de alta un edificio.
+func (s *EdificioService) Crear(req models.EdificioRequest, createdBy uint) (*models.Edificio, error) {
+	now := time.Now()
+	e := models.Edificio{
+		ID:        primitive.NewObjectID(),
+		Nombre:    strings.TrimSpace(req.Nombre),
+		Direccion: strings.TrimSpace(req.Direccion),
+		Notas:     req.Notas,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.coll.InsertOne(ctx, e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Actualizar modifica los datos de un edificio.
+func (s *EdificioService) Actualizar(id string, req models.EdificioRequest) (*models.Edificio, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	e, err := s.getEdificio(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	e.Nombre = strings.TrimSpace(req.Nombre)
+	e.Direccion = strings.TrimSpace(req.Direccion)
+	e.Notas = req.Notas
+	e.UpdatedAt = time.Now()
+
+	if _, err := s.coll.ReplaceOne(ctx, bson.M{"_id": e.ID}, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Eliminar borra un edificio sin gastos comunes cargados. Sus unidades pasan a
+// ser propiedades independientes.
+func (s *EdificioService) Eliminar(id string, userID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	e, err := s.getEdificio(ctx, id)
+	if err != nil {
+		return err
+	}
+	n, err := s.gastos.CountDocuments(ctx, bson.M{"edificio_id": e.ID})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("el edificio tiene gastos comunes cargados; elimínelos primero")
+	}
+	unidades, err := s.props.Distinct(ctx, "_id", bson.M{"edificio_id": e.ID})
+	if err != nil {
+		return err
+	}
+	historial := NewHistorialPropiedadService()
+	for _, propID := range unidades {
+		if _, err := historial.actualizarPropiedad(ctx, bson.M{"_id": propID, "edificio_id": e.ID}, bson.M{
+			"$unset": bson.M{"edificio_id": "", "unidad": "", "coeficiente": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		}, models.OperacionEliminarEdificio, userID); err != nil {
+			return err
+		}
+	}
+	_, err = s.coll.DeleteOne(ctx, bson.M{"_id": e.ID})
+	return err
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Resumen
+// ─────────────────────────────────────────────────────────────────────────────
+
+// GetResumen calcula la ocupación y los ingresos del año del edificio, con el
+// resultado de cada unidad (ver AlquilerService.GetResumen).
+func (s *EdificioService) GetResumen(id string, anio int) (*models.ResumenEdificio, error) {
+	if anio == 0 {
+		anio = time.Now().Year()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e, err := s.getEdificio(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	props, err := s.unidades(ctx, e.ID, bson.M{"anio": bson.M{"$lte": anio}})
+	if err != nil {
+		return nil, err
+	}
+	if err := NewAlquilerService().cargarPagos(ctx, props, anio); err != nil {
+		return nil, err
+	}
+
+	desde := time.Date(anio, time.January, 1, 0, 0, 0, 0, time.Local)
+	hasta := desde.AddDate(1, 0, 0)
+	gastos, err := NewGastoService().totalesPorPropiedad(ctx, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+
+	// Años cerrados: la ocupación sale del historial (al cierre del año y la
+	// tasa por días ocupados), no de la foto actual de cada propiedad
+	var tramos []models.TramoOcupacion
+	var alCierre map[primitive.ObjectID]models.TramoOcupacion
+	historico := anio < time.Now().Year() && len(props) > 0
+	if historico {
+		ids := make([]primitive.ObjectID, 0, len(props))
+		for _, p := range props {
+			ids = append(ids, p.ID)
+		}
+		if tramos, err = NewOcupacionService().tramosDePropiedades(ctx, ids, desde, hasta); err != nil {
+			return nil, err
+		}
+		alCierre = tramosAl(tramos, hasta.Add(-time.Nanosecond))
+	}
+
+	e.CantidadUnidades = len(props)
+	resumen := &models.ResumenEdificio{
+		Edificio:      *e,
+		Anio:          anio,
+		TotalUnidades: len(props),
+		Unidades:      []models.UnidadEdificio{},
+	}
+	for _, p := range props {
+		u := models.UnidadEdificio{
+			RentabilidadPropiedad: models.RentabilidadPropiedad{PropiedadID: p.ID, Direccion: p.Direccion},
+			Unidad:                p.Unidad,
+			Coeficiente:           p.Coeficiente,
+			Ocupada:               p.Ocupada,
+			Inquilino:             p.Inquilino,
+			AlquilerMensual:       p.AlquilerMensual,
+		}
+		if historico {
+			t, ok := alCierre[p.ID]
+			u.Ocupada = ok && t.Ocupada
+			u.Inquilino = ""
+			if u.Ocupada {
+				u.Inquilino = t.Inquilino
+			}
+			if ok {
+				u.AlquilerMensual = t.AlquilerMensual
+			}
+		}
+		if u.Ocupada {
+			resumen.UnidadesOcupadas++
+			resumen.IngresoAnualProyectado += u.AlquilerMensual * 12
+		}
+		for _, pago := range p.Pagos {
+			u.Ingresos += pago.Cobrado
+			if pago.Estado != models.PagadoEstado {
+				u.Deuda += pago.Saldo
+			}
+		}
+
+		u.Deuda = roundDos(u.Deuda)
+		calcularRentabilidad(&u.RentabilidadPropiedad, gastos[p.ID])
+		resumen.IngresosCobrados += u.Ingresos
+		resumen.GastosTotales += u.Gastos
+		resumen.DeudaTotal += u.Deuda
+		resumen.Unidades = append(resumen.Unidades, u)
+	}
+
+	comunes, err := s.listarGastos(ctx, bson.M{"edificio_id": e.ID, "fecha": bson.M{"$gte": desde, "$lt": hasta}})
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range comunes {
+		resumen.GastosComunes += g.Monto
+	}
+
+	resumen.IngresoAnualProyectado = roundDos(resumen.IngresoAnualProyectado)
+	resumen.IngresosCobrados = roundDos(resumen.IngresosCobrados)
+	resumen.GastosTotales = roundDos(resumen.GastosTotales)
+	resumen.DeudaTotal = roundDos(resumen.DeudaTotal)
+	resumen.GastosComunes = roundDos(resumen.GastosComunes)
+	resumen.Resultado = roundDos(resumen.IngresosCobrados - resumen.GastosTotales)
+	if historico {
+		resumen.TasaOcupacion = metricasOcupacion(tramos, desde, hasta).TasaOcupacion
+	} else if resumen.TotalUnidades > 0 {
+		resumen.TasaOcupacion = float64(resumen.UnidadesOcupadas) / float64(resumen.TotalUnidades) * 100
+	}
+	return resumen, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Gastos comunes
+// ─────────────────────────────────────────────────────────────────────────────
+
+// CrearGasto registra un gasto común y lo prorratea entre las unidades según
+// su coeficiente. Cada parte queda como gasto de la unidad (a cargo de quien
+// corresponda) y el pago se registra o vincula una sola vez, por el total.
+func (s *EdificioService) CrearGasto(id string, req models.CrearGastoRequest, userID uint) (*models.GastoEdificio, error) {
+	if req.RegistrarEnCaja && req.MovementID != nil {
+		return nil, errors.New("indique registrar en caja o un movimiento existente, no ambos")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	e, err := s.getEdificio(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	props, err := s.unidades(ctx, e.ID, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	if len(props) == 0 {
+		return nil, errors.New("el edificio no tiene unidades asignadas")
+	}
+
+	now := time.Now()
+	fecha := now
+	if req.Fecha != nil {
+		fecha = *req.Fecha
+	}
+	g := &models.GastoEdificio{
+		ID:          primitive.NewObjectID(),
+		EdificioID:  e.ID,
+		Fecha:       fecha,
+		Categoria:   req.Categoria,
+		Descripcion: req.Descripcion,
+		Monto:       roundDos(req.Monto),
+		ACargoDe:    req.ACargoDe,
+		CreatedBy:   userID,
+		CreatedAt:   now,
+	}
+
+	montos := prorratear(g.Monto, props)
+	partes := []interface{}{}
+	for i, p := range props {
+		if montos[i] == 0 {
+			continue
+		}
+		aCargo := req.ACargoDe
+		if aCargo == "" {
+			aCargo = models.GastoACargoAdministracion
+			if p.PropietarioID != nil {
+				aCargo = models.GastoACargoPropietario
+			}
+		}
+		if aCargo == models.GastoACargoPropietario && p.PropietarioID == nil {
+			return nil, errors.New("la unidad " + nombreUnidad(p) + " no tiene propietario asignado")
+		}
+		parte := models.GastoPropiedad{
+			ID:              primitive.NewObjectID(),
+			PropiedadID:     p.ID,
+			PropietarioID:   p.PropietarioID,
+			Fecha:           fecha,
+			Categoria:       g.Categoria,
+			Descripcion:     descripcionParte(e, g.Descripcion),
+			Monto:           montos[i],
+			ACargoDe:        aCargo,
+			CreatedBy:       userID,
+			CreatedAt:       now,
+			GastoEdificioID: &g.ID,
+		}
+		partes = append(partes, parte)
+		g.Prorrateo = append(g.Prorrateo, models.ParteGastoEdificio{
+			PropiedadID: p.ID,
+			Direccion:   p.Direccion,
+			Unidad:      p.Unidad,
+			Coeficiente: p.Coeficiente,
+			Monto:       parte.Monto,
+			GastoID:     parte.ID,
+		})
+	}
+
+	switch {
+	case req.MovementID != nil:
+		if err := NewGastoService().validarEgreso(ctx, *req.MovementID, primitive.NilObjectID); err != nil {
+			return nil, err
+		}
+		g.MovementID = req.MovementID
+		g.MovementVinculado = true
+	case req.RegistrarEnCaja:
+		conceptID := getOrCreateConcepto(conceptoGastosPropiedades, "Egreso", userID)
+		if conceptID == 0 {
+			return nil, errors.New("no se pudo obtener el concepto de gastos de propiedades")
+		}
+		details := "Gasto común " + g.Categoria + " - " + e.Nombre
+		if g.Descripcion != "" {
+			details += " - " + g.Descripcion
+		}
+		movID, err := crearMovimientoCaja("Egreso", conceptID, g.Monto, details, userID)
+		if err != nil {
+			return nil, errors.New("no se pudo registrar el egreso en caja: " + err.Error())
+		}
+		g.MovementID = movID
+	}
+
+	if _, err := s.gastos.InsertOne(ctx, g); err != nil {
+		s.anularEgreso(g, userID)
+		return nil, err
+	}
+	if _, err := s.partes.InsertMany(ctx, partes); err != nil {
+		s.gastos.DeleteOne(ctx, bson.M{"_id": g.ID})
+		s.partes.DeleteMany(ctx, bson.M{"gasto_edificio_id": g.ID})
+		s.anularEgreso(g, userID)
+		return nil, err
+	}
+	return g, nil
+}
+
+// ListarGastos devuelve los gastos comunes del edificio, del más reciente al
+// más antiguo.
+func (s *EdificioService) ListarGastos(id string) ([]models.GastoEdificio, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.listarGastos(ctx, bson.M{"edificio_id": objID})
+}
+
+// EliminarGasto borra un gasto común y sus partes, siempre que ninguna se haya
+// liquidado, y anula el Egreso de caja que generó.
+func (s *EdificioService) EliminarGasto(id string, userID uint) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var g models.GastoEdificio
+	if err := s.gastos.FindOne(ctx, bson.M{"_id": objID}).Decode(&g); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errors.New("gasto no encontrado")
+		}
+		return err
+	}
+	n, err := s.partes.CountDocuments(ctx, bson.M{"gasto_edificio_id": g.ID, "liquidacion_id": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("una parte del gasto ya se descontó en una liquidación; anule la liquidación primero")
+	}
+
+	if _, err := s.partes.DeleteMany(ctx, bson.M{"gasto_edificio_id": g.ID}); err != nil {
+		return err
+	}
+	s.anularEgreso(&g, userID)
+	_, err = s.gastos.DeleteOne(ctx, bson.M{"_id": g.ID})
+	return err
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+// prorratear reparte el monto entre las unidades según su coeficiente (en
+// partes iguales si ninguna lo tiene). El redondeo se ajusta en la última
+// unidad que recibe parte, así la suma coincide con el total.
+func prorratear(monto float64, props []models.Propiedad) []float64 {
+	pesos := make([]float64, len(props))
+	var total float64
+	for i, p := range props {
+		pesos[i] = p.Coeficiente
+		total += p.Coeficiente
+	}
+	if total == 0 {
+		for i := range pesos {
+			pesos[i] = 1
+		}
+		total = float64(len(pesos))
+	}
+
+	montos := make([]float64, len(props))
+	ultima := -1
+	var asignado float64
+	for i, peso := range pesos {
+		if peso == 0 {
+			continue
+		}
+		montos[i] = roundDos(monto * peso / total)
+		asignado += montos[i]
+		ultima = i
+	}
+	if ultima >= 0 {
+		montos[ultima] = roundDos(montos[ultima] + monto - asignado)
+	}
+	return montos
+}
+
+// resolverID valida el edificio indicado al cargar una propiedad
+// ("" = propiedad independiente).
+func (s *EdificioService) resolverID(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	e, err := s.getEdificio(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &e.ID, nil
+}
+
+// unidades devuelve las propiedades del edificio ordenadas por unidad.
+func (s *EdificioService) unidades(ctx context.Context, edificioID primitive.ObjectID, filtro bson.M) ([]models.Propiedad, error) {
+	filtro["edificio_id"] = edificioID
+	cursor, err := s.props.Find(ctx, filtro,
+		options.Find().SetSort(bson.D{{Key: "unidad", Value: 1}, {Key: "direccion", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	props := []models.Propiedad{}
+	if err := cursor.All(ctx, &props); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+func (s *EdificioService) listarGastos(ctx context.Context, filtro bson.M) ([]models.GastoEdificio, error) {
+	cursor, err := s.gastos.Find(ctx, filtro, options.Find().SetSort(bson.D{{Key: "fecha", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	gastos := []models.GastoEdificio{}
+	if err := cursor.All(ctx, &gastos); err != nil {
+		return nil, err
+	}
+	return gastos, nil
+}
+
+// anularEgreso anula el Egreso de caja que generó el gasto común (los Egresos
+// solo vinculados se conservan).
+func (s *EdificioService) anularEgreso(g *models.GastoEdificio, userID uint) {
+	if g.MovementID == nil || g.MovementVinculado {
+		return
+	}
+	if err := NewMovementService().SoftDeleteMovement(*g.MovementID, userID); err != nil {
+		log.Printf("[ALQUILER] Advertencia: no se pudo eliminar movimiento %d de MySQL: %v", *g.MovementID, err)
+	}
+}
+
+func (s *EdificioService) getEdificio(ctx context.Context, id string) (*models.Edificio, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de edificio inválido")
+	}
+	var e models.Edificio
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&e); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("edificio no encontrado")
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+// nombreUnidad identifica la unidad en los mensajes ("3° B" o su dirección).
+func nombreUnidad(p models.Propiedad) string {
+	if p.Unidad != "" {
+		return p.Unidad
+	}
+	return p.Direccion
+}
+
+// descripcionParte es la descripción del gasto en cada unidad.
+func descripcionParte(e *models.Edificio, descripcion string) string {
+	if descripcion == "" {
+		return "Gasto común " + e.Nombre
+	}
+	return "Gasto común " + e.Nombre + " - " + descripcion
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+)
+
+func TestProrratear(t *testing.T) {
+	unidades := func(coefs ...float64) []models.Propiedad {
+		props := make([]models.Propiedad, len(coefs))
+		for i, c := range coefs {
+			props[i].Coeficiente = c
+		}
+		return props
+	}
+
+	casos := []struct {
+		nombre string
+		monto  float64
+		props  []models.Propiedad
+		want   []float64
+	}{
+		{"según el coeficiente", 1000, unidades(50, 30, 20), []float64{500, 300, 200}},
+		{"sin coeficientes, en partes iguales", 900, unidades(0, 0, 0), []float64{300, 300, 300}},
+		{"el redondeo va a la última unidad", 100, unidades(0, 0, 0), []float64{33.33, 33.33, 33.34}},
+		{"las unidades sin coeficiente no pagan", 1000, unidades(60, 0, 40), []float64{600, 0, 400}},
+		{"el ajuste va a la última que paga", 100, unidades(1, 1, 1, 0), []float64{33.33, 33.33, 33.34, 0}},
+		{"coeficientes que no suman 100", 1500, unidades(1, 2), []float64{500, 1000}},
+		{"una sola unidad", 1234.56, unidades(0), []float64{1234.56}},
+		{"sin unidades", 1000, unidades(), []float64{}},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			got := prorratear(c.monto, c.props)
+			if len(got) != len(c.want) {
+				t.Fatalf("prorratear() = %v, want %v", got, c.want)
+			}
+			var suma float64
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Errorf("prorratear() = %v, want %v", got, c.want)
+					break
+				}
+				suma += got[i]
+			}
+			if len(got) > 0 && roundDos(suma) != c.monto {
+				t.Errorf("la suma %v no coincide con el total %v", roundDos(suma), c.monto)
+			}
+		})
+	}
+}
+
+func TestCalcularRentabilidad(t *testing.T) {
+	casos := []struct {
+		nombre        string
+		ingresos      float64
+		gastos        totalesGasto
+		wantResultado float64
+		wantMargen    float64
+	}{
+		{"con ganancia", 100000, totalesGasto{Gastos: 25000}, 75000, 75},
+		{"gastos mayores a lo cobrado", 1000, totalesGasto{Gastos: 1500.25}, -500.25, -50.03},
+		{"pérdida de un centavo", 100, totalesGasto{Gastos: 100.01}, -0.01, -0.01},
+		{"sin cobros no hay margen", 0, totalesGasto{Gastos: 3000}, -3000, 0},
+		{"los gastos del inquilino no restan", 5000, totalesGasto{Gastos: 1000, GastosInquilino: 800}, 4000, 80},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			rent := models.RentabilidadPropiedad{Ingresos: c.ingresos}
+			calcularRentabilidad(&rent, c.gastos)
+			if rent.Resultado != c.wantResultado || rent.Margen != c.wantMargen {
+				t.Errorf("resultado/margen = %v/%v, want %v/%v", rent.Resultado, rent.Margen, c.wantResultado, c.wantMargen)
+			}
+		})
+	}
+}
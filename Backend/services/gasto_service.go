@@ -0,0 +1,330 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrGastoNoEncontrado indica que el gasto pedido no existe (o ya se eliminó).
+var ErrGastoNoEncontrado = errors.New("gasto no encontrado")
+
+// GastoService maneja los costos de las propiedades (colección gastos_propiedad):
+// expensas, impuestos, reparaciones, etc., indicando quién los soporta.
+type GastoService struct {
+	coll  *mongo.Collection
+	props *mongo.Collection
+}
+
+func NewGastoService() *GastoService {
+	return &GastoService{
+		coll:  database.MongoDB.Collection(database.CollectionGastosPropiedad),
+		props: database.MongoDB.Collection(database.CollectionPropiedades),
+	}
+}
+
+// Nombre del concepto de caja para los gastos pagados por cuenta de propietarios
+const conceptoGastosPropiedades = "Gastos de Propiedades"
+
+// Crear registra un gasto de la propiedad. Si está a cargo del propietario se
+// descuenta en su próxima liquidación. El pago puede registrarse como Egreso
+// nuevo en caja o vincularse a un Egreso existente.
+func (s *GastoService) Crear(propID string, req models.CrearGastoRequest, userID uint) (*models.GastoPropiedad, error) {
+	prop, err := NewAlquilerService().getPropiedad(propID)
+	if err != nil {
+		return nil, err
+	}
+	if req.RegistrarEnCaja && req.MovementID != nil {
+		return nil, errors.New("indique registrar en caja o un movimiento existente, no ambos")
+	}
+
+	aCargo := req.ACargoDe
+	if aCargo == "" {
+		aCargo = models.GastoACargoAdministracion
+		if prop.PropietarioID != nil {
+			aCargo = models.GastoACargoPropietario
+		}
+	}
+	if aCargo == models.GastoACargoPropietario && prop.PropietarioID == nil {
+		return nil, errors.New("la propiedad no tiene propietario asignado")
+	}
+
+	now := time.Now()
+	fecha := now
+	if req.Fecha != nil {
+		fecha = *req.Fecha
+	}
+	g := &models.GastoPropiedad{
+		ID:            primitive.NewObjectID(),
+		PropiedadID:   prop.ID,
+		PropietarioID: prop.PropietarioID,
+		Fecha:         fecha,
+		Categoria:     req.Categoria,
+		Descripcion:   req.Descripcion,
+		Monto:         roundDos(req.Monto),
+		ACargoDe:      aCargo,
+		CreatedBy:     userID,
+		CreatedAt:     now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	switch {
+	case req.MovementID != nil:
+		if err := s.validarEgreso(ctx, *req.MovementID, primitive.NilObjectID); err != nil {
+			return nil, err
+		}
+		g.MovementID = req.MovementID
+		g.MovementVinculado = true
+	case req.RegistrarEnCaja:
+		conceptID := getOrCreateConcepto(conceptoGastosPropiedades, "Egreso", userID)
+		if conceptID == 0 {
+			return nil, errors.New("no se pudo obtener el concepto de gastos de propiedades")
+		}
+		details := "Gasto " + g.Categoria + " - " + prop.Direccion
+		if g.Descripcion != "" {
+			details += " - " + g.Descripcion
+		}
+		movID, err := crearMovimientoCaja("Egreso", conceptID, g.Monto, details, userID)
+		if err != nil {
+			return nil, errors.New("no se pudo registrar el egreso en caja: " + err.Error())
+		}
+		g.MovementID = movID
+	}
+
+	if _, err := s.coll.InsertOne(ctx, g); err != nil {
+		// Sin el gasto el Egreso recién creado quedaría huérfano en la caja
+		if g.MovementID != nil && !g.MovementVinculado {
+			if errEliminar := NewMovementService().SoftDeleteMovement(*g.MovementID, userID); errEliminar != nil {
+				log.Printf("[ALQUILER] Advertencia: no se pudo eliminar movimiento %d de MySQL: %v", *g.MovementID, errEliminar)
+			}
+		}
+		return nil, err
+	}
+	return g, nil
+}
+
+// Listar devuelve los gastos de una propiedad, del más reciente al más antiguo.
+func (s *GastoService) Listar(propID string) ([]models.GastoPropiedad, error) {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx, bson.M{"propiedad_id": objID},
+		options.Find().SetSort(bson.D{{Key: "fecha", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	gastos := []models.GastoPropiedad{}
+	if err := cursor.All(ctx, &gastos); err != nil {
+		return nil, err
+	}
+	for i := range gastos {
+		if gastos[i].ACargoDe == "" {
+			gastos[i].ACargoDe = models.GastoACargoPropietario
+		}
+	}
+	return gastos, nil
+}
+
+// Actualizar corrige un gasto que todavía no se liquidó.
+func (s *GastoService) Actualizar(id string, req models.ActualizarGastoRequest) (*models.GastoPropiedad, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	g, err := s.getGasto(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if g.LiquidacionID != nil {
+		return nil, errors.New("el gasto ya se descontó en una liquidación; anule la liquidación primero")
+	}
+	if g.GastoEdificioID != nil {
+		return nil, errors.New("el gasto es parte de un gasto común del edificio; modifíquelo desde el edificio")
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	if req.Categoria != nil {
+		set["categoria"] = *req.Categoria
+	}
+	if req.Descripcion != nil {
+		set["descripcion"] = *req.Descripcion
+	}
+	if req.Monto != nil {
+		if g.MovementID != nil && !g.MovementVinculado {
+			return nil, errors.New("el gasto tiene su egreso en caja; elimínelo y vuelva a cargarlo para cambiar el monto")
+		}
+		set["monto"] = roundDos(*req.Monto)
+	}
+	if req.Fecha != nil {
+		set["fecha"] = *req.Fecha
+	}
+	if req.ACargoDe != nil {
+		if *req.ACargoDe == models.GastoACargoPropietario && g.PropietarioID == nil {
+			return nil, errors.New("la propiedad no tenía propietario asignado al cargar el gasto")
+		}
+		set["a_cargo_de"] = *req.ACargoDe
+	}
+	if req.MovementID != nil {
+		if g.MovementID != nil && !g.MovementVinculado {
+			return nil, errors.New("el gasto ya tiene su egreso en caja")
+		}
+		if *req.MovementID == 0 {
+			unset["movement_id"] = ""
+			unset["movement_vinculado"] = ""
+		} else {
+			if err := s.validarEgreso(ctx, *req.MovementID, g.ID); err != nil {
+				return nil, err
+			}
+			set["movement_id"] = *req.MovementID
+			set["movement_vinculado"] = true
+		}
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(update) == 0 {
+		return g, nil
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.GastoPropiedad
+	if err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": g.ID}, update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Eliminar borra un gasto que todavía no se liquidó y anula el Egreso de caja
+// que generó (los Egresos solo vinculados se conservan).
+func (s *GastoService) Eliminar(id string, userID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	g, err := s.getGasto(ctx, id)
+	if err != nil {
+		return err
+	}
+	if g.LiquidacionID != nil {
+		return errors.New("el gasto ya se descontó en una liquidación; anule la liquidación primero")
+	}
+	if g.GastoEdificioID != nil {
+		return errors.New("el gasto es parte de un gasto común del edificio; elimínelo desde el edificio")
+	}
+
+	if g.MovementID != nil && !g.MovementVinculado {
+		// Si el Egreso no se anula el gasto se conserva para poder reintentar
+		if err := NewMovementService().SoftDeleteMovement(*g.MovementID, userID); err != nil {
+			return errors.New("no se pudo anular el egreso en caja: " + err.Error())
+		}
+	}
+	_, err = s.coll.DeleteOne(ctx, bson.M{"_id": g.ID})
+	return err
+}
+
+// totalesGasto son los gastos de una propiedad en un rango, según quién los soporta
+type totalesGasto struct {
+	Gastos          float64 // propietario o administración
+	GastosInquilino float64
+}
+
+// totalesPorPropiedad suma los gastos con fecha en [desde, hasta) por propiedad.
+func (s *GastoService) totalesPorPropiedad(ctx context.Context, desde, hasta time.Time) (map[primitive.ObjectID]totalesGasto, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"fecha": bson.M{"$gte": desde, "$lt": hasta}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"propiedad_id": "$propiedad_id", "a_cargo_de": "$a_cargo_de"},
+			"total": bson.M{"$sum": "$monto"},
+		}}},
+	}
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var filas []struct {
+		ID struct {
+			PropiedadID primitive.ObjectID `bson:"propiedad_id"`
+			ACargoDe    string             `bson:"a_cargo_de"`
+		} `bson:"_id"`
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &filas); err != nil {
+		return nil, err
+	}
+
+	res := map[primitive.ObjectID]totalesGasto{}
+	for _, f := range filas {
+		t := res[f.ID.PropiedadID]
+		if f.ID.ACargoDe == models.GastoACargoInquilino {
+			t.GastosInquilino += f.Total
+		} else {
+			t.Gastos += f.Total
+		}
+		res[f.ID.PropiedadID] = t
+	}
+	return res, nil
+}
+
+// validarEgreso comprueba que el movimiento exista, sea un Egreso vigente y no
+// esté vinculado a otro gasto (de una propiedad o de un edificio).
+func (s *GastoService) validarEgreso(ctx context.Context, movementID uint, gastoID primitive.ObjectID) error {
+	var mov models.Movement
+	if err := database.DB.Where("movement_id = ?", movementID).First(&mov).Error; err != nil {
+		return errors.New("movimiento de caja no encontrado")
+	}
+	if mov.MovementType != "Egreso" {
+		return errors.New("el movimiento vinculado debe ser un Egreso")
+	}
+	filtro := bson.M{"movement_id": movementID, "_id": bson.M{"$ne": gastoID}}
+	n, err := s.coll.CountDocuments(ctx, filtro)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		// Los gastos comunes de edificio también tienen su Egreso
+		n, err = database.MongoDB.Collection(database.CollectionGastosEdificio).CountDocuments(ctx, filtro)
+		if err != nil {
+			return err
+		}
+	}
+	if n > 0 {
+		return errors.New("el movimiento ya está vinculado a otro gasto")
+	}
+	return nil
+}
+
+func (s *GastoService) getGasto(ctx context.Context, id string) (*models.GastoPropiedad, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	var g models.GastoPropiedad
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&g); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrGastoNoEncontrado
+		}
+		return nil, err
+	}
+	return &g, nil
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// HistorialPropiedadService lleva el registro de cambios de las propiedades
+// (colección historial_propiedades) y la historia de su alquiler mensual con
+// fechas de vigencia (colección historial_montos).
+type HistorialPropiedadService struct {
+	coll     *mongo.Collection
+	montos   *mongo.Collection
+	props    *mongo.Collection
+	periodos *mongo.Collection
+}
+
+func NewHistorialPropiedadService() *HistorialPropiedadService {
+	return &HistorialPropiedadService{
+		coll:     database.MongoDB.Collection(database.CollectionHistorialPropiedades),
+		montos:   database.MongoDB.Collection(database.CollectionHistorialMontos),
+		props:    database.MongoDB.Collection(database.CollectionPropiedades),
+		periodos: database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Registro de cambios
+// ─────────────────────────────────────────────────────────────────────────────
+
+// registrarCambios guarda un cambio por cada campo que difiere entre las dos
+// versiones de la propiedad.
+func (s *HistorialPropiedadService) registrarCambios(ctx context.Context, antes, despues *models.Propiedad, operacion string, userID uint) error {
+	cambios, err := diferenciasPropiedad(antes, despues)
+	if err != nil {
+		return err
+	}
+	return s.registrar(ctx, despues.ID, operacion, userID, cambios...)
+}
+
+// actualizarPropiedad aplica update a la propiedad que cumple el filtro y
+// registra los cambios en su historial. Devuelve la versión anterior, o nil si
+// ninguna propiedad cumple el filtro. Si el historial no se puede registrar
+// solo se advierte: el cambio ya se aplicó.
+func (s *HistorialPropiedadService) actualizarPropiedad(ctx context.Context, filtro, update bson.M, operacion string, userID uint) (*models.Propiedad, error) {
+	var antes models.Propiedad
+	err := s.props.FindOneAndUpdate(ctx, filtro, update).Decode(&antes)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var despues models.Propiedad
+	if err = s.props.FindOne(ctx, bson.M{"_id": antes.ID}).Decode(&despues); err == nil {
+		err = s.registrarCambios(ctx, &antes, &despues, operacion, userID)
+	}
+	if err != nil {
+		utils.Logger.Warn("No se pudo registrar el historial de la propiedad",
+			zap.String("propiedad", antes.ID.Hex()), zap.String("operacion", operacion), zap.Error(err))
+	}
+	return &antes, nil
+}
+
+// registrar agrega entradas al registro de cambios de una propiedad.
+func (s *HistorialPropiedadService) registrar(ctx context.Context, propID primitive.ObjectID, operacion string, userID uint, cambios ...models.CambioPropiedad) error {
+	if len(cambios) == 0 {
+		return nil
+	}
+	now := time.Now()
+	docs := make([]interface{}, len(cambios))
+	for i := range cambios {
+		cambios[i].ID = primitive.NewObjectID()
+		cambios[i].PropiedadID = propID
+		cambios[i].Operacion = operacion
+		cambios[i].UsuarioID = userID
+		cambios[i].Fecha = now
+		docs[i] = cambios[i]
+	}
+	_, err := s.coll.InsertMany(ctx, docs)
+	return err
+}
+
+// Cambios devuelve el registro de cambios de una propiedad, el más reciente
+// primero; campo filtra por un campo puntual (por ejemplo "alquiler_mensual").
+func (s *HistorialPropiedadService) Cambios(propID, campo string) ([]models.CambioPropiedad, error) {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	filter := bson.M{"propiedad_id": objID}
+	if campo != "" {
+		filter["campo"] = campo
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "fecha", Value: -1}, {Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	cambios := []models.CambioPropiedad{}
+	if err := cursor.All(ctx, &cambios); err != nil {
+		return nil, err
+	}
+	return cambios, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Historia del alquiler mensual
+// ─────────────────────────────────────────────────────────────────────────────
+
+// registrarMonto guarda un nuevo valor del alquiler y lo aplica a los períodos
+// sin cobros desde el mes en que rige hasta el próximo cambio registrado. Los
+// períodos con cobros conservan el monto con el que se cobraron.
+func (s *HistorialPropiedadService) registrarMonto(ctx context.Context, m *models.MontoAlquiler) error {
+	m.ID = primitive.NewObjectID()
+	m.VigenteDesde = inicioMes(m.VigenteDesde)
+	m.CreatedAt = time.Now()
+	if _, err := s.montos.InsertOne(ctx, m); err != nil {
+		return err
+	}
+
+	desde := bson.M{"$or": []bson.M{
+		{"anio": bson.M{"$gt": m.VigenteDesde.Year()}},
+		{"anio": m.VigenteDesde.Year(), "mes": bson.M{"$gte": int(m.VigenteDesde.Month()) - 1}},
+	}}
+	rango := []bson.M{desde}
+	var siguiente models.MontoAlquiler
+	err := s.montos.FindOne(ctx,
+		bson.M{"propiedad_id": m.PropiedadID, "vigente_desde": bson.M{"$gt": m.VigenteDesde}},
+		options.FindOne().SetSort(bson.D{{Key: "vigente_desde", Value: 1}}),
+	).Decode(&siguiente)
+	switch {
+	case err == nil:
+		rango = append(rango, bson.M{"$or": []bson.M{
+			{"anio": bson.M{"$lt": siguiente.VigenteDesde.Year()}},
+			{"anio": siguiente.VigenteDesde.Year(), "mes": bson.M{"$lt": int(siguiente.VigenteDesde.Month()) - 1}},
+		}})
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		return err
+	}
+
+	_, err = s.periodos.UpdateMany(ctx, bson.M{
+		"propiedad_id": m.PropiedadID,
+		"$and":         rango,
+		"estado":       bson.M{"$ne": string(models.PagadoEstado)},
+		"cobros.0":     bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"monto": m.Monto, "updated_at": time.Now()}})
+	return err
+}
+
+// montosDe devuelve la historia del alquiler de una propiedad en orden de vigencia.
+func (s *HistorialPropiedadService) montosDe(ctx context.Context, propID primitive.ObjectID) ([]models.MontoAlquiler, error) {
+	cursor, err := s.montos.Find(ctx, bson.M{"propiedad_id": propID},
+		options.Find().SetSort(bson.D{{Key: "vigente_desde", Value: 1}, {Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	montos := []models.MontoAlquiler{}
+	if err := cursor.All(ctx, &montos); err != nil {
+		return nil, err
+	}
+	return montos, nil
+}
+
+// Montos devuelve la historia del alquiler de una propiedad.
+func (s *HistorialPropiedadService) Montos(propID string) ([]models.MontoAlquiler, error) {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return s.montosDe(ctx, objID)
+}
+
+// montoVigente es el alquiler que rige en un mes (0-11) según la historia
+// ordenada por vigencia. Antes del primer cambio registrado vale el monto que
+// ese cambio reemplazó; sin historia, el actual de la propiedad.
+func montoVigente(montos []models.MontoAlquiler, anio, mes int, actual float64) float64 {
+	if len(montos) == 0 {
+		return actual
+	}
+	inicio := time.Date(anio, time.Month(mes+1), 1, 0, 0, 0, 0, time.Local)
+	vigente := -1
+	for i, m := range montos {
+		if m.VigenteDesde.After(inicio) {
+			break
+		}
+		vigente = i
+	}
+	if vigente >= 0 {
+		return montos[vigente].Monto
+	}
+	if montos[0].MontoAnterior > 0 {
+		return montos[0].MontoAnterior
+	}
+	return montos[0].Monto
+}
+
+// eliminarMontosPorPropiedad borra la historia del alquiler de una propiedad
+// eliminada (el registro de cambios se conserva).
+func (s *HistorialPropiedadService) eliminarMontosPorPropiedad(ctx context.Context, propID primitive.ObjectID) error {
+	_, err := s.montos.DeleteMany(ctx, bson.M{"propiedad_id": propID})
+	return err
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Migración
+// ─────────────────────────────────────────────────────────────────────────────
+
+// MigrarMontosLegacy arma la historia del alquiler de las propiedades cargadas
+// antes de existir: el monto actual rige desde el alta y, si la propiedad tiene
+// metadata.monto_anterior de la última actualización, ese monto rige hasta la
+// fecha de la actualización. Luego se quita monto_anterior de la metadata.
+func (s *HistorialPropiedadService) MigrarMontosLegacy() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	conHistoria, err := s.montos.Distinct(ctx, "propiedad_id", bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := s.props.Find(ctx, bson.M{"_id": bson.M{"$nin": conHistoria}})
+	if err != nil {
+		return 0, err
+	}
+	var props []models.Propiedad
+	if err := cursor.All(ctx, &props); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for i, p := range props {
+		alta := inicioMes(p.CreatedAt)
+		if p.Anio > 0 {
+			if inicio := time.Date(p.Anio, time.January, 1, 0, 0, 0, 0, time.Local); inicio.Before(alta) {
+				alta = inicio
+			}
+		}
+		docs := []interface{}{}
+		nuevo := func(monto, anterior float64, desde time.Time, notas string) {
+			docs = append(docs, models.MontoAlquiler{
+				ID:            primitive.NewObjectID(),
+				PropiedadID:   p.ID,
+				Monto:         monto,
+				MontoAnterior: anterior,
+				VigenteDesde:  inicioMes(desde),
+				Origen:        models.OrigenMontoMigracion,
+				Notas:         notas,
+				UsuarioID:     p.CreatedBy,
+				CreatedAt:     now,
+			})
+		}
+
+		anterior, fecha := montoAnteriorLegacy(p.Metadata)
+		if anterior > 0 && !fecha.IsZero() && inicioMes(fecha).After(alta) {
+			nuevo(anterior, 0, alta, "")
+			notas, _ := p.Metadata["ultima_actualizacion_notas"].(string)
+			nuevo(p.AlquilerMensual, anterior, fecha, notas)
+		} else {
+			nuevo(p.AlquilerMensual, 0, alta, "")
+		}
+		if _, err := s.montos.InsertMany(ctx, docs); err != nil {
+			return i, err
+		}
+		if _, ok := p.Metadata["monto_anterior"]; ok {
+			if _, err := s.props.UpdateOne(ctx, bson.M{"_id": p.ID},
+				bson.M{"$unset": bson.M{"metadata.monto_anterior": ""}}); err != nil {
+				return i, err
+			}
+		}
+	}
+	if len(props) > 0 {
+		utils.Logger.Info("Historia de alquileres generada para propiedades existentes", zap.Int("propiedades", len(props)))
+	}
+	return len(props), nil
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+// montoAnteriorLegacy lee el monto previo y la fecha que ActualizarMonto dejaba
+// en la metadata (fecha en formato 02/01/2006).
+func montoAnteriorLegacy(meta map[string]interface{}) (float64, time.Time) {
+	var monto float64
+	switch v := meta["monto_anterior"].(type) {
+	case float64:
+		monto = v
+	case int32:
+		monto = float64(v)
+	case int64:
+		monto = float64(v)
+	case string:
+		monto, _ = strconv.ParseFloat(v, 64)
+	}
+	texto, _ := meta["ultima_actualizacion_fecha"].(string)
+	fecha, err := time.ParseInLocation("02/01/2006", texto, time.Local)
+	if err != nil {
+		return monto, time.Time{}
+	}
+	return monto, fecha
+}
+
+// diferenciasPropiedad compara dos versiones de una propiedad tal como se
+// guardan en la base y devuelve un cambio por campo distinto (los de Metadata,
+// uno por clave). updated_at no se registra.
+func diferenciasPropiedad(antes, despues *models.Propiedad) ([]models.CambioPropiedad, error) {
+	a, err := camposPropiedad(antes)
+	if err != nil {
+		return nil, err
+	}
+	d, err := camposPropiedad(despues)
+	if err != nil {
+		return nil, err
+	}
+
+	claves := make([]string, 0, len(a)+len(d))
+	for k := range a {
+		claves = append(claves, k)
+	}
+	for k := range d {
+		if _, ok := a[k]; !ok {
+			claves = append(claves, k)
+		}
+	}
+	sort.Strings(claves)
+
+	cambios := []models.CambioPropiedad{}
+	for _, k := range claves {
+		if k == "updated_at" || reflect.DeepEqual(a[k], d[k]) {
+			continue
+		}
+		cambios = append(cambios, models.CambioPropiedad{Campo: k, Anterior: a[k], Nuevo: d[k]})
+	}
+	return cambios, nil
+}
+
+// camposPropiedad aplana el documento de la propiedad: cada clave de Metadata
+// queda como "metadata.<clave>".
+func camposPropiedad(p *models.Propiedad) (bson.M, error) {
+	raw, err := bson.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	campos := bson.M{}
+	for k, v := range doc {
+		if meta, ok := v.(bson.M); ok && k == "metadata" {
+			for mk, mv := range meta {
+				campos["metadata."+mk] = mv
+			}
+			continue
+		}
+		campos[k] = v
+	}
+	return campos, nil
+}
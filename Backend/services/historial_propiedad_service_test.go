@@ -0,0 +1,50 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+	"time"
+)
+
+func TestMontoVigente(t *testing.T) {
+	desde := func(anio int, mes time.Month) time.Time {
+		return time.Date(anio, mes, 1, 0, 0, 0, 0, time.Local)
+	}
+	historia := []models.MontoAlquiler{
+		{Monto: 100000, MontoAnterior: 80000, VigenteDesde: desde(2025, time.March)},
+		{Monto: 120000, MontoAnterior: 100000, VigenteDesde: desde(2025, time.September)},
+		{Monto: 150000, MontoAnterior: 120000, VigenteDesde: desde(2026, time.January)},
+	}
+	sinAnterior := []models.MontoAlquiler{{Monto: 90000, VigenteDesde: desde(2025, time.June)}}
+	// Ediciones del mismo mes: rige la última registrada
+	editadas := []models.MontoAlquiler{
+		{Monto: 500000, VigenteDesde: desde(2026, time.April), Origen: models.OrigenMontoEdicion},
+		{Monto: 510000, VigenteDesde: desde(2026, time.April), Origen: models.OrigenMontoEdicion},
+		{Monto: 505000, VigenteDesde: desde(2026, time.April), Origen: models.OrigenMontoEdicion},
+	}
+
+	casos := []struct {
+		nombre    string
+		montos    []models.MontoAlquiler
+		anio, mes int // mes 0-11
+		actual    float64
+		want      float64
+	}{
+		{"sin historia vale el actual", nil, 2025, 5, 70000, 70000},
+		{"antes del primer cambio vale el monto reemplazado", historia, 2025, 1, 150000, 80000},
+		{"antes del primer cambio sin monto anterior", sinAnterior, 2025, 0, 95000, 90000},
+		{"el mes en que empieza a regir", historia, 2025, 2, 150000, 100000},
+		{"entre dos cambios", historia, 2025, 7, 150000, 100000},
+		{"diciembre es del cambio anterior a enero", historia, 2025, 11, 150000, 120000},
+		{"enero del año siguiente", historia, 2026, 0, 150000, 150000},
+		{"después del último cambio", historia, 2027, 5, 99999, 150000},
+		{"varios valores en el mismo mes", editadas, 2026, 3, 0, 505000},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := montoVigente(c.montos, c.anio, c.mes, c.actual); got != c.want {
+				t.Errorf("montoVigente(%d, %d) = %v, want %v", c.anio, c.mes, got, c.want)
+			}
+		})
+	}
+}
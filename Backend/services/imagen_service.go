@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bytes"
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // decodificadores registrados para image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Las imágenes de las propiedades se guardan en el AlmacenArchivos y el
+// documento solo conserva su URL (/api/alquileres/imagenes/<id>). Cada imagen
+// tiene una miniatura JPEG (<id>-min) para los listados.
+const (
+	prefijoURLImagen = "/api/alquileres/imagenes/"
+	sufijoMiniatura  = "-min"
+	maxBytesImagen   = 10 << 20 // 10 MB por imagen
+	maxPixelesImagen = 40e6     // 40 megapíxeles: una imagen chica en bytes puede ocupar GB al decodificarse
+	ladoMiniatura    = 320      // lado mayor de la miniatura, en píxeles
+)
+
+// ImagenService guarda, sirve y elimina las imágenes de las propiedades.
+type ImagenService struct {
+	props   *mongo.Collection
+	almacen AlmacenArchivos
+}
+
+func NewImagenService() *ImagenService {
+	return &ImagenService{
+		props:   database.MongoDB.Collection(database.CollectionPropiedades),
+		almacen: GetAlmacen(),
+	}
+}
+
+// urlImagen es la referencia que se guarda en Propiedad.Imagenes.
+func urlImagen(id string) string {
+	return prefijoURLImagen + id
+}
+
+// idImagen devuelve el ID de una referencia propia (false si es una URL externa
+// o un data-URL).
+func idImagen(ref string) (string, bool) {
+	id, ok := strings.CutPrefix(ref, prefijoURLImagen)
+	if !ok || !idArchivoValido.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
+
+var errImagenInvalida = errors.New("el archivo no es una imagen válida (JPEG, PNG, GIF o WebP)")
+
+// guardar valida la imagen, la guarda con su miniatura y devuelve su URL.
+func (s *ImagenService) guardar(datos []byte, nombre string) (string, error) {
+	if len(datos) > maxBytesImagen {
+		return "", fmt.Errorf("la imagen supera el máximo de %d MB", maxBytesImagen>>20)
+	}
+	// Las dimensiones se leen del encabezado antes de decodificar la imagen entera
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(datos))
+	if err != nil {
+		return "", errImagenInvalida
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxPixelesImagen {
+		return "", fmt.Errorf("la imagen supera el máximo de %d megapíxeles", int64(maxPixelesImagen/1e6))
+	}
+	img, formato, err := image.Decode(bytes.NewReader(datos))
+	if err != nil {
+		return "", errImagenInvalida
+	}
+
+	id := primitive.NewObjectID().Hex()
+	if nombre == "" {
+		nombre = id + "." + formato
+	}
+	if err := s.almacen.Guardar(id, nombre, "image/"+formato, datos); err != nil {
+		return "", err
+	}
+
+	miniatura, err := crearMiniatura(img)
+	if err == nil {
+		err = s.almacen.Guardar(id+sufijoMiniatura, nombre, "image/jpeg", miniatura)
+	}
+	if err != nil {
+		// Sin miniatura se sirve la imagen original
+		utils.Logger.Warn("No se pudo generar la miniatura", zap.String("imagen", id), zap.Error(err))
+	}
+	return urlImagen(id), nil
+}
+
+// crearMiniatura reduce la imagen a ladoMiniatura de lado mayor (las más chicas
+// quedan igual) y la codifica en JPEG.
+func crearMiniatura(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	ancho, alto := b.Dx(), b.Dy()
+	if ancho > ladoMiniatura || alto > ladoMiniatura {
+		if ancho >= alto {
+			alto = max(1, alto*ladoMiniatura/ancho)
+			ancho = ladoMiniatura
+		} else {
+			ancho = max(1, ancho*ladoMiniatura/alto)
+			alto = ladoMiniatura
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, ancho, alto))
+	// Fondo blanco para las imágenes con transparencia
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodificarDataURL extrae los bytes de un data-URL base64 ("data:image/jpeg;base64,...").
+func decodificarDataURL(s string) ([]byte, error) {
+	cabecera, datos, ok := strings.Cut(s, ",")
+	if !ok || !strings.HasPrefix(cabecera, "data:") || !strings.HasSuffix(cabecera, ";base64") {
+		return nil, errors.New("data-URL de imagen inválido")
+	}
+	return base64.StdEncoding.DecodeString(datos)
+}
+
+// resolverImagenes reemplaza los data-URL por imágenes guardadas en el almacén.
+// Las referencias propias y las URL externas se conservan. Si falla, elimina
+// las imágenes que llegó a guardar.
+func (s *ImagenService) resolverImagenes(entradas []string) ([]string, error) {
+	refs := make([]string, 0, len(entradas))
+	var nuevas []string
+	for i, e := range entradas {
+		if !strings.HasPrefix(e, "data:") {
+			refs = append(refs, e)
+			continue
+		}
+		datos, err := decodificarDataURL(e)
+		if err == nil {
+			var ref string
+			ref, err = s.guardar(datos, "")
+			if err == nil {
+				refs = append(refs, ref)
+				nuevas = append(nuevas, ref)
+				continue
+			}
+		}
+		s.eliminar(nuevas)
+		return nil, fmt.Errorf("imagen %d: %w", i+1, err)
+	}
+	return refs, nil
+}
+
+// eliminar borra del almacén las imágenes propias (y sus miniaturas). Los
+// errores solo se registran: la propiedad ya no las referencia.
+func (s *ImagenService) eliminar(refs []string) {
+	for _, ref := range refs {
+		id, ok := idImagen(ref)
+		if !ok {
+			continue
+		}
+		for _, archivo := range []string{id, id + sufijoMiniatura} {
+			if err := s.almacen.Eliminar(archivo); err != nil && !errors.Is(err, ErrArchivoNoEncontrado) {
+				utils.Logger.Warn("No se pudo eliminar la imagen", zap.String("archivo", archivo), zap.Error(err))
+			}
+		}
+	}
+}
+
+// imagenesQuitadas devuelve las referencias de antes que ya no están en despues.
+func imagenesQuitadas(antes, despues []string) []string {
+	siguen := make(map[string]bool, len(despues))
+	for _, r := range despues {
+		siguen[r] = true
+	}
+	var res []string
+	for _, r := range antes {
+		if !siguen[r] {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// Abrir devuelve el contenido de una imagen o de su miniatura. Si la miniatura
+// no existe se devuelve la imagen original.
+func (s *ImagenService) Abrir(id string, miniatura bool) (io.ReadCloser, *ArchivoInfo, error) {
+	if !idArchivoValido.MatchString(id) || strings.HasSuffix(id, sufijoMiniatura) {
+		return nil, nil, ErrArchivoNoEncontrado
+	}
+	if miniatura {
+		r, info, err := s.almacen.Abrir(id + sufijoMiniatura)
+		if !errors.Is(err, ErrArchivoNoEncontrado) {
+			return r, info, err
+		}
+	}
+	return s.almacen.Abrir(id)
+}
+
+// Agregar guarda una imagen subida y la suma a las de la propiedad.
+func (s *ImagenService) Agregar(propID string, datos []byte, nombre string, userID uint) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return "", errors.New("ID inválido")
+	}
+	ref, err := s.guardar(datos, nombre)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := s.props.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$push": bson.M{"imagenes": ref},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("propiedad no encontrada")
+	}
+	if err != nil {
+		s.eliminar([]string{ref})
+		return "", err
+	}
+	s.registrarCambio(ctx, objID, userID, models.CambioPropiedad{Campo: "imagenes", Nuevo: ref})
+	return ref, nil
+}
+
+// Quitar saca una imagen de la propiedad y la elimina del almacén.
+func (s *ImagenService) Quitar(propID, imagenID string, userID uint) error {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ref := urlImagen(imagenID)
+	res, err := s.props.UpdateOne(ctx, bson.M{"_id": objID, "imagenes": ref}, bson.M{
+		"$pull": bson.M{"imagenes": ref},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("la propiedad no tiene esa imagen")
+	}
+	s.eliminar([]string{ref})
+	s.registrarCambio(ctx, objID, userID, models.CambioPropiedad{Campo: "imagenes", Anterior: ref})
+	return nil
+}
+
+// registrarCambio deja en el historial de la propiedad la imagen agregada o quitada.
+func (s *ImagenService) registrarCambio(ctx context.Context, propID primitive.ObjectID, userID uint, cambio models.CambioPropiedad) {
+	if err := NewHistorialPropiedadService().registrar(ctx, propID, models.OperacionImagenes, userID, cambio); err != nil {
+		utils.Logger.Warn("No se pudo registrar el cambio de imágenes en el historial",
+			zap.String("propiedad", propID.Hex()), zap.Error(err))
+	}
+}
+
+// MigrarImagenesBase64 pasa al almacén las imágenes guardadas como data-URL
+// dentro de los documentos de propiedades. Es idempotente: una propiedad ya
+// migrada no tiene data-URLs.
+func (s *ImagenService) MigrarImagenesBase64() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	cursor, err := s.props.Find(ctx,
+		bson.M{"imagenes": bson.M{"$regex": "^data:"}},
+		options.Find().SetProjection(bson.M{"_id": 1, "imagenes": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migradas := 0
+	for cursor.Next(ctx) {
+		var prop models.Propiedad
+		if err := cursor.Decode(&prop); err != nil {
+			return migradas, err
+		}
+		refs, err := s.resolverImagenes(prop.Imagenes)
+		if err != nil {
+			utils.Logger.Warn("No se pudieron migrar las imágenes de la propiedad",
+				zap.String("propiedad_id", prop.ID.Hex()), zap.Error(err))
+			continue
+		}
+		// El filtro por las imágenes originales evita pisar una edición concurrente
+		res, err := s.props.UpdateOne(ctx,
+			bson.M{"_id": prop.ID, "imagenes": prop.Imagenes},
+			bson.M{"$set": bson.M{"imagenes": refs}})
+		if err != nil || res.MatchedCount == 0 {
+			s.eliminar(imagenesQuitadas(refs, prop.Imagenes))
+			if err != nil {
+				return migradas, err
+			}
+			continue
+		}
+		migradas++
+	}
+	return migradas, cursor.Err()
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"strings"
+	"testing"
+)
+
+func TestGuardarImagenRechazaDimensiones(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black}), nil); err != nil {
+		t.Fatal(err)
+	}
+	datos := buf.Bytes()
+	// El encabezado declara 65535x65535 aunque el archivo pese unos bytes
+	copy(datos[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+
+	_, err := (&ImagenService{}).guardar(datos, "")
+	if err == nil || !strings.Contains(err.Error(), "megapíxeles") {
+		t.Errorf("guardar() error = %v, want límite de megapíxeles", err)
+	}
+}
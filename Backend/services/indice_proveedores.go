@@ -0,0 +1,310 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TipoSerie indica cómo se calcula el coeficiente de actualización de un índice.
+type TipoSerie string
+
+const (
+	// SerieVariacionMensual: porcentajes mensuales que se componen (IPC)
+	SerieVariacionMensual TipoSerie = "variacion_mensual"
+	// SerieNivel: valores del índice; el coeficiente es el cociente entre el
+	// valor a la fecha de actualización y el de la fecha base (ICL, CER, UVA, CAC)
+	SerieNivel TipoSerie = "nivel"
+)
+
+// ValorIndice es un dato de una serie: porcentaje mensual o valor del índice.
+// Fuente, Manual y ActualizadoEn vienen de la copia local (ver IndiceValor).
+type ValorIndice struct {
+	Fecha         time.Time
+	Valor         float64
+	Fuente        string
+	Manual        bool
+	ActualizadoEn time.Time
+}
+
+// errSinFuenteAutomatica: el índice no se puede descargar y solo admite carga manual
+var errSinFuenteAutomatica = errors.New("el índice no tiene fuente automática: sus valores se cargan manualmente")
+
+// ProveedorIndice obtiene la serie de un índice de actualización de alquileres.
+type ProveedorIndice interface {
+	Codigo() string
+	Nombre() string
+	Fuente() string
+	Tipo() TipoSerie
+	// Descargar trae los valores publicados entre desde y hasta, ordenados por fecha
+	Descargar(desde, hasta time.Time) ([]ValorIndice, error)
+}
+
+// proveedoresIndice registra los índices admitidos en contratos (salvo "ninguno").
+var proveedoresIndice = map[string]ProveedorIndice{
+	models.IndiceIPC: proveedorIPC{},
+	models.IndiceICL: proveedorBCRA{codigo: models.IndiceICL, nombre: "Índice para Contratos de Locación (ICL)", variable: 40},
+	models.IndiceCER: proveedorBCRA{codigo: models.IndiceCER, nombre: "Coeficiente de Estabilización de Referencia (CER)", variable: 30},
+	models.IndiceUVA: proveedorBCRA{codigo: models.IndiceUVA, nombre: "Unidad de Valor Adquisitivo (UVA)", variable: 31},
+	models.IndiceCAC: proveedorCAC{},
+}
+
+// GetProveedorIndice devuelve el proveedor del índice indicado.
+func GetProveedorIndice(codigo string) (ProveedorIndice, error) {
+	p, ok := proveedoresIndice[codigo]
+	if !ok {
+		return nil, fmt.Errorf("índice %q no admitido", codigo)
+	}
+	return p, nil
+}
+
+// ─── IPC (INDEC vía ArgentinaDatos) ─────────────────────────────────────────
+
+type proveedorIPC struct{}
+
+func (proveedorIPC) Codigo() string  { return models.IndiceIPC }
+func (proveedorIPC) Nombre() string  { return "Índice de Precios al Consumidor (IPC)" }
+func (proveedorIPC) Fuente() string  { return fuenteIPC }
+func (proveedorIPC) Tipo() TipoSerie { return SerieVariacionMensual }
+
+func (proveedorIPC) Descargar(desde, hasta time.Time) ([]ValorIndice, error) {
+	datos, err := NewInflacionService().fetchIPC()
+	if err != nil {
+		return nil, err
+	}
+	var res []ValorIndice
+	for _, d := range datos {
+		fecha, err := parseFechaIPC(d.Fecha)
+		if err != nil {
+			continue
+		}
+		fecha = primerDiaMes(fecha)
+		if fecha.Before(primerDiaMes(desde)) || fecha.After(hasta) {
+			continue
+		}
+		res = append(res, ValorIndice{Fecha: fecha, Valor: d.Valor})
+	}
+	return res, nil
+}
+
+// ─── ICL, CER y UVA (API de estadísticas del BCRA) ──────────────────────────
+
+const (
+	bcraMonetariasURL = "https://api.bcra.gob.ar/estadisticas/v3.0/monetarias/%d?desde=%s&hasta=%s&limit=%d&offset=%d"
+	bcraLimite        = 3000
+)
+
+// proveedorBCRA descarga una variable diaria de la API de estadísticas del BCRA.
+type proveedorBCRA struct {
+	codigo   string
+	nombre   string
+	variable int
+}
+
+func (p proveedorBCRA) Codigo() string { return p.codigo }
+func (p proveedorBCRA) Nombre() string { return p.nombre }
+func (p proveedorBCRA) Fuente() string { return fmt.Sprintf("BCRA (variable %d)", p.variable) }
+func (proveedorBCRA) Tipo() TipoSerie  { return SerieNivel }
+
+func (p proveedorBCRA) Descargar(desde, hasta time.Time) ([]ValorIndice, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	var res []ValorIndice
+
+	// La API devuelve como máximo bcraLimite registros por consulta
+	for offset := 0; ; offset += bcraLimite {
+		url := fmt.Sprintf(bcraMonetariasURL, p.variable, desde.Format("2006-01-02"), hasta.Format("2006-01-02"), bcraLimite, offset)
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("error de conexión con el BCRA: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("el BCRA respondió con status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo respuesta: %w", err)
+		}
+
+		var raw struct {
+			Results []struct {
+				Fecha string  `json:"fecha"`
+				Valor float64 `json:"valor"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("error parseando JSON: %w", err)
+		}
+		for _, r := range raw.Results {
+			fecha, err := time.Parse("2006-01-02", r.Fecha)
+			if err != nil {
+				continue
+			}
+			res = append(res, ValorIndice{Fecha: fecha, Valor: r.Valor})
+		}
+		if len(raw.Results) < bcraLimite {
+			break
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Fecha.Before(res[j].Fecha) })
+	return res, nil
+}
+
+// ─── CAC (Cámara Argentina de la Construcción) ─────────────────────────────
+
+// proveedorCAC: la CAC publica el índice del costo de la construcción en
+// informes mensuales, sin una API pública; los valores se cargan a mano.
+type proveedorCAC struct{}
+
+func (proveedorCAC) Codigo() string  { return models.IndiceCAC }
+func (proveedorCAC) Nombre() string  { return "Índice del Costo de la Construcción (CAC)" }
+func (proveedorCAC) Fuente() string  { return "Cámara Argentina de la Construcción (carga manual)" }
+func (proveedorCAC) Tipo() TipoSerie { return SerieNivel }
+
+func (proveedorCAC) Descargar(desde, hasta time.Time) ([]ValorIndice, error) {
+	return nil, errSinFuenteAutomatica
+}
+
+// ─── Cálculo del coeficiente ────────────────────────────────────────────────
+
+// calcularActualizacion aplica la fórmula que corresponde al tipo de serie:
+//   - variación mensual: producto de (1 + pct/100) de los meses en [desde, hasta)
+//   - nivel: valor vigente en `hasta` / valor vigente en `desde` (último dato
+//     publicado a cada fecha, como indica la Ley 27.551 para el ICL)
+func calcularActualizacion(p ProveedorIndice, serie []ValorIndice, desde, hasta time.Time) *models.DetalleInflacion {
+	detalle := &models.DetalleInflacion{
+		Meses:         []models.MesInflacion{},
+		Fuente:        p.Fuente(),
+		FechaConsulta: time.Now(),
+		Indice:        p.Codigo(),
+		Coeficiente:   1,
+	}
+
+	if p.Tipo() == SerieVariacionMensual {
+		mesDesde, mesHasta := primerDiaMes(desde), primerDiaMes(hasta)
+		acumulado := 1.0
+		for _, v := range serie {
+			mes := primerDiaMes(v.Fecha)
+			if mes.Before(mesDesde) || !mes.Before(mesHasta) {
+				continue
+			}
+			detalle.Meses = append(detalle.Meses, models.MesInflacion{
+				Periodo: formatPeriodo(mes),
+				Pct:     roundDos(v.Valor),
+			})
+			acumulado *= 1.0 + v.Valor/100.0
+			registrarFrescura(detalle, v)
+		}
+		if len(detalle.Meses) == 0 {
+			detalle.Error = "Sin datos para el período solicitado"
+			return detalle
+		}
+		detalle.Coeficiente = roundCoeficiente(acumulado)
+		detalle.AcumuladoPct = roundDos((acumulado - 1.0) * 100.0)
+		return detalle
+	}
+
+	base, okBase := valorVigente(serie, desde)
+	actual, okActual := valorVigente(serie, hasta)
+	if !okBase || !okActual || base.Valor == 0 {
+		detalle.Error = "Sin datos de " + strings.ToUpper(p.Codigo()) + " para las fechas solicitadas"
+		return detalle
+	}
+	coef := actual.Valor / base.Valor
+	detalle.Coeficiente = roundCoeficiente(coef)
+	detalle.AcumuladoPct = roundDos((coef - 1.0) * 100.0)
+	detalle.ValorDesde = base.Valor
+	detalle.ValorHasta = actual.Valor
+	detalle.FechaValorDesde = &base.Fecha
+	detalle.FechaValorHasta = &actual.Fecha
+	registrarFrescura(detalle, base)
+	registrarFrescura(detalle, actual)
+	return detalle
+}
+
+// registrarFrescura agrega al detalle la frescura de un dato usado en el cálculo.
+func registrarFrescura(detalle *models.DetalleInflacion, v ValorIndice) {
+	if v.Manual {
+		detalle.Manual = true
+	}
+	if !v.ActualizadoEn.IsZero() && (detalle.ActualizadoEn == nil || v.ActualizadoEn.After(*detalle.ActualizadoEn)) {
+		t := v.ActualizadoEn
+		detalle.ActualizadoEn = &t
+	}
+}
+
+// valorVigente devuelve el último valor publicado hasta la fecha (serie ordenada).
+func valorVigente(serie []ValorIndice, fecha time.Time) (ValorIndice, bool) {
+	i := sort.Search(len(serie), func(i int) bool { return serie[i].Fecha.After(fecha) })
+	if i == 0 {
+		return ValorIndice{}, false
+	}
+	return serie[i-1], true
+}
+
+func roundCoeficiente(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
+
+// calculadoraIndices lee cada serie de la copia local una sola vez para
+// calcular varias actualizaciones dentro de un mismo rango de fechas.
+type calculadoraIndices struct {
+	desde, hasta time.Time
+	series       map[string][]ValorIndice
+	errores      map[string]error
+}
+
+// newCalculadoraIndices prepara una calculadora para fechas entre desde y hasta.
+// Se lee con margen hacia atrás para encontrar el último valor publicado
+// antes de la fecha base.
+func newCalculadoraIndices(desde, hasta time.Time) *calculadoraIndices {
+	return &calculadoraIndices{
+		desde:   desde.AddDate(0, 0, -45),
+		hasta:   hasta,
+		series:  map[string][]ValorIndice{},
+		errores: map[string]error{},
+	}
+}
+
+func (c *calculadoraIndices) calcular(indice string, desde, hasta time.Time) *models.DetalleInflacion {
+	p, err := GetProveedorIndice(indice)
+	if err != nil {
+		return &models.DetalleInflacion{
+			Meses:         []models.MesInflacion{},
+			FechaConsulta: time.Now(),
+			Indice:        indice,
+			Coeficiente:   1,
+			Error:         err.Error(),
+		}
+	}
+
+	serie, cargada := c.series[indice]
+	if !cargada && c.errores[indice] == nil {
+		serie, err = NewInflacionService().serieLocal(indice, c.desde, c.hasta)
+		if err != nil {
+			log.Printf("[INDICES] Error al leer la serie %s: %v", strings.ToUpper(indice), err)
+			c.errores[indice] = err
+		} else {
+			c.series[indice] = serie
+		}
+	}
+	if err := c.errores[indice]; err != nil {
+		return &models.DetalleInflacion{
+			Meses:         []models.MesInflacion{},
+			Fuente:        p.Fuente() + " (sin datos locales)",
+			FechaConsulta: time.Now(),
+			Indice:        indice,
+			Coeficiente:   1,
+			Error:         err.Error(),
+		}
+	}
+	return calcularActualizacion(p, serie, desde, hasta)
+}
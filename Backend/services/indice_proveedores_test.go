@@ -0,0 +1,113 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+	"time"
+)
+
+func TestCalcularActualizacion(t *testing.T) {
+	fecha := func(anio int, mes time.Month, dia int) time.Time {
+		return time.Date(anio, mes, dia, 0, 0, 0, 0, time.Local)
+	}
+	ipc := []ValorIndice{
+		{Fecha: fecha(2025, time.December, 1), Valor: 1.5},
+		{Fecha: fecha(2026, time.January, 1), Valor: 2},
+		{Fecha: fecha(2026, time.February, 1), Valor: 3},
+		{Fecha: fecha(2026, time.March, 1), Valor: 4, Manual: true},
+		{Fecha: fecha(2026, time.April, 1), Valor: 5},
+	}
+	icl := []ValorIndice{
+		{Fecha: fecha(2026, time.January, 1), Valor: 100},
+		{Fecha: fecha(2026, time.January, 15), Valor: 110},
+		{Fecha: fecha(2026, time.February, 1), Valor: 120},
+	}
+	// Meses con deflación y un índice de nivel que baja
+	deflacion := []ValorIndice{
+		{Fecha: fecha(2026, time.May, 1), Valor: -0.3},
+		{Fecha: fecha(2026, time.June, 1), Valor: -1},
+	}
+	iclEnBaja := []ValorIndice{
+		{Fecha: fecha(2026, time.March, 1), Valor: 120},
+		{Fecha: fecha(2026, time.April, 1), Valor: 110},
+	}
+	proveedorICL := proveedorBCRA{codigo: models.IndiceICL, variable: 40}
+
+	casos := []struct {
+		nombre         string
+		proveedor      ProveedorIndice
+		serie          []ValorIndice
+		desde, hasta   time.Time
+		wantCoef       float64
+		wantAcumulado  float64
+		wantMeses      int
+		wantValorDesde float64
+		wantValorHasta float64
+		wantManual     bool
+		wantError      bool
+	}{
+		{
+			nombre: "IPC compone los meses de [desde, hasta)", proveedor: proveedorIPC{}, serie: ipc,
+			desde: fecha(2026, time.January, 15), hasta: fecha(2026, time.April, 1),
+			wantCoef: 1.0926, wantAcumulado: 9.26, wantMeses: 3, wantManual: true,
+		},
+		{
+			nombre: "IPC de un solo mes", proveedor: proveedorIPC{}, serie: ipc,
+			desde: fecha(2025, time.December, 1), hasta: fecha(2026, time.January, 10),
+			wantCoef: 1.015, wantAcumulado: 1.5, wantMeses: 1,
+		},
+		{
+			nombre: "IPC sin datos en el rango", proveedor: proveedorIPC{}, serie: ipc,
+			desde: fecha(2026, time.June, 1), hasta: fecha(2026, time.September, 1),
+			wantCoef: 1, wantError: true,
+		},
+		{
+			nombre: "IPC con variaciones negativas", proveedor: proveedorIPC{}, serie: deflacion,
+			desde: fecha(2026, time.May, 1), hasta: fecha(2026, time.July, 1),
+			wantCoef: 0.987, wantAcumulado: -1.3, wantMeses: 2,
+		},
+		{
+			nombre: "nivel toma el último valor publicado a cada fecha", proveedor: proveedorICL, serie: icl,
+			desde: fecha(2026, time.January, 10), hasta: fecha(2026, time.February, 5),
+			wantCoef: 1.2, wantAcumulado: 20, wantValorDesde: 100, wantValorHasta: 120,
+		},
+		{
+			nombre: "nivel con la fecha exacta de publicación", proveedor: proveedorICL, serie: icl,
+			desde: fecha(2026, time.January, 15), hasta: fecha(2026, time.February, 1),
+			wantCoef: 1.0909, wantAcumulado: 9.09, wantValorDesde: 110, wantValorHasta: 120,
+		},
+		{
+			nombre: "nivel que baja", proveedor: proveedorICL, serie: iclEnBaja,
+			desde: fecha(2026, time.March, 1), hasta: fecha(2026, time.April, 1),
+			wantCoef: 0.9167, wantAcumulado: -8.33, wantValorDesde: 120, wantValorHasta: 110,
+		},
+		{
+			nombre: "nivel sin valor a la fecha base", proveedor: proveedorICL, serie: icl,
+			desde: fecha(2025, time.December, 20), hasta: fecha(2026, time.February, 1),
+			wantCoef: 1, wantError: true,
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			d := calcularActualizacion(c.proveedor, c.serie, c.desde, c.hasta)
+			if (d.Error != "") != c.wantError {
+				t.Fatalf("Error = %q, want error %v", d.Error, c.wantError)
+			}
+			if d.Coeficiente != c.wantCoef || d.AcumuladoPct != c.wantAcumulado {
+				t.Errorf("coeficiente/acumulado = %v/%v, want %v/%v", d.Coeficiente, d.AcumuladoPct, c.wantCoef, c.wantAcumulado)
+			}
+			if len(d.Meses) != c.wantMeses {
+				t.Errorf("meses = %d, want %d", len(d.Meses), c.wantMeses)
+			}
+			if d.ValorDesde != c.wantValorDesde || d.ValorHasta != c.wantValorHasta {
+				t.Errorf("valores = %v → %v, want %v → %v", d.ValorDesde, d.ValorHasta, c.wantValorDesde, c.wantValorHasta)
+			}
+			if d.Manual != c.wantManual {
+				t.Errorf("manual = %v, want %v", d.Manual, c.wantManual)
+			}
+			if d.Indice != c.proveedor.Codigo() {
+				t.Errorf("indice = %q, want %q", d.Indice, c.proveedor.Codigo())
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// Copia local de las series de índices. El IPC se guarda en ipc_mensual (ver
+// SincronizarIPC); los índices de nivel (ICL, CER, UVA, CAC) en indice_valors.
+// Los cálculos de actualización leen siempre de acá, sin consultar las APIs.
+
+// inicioSeriesIndices es desde dónde se descargan los índices de nivel la primera vez
+var inicioSeriesIndices = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const fuenteManual = "Carga manual"
+
+// SincronizarIndice actualiza la copia local del índice indicado con su fuente
+// automática. Los valores cargados a mano no se pisan.
+func (s *InflacionService) SincronizarIndice(codigo string) (int, error) {
+	if codigo == models.IndiceIPC {
+		return s.SincronizarIPC()
+	}
+	p, err := GetProveedorIndice(codigo)
+	if err != nil {
+		return 0, err
+	}
+
+	// Se vuelve a pedir un margen antes del último dato por si hubo correcciones
+	desde := inicioSeriesIndices
+	var ultimo models.IndiceValor
+	if err := database.DB.Where("indice = ? AND manual = ?", codigo, false).
+		Order("fecha DESC").First(&ultimo).Error; err == nil {
+		desde = ultimo.Fecha.AddDate(0, 0, -45)
+	}
+
+	datos, err := p.Descargar(desde, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	var manuales []time.Time
+	if err := database.DB.Model(&models.IndiceValor{}).
+		Where("indice = ? AND manual = ? AND fecha >= ?", codigo, true, desde).
+		Pluck("fecha", &manuales).Error; err != nil {
+		return 0, err
+	}
+	esManual := map[string]bool{}
+	for _, f := range manuales {
+		esManual[f.Format("2006-01-02")] = true
+	}
+
+	now := time.Now()
+	registros := make([]models.IndiceValor, 0, len(datos))
+	for _, d := range datos {
+		if esManual[d.Fecha.Format("2006-01-02")] {
+			continue
+		}
+		registros = append(registros, models.IndiceValor{
+			Indice:        codigo,
+			Fecha:         d.Fecha,
+			Valor:         d.Valor,
+			Fuente:        p.Fuente(),
+			ActualizadoEn: now,
+		})
+	}
+	if len(registros) == 0 {
+		return 0, nil
+	}
+
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "indice"}, {Name: "fecha"}},
+		DoUpdates: clause.AssignmentColumns([]string{"valor", "fuente", "actualizado_en"}),
+	}).CreateInBatches(registros, 500).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(registros), nil
+}
+
+// SincronizarIndices actualiza todos los índices con fuente automática y
+// devuelve el error de cada uno que falló.
+func (s *InflacionService) SincronizarIndices() map[string]error {
+	errores := map[string]error{}
+	for codigo := range proveedoresIndice {
+		n, err := s.SincronizarIndice(codigo)
+		if errors.Is(err, errSinFuenteAutomatica) {
+			continue
+		}
+		if err != nil {
+			errores[codigo] = err
+			utils.Logger.Warn("No se pudo sincronizar el índice",
+				zap.String("indice", codigo), zap.Error(err))
+			continue
+		}
+		utils.Logger.Info("Índice sincronizado", zap.String("indice", codigo), zap.Int("valores", n))
+	}
+	return errores
+}
+
+// serieLocal devuelve los valores guardados del índice entre desde y hasta. Si
+// no hay ninguno intenta una sincronización antes de responder.
+func (s *InflacionService) serieLocal(codigo string, desde, hasta time.Time) ([]ValorIndice, error) {
+	serie, err := s.leerSerie(codigo, desde, hasta)
+	if err != nil || len(serie) > 0 {
+		return serie, err
+	}
+	if _, err := s.SincronizarIndice(codigo); err != nil {
+		return nil, fmt.Errorf("no hay datos de %s locales y no se pudo sincronizar: %w", strings.ToUpper(codigo), err)
+	}
+	return s.leerSerie(codigo, desde, hasta)
+}
+
+func (s *InflacionService) leerSerie(codigo string, desde, hasta time.Time) ([]ValorIndice, error) {
+	var serie []ValorIndice
+	if codigo == models.IndiceIPC {
+		var meses []models.IPCMensual
+		if err := database.DB.Where("periodo >= ? AND periodo <= ?", primerDiaMes(desde), hasta).
+			Order("periodo ASC").Find(&meses).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range meses {
+			serie = append(serie, ValorIndice{
+				Fecha: m.Periodo, Valor: m.Pct, Fuente: m.Fuente,
+				Manual: m.Manual, ActualizadoEn: m.ActualizadoEn,
+			})
+		}
+		return serie, nil
+	}
+
+	var valores []models.IndiceValor
+	if err := database.DB.Where("indice = ? AND fecha >= ? AND fecha <= ?", codigo, desde, hasta).
+		Order("fecha ASC").Find(&valores).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range valores {
+		serie = append(serie, ValorIndice{
+			Fecha: v.Fecha, Valor: v.Valor, Fuente: v.Fuente,
+			Manual: v.Manual, ActualizadoEn: v.ActualizadoEn,
+		})
+	}
+	return serie, nil
+}
+
+// ─── Consulta ───────────────────────────────────────────────────────────────
+
+// EstadoIndices describe los índices disponibles junto con la frescura de su
+// copia local.
+func (s *InflacionService) EstadoIndices() ([]models.IndiceDisponible, error) {
+	res := make([]models.IndiceDisponible, 0, len(proveedoresIndice))
+	for _, p := range proveedoresIndice {
+		_, sinFuente := p.(proveedorCAC)
+		item := models.IndiceDisponible{
+			Codigo:     p.Codigo(),
+			Nombre:     p.Nombre(),
+			Fuente:     p.Fuente(),
+			Tipo:       string(p.Tipo()),
+			Automatico: !sinFuente,
+		}
+
+		var estado struct {
+			Cantidad      int64
+			Manuales      int64
+			PrimerDato    *time.Time
+			UltimoDato    *time.Time
+			ActualizadoEn *time.Time
+		}
+		q := database.DB.Model(&models.IndiceValor{}).Where("indice = ?", p.Codigo()).
+			Select("COUNT(*) AS cantidad, COALESCE(SUM(manual), 0) AS manuales, " +
+				"MIN(fecha) AS primer_dato, MAX(fecha) AS ultimo_dato, MAX(actualizado_en) AS actualizado_en")
+		if p.Codigo() == models.IndiceIPC {
+			q = database.DB.Model(&models.IPCMensual{}).
+				Select("COUNT(*) AS cantidad, COALESCE(SUM(manual), 0) AS manuales, " +
+					"MIN(periodo) AS primer_dato, MAX(periodo) AS ultimo_dato, MAX(actualizado_en) AS actualizado_en")
+		}
+		if err := q.Scan(&estado).Error; err != nil {
+			return nil, err
+		}
+		item.Cantidad = estado.Cantidad
+		item.Manuales = estado.Manuales
+		item.PrimerDato = estado.PrimerDato
+		item.UltimoDato = estado.UltimoDato
+		item.ActualizadoEn = estado.ActualizadoEn
+		res = append(res, item)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Codigo < res[j].Codigo })
+	return res, nil
+}
+
+// ListarValores devuelve los valores guardados de un índice, con su fuente y
+// fecha de actualización. Los meses de IPC se informan con Valor = % mensual.
+func (s *InflacionService) ListarValores(codigo string, desde, hasta time.Time) ([]models.IndiceValor, error) {
+	if _, err := GetProveedorIndice(codigo); err != nil {
+		return nil, err
+	}
+	serie, err := s.leerSerie(codigo, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	valores := make([]models.IndiceValor, 0, len(serie))
+	for _, v := range serie {
+		valores = append(valores, models.IndiceValor{
+			Indice:        codigo,
+			Fecha:         v.Fecha,
+			Valor:         v.Valor,
+			Fuente:        v.Fuente,
+			Manual:        v.Manual,
+			ActualizadoEn: v.ActualizadoEn,
+		})
+	}
+	return valores, nil
+}
+
+// ─── Carga manual ───────────────────────────────────────────────────────────
+
+// CargarValor carga o corrige un valor del índice. Queda marcado como manual y
+// las sincronizaciones posteriores no lo modifican.
+func (s *InflacionService) CargarValor(codigo, fecha string, valor float64, userID uint) error {
+	if _, err := GetProveedorIndice(codigo); err != nil {
+		return err
+	}
+	f, err := parseFechaValor(fecha)
+	if err != nil {
+		return err
+	}
+	return s.guardarManual(codigo, f, valor, userID)
+}
+
+// EliminarValor borra un valor del índice. Si el índice tiene fuente
+// automática, la próxima sincronización vuelve a traer el dato publicado.
+func (s *InflacionService) EliminarValor(codigo, fecha string) error {
+	if _, err := GetProveedorIndice(codigo); err != nil {
+		return err
+	}
+	f, err := parseFechaValor(fecha)
+	if err != nil {
+		return err
+	}
+
+	var res = database.DB.Where("indice = ? AND fecha = ?", codigo, f).Delete(&models.IndiceValor{})
+	if codigo == models.IndiceIPC {
+		res = database.DB.Where("periodo = ?", primerDiaMes(f)).Delete(&models.IPCMensual{})
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("no hay un valor cargado para esa fecha")
+	}
+	return nil
+}
+
+// ImportarCSV carga valores desde un CSV con columnas fecha,valor (la fila de
+// encabezado es opcional). Admite separador coma o punto y coma, fechas
+// AAAA-MM-DD, DD/MM/AAAA o AAAA-MM, y decimales con coma o punto.
+func (s *InflacionService) ImportarCSV(codigo string, r io.Reader, userID uint) (*models.ResultadoImportacionIndice, error) {
+	if _, err := GetProveedorIndice(codigo); err != nil {
+		return nil, err
+	}
+	contenido, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	lector := csv.NewReader(strings.NewReader(string(contenido)))
+	if primera, _, _ := strings.Cut(string(contenido), "\n"); strings.Contains(primera, ";") {
+		lector.Comma = ';'
+	}
+	lector.FieldsPerRecord = -1
+	lector.TrimLeadingSpace = true
+
+	filas, err := lector.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %w", err)
+	}
+
+	res := &models.ResultadoImportacionIndice{Errores: []string{}}
+	for i, fila := range filas {
+		if len(fila) < 2 {
+			res.Errores = append(res.Errores, fmt.Sprintf("fila %d: se esperaban fecha y valor", i+1))
+			continue
+		}
+		fecha, errFecha := parseFechaValor(fila[0])
+		valor, errValor := parseValorCSV(fila[1])
+		if i == 0 && errFecha != nil && errValor != nil {
+			continue // encabezado
+		}
+		if errFecha != nil {
+			res.Errores = append(res.Errores, fmt.Sprintf("fila %d: %v", i+1, errFecha))
+			continue
+		}
+		if errValor != nil {
+			res.Errores = append(res.Errores, fmt.Sprintf("fila %d: valor inválido %q", i+1, fila[1]))
+			continue
+		}
+		if err := s.guardarManual(codigo, fecha, valor, userID); err != nil {
+			res.Errores = append(res.Errores, fmt.Sprintf("fila %d: %v", i+1, err))
+			continue
+		}
+		res.Cargados++
+	}
+	return res, nil
+}
+
+func (s *InflacionService) guardarManual(codigo string, fecha time.Time, valor float64, userID uint) error {
+	now := time.Now()
+	if codigo == models.IndiceIPC {
+		if valor <= -100 {
+			return errors.New("la variación mensual del IPC debe ser mayor a -100%")
+		}
+		return database.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "periodo"}},
+			DoUpdates: clause.AssignmentColumns([]string{"pct", "fuente", "actualizado_en", "manual", "cargado_por"}),
+		}).Create(&models.IPCMensual{
+			Periodo:       primerDiaMes(fecha),
+			Pct:           roundDos(valor),
+			Fuente:        fuenteManual,
+			ActualizadoEn: now,
+			Manual:        true,
+			CargadoPor:    &userID,
+		}).Error
+	}
+
+	if valor <= 0 {
+		return errors.New("el valor del índice debe ser mayor a cero")
+	}
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "indice"}, {Name: "fecha"}},
+		DoUpdates: clause.AssignmentColumns([]string{"valor", "fuente", "actualizado_en", "manual", "cargado_por"}),
+	}).Create(&models.IndiceValor{
+		Indice:        codigo,
+		Fecha:         fecha,
+		Valor:         valor,
+		Fuente:        fuenteManual,
+		ActualizadoEn: now,
+		Manual:        true,
+		CargadoPor:    &userID,
+	}).Error
+}
+
+// parseFechaValor interpreta la fecha de un valor cargado a mano.
+func parseFechaValor(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2006-01"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("fecha inválida %q (use AAAA-MM-DD, DD/MM/AAAA o AAAA-MM)", s)
+}
+
+// parseValorCSV acepta "1234.56", "1234,56" y "1.234,56".
+func parseValorCSV(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	return strconv.ParseFloat(s, 64)
+}
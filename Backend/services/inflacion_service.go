@@ -1,18 +1,26 @@
 package services
 
 import (
+	"caja-fuerte/database"
 	"caja-fuerte/models"
+	"caja-fuerte/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"net/http"
 	"sort"
 	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
 )
 
 const argentinadatosIPCURL = "https://api.argentinadatos.com/v1/finanzas/indices/inflacion"
 
+const fuenteIPC = "INDEC vía ArgentinaDatos"
+
 // datoIPCRaw es la estructura que devuelve ArgentinaDatos
 type datoIPCRaw struct {
 	Fecha string  `json:"fecha"` // "2025-03-31" o "2025-03"
@@ -20,67 +28,26 @@ type datoIPCRaw struct {
 }
 
 // InflacionService obtiene y procesa datos de IPC del INDEC vía ArgentinaDatos
-type InflacionService struct{}
+type InflacionService struct {
+	stopChan chan bool
+}
 
 func NewInflacionService() *InflacionService {
-	return &InflacionService{}
+	return &InflacionService{
+		stopChan: make(chan bool),
+	}
 }
 
 // ObtenerAcumulado calcula la inflación acumulada del período [desde, hasta).
 
 func (s *InflacionService) ObtenerAcumulado(desde, hasta time.Time) *models.DetalleInflacion {
-	datos, err := s.fetchIPC()
-	if err != nil {
-		log.Printf("[INFLACION] Error al consultar ArgentinaDatos: %v", err)
-		return &models.DetalleInflacion{
-			Meses:         []models.MesInflacion{},
-			AcumuladoPct:  0,
-			Fuente:        "ArgentinaDatos (error al consultar)",
-			FechaConsulta: time.Now(),
-			Error:         err.Error(),
-		}
-	}
-
-	mesDesde := primerDiaMes(desde)
-	mesHasta := primerDiaMes(hasta)
-
-	var meses []models.MesInflacion
-	acumulado := 1.0
-
-	for _, d := range datos {
-		fecha, err := parseFechaIPC(d.Fecha)
-		if err != nil {
-			continue
-		}
-		mesF := primerDiaMes(fecha)
-
-		// Incluir meses: desde <= mesF < hasta
-		if (mesF.Equal(mesDesde) || mesF.After(mesDesde)) && mesF.Before(mesHasta) {
-			meses = append(meses, models.MesInflacion{
-				Periodo: formatPeriodo(fecha),
-				Pct:     roundDos(d.Valor),
-			})
-			acumulado *= (1.0 + d.Valor/100.0)
-		}
-	}
-
-	// Si no hay datos (API sin datos del período), devolver estructura vacía pero válida
-	if len(meses) == 0 {
-		return &models.DetalleInflacion{
-			Meses:         []models.MesInflacion{},
-			AcumuladoPct:  0,
-			Fuente:        "INDEC vía ArgentinaDatos",
-			FechaConsulta: time.Now(),
-			Error:         "Sin datos para el período solicitado",
-		}
-	}
+	return s.CalcularActualizacion(models.IndiceIPC, desde, hasta)
+}
 
-	return &models.DetalleInflacion{
-		Meses:         meses,
-		AcumuladoPct:  roundDos((acumulado - 1.0) * 100.0),
-		Fuente:        "INDEC vía ArgentinaDatos",
-		FechaConsulta: time.Now(),
-	}
+// CalcularActualizacion calcula el coeficiente de actualización del período
+// [desde, hasta) con el índice indicado (ver ProveedorIndice).
+func (s *InflacionService) CalcularActualizacion(indice string, desde, hasta time.Time) *models.DetalleInflacion {
+	return newCalculadoraIndices(desde, hasta).calcular(indice, desde, hasta)
 }
 
 // fetchIPC descarga y ordena los datos mensuales de inflación
@@ -115,6 +82,197 @@ func (s *InflacionService) fetchIPC() ([]datoIPCRaw, error) {
 	return datos, nil
 }
 
+// ─── Copia local de la serie IPC ────────────────────────────────────────────
+
+// SincronizarIPC descarga la serie completa de ArgentinaDatos y actualiza la
+// copia local (tabla ipc_mensual). Los meses cargados a mano se conservan.
+// Devuelve la cantidad de meses guardados.
+func (s *InflacionService) SincronizarIPC() (int, error) {
+	datos, err := s.fetchIPC()
+	if err != nil {
+		return 0, err
+	}
+
+	var manuales []time.Time
+	if err := database.DB.Model(&models.IPCMensual{}).Where("manual = ?", true).
+		Pluck("periodo", &manuales).Error; err != nil {
+		return 0, err
+	}
+	esManual := map[string]bool{}
+	for _, m := range manuales {
+		esManual[claveMes(m)] = true
+	}
+
+	now := time.Now()
+	registros := make([]models.IPCMensual, 0, len(datos))
+	for _, d := range datos {
+		fecha, err := parseFechaIPC(d.Fecha)
+		if err != nil || esManual[claveMes(fecha)] {
+			continue
+		}
+		registros = append(registros, models.IPCMensual{
+			Periodo:       primerDiaMes(fecha),
+			Pct:           roundDos(d.Valor),
+			Fuente:        fuenteIPC,
+			ActualizadoEn: now,
+		})
+	}
+	if len(datos) == 0 {
+		return 0, errors.New("ArgentinaDatos no devolvió datos de IPC")
+	}
+	if len(registros) == 0 {
+		return 0, nil
+	}
+
+	err = database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "periodo"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pct", "fuente", "actualizado_en"}),
+	}).CreateInBatches(registros, 200).Error
+	if err != nil {
+		return 0, err
+	}
+	return len(registros), nil
+}
+
+// GetSerieIPCLocal devuelve la serie IPC guardada localmente, ordenada por período.
+// Si la tabla está vacía intenta una sincronización antes de responder.
+func (s *InflacionService) GetSerieIPCLocal() ([]models.IPCMensual, error) {
+	var serie []models.IPCMensual
+	if err := database.DB.Order("periodo ASC").Find(&serie).Error; err != nil {
+		return nil, err
+	}
+	if len(serie) > 0 {
+		return serie, nil
+	}
+
+	if _, err := s.SincronizarIPC(); err != nil {
+		return nil, fmt.Errorf("no hay datos de IPC locales y no se pudo sincronizar: %w", err)
+	}
+	if err := database.DB.Order("periodo ASC").Find(&serie).Error; err != nil {
+		return nil, err
+	}
+	return serie, nil
+}
+
+// IndicePrecios es un índice de nivel de precios construido encadenando las
+// variaciones mensuales del IPC (base 100 al inicio de la serie).
+type IndicePrecios struct {
+	niveles       map[string]float64 // "2025-03" -> nivel al cierre del mes
+	primero       time.Time
+	ultimo        time.Time
+	actualizadoEn time.Time
+}
+
+// GetIndicePrecios arma el índice de precios a partir de la copia local.
+func (s *InflacionService) GetIndicePrecios() (*IndicePrecios, error) {
+	serie, err := s.GetSerieIPCLocal()
+	if err != nil {
+		return nil, err
+	}
+
+	ip := &IndicePrecios{niveles: map[string]float64{}}
+	nivel := 100.0
+	for i, m := range serie {
+		nivel *= 1.0 + m.Pct/100.0
+		ip.niveles[claveMes(m.Periodo)] = nivel
+		if i == 0 {
+			ip.primero = primerDiaMes(m.Periodo)
+		}
+		ip.ultimo = primerDiaMes(m.Periodo)
+		if m.ActualizadoEn.After(ip.actualizadoEn) {
+			ip.actualizadoEn = m.ActualizadoEn
+		}
+	}
+	return ip, nil
+}
+
+// Nivel devuelve el nivel de precios del mes. Si el mes está fuera de la serie
+// publicada se usa el extremo más cercano y se informa como estimado.
+func (ip *IndicePrecios) Nivel(mes time.Time) (float64, bool) {
+	mes = primerDiaMes(mes)
+	if v, ok := ip.niveles[claveMes(mes)]; ok {
+		return v, false
+	}
+	if mes.After(ip.ultimo) {
+		return ip.niveles[claveMes(ip.ultimo)], true
+	}
+	return ip.niveles[claveMes(ip.primero)], true
+}
+
+// FactorReal devuelve el multiplicador que lleva pesos del mes `mes` a pesos del mes `base`.
+func (ip *IndicePrecios) FactorReal(mes, base time.Time) (float64, bool) {
+	nivelMes, estMes := ip.Nivel(mes)
+	nivelBase, estBase := ip.Nivel(base)
+	if nivelMes == 0 {
+		return 1, true
+	}
+	return nivelBase / nivelMes, estMes || estBase
+}
+
+// InflacionPromedio devuelve la variación mensual promedio (geométrica, en %)
+// de los últimos `meses` meses publicados. Se usa como supuesto para proyectar.
+func (ip *IndicePrecios) InflacionPromedio(meses int) float64 {
+	if meses <= 0 || len(ip.niveles) < 2 {
+		return 0
+	}
+	desde := ip.ultimo.AddDate(0, -meses, 0)
+	if desde.Before(ip.primero) {
+		desde = ip.primero
+		meses = int(ip.ultimo.Sub(desde).Hours()/24/30.4 + 0.5)
+		if meses <= 0 {
+			return 0
+		}
+	}
+	nivelDesde, _ := ip.Nivel(desde)
+	nivelHasta, _ := ip.Nivel(ip.ultimo)
+	if nivelDesde == 0 {
+		return 0
+	}
+	return (math.Pow(nivelHasta/nivelDesde, 1/float64(meses)) - 1) * 100
+}
+
+// UltimoPeriodo devuelve el último mes con IPC publicado.
+func (ip *IndicePrecios) UltimoPeriodo() time.Time {
+	return ip.ultimo
+}
+
+// ActualizadoEn devuelve la fecha de la última sincronización de la serie.
+func (ip *IndicePrecios) ActualizadoEn() time.Time {
+	return ip.actualizadoEn
+}
+
+// Start sincroniza la copia local de los índices (IPC, ICL, CER, UVA) al
+// iniciar y luego una vez por día: el INDEC publica un dato por mes y el BCRA
+// uno por día, así que no hace falta más frecuencia.
+func (s *InflacionService) Start() {
+	go func() {
+		s.sincronizarYLoguear()
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sincronizarYLoguear()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Stop detiene la sincronización periódica.
+func (s *InflacionService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *InflacionService) sincronizarYLoguear() {
+	if errores := s.SincronizarIndices(); len(errores) > 0 {
+		utils.Logger.Warn("Sincronización de índices incompleta", zap.Int("con_error", len(errores)))
+	}
+}
+
 // ─── Helpers ────────────────────────────────────────────────────────────────
 
 func primerDiaMes(t time.Time) time.Time {
@@ -136,6 +294,11 @@ func formatPeriodo(t time.Time) string {
 	return fmt.Sprintf("%s %d", nombres[t.Month()-1], t.Year())
 }
 
+// roundDos redondea a 2 decimales (los negativos, alejándose de cero).
 func roundDos(v float64) float64 {
-	return float64(int(v*100+0.5)) / 100
+	return math.Round(v*100) / 100
+}
+
+func claveMes(t time.Time) string {
+	return t.Format("2006-01")
 }
@@ -0,0 +1,35 @@
+package services
+
+import "testing"
+
+func TestRedondeo(t *testing.T) {
+	casos := []struct {
+		nombre string
+		fn     func(float64) float64
+		v      float64
+		want   float64
+	}{
+		{"dos decimales", roundDos, 1234.565, 1234.57},
+		{"dos decimales hacia abajo", roundDos, 10.004, 10},
+		{"entero negativo", roundDos, -1, -1},
+		{"faltante de un centavo", roundDos, -0.01, -0.01},
+		{"negativo se aleja de cero", roundDos, -2.345, -2.35},
+		{"negativo hacia cero", roundDos, -2.344, -2.34},
+		{"cero", roundDos, 0, 0},
+		// Las variaciones del IPC (sincronizadas o manuales) se guardan con roundDos
+		{"IPC con deflación", roundDos, -0.125, -0.13},
+		{"IPC con deflación de un décimo", roundDos, -0.1, -0.1},
+		{"cuatro decimales", roundCuatro, 0.12345, 0.1235},
+		{"cuatro decimales negativo", roundCuatro, -0.12345, -0.1235},
+		{"variación negativa de un punto básico", roundCuatro, -0.0001, -0.0001},
+		{"coeficiente", roundCoeficiente, 1.09091, 1.0909},
+		{"coeficiente negativo", roundCoeficiente, -0.00005, -0.0001},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := c.fn(c.v); got != c.want {
+				t.Errorf("redondeo(%v) = %v, want %v", c.v, got, c.want)
+			}
+		})
+	}
+}
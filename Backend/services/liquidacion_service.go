@@ -0,0 +1,534 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LiquidacionService arma la rendición mensual a cada propietario: alquileres
+// cobrados en el mes menos la comisión de administración y los gastos pagados
+// por su cuenta. El pago de la liquidación se registra como Egreso en caja.
+type LiquidacionService struct {
+	coll         *mongo.Collection
+	propietarios *mongo.Collection
+	props        *mongo.Collection
+	periodos     *mongo.Collection
+	contratos    *mongo.Collection
+	gastos       *mongo.Collection
+}
+
+func NewLiquidacionService() *LiquidacionService {
+	return &LiquidacionService{
+		coll:         database.MongoDB.Collection(database.CollectionLiquidaciones),
+		propietarios: database.MongoDB.Collection(database.CollectionPropietarios),
+		props:        database.MongoDB.Collection(database.CollectionPropiedades),
+		periodos:     database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
+		contratos:    database.MongoDB.Collection(database.CollectionContratos),
+		gastos:       database.MongoDB.Collection(database.CollectionGastosPropiedad),
+	}
+}
+
+// Nombre del concepto de caja para los pagos a propietarios
+const conceptoLiquidacionPropietarios = "Liquidación a Propietarios"
+
+// Previsualizar calcula la liquidación del mes sin guardarla.
+func (s *LiquidacionService) Previsualizar(propietarioID string, anio, mes int) (*models.LiquidacionPropietario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	prop, err := NewPropietarioService().getPropietario(ctx, propietarioID)
+	if err != nil {
+		return nil, err
+	}
+	return s.calcular(ctx, prop, anio, mes)
+}
+
+// ErrLiquidacionPendiente indica que el propietario ya tiene una liquidación sin pagar.
+var ErrLiquidacionPendiente = errors.New("el propietario tiene una liquidación pendiente de pago")
+
+// Generar guarda la liquidación del mes y marca sus gastos como descontados.
+// Se puede volver a liquidar el mismo mes: solo toma los cobros y gastos que
+// quedaron afuera de las liquidaciones anteriores.
+func (s *LiquidacionService) Generar(propietarioID string, req models.GenerarLiquidacionRequest, userID uint) (*models.LiquidacionPropietario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	prop, err := NewPropietarioService().getPropietario(ctx, propietarioID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.coll.CountDocuments(ctx, bson.M{"propietario_id": prop.ID, "estado": models.LiquidacionPendiente})
+	if err != nil {
+		return nil, err
+	}
+	if n > 0 {
+		return nil, ErrLiquidacionPendiente
+	}
+
+	liq, err := s.calcular(ctx, prop, req.Anio, req.Mes)
+	if err != nil {
+		return nil, err
+	}
+	if len(liq.Items) == 0 && len(liq.Gastos) == 0 {
+		return nil, errors.New("no hay cobros ni gastos para liquidar en el período")
+	}
+
+	liq.ID = primitive.NewObjectID()
+	liq.Estado = models.LiquidacionPendiente
+	liq.CreatedBy = userID
+	liq.CreatedAt = time.Now()
+	if _, err := s.coll.InsertOne(ctx, liq); err != nil {
+		// Otra liquidación del mismo propietario se generó en paralelo
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrLiquidacionPendiente
+		}
+		return nil, err
+	}
+
+	if len(liq.Gastos) > 0 {
+		ids := make([]primitive.ObjectID, len(liq.Gastos))
+		for i, g := range liq.Gastos {
+			ids[i] = g.ID
+		}
+		if _, err := s.gastos.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": ids}},
+			bson.M{"$set": bson.M{"liquidacion_id": liq.ID}},
+		); err != nil {
+			return nil, err
+		}
+	}
+	return liq, nil
+}
+
+// Listar devuelve las liquidaciones de un propietario, de la más reciente a la más antigua.
+func (s *LiquidacionService) Listar(propietarioID string) ([]models.LiquidacionPropietario, error) {
+	objID, err := primitive.ObjectIDFromHex(propietarioID)
+	if err != nil {
+		return nil, errors.New("ID de propietario inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "anio", Value: -1}, {Key: "mes", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := s.coll.Find(ctx, bson.M{"propietario_id": objID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	liquidaciones := []models.LiquidacionPropietario{}
+	if err := cursor.All(ctx, &liquidaciones); err != nil {
+		return nil, err
+	}
+	return liquidaciones, nil
+}
+
+// GetByID devuelve una liquidación.
+func (s *LiquidacionService) GetByID(id string) (*models.LiquidacionPropietario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.getLiquidacion(ctx, id)
+}
+
+// Pagar registra el pago al propietario como Egreso en caja. Si el neto no es
+// positivo la liquidación se cierra sin movimiento y el saldo pasa a la siguiente.
+// La liquidación se marca pagada antes de crear el Egreso, así dos pagos
+// simultáneos no generan dos movimientos; si el Egreso falla vuelve a pendiente.
+func (s *LiquidacionService) Pagar(id string, userID uint) (*models.LiquidacionPropietario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	liq, err := s.getLiquidacion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if liq.Estado != models.LiquidacionPendiente {
+		return nil, errors.New("la liquidación no está pendiente de pago")
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.LiquidacionPropietario
+	err = s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": liq.ID, "estado": models.LiquidacionPendiente},
+		bson.M{"$set": bson.M{"estado": models.LiquidacionPagada, "pagada_en": now, "pagada_por": userID}},
+		opts,
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("la liquidación no está pendiente de pago")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if liq.Neto <= 0 {
+		return &updated, nil
+	}
+
+	movID, err := s.egresoLiquidacion(liq, userID)
+	if err != nil {
+		s.volverAPendiente(ctx, liq.ID)
+		return nil, err
+	}
+	if err := s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": liq.ID},
+		bson.M{"$set": bson.M{"movement_id": *movID}},
+		opts,
+	).Decode(&updated); err != nil {
+		// Sin el vínculo el Egreso quedaría huérfano: se descarta y se reintenta
+		if errEliminar := NewMovementService().SoftDeleteMovement(*movID, userID); errEliminar != nil {
+			log.Printf("[ALQUILER] Advertencia: no se pudo descartar el egreso %d de la liquidación %s: %v", *movID, id, errEliminar)
+		}
+		s.volverAPendiente(ctx, liq.ID)
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// egresoLiquidacion registra en caja el pago del neto al propietario.
+func (s *LiquidacionService) egresoLiquidacion(liq *models.LiquidacionPropietario, userID uint) (*uint, error) {
+	conceptID := getOrCreateConcepto(conceptoLiquidacionPropietarios, "Egreso", userID)
+	if conceptID == 0 {
+		return nil, errors.New("no se pudo obtener el concepto de liquidación a propietarios")
+	}
+	details := "Liquidación " + nombreMes(time.Month(liq.Mes+1)) + " " + strconv.Itoa(liq.Anio) + " - " + liq.Propietario
+	movID, err := crearMovimientoCaja("Egreso", conceptID, liq.Neto, details, userID)
+	if err != nil {
+		return nil, errors.New("no se pudo registrar el egreso en caja: " + err.Error())
+	}
+	return movID, nil
+}
+
+// volverAPendiente deshace el paso a pagada de un pago que no se completó.
+func (s *LiquidacionService) volverAPendiente(ctx context.Context, id primitive.ObjectID) {
+	if _, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "estado": models.LiquidacionPagada, "movement_id": bson.M{"$exists": false}},
+		bson.M{
+			"$set":   bson.M{"estado": models.LiquidacionPendiente},
+			"$unset": bson.M{"pagada_en": "", "pagada_por": ""},
+		},
+	); err != nil {
+		log.Printf("[ALQUILER] Advertencia: la liquidación %s quedó pagada sin egreso: %v", id.Hex(), err)
+	}
+}
+
+// Anular descarta una liquidación pendiente: sus cobros y gastos vuelven a
+// quedar disponibles para la próxima liquidación.
+func (s *LiquidacionService) Anular(id string) (*models.LiquidacionPropietario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	liq, err := s.getLiquidacion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if liq.Estado != models.LiquidacionPendiente {
+		return nil, errors.New("solo se puede anular una liquidación pendiente de pago")
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.LiquidacionPropietario
+	if err := s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": liq.ID, "estado": models.LiquidacionPendiente},
+		bson.M{"$set": bson.M{"estado": models.LiquidacionAnulada, "anulada_en": now}}, opts,
+	).Decode(&updated); err != nil {
+		return nil, err
+	}
+	if _, err := s.gastos.UpdateMany(ctx,
+		bson.M{"liquidacion_id": liq.ID},
+		bson.M{"$unset": bson.M{"liquidacion_id": ""}},
+	); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// GenerarPDF arma el resumen de la liquidación para entregar al propietario.
+func (s *LiquidacionService) GenerarPDF(id string) (string, []byte, error) {
+	liq, err := s.GetByID(id)
+	if err != nil {
+		return "", nil, err
+	}
+	periodo := nombreMes(time.Month(liq.Mes+1)) + " " + strconv.Itoa(liq.Anio)
+
+	cobros := utils.SeccionTabular{
+		Titulo:   "Alquileres cobrados",
+		Columnas: []string{"Propiedad", "Inquilino", "Período", "Fecha cobro", "Cobrado", "Comisión %", "Comisión"},
+		Totales:  []interface{}{"Total", "", "", "", liq.TotalCobrado, "", liq.TotalComision},
+	}
+	for _, it := range liq.Items {
+		cobros.Filas = append(cobros.Filas, []interface{}{
+			it.Direccion, it.Inquilino,
+			fmt.Sprintf("%02d/%d", it.Mes+1, it.Anio),
+			it.Fecha.Format("02/01/2006"),
+			it.Monto, strconv.FormatFloat(it.ComisionPct, 'f', -1, 64) + "%", it.Comision,
+		})
+	}
+
+	gastos := utils.SeccionTabular{
+		Titulo:   "Gastos por cuenta del propietario",
+		Columnas: []string{"Fecha", "Propiedad", "Categoría", "Descripción", "Monto"},
+		Totales:  []interface{}{"Total", "", "", "", liq.TotalGastos},
+	}
+	direcciones := map[primitive.ObjectID]string{}
+	for _, it := range liq.Items {
+		direcciones[it.PropiedadID] = it.Direccion
+	}
+	for _, g := range liq.Gastos {
+		dir, ok := direcciones[g.PropiedadID]
+		if !ok {
+			var p models.Propiedad
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.props.FindOne(ctx, bson.M{"_id": g.PropiedadID}).Decode(&p); err == nil {
+				dir = p.Direccion
+				direcciones[g.PropiedadID] = dir
+			}
+			cancel()
+		}
+		gastos.Filas = append(gastos.Filas, []interface{}{
+			g.Fecha.Format("02/01/2006"), dir, g.Categoria, g.Descripcion, g.Monto,
+		})
+	}
+
+	resumen := utils.SeccionTabular{
+		Titulo:   "Resumen",
+		Columnas: []string{"Concepto", "Importe"},
+		Filas: [][]interface{}{
+			{"Alquileres cobrados", liq.TotalCobrado},
+			{"Comisión de administración", -liq.TotalComision},
+			{"Gastos", -liq.TotalGastos},
+			{"Saldo anterior", liq.SaldoAnterior},
+		},
+		Totales: []interface{}{"Neto a liquidar", liq.Neto},
+	}
+
+	subtitulo := liq.Propietario + " - " + periodo + " - " + string(liq.Estado)
+	if liq.PagadaEn != nil {
+		subtitulo += " el " + liq.PagadaEn.Format("02/01/2006")
+	}
+	datos, err := utils.GenerarPDF(utils.DocumentoTabular{
+		Titulo:    "Liquidación a propietario",
+		Subtitulo: subtitulo,
+		Generado:  time.Now(),
+		Secciones: []utils.SeccionTabular{cobros, gastos, resumen},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("liquidacion_%d-%02d_%s.pdf", liq.Anio, liq.Mes+1, liq.ID.Hex()), datos, nil
+}
+
+// cobroLiquidado indica si un cobro forma parte de una liquidación vigente
+// (en ese caso no se puede revertir sin anular antes la liquidación).
+func (s *LiquidacionService) cobroLiquidado(ctx context.Context, cobroID primitive.ObjectID) (bool, error) {
+	n, err := s.coll.CountDocuments(ctx, bson.M{
+		"items.cobro_id": cobroID,
+		"estado":         bson.M{"$ne": models.LiquidacionAnulada},
+	})
+	return n > 0, err
+}
+
+// ─── Cálculo ────────────────────────────────────────────────────────────────
+
+// calcular arma la liquidación del mes: cobros del mes de las propiedades del
+// propietario que no se liquidaron todavía, con la comisión de su contrato, y
+// los gastos pendientes hasta fin de mes.
+func (s *LiquidacionService) calcular(ctx context.Context, prop *models.Propietario, anio, mes int) (*models.LiquidacionPropietario, error) {
+	if mes < 0 || mes > 11 {
+		return nil, errors.New("mes inválido")
+	}
+	desde := time.Date(anio, time.Month(mes+1), 1, 0, 0, 0, 0, time.Local)
+	hasta := desde.AddDate(0, 1, 0)
+	if desde.After(time.Now()) {
+		return nil, errors.New("no se puede liquidar un mes futuro")
+	}
+
+	liq := &models.LiquidacionPropietario{
+		PropietarioID: prop.ID,
+		Propietario:   prop.Nombre,
+		Anio:          anio,
+		Mes:           mes,
+		Items:         []models.ItemLiquidacion{},
+		Gastos:        []models.GastoPropiedad{},
+	}
+
+	// Propiedades del propietario
+	cursor, err := s.props.Find(ctx, bson.M{"propietario_id": prop.ID},
+		options.Find().SetProjection(bson.M{"direccion": 1, "inquilino": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var propiedades []models.Propiedad
+	if err := cursor.All(ctx, &propiedades); err != nil {
+		return nil, err
+	}
+	porID := map[primitive.ObjectID]models.Propiedad{}
+	ids := make([]primitive.ObjectID, 0, len(propiedades))
+	for _, p := range propiedades {
+		porID[p.ID] = p
+		ids = append(ids, p.ID)
+	}
+
+	// Cobros ya incluidos en otras liquidaciones
+	liquidados := map[primitive.ObjectID]bool{}
+	previos, err := s.coll.Distinct(ctx, "items.cobro_id", bson.M{
+		"propietario_id": prop.ID,
+		"estado":         bson.M{"$ne": models.LiquidacionAnulada},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range previos {
+		if oid, ok := v.(primitive.ObjectID); ok {
+			liquidados[oid] = true
+		}
+	}
+
+	if len(ids) > 0 {
+		periodos, err := NewAlquilerService().listarPeriodos(ctx, bson.M{
+			"propiedad_id": bson.M{"$in": ids},
+			"cobros.fecha": bson.M{"$gte": desde, "$lt": hasta},
+		})
+		if err != nil {
+			return nil, err
+		}
+		comisiones, err := s.comisiones(ctx, periodos)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range periodos {
+			propiedad := porID[p.PropiedadID]
+			c := comisiones[contratoKey(p.ContratoID)]
+			inquilino := c.Inquilino
+			if inquilino == "" {
+				inquilino = propiedad.Inquilino
+			}
+			for _, cobro := range p.Cobros {
+				if cobro.Fecha.Before(desde) || !cobro.Fecha.Before(hasta) || liquidados[cobro.ID] {
+					continue
+				}
+				// La comisión se calcula sobre todo lo cobrado (alquiler + punitorios)
+				comision := roundDos(cobro.Monto * c.ComisionPct / 100)
+				liq.Items = append(liq.Items, models.ItemLiquidacion{
+					CobroID:     cobro.ID,
+					PropiedadID: p.PropiedadID,
+					Direccion:   propiedad.Direccion,
+					ContratoID:  p.ContratoID,
+					Inquilino:   inquilino,
+					Anio:        p.Anio,
+					Mes:         p.Mes,
+					Fecha:       cobro.Fecha,
+					Monto:       cobro.Monto,
+					ComisionPct: c.ComisionPct,
+					Comision:    comision,
+				})
+				liq.TotalCobrado += cobro.Monto
+				liq.TotalComision += comision
+			}
+		}
+		sort.Slice(liq.Items, func(i, j int) bool {
+			if liq.Items[i].Direccion != liq.Items[j].Direccion {
+				return liq.Items[i].Direccion < liq.Items[j].Direccion
+			}
+			return liq.Items[i].Fecha.Before(liq.Items[j].Fecha)
+		})
+	}
+
+	// Gastos pendientes a su cargo hasta fin de mes (sin a_cargo_de = anteriores
+	// a que se distinguiera quién paga, siempre del propietario)
+	cursor, err = s.gastos.Find(ctx, bson.M{
+		"propietario_id": prop.ID,
+		"a_cargo_de":     bson.M{"$in": bson.A{models.GastoACargoPropietario, nil}},
+		"liquidacion_id": bson.M{"$exists": false},
+		"fecha":          bson.M{"$lt": hasta},
+	}, options.Find().SetSort(bson.D{{Key: "fecha", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &liq.Gastos); err != nil {
+		return nil, err
+	}
+	for _, g := range liq.Gastos {
+		liq.TotalGastos += g.Monto
+	}
+
+	// Saldo negativo de la última liquidación (gastos mayores a lo cobrado)
+	var anterior models.LiquidacionPropietario
+	err = s.coll.FindOne(ctx,
+		bson.M{"propietario_id": prop.ID, "estado": models.LiquidacionPagada},
+		options.FindOne().SetSort(bson.D{{Key: "pagada_en", Value: -1}}),
+	).Decode(&anterior)
+	if err == nil && anterior.Neto < 0 {
+		liq.SaldoAnterior = anterior.Neto
+	}
+
+	liq.TotalCobrado = roundDos(liq.TotalCobrado)
+	liq.TotalComision = roundDos(liq.TotalComision)
+	liq.TotalGastos = roundDos(liq.TotalGastos)
+	liq.Neto = roundDos(liq.TotalCobrado - liq.TotalComision - liq.TotalGastos + liq.SaldoAnterior)
+	return liq, nil
+}
+
+// comisiones devuelve el contrato (comisión e inquilino) de cada período.
+func (s *LiquidacionService) comisiones(ctx context.Context, periodos []models.PeriodoAlquiler) (map[string]models.Contrato, error) {
+	res := map[string]models.Contrato{}
+	var ids []primitive.ObjectID
+	for _, p := range periodos {
+		if p.ContratoID != nil {
+			ids = append(ids, *p.ContratoID)
+		}
+	}
+	if len(ids) == 0 {
+		return res, nil
+	}
+	cursor, err := s.contratos.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"comision_pct": 1, "inquilino": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var contratos []models.Contrato
+	if err := cursor.All(ctx, &contratos); err != nil {
+		return nil, err
+	}
+	for _, c := range contratos {
+		res[c.ID.Hex()] = c
+	}
+	return res, nil
+}
+
+func contratoKey(id *primitive.ObjectID) string {
+	if id == nil {
+		return ""
+	}
+	return id.Hex()
+}
+
+func (s *LiquidacionService) getLiquidacion(ctx context.Context, id string) (*models.LiquidacionPropietario, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de liquidación inválido")
+	}
+	var liq models.LiquidacionPropietario
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&liq); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("liquidación no encontrada")
+		}
+		return nil, err
+	}
+	return &liq, nil
+}
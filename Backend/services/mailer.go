@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bytes"
+	"caja-fuerte/config"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Email es un mensaje a enviar por SMTP.
+type Email struct {
+	Para     []string
+	Asunto   string
+	Cuerpo   string // Texto plano
+	Adjuntos []Adjunto
+}
+
+// Adjunto es un archivo adjunto de un Email.
+type Adjunto struct {
+	Nombre      string
+	ContentType string
+	Datos       []byte
+}
+
+// Mailer envía correos con la configuración SMTP_* del entorno.
+// Para desarrollo se puede apuntar a un catcher local (MailHog/Mailpit)
+// con SMTP_HOST=localhost, SMTP_PORT=1025 y SMTP_TLS=none.
+type Mailer struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     string
+	tlsMode  string
+}
+
+func NewMailer() *Mailer {
+	cfg := config.AppConfig
+	return &Mailer{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		user:     cfg.SMTPUser,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+		tlsMode:  strings.ToLower(cfg.SMTPTLS),
+	}
+}
+
+// Configurado indica si hay un servidor SMTP definido.
+func (m *Mailer) Configurado() bool {
+	return m.host != ""
+}
+
+// Enviar arma el mensaje MIME y lo entrega al servidor SMTP.
+func (m *Mailer) Enviar(email Email) error {
+	if !m.Configurado() {
+		return errors.New("SMTP no configurado (definir SMTP_HOST)")
+	}
+	if len(email.Para) == 0 {
+		return errors.New("el correo no tiene destinatarios")
+	}
+
+	msg, err := m.armarMensaje(email)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.host, m.port)
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	if m.tlsMode == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: m.host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error conectando a %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(60 * time.Second))
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if m.tlsMode == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("el servidor SMTP no soporta STARTTLS (usar SMTP_TLS=none para servidores locales)")
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("error en STARTTLS: %w", err)
+		}
+	}
+
+	if m.user != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.user, m.password, m.host)); err != nil {
+			return fmt.Errorf("error de autenticación SMTP: %w", err)
+		}
+	}
+
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	for _, rcpt := range email.Para {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("destinatario rechazado %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// armarMensaje genera el mensaje multipart/mixed con el cuerpo y los adjuntos.
+func (m *Mailer) armarMensaje(email Email) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", m.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(email.Para, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Asunto))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	parte, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(parte)
+	qp.Write([]byte(email.Cuerpo))
+	qp.Close()
+
+	for _, adj := range email.Adjuntos {
+		contentType := adj.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		nombre := mime.QEncoding.Encode("utf-8", adj.Nombre)
+		parte, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", contentType, nombre)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", nombre)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Base64 en líneas de 76 caracteres (RFC 2045)
+		codificado := base64.StdEncoding.EncodeToString(adj.Datos)
+		for len(codificado) > 76 {
+			parte.Write([]byte(codificado[:76] + "\r\n"))
+			codificado = codificado[76:]
+		}
+		parte.Write([]byte(codificado + "\r\n"))
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// MoraService actualiza el estado de morosidad de los períodos impagos según
+// reglas configurables y guarda el historial de cambios de estado.
+type MoraService struct {
+	config    *mongo.Collection
+	props     *mongo.Collection
+	periodos  *mongo.Collection
+	historial *mongo.Collection
+}
+
+func NewMoraService() *MoraService {
+	return &MoraService{
+		config:    database.MongoDB.Collection(database.CollectionConfiguracionAlquileres),
+		props:     database.MongoDB.Collection(database.CollectionPropiedades),
+		periodos:  database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
+		historial: database.MongoDB.Collection(database.CollectionHistorialEstados),
+	}
+}
+
+// ID del documento de configuración con las reglas de mora
+const configMora = "mora"
+
+var (
+	entradaMoraMu sync.Mutex
+	entradaMora   cron.EntryID // tarea de actualización en el Scheduler
+)
+
+// reglasMoraPorDefecto: vence el día 10, atraso leve desde el día siguiente y
+// grave después de un mes; corre todos los días a las 00:30.
+func reglasMoraPorDefecto() *models.ReglasMora {
+	return &models.ReglasMora{
+		DiaVencimiento: 10,
+		Niveles: []models.NivelMora{
+			{Estado: models.Atraso1Estado, DiasDesde: 1, Nombre: "Atraso leve"},
+			{Estado: models.Atraso2Estado, DiasDesde: 31, Nombre: "Atraso grave"},
+		},
+		Cron: "30 0 * * *",
+	}
+}
+
+// GetReglas devuelve las reglas generales de mora.
+func (s *MoraService) GetReglas() (*models.ReglasMora, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.reglas(ctx)
+}
+
+// GuardarReglas reemplaza las reglas generales y reprograma la actualización.
+func (s *MoraService) GuardarReglas(r models.ReglasMora, userID uint) (*models.ReglasMora, error) {
+	if err := validarReglasMora(&r); err != nil {
+		return nil, err
+	}
+	r.UpdatedAt = time.Now()
+	r.UpdatedBy = userID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.config.UpdateOne(ctx,
+		bson.M{"_id": configMora},
+		bson.M{"$set": r},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.programar(r.Cron); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *MoraService) reglas(ctx context.Context) (*models.ReglasMora, error) {
+	var r models.ReglasMora
+	err := s.config.FindOne(ctx, bson.M{"_id": configMora}).Decode(&r)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return reglasMoraPorDefecto(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// validarReglasMora verifica que los niveles sean crecientes y no repitan estado.
+func validarReglasMora(r *models.ReglasMora) error {
+	if r.DiaVencimiento < 1 || r.DiaVencimiento > 28 {
+		return errors.New("el día de vencimiento debe estar entre 1 y 28")
+	}
+	if r.DiasGracia < 0 {
+		return errors.New("los días de gracia no pueden ser negativos")
+	}
+	if len(r.Niveles) == 0 {
+		return errors.New("debe definir al menos un nivel de atraso")
+	}
+	vistos := map[models.EstadoPago]bool{}
+	for i, n := range r.Niveles {
+		if n.Estado != models.Atraso1Estado && n.Estado != models.Atraso2Estado {
+			return fmt.Errorf("estado de atraso inválido: %s", n.Estado)
+		}
+		if vistos[n.Estado] {
+			return fmt.Errorf("el estado %s está repetido", n.Estado)
+		}
+		vistos[n.Estado] = true
+		if n.DiasDesde < 1 {
+			return errors.New("los niveles de atraso empiezan al menos un día después del vencimiento")
+		}
+		if i > 0 && n.DiasDesde <= r.Niveles[i-1].DiasDesde {
+			return errors.New("los niveles de atraso deben tener días crecientes")
+		}
+		if n.Nombre == "" {
+			r.Niveles[i].Nombre = string(n.Estado)
+		}
+	}
+	if err := ValidarCron(r.Cron); err != nil {
+		return fmt.Errorf("expresión cron inválida: %w", err)
+	}
+	return nil
+}
+
+// ─── Actualización de estados ───────────────────────────────────────────────
+
+// Start programa la actualización de estados de morosidad en el Scheduler.
+func (s *MoraService) Start() {
+	r, err := s.GetReglas()
+	if err != nil {
+		utils.Logger.Error("Error leyendo las reglas de mora", zap.Error(err))
+		r = reglasMoraPorDefecto()
+	}
+	if err := s.programar(r.Cron); err != nil {
+		utils.Logger.Error("Error programando la actualización de morosos", zap.Error(err))
+	}
+}
+
+func (s *MoraService) programar(expr string) error {
+	entradaMoraMu.Lock()
+	defer entradaMoraMu.Unlock()
+
+	if entradaMora != 0 {
+		GetScheduler().Quitar(entradaMora)
+		entradaMora = 0
+	}
+	id, err := GetScheduler().Programar("estados-morosos", expr, func() {
+		res, err := s.ActualizarEstados(models.OrigenMoraProgramada, 0)
+		if err != nil {
+			utils.Logger.Error("Error actualizando estados de morosidad", zap.Error(err))
+			return
+		}
+		utils.Logger.Info("Estados de morosidad actualizados",
+			zap.Int("revisados", res.Revisados), zap.Int("actualizados", res.Actualizados))
+	})
+	if err != nil {
+		return err
+	}
+	entradaMora = id
+	return nil
+}
+
+// ActualizarEstados recalcula el estado de los períodos impagos ya vencidos de
+// las propiedades ocupadas según los días de atraso desde el vencimiento (día
+// de vencimiento y gracia de la propiedad o de las reglas generales).
+func (s *MoraService) ActualizarEstados(origen string, userID uint) (*models.ResultadoMora, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	reglas, err := s.reglas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.props.Find(ctx, bson.M{"ocupada": true}, options.Find().SetProjection(bson.M{
+		"_id": 1, "direccion": 1, "inquilino": 1, "contrato_vigente_id": 1,
+		"dia_vencimiento": 1, "dias_gracia": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	var ocupadas []models.Propiedad
+	if err := cursor.All(ctx, &ocupadas); err != nil {
+		return nil, err
+	}
+	res := &models.ResultadoMora{}
+	if len(ocupadas) == 0 {
+		return res, nil
+	}
+	props := make(map[primitive.ObjectID]models.Propiedad, len(ocupadas))
+	ids := make([]primitive.ObjectID, len(ocupadas))
+	for i, p := range ocupadas {
+		props[p.ID] = p
+		ids[i] = p.ID
+	}
+
+	// Períodos impagos hasta el mes en curso (el vencimiento decide si hay atraso)
+	now := time.Now()
+	periodos, err := NewAlquilerService().listarPeriodos(ctx, bson.M{
+		"propiedad_id": bson.M{"$in": ids},
+		"estado":       bson.M{"$ne": string(models.PagadoEstado)},
+		"$expr":        bson.M{"$lte": bson.A{exprIndicePeriodo, indiceMes(now)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hoy := inicioDia(now)
+	var avisos []AvisoMora
+	for _, p := range periodos {
+		res.Revisados++
+		dias := diasAtrasoMora(reglas, props[p.PropiedadID], p.Anio, p.Mes, hoy)
+		nuevo := estadoMora(reglas, dias)
+		if p.Estado == nuevo {
+			continue
+		}
+		// El filtro por estado evita pisar un pago registrado durante la corrida
+		r, err := s.periodos.UpdateOne(ctx,
+			bson.M{"_id": p.ID, "estado": string(p.Estado)},
+			bson.M{"$set": bson.M{"estado": string(nuevo), "updated_at": now}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if r.ModifiedCount == 0 {
+			continue
+		}
+		res.Actualizados++
+		s.registrarTransicion(ctx, &p, nuevo, dias, origen, userID)
+		if nuevo != models.PendienteEstado {
+			avisos = append(avisos, AvisoMora{
+				Periodo:     p,
+				Propiedad:   props[p.PropiedadID],
+				Estado:      nuevo,
+				Nivel:       nombreNivelMora(reglas, nuevo),
+				DiasAtraso:  dias,
+				Vencimiento: vencimientoPago(reglas, props[p.PropiedadID], p.Anio, p.Mes),
+			})
+		}
+	}
+	// Los avisos a inquilinos no demoran la corrida
+	if len(avisos) > 0 {
+		go NewNotificacionService().AvisarMora(avisos)
+	}
+	return res, nil
+}
+
+// estadoPeriodo calcula el estado de mora que corresponde hoy a un período impago.
+func (s *MoraService) estadoPeriodo(ctx context.Context, prop *models.Propiedad, anio, mes int) (models.EstadoPago, int, error) {
+	reglas, err := s.reglas(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	dias := diasAtrasoMora(reglas, *prop, anio, mes, inicioDia(time.Now()))
+	return estadoMora(reglas, dias), dias, nil
+}
+
+// vencimientoPago es el día de vencimiento del período, sin días de gracia:
+// el de la propiedad o, si no tiene, el de las reglas generales.
+func vencimientoPago(reglas *models.ReglasMora, prop models.Propiedad, anio, mes int) time.Time {
+	dia := reglas.DiaVencimiento
+	if prop.DiaVencimiento > 0 {
+		dia = prop.DiaVencimiento
+	}
+	return time.Date(anio, time.Month(mes+1), dia, 0, 0, 0, 0, time.Local)
+}
+
+// vencimientoMora es el vencimiento del período con los días de gracia sumados.
+func vencimientoMora(reglas *models.ReglasMora, prop models.Propiedad, anio, mes int) time.Time {
+	gracia := reglas.DiasGracia
+	if prop.DiasGracia != nil {
+		gracia = *prop.DiasGracia
+	}
+	return vencimientoPago(reglas, prop, anio, mes).AddDate(0, 0, gracia)
+}
+
+// inicioMora es el primer día de atraso del período: desde ahí corren la mora
+// y los punitorios.
+func inicioMora(reglas *models.ReglasMora, prop models.Propiedad, anio, mes int) time.Time {
+	return vencimientoMora(reglas, prop, anio, mes).AddDate(0, 0, 1)
+}
+
+// diasAtrasoMora cuenta los días transcurridos desde el vencimiento con gracia
+// (0 si todavía no venció).
+func diasAtrasoMora(reglas *models.ReglasMora, prop models.Propiedad, anio, mes int, hoy time.Time) int {
+	venc := vencimientoMora(reglas, prop, anio, mes)
+	if !hoy.After(venc) {
+		return 0
+	}
+	return diasEntre(venc, hoy)
+}
+
+// estadoMora devuelve el nivel más alto alcanzado con los días de atraso.
+func estadoMora(reglas *models.ReglasMora, dias int) models.EstadoPago {
+	estado := models.PendienteEstado
+	for _, n := range reglas.Niveles {
+		if dias >= n.DiasDesde {
+			estado = n.Estado
+		}
+	}
+	return estado
+}
+
+// nombreNivelMora devuelve la etiqueta configurada para el estado de atraso.
+func nombreNivelMora(reglas *models.ReglasMora, estado models.EstadoPago) string {
+	for _, n := range reglas.Niveles {
+		if n.Estado == estado {
+			return n.Nombre
+		}
+	}
+	return string(estado)
+}
+
+// ─── Historial ──────────────────────────────────────────────────────────────
+
+// registrarTransicion guarda un cambio de estado del período. Un error al
+// guardar el historial no revierte el cambio: solo se registra en el log.
+func (s *MoraService) registrarTransicion(ctx context.Context, p *models.PeriodoAlquiler, nuevo models.EstadoPago, dias int, origen string, userID uint) {
+	t := models.TransicionEstado{
+		ID:             primitive.NewObjectID(),
+		PeriodoID:      p.ID,
+		PropiedadID:    p.PropiedadID,
+		ContratoID:     p.ContratoID,
+		Anio:           p.Anio,
+		Mes:            p.Mes,
+		EstadoAnterior: p.Estado,
+		EstadoNuevo:    nuevo,
+		DiasAtraso:     dias,
+		Origen:         origen,
+		UsuarioID:      userID,
+		Fecha:          time.Now(),
+	}
+	if _, err := s.historial.InsertOne(ctx, t); err != nil {
+		utils.Logger.Warn("No se pudo registrar el cambio de estado del período",
+			zap.String("periodo_id", p.ID.Hex()), zap.Error(err))
+	}
+}
+
+// Historial devuelve los cambios de estado de un período, del más antiguo al más reciente.
+func (s *MoraService) Historial(propID string, anio, mes int) ([]models.TransicionEstado, error) {
+	objID, err := primitive.ObjectIDFromHex(propID)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.historial.Find(ctx,
+		bson.M{"propiedad_id": objID, "anio": anio, "mes": mes},
+		options.Find().SetSort(bson.D{{Key: "fecha", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transiciones := []models.TransicionEstado{}
+	if err := cursor.All(ctx, &transiciones); err != nil {
+		return nil, err
+	}
+	return transiciones, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+	"time"
+)
+
+func TestEstadoMora(t *testing.T) {
+	reglas := &models.ReglasMora{
+		DiaVencimiento: 10,
+		DiasGracia:     5,
+		Niveles: []models.NivelMora{
+			{Estado: models.Atraso1Estado, DiasDesde: 1},
+			{Estado: models.Atraso2Estado, DiasDesde: 30},
+		},
+	}
+	casos := []struct {
+		dias int
+		want models.EstadoPago
+	}{
+		{0, models.PendienteEstado},
+		{1, models.Atraso1Estado},
+		{29, models.Atraso1Estado},
+		{30, models.Atraso2Estado},
+		{365, models.Atraso2Estado},
+	}
+	for _, c := range casos {
+		if got := estadoMora(reglas, c.dias); got != c.want {
+			t.Errorf("estadoMora(%d) = %q, want %q", c.dias, got, c.want)
+		}
+	}
+}
+
+func TestDiasAtrasoMora(t *testing.T) {
+	reglas := &models.ReglasMora{DiaVencimiento: 10, DiasGracia: 5}
+	sinGracia := 0
+	propia := models.Propiedad{DiaVencimiento: 5, DiasGracia: &sinGracia}
+	dia := func(d int) time.Time { return time.Date(2026, time.March, d, 0, 0, 0, 0, time.Local) }
+
+	casos := []struct {
+		nombre string
+		prop   models.Propiedad
+		hoy    time.Time
+		want   int
+	}{
+		{"antes del vencimiento", models.Propiedad{}, dia(9), 0},
+		{"dentro de la gracia", models.Propiedad{}, dia(15), 0},
+		{"primer día de mora", models.Propiedad{}, dia(16), 1},
+		{"vencimiento propio sin gracia", propia, dia(5), 0},
+		{"vencimiento propio, primer día", propia, dia(6), 1},
+		{"vencimiento propio, mes siguiente", propia, time.Date(2026, time.April, 5, 0, 0, 0, 0, time.Local), 31},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			got := diasAtrasoMora(reglas, c.prop, 2026, 2, c.hoy)
+			if got != c.want {
+				t.Errorf("diasAtrasoMora() = %d, want %d", got, c.want)
+			}
+			// Los punitorios cuentan los mismos días de atraso que la mora
+			pol := &models.PoliticaPunitorio{Activo: true, Tipo: models.PunitorioFijo, MontoFijo: 1}
+			p := &models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 1000}
+			if _, dias := calcularPunitorio(pol, inicioMora(reglas, c.prop, 2026, 2), p, c.hoy); dias != got {
+				t.Errorf("calcularPunitorio() cuenta %d días de atraso, la mora %d", dias, got)
+			}
+		})
+	}
+}
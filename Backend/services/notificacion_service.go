@@ -0,0 +1,778 @@
+package services
+
+import (
+	"bytes"
+	"caja-fuerte/config"
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ─── Canales ────────────────────────────────────────────────────────────────
+
+// CanalNotificacion entrega un aviso al inquilino por un medio.
+type CanalNotificacion interface {
+	Codigo() string
+	// Destino es el dato de contacto de la persona para el canal ("" si no lo tiene)
+	Destino(p *models.Persona) string
+	Enviar(destino, asunto, cuerpo string) error
+}
+
+// canalEmail envía los avisos por SMTP con el Mailer.
+type canalEmail struct {
+	mailer *Mailer
+}
+
+func (c *canalEmail) Codigo() string { return models.CanalEmail }
+
+func (c *canalEmail) Destino(p *models.Persona) string { return strings.TrimSpace(p.Email) }
+
+func (c *canalEmail) Enviar(destino, asunto, cuerpo string) error {
+	return c.mailer.Enviar(Email{Para: []string{destino}, Asunto: asunto, Cuerpo: cuerpo})
+}
+
+// GatewayMensajeria es la pasarela que entrega mensajes de WhatsApp o SMS.
+type GatewayMensajeria interface {
+	Enviar(canal, telefono, texto string) error
+}
+
+// canalMensajeria envía los avisos de WhatsApp o SMS a través de la pasarela.
+type canalMensajeria struct {
+	codigo  string
+	gateway GatewayMensajeria
+}
+
+func (c *canalMensajeria) Codigo() string { return c.codigo }
+
+func (c *canalMensajeria) Destino(p *models.Persona) string { return normalizarTelefono(p.Telefono) }
+
+func (c *canalMensajeria) Enviar(destino, _, cuerpo string) error {
+	return c.gateway.Enviar(c.codigo, destino, cuerpo)
+}
+
+// gatewayHTTP hace un POST JSON {canal, desde, para, texto} a MENSAJERIA_URL
+// con el token como Bearer. Cualquier respuesta que no sea 2xx es un error.
+type gatewayHTTP struct {
+	url       string
+	token     string
+	remitente string
+	client    *http.Client
+}
+
+func (g *gatewayHTTP) Enviar(canal, telefono, texto string) error {
+	body, err := json.Marshal(map[string]string{
+		"canal": canal,
+		"desde": g.remitente,
+		"para":  telefono,
+		"texto": texto,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("la pasarela de mensajería respondió %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// gatewayFake no envía nada: solo registra el mensaje en el log. Es la
+// pasarela por defecto para desarrollo (MENSAJERIA_PROVEEDOR=fake).
+type gatewayFake struct{}
+
+func (gatewayFake) Enviar(canal, telefono, texto string) error {
+	utils.Logger.Info("Mensaje simulado", zap.String("canal", canal),
+		zap.String("para", telefono), zap.String("texto", texto))
+	return nil
+}
+
+func nuevoGatewayMensajeria() GatewayMensajeria {
+	cfg := config.AppConfig
+	if strings.ToLower(cfg.MensajeriaProveedor) == "http" && cfg.MensajeriaURL != "" {
+		return &gatewayHTTP{
+			url:       cfg.MensajeriaURL,
+			token:     cfg.MensajeriaToken,
+			remitente: cfg.MensajeriaRemitente,
+			client:    &http.Client{Timeout: 15 * time.Second},
+		}
+	}
+	return gatewayFake{}
+}
+
+// normalizarTelefono deja solo los dígitos (y el + inicial) del teléfono.
+func normalizarTelefono(tel string) string {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(tel) {
+		if (r >= '0' && r <= '9') || (r == '+' && i == 0) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ─── Servicio ───────────────────────────────────────────────────────────────
+
+// NotificacionService envía avisos a los inquilinos (recordatorio de
+// vencimiento, atraso y actualización del alquiler) por los canales
+// configurados y guarda el registro de envíos.
+type NotificacionService struct {
+	config    *mongo.Collection
+	envios    *mongo.Collection
+	props     *mongo.Collection
+	contratos *mongo.Collection
+	personas  *mongo.Collection
+	canales   map[string]CanalNotificacion
+}
+
+func NewNotificacionService() *NotificacionService {
+	gateway := nuevoGatewayMensajeria()
+	return &NotificacionService{
+		config:    database.MongoDB.Collection(database.CollectionConfiguracionAlquileres),
+		envios:    database.MongoDB.Collection(database.CollectionNotificaciones),
+		props:     database.MongoDB.Collection(database.CollectionPropiedades),
+		contratos: database.MongoDB.Collection(database.CollectionContratos),
+		personas:  database.MongoDB.Collection(database.CollectionPersonas),
+		canales: map[string]CanalNotificacion{
+			models.CanalEmail:    &canalEmail{mailer: NewMailer()},
+			models.CanalWhatsApp: &canalMensajeria{codigo: models.CanalWhatsApp, gateway: gateway},
+			models.CanalSMS:      &canalMensajeria{codigo: models.CanalSMS, gateway: gateway},
+		},
+	}
+}
+
+// ID del documento de configuración de avisos
+const configNotificaciones = "notificaciones"
+
+var (
+	entradaRecordatoriosMu sync.Mutex
+	entradaRecordatorios   cron.EntryID // tarea de recordatorios en el Scheduler
+)
+
+// plantillasPorDefecto se usan para los tipos que no tienen plantilla propia.
+var plantillasPorDefecto = map[models.TipoNotificacion]models.PlantillaNotificacion{
+	models.NotifRecordatorio: {
+		Asunto: "Recordatorio: vence el alquiler de {{.Periodo}}",
+		Cuerpo: "Hola {{.Inquilino}}, te recordamos que el {{.Vencimiento}} vence el alquiler de {{.Periodo}} " +
+			"de {{.Direccion}} por $ {{.Saldo}}.\n{{.Emisor}}",
+	},
+	models.NotifMora: {
+		Asunto: "Alquiler de {{.Periodo}} vencido",
+		Cuerpo: "Hola {{.Inquilino}}, el alquiler de {{.Periodo}} de {{.Direccion}} venció el {{.Vencimiento}} " +
+			"y registra {{.DiasAtraso}} días de atraso ({{.Estado}}). Saldo: $ {{.Saldo}}.\n{{.Emisor}}",
+	},
+	models.NotifActualizacion: {
+		Asunto: "Actualización del alquiler de {{.Direccion}}",
+		Cuerpo: "Hola {{.Inquilino}}, el alquiler de {{.Direccion}} se actualiza de $ {{.MontoAnterior}} " +
+			"a $ {{.MontoNuevo}} a partir de {{.VigenteDesde}}.\n{{.Emisor}}",
+	},
+}
+
+// configNotificacionesPorDefecto: desactivado, por email, 3 días antes, a las 9:00.
+func configNotificacionesPorDefecto() *models.ConfigNotificaciones {
+	return &models.ConfigNotificaciones{
+		Canales:    []string{models.CanalEmail},
+		DiasAntes:  3,
+		Cron:       "0 9 * * *",
+		Plantillas: map[models.TipoNotificacion]models.PlantillaNotificacion{},
+	}
+}
+
+// GetConfig devuelve la configuración de avisos con las plantillas por defecto
+// completadas.
+func (s *NotificacionService) GetConfig() (*models.ConfigNotificaciones, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, err := s.configuracion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for tipo, p := range plantillasPorDefecto {
+		if _, ok := cfg.Plantillas[tipo]; !ok {
+			cfg.Plantillas[tipo] = p
+		}
+	}
+	return cfg, nil
+}
+
+// GuardarConfig reemplaza la configuración y reprograma los recordatorios.
+func (s *NotificacionService) GuardarConfig(cfg models.ConfigNotificaciones, userID uint) (*models.ConfigNotificaciones, error) {
+	if err := validarConfigNotificaciones(&cfg); err != nil {
+		return nil, err
+	}
+	cfg.UpdatedAt = time.Now()
+	cfg.UpdatedBy = userID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.config.UpdateOne(ctx,
+		bson.M{"_id": configNotificaciones},
+		bson.M{"$set": cfg},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.programar(cfg.Cron); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (s *NotificacionService) configuracion(ctx context.Context) (*models.ConfigNotificaciones, error) {
+	var cfg models.ConfigNotificaciones
+	err := s.config.FindOne(ctx, bson.M{"_id": configNotificaciones}).Decode(&cfg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return configNotificacionesPorDefecto(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Plantillas == nil {
+		cfg.Plantillas = map[models.TipoNotificacion]models.PlantillaNotificacion{}
+	}
+	return &cfg, nil
+}
+
+// validarConfigNotificaciones verifica canales, cron y que las plantillas se
+// puedan armar con datos de ejemplo.
+func validarConfigNotificaciones(cfg *models.ConfigNotificaciones) error {
+	if len(cfg.Canales) == 0 {
+		return errors.New("debe elegir al menos un canal")
+	}
+	vistos := map[string]bool{}
+	for _, c := range cfg.Canales {
+		if c != models.CanalEmail && c != models.CanalWhatsApp && c != models.CanalSMS {
+			return fmt.Errorf("canal inválido: %s", c)
+		}
+		if vistos[c] {
+			return fmt.Errorf("el canal %s está repetido", c)
+		}
+		vistos[c] = true
+	}
+	if cfg.DiasAntes < 0 || cfg.DiasAntes > 28 {
+		return errors.New("los días de anticipación deben estar entre 0 y 28")
+	}
+	if err := ValidarCron(cfg.Cron); err != nil {
+		return fmt.Errorf("expresión cron inválida: %w", err)
+	}
+	if cfg.Plantillas == nil {
+		cfg.Plantillas = map[models.TipoNotificacion]models.PlantillaNotificacion{}
+	}
+	ejemplo := datosAviso{Inquilino: "Juan Pérez", Direccion: "Calle 123", Periodo: "Marzo 2025",
+		Monto: "100.000,00", Saldo: "100.000,00", Vencimiento: "10/03/2025", DiasAtraso: 5,
+		Estado: "Atraso leve", MontoAnterior: "100.000,00", MontoNuevo: "120.000,00", VigenteDesde: "Abril 2025"}
+	for tipo, p := range cfg.Plantillas {
+		if _, ok := plantillasPorDefecto[tipo]; !ok {
+			return fmt.Errorf("tipo de aviso inválido: %s", tipo)
+		}
+		if strings.TrimSpace(p.Cuerpo) == "" {
+			return fmt.Errorf("la plantilla %s no tiene texto", tipo)
+		}
+		if _, _, err := armarAviso(p, ejemplo); err != nil {
+			return fmt.Errorf("plantilla %s inválida: %w", tipo, err)
+		}
+	}
+	return nil
+}
+
+// ─── Plantillas ─────────────────────────────────────────────────────────────
+
+// datosAviso son los campos disponibles en las plantillas.
+type datosAviso struct {
+	Inquilino     string
+	Direccion     string
+	Periodo       string
+	Monto         string
+	Saldo         string
+	Vencimiento   string
+	DiasAtraso    int
+	Estado        string
+	MontoAnterior string
+	MontoNuevo    string
+	VigenteDesde  string
+	Emisor        string
+}
+
+// armarAviso ejecuta las plantillas de asunto y cuerpo.
+func armarAviso(p models.PlantillaNotificacion, datos datosAviso) (string, string, error) {
+	ejecutar := func(texto string) (string, error) {
+		t, err := template.New("aviso").Option("missingkey=error").Parse(texto)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		if err := t.Execute(&b, datos); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	asunto, err := ejecutar(p.Asunto)
+	if err != nil {
+		return "", "", err
+	}
+	cuerpo, err := ejecutar(p.Cuerpo)
+	if err != nil {
+		return "", "", err
+	}
+	return asunto, cuerpo, nil
+}
+
+func plantilla(cfg *models.ConfigNotificaciones, tipo models.TipoNotificacion) models.PlantillaNotificacion {
+	if p, ok := cfg.Plantillas[tipo]; ok {
+		return p
+	}
+	return plantillasPorDefecto[tipo]
+}
+
+// ─── Envío ──────────────────────────────────────────────────────────────────
+
+// aviso es un envío a preparar para una propiedad.
+type aviso struct {
+	tipo  models.TipoNotificacion
+	prop  models.Propiedad
+	anio  int // período del aviso (solo recordatorio y mora)
+	mes   int
+	datos datosAviso
+	// clave identifica el aviso; se le agrega el canal
+	clave string
+}
+
+// destinatario busca la ficha del inquilino del contrato vigente de la propiedad.
+func (s *NotificacionService) destinatario(ctx context.Context, prop *models.Propiedad) (*models.Persona, *primitive.ObjectID, error) {
+	if prop.ContratoVigenteID == nil {
+		return nil, nil, nil
+	}
+	var contrato models.Contrato
+	err := s.contratos.FindOne(ctx, bson.M{"_id": *prop.ContratoVigenteID}).Decode(&contrato)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if contrato.InquilinoID == nil {
+		return nil, &contrato.ID, nil
+	}
+	var persona models.Persona
+	err = s.personas.FindOne(ctx, bson.M{"_id": *contrato.InquilinoID}).Decode(&persona)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, &contrato.ID, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &persona, &contrato.ID, nil
+}
+
+// enviar manda el aviso por cada canal configurado y registra el resultado.
+// El registro se inserta antes de enviar: el índice único de la clave hace que
+// un aviso ya registrado no se repita (si falló queda en cola y lo reenvía
+// ReintentarFallidos).
+func (s *NotificacionService) enviar(ctx context.Context, cfg *models.ConfigNotificaciones, a aviso) (enviados, errores int) {
+	persona, contratoID, err := s.destinatario(ctx, &a.prop)
+	if err != nil {
+		utils.Logger.Warn("No se pudo obtener el inquilino para el aviso",
+			zap.String("propiedad_id", a.prop.ID.Hex()), zap.Error(err))
+		return 0, 1
+	}
+	a.datos.Inquilino = a.prop.Inquilino
+	if persona != nil && persona.Nombre != "" {
+		a.datos.Inquilino = persona.Nombre
+	}
+	a.datos.Direccion = a.prop.Direccion
+	a.datos.Emisor = config.AppConfig.ReciboEmisor
+
+	asunto, cuerpo, err := armarAviso(plantilla(cfg, a.tipo), a.datos)
+	if err != nil {
+		utils.Logger.Error("Error armando el aviso", zap.String("tipo", string(a.tipo)), zap.Error(err))
+		return 0, 1
+	}
+
+	for _, codigo := range cfg.Canales {
+		canal, ok := s.canales[codigo]
+		if !ok {
+			continue
+		}
+		clave := a.clave + ":" + codigo
+		envio := models.Notificacion{
+			ID:          primitive.NewObjectID(),
+			Tipo:        a.tipo,
+			Canal:       codigo,
+			Inquilino:   a.datos.Inquilino,
+			PropiedadID: a.prop.ID,
+			Direccion:   a.prop.Direccion,
+			ContratoID:  contratoID,
+			Anio:        a.anio,
+			Mes:         a.mes,
+			Cuerpo:      cuerpo,
+			Clave:       clave,
+			Estado:      models.NotifEnviando,
+			Fecha:       time.Now(),
+		}
+		if codigo == models.CanalEmail {
+			envio.Asunto = asunto
+		}
+		if persona != nil {
+			envio.PersonaID = &persona.ID
+			envio.Destino = canal.Destino(persona)
+		}
+
+		if envio.Destino == "" {
+			envio.Estado = models.NotifOmitida
+			envio.Error = "el inquilino no tiene cargado el dato de contacto del canal"
+		}
+		if _, err := s.envios.InsertOne(ctx, envio); err != nil {
+			// Clave repetida: el aviso ya se envió o está en cola
+			if !mongo.IsDuplicateKeyError(err) {
+				utils.Logger.Warn("No se pudo registrar el aviso", zap.String("clave", clave), zap.Error(err))
+				errores++
+			}
+			continue
+		}
+		if envio.Estado == models.NotifOmitida {
+			continue
+		}
+
+		set := bson.M{"estado": models.NotifEnviada, "fecha": time.Now()}
+		if err := canal.Enviar(envio.Destino, asunto, cuerpo); err != nil {
+			set["estado"] = models.NotifError
+			set["error"] = err.Error()
+			errores++
+		} else {
+			enviados++
+		}
+		if _, err := s.envios.UpdateOne(ctx, bson.M{"_id": envio.ID}, bson.M{"$set": set}); err != nil {
+			utils.Logger.Warn("No se pudo registrar el resultado del aviso", zap.String("clave", clave), zap.Error(err))
+		}
+	}
+	return enviados, errores
+}
+
+// ─── Avisos ─────────────────────────────────────────────────────────────────
+
+// AvisoMora es un período que acaba de pasar a un estado de atraso.
+type AvisoMora struct {
+	Periodo     models.PeriodoAlquiler
+	Propiedad   models.Propiedad
+	Estado      models.EstadoPago
+	Nivel       string // nombre del nivel de atraso
+	DiasAtraso  int
+	Vencimiento time.Time // sin los días de gracia
+}
+
+// AvisarMora avisa a los inquilinos de los períodos que pasaron a atraso. Se
+// envía una vez por período y estado.
+func (s *NotificacionService) AvisarMora(avisos []AvisoMora) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cfg, err := s.configuracion(ctx)
+	if err != nil {
+		utils.Logger.Error("Error leyendo la configuración de avisos", zap.Error(err))
+		return
+	}
+	if !cfg.Activo {
+		return
+	}
+	for _, m := range avisos {
+		p := m.Periodo
+		s.enviar(ctx, cfg, aviso{
+			tipo: models.NotifMora,
+			prop: m.Propiedad,
+			anio: p.Anio,
+			mes:  p.Mes,
+			datos: datosAviso{
+				Periodo:     nombreMes(time.Month(p.Mes+1)) + " " + strconv.Itoa(p.Anio),
+				Monto:       utils.FormatMonto(p.Monto),
+				Saldo:       utils.FormatMonto(saldoAviso(p)),
+				Vencimiento: m.Vencimiento.Format("02/01/2006"),
+				DiasAtraso:  m.DiasAtraso,
+				Estado:      m.Nivel,
+			},
+			clave: fmt.Sprintf("%s:%s:%s", models.NotifMora, p.ID.Hex(), m.Estado),
+		})
+	}
+}
+
+// AvisarActualizacion avisa al inquilino que cambió el alquiler mensual a
+// partir del mes de vigenteDesde. Se envía una vez por propiedad, mes de
+// vigencia y monto.
+func (s *NotificacionService) AvisarActualizacion(prop models.Propiedad, montoAnterior float64, vigenteDesde time.Time) {
+	if prop.AlquilerMensual == montoAnterior {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cfg, err := s.configuracion(ctx)
+	if err != nil {
+		utils.Logger.Error("Error leyendo la configuración de avisos", zap.Error(err))
+		return
+	}
+	if !cfg.Activo {
+		return
+	}
+	desde := inicioMes(vigenteDesde)
+	s.enviar(ctx, cfg, aviso{
+		tipo: models.NotifActualizacion,
+		prop: prop,
+		datos: datosAviso{
+			Monto:         utils.FormatMonto(prop.AlquilerMensual),
+			MontoAnterior: utils.FormatMonto(montoAnterior),
+			MontoNuevo:    utils.FormatMonto(prop.AlquilerMensual),
+			VigenteDesde:  nombreMes(desde.Month()) + " " + strconv.Itoa(desde.Year()),
+		},
+		clave: fmt.Sprintf("%s:%s:%d:%.2f", models.NotifActualizacion, prop.ID.Hex(), indiceMes(desde), prop.AlquilerMensual),
+	})
+}
+
+// saldoAviso es lo que resta pagar del período, punitorios incluidos.
+func saldoAviso(p models.PeriodoAlquiler) float64 {
+	return roundDos(p.Monto - p.Pagado + p.PunitorioDevengado - p.PunitorioPagado)
+}
+
+// maxIntentosAviso es la cantidad de reintentos de un envío con error; después
+// queda en el registro como error para revisarlo a mano.
+const maxIntentosAviso = 5
+
+// ReintentarFallidos reenvía los avisos que quedaron con error (recordatorios,
+// mora y actualizaciones), con el mismo texto y destino, y actualiza su registro.
+func (s *NotificacionService) ReintentarFallidos(ctx context.Context) (enviados, errores int) {
+	cursor, err := s.envios.Find(ctx, bson.M{
+		"estado":   models.NotifError,
+		"intentos": bson.M{"$not": bson.M{"$gte": maxIntentosAviso}},
+	})
+	if err != nil {
+		utils.Logger.Warn("No se pudieron leer los avisos con error", zap.Error(err))
+		return 0, 0
+	}
+	var fallidos []models.Notificacion
+	if err := cursor.All(ctx, &fallidos); err != nil {
+		utils.Logger.Warn("No se pudieron leer los avisos con error", zap.Error(err))
+		return 0, 0
+	}
+
+	for _, n := range fallidos {
+		canal, ok := s.canales[n.Canal]
+		if !ok || n.Destino == "" {
+			continue
+		}
+		// Registros anteriores a la cola: el aviso pudo salir en otro envío
+		if otro, err := s.envios.CountDocuments(ctx, bson.M{"clave": n.Clave, "estado": bson.M{"$ne": models.NotifError}}); err != nil || otro > 0 {
+			continue
+		}
+		set := bson.M{"estado": models.NotifEnviada, "fecha": time.Now()}
+		unset := bson.M{"error": ""}
+		if err := canal.Enviar(n.Destino, n.Asunto, n.Cuerpo); err != nil {
+			set["estado"] = models.NotifError
+			set["error"] = err.Error()
+			unset = bson.M{}
+			errores++
+		} else {
+			enviados++
+		}
+		update := bson.M{"$set": set, "$inc": bson.M{"intentos": 1}}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+		if _, err := s.envios.UpdateOne(ctx, bson.M{"_id": n.ID, "estado": models.NotifError}, update); err != nil {
+			utils.Logger.Warn("No se pudo registrar el reintento del aviso", zap.String("clave", n.Clave), zap.Error(err))
+		}
+	}
+	return enviados, errores
+}
+
+// ─── Recordatorios ──────────────────────────────────────────────────────────
+
+// Start programa los recordatorios de vencimiento en el Scheduler.
+func (s *NotificacionService) Start() {
+	cfg, err := s.GetConfig()
+	if err != nil {
+		utils.Logger.Error("Error leyendo la configuración de avisos", zap.Error(err))
+		cfg = configNotificacionesPorDefecto()
+	}
+	if err := s.programar(cfg.Cron); err != nil {
+		utils.Logger.Error("Error programando los recordatorios de vencimiento", zap.Error(err))
+	}
+}
+
+func (s *NotificacionService) programar(expr string) error {
+	entradaRecordatoriosMu.Lock()
+	defer entradaRecordatoriosMu.Unlock()
+
+	if entradaRecordatorios != 0 {
+		GetScheduler().Quitar(entradaRecordatorios)
+		entradaRecordatorios = 0
+	}
+	id, err := GetScheduler().Programar("recordatorios-vencimiento", expr, func() {
+		res, err := s.EnviarRecordatorios()
+		if err != nil {
+			utils.Logger.Error("Error enviando recordatorios de vencimiento", zap.Error(err))
+			return
+		}
+		utils.Logger.Info("Recordatorios de vencimiento enviados",
+			zap.Int("periodos", res.Periodos), zap.Int("enviados", res.Enviados), zap.Int("errores", res.Errores))
+	})
+	if err != nil {
+		return err
+	}
+	entradaRecordatorios = id
+	return nil
+}
+
+// EnviarRecordatorios avisa a los inquilinos de las propiedades ocupadas cuyo
+// período impago vence dentro de los próximos DiasAntes días (vencimiento sin
+// gracia). Cada período se recuerda una sola vez por canal. Antes reenvía los
+// avisos de cualquier tipo que quedaron con error (ReintentarFallidos).
+func (s *NotificacionService) EnviarRecordatorios() (*models.ResultadoRecordatorios, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	res := &models.ResultadoRecordatorios{}
+	cfg, err := s.configuracion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Activo {
+		return res, nil
+	}
+	res.Enviados, res.Errores = s.ReintentarFallidos(ctx)
+
+	reglas, err := NewMoraService().reglas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.props.Find(ctx, bson.M{"ocupada": true})
+	if err != nil {
+		return nil, err
+	}
+	var ocupadas []models.Propiedad
+	if err := cursor.All(ctx, &ocupadas); err != nil {
+		return nil, err
+	}
+	if len(ocupadas) == 0 {
+		return res, nil
+	}
+	props := make(map[primitive.ObjectID]models.Propiedad, len(ocupadas))
+	ids := make([]primitive.ObjectID, len(ocupadas))
+	for i, p := range ocupadas {
+		props[p.ID] = p
+		ids[i] = p.ID
+	}
+
+	// El período vence en su propio mes: con la anticipación puede ser el del mes siguiente
+	hoy := inicioDia(time.Now())
+	periodos, err := NewAlquilerService().listarPeriodos(ctx, bson.M{
+		"propiedad_id": bson.M{"$in": ids},
+		"estado":       string(models.PendienteEstado),
+		"$expr": bson.M{"$and": bson.A{
+			bson.M{"$gte": bson.A{exprIndicePeriodo, indiceMes(hoy)}},
+			bson.M{"$lte": bson.A{exprIndicePeriodo, indiceMes(hoy) + 1}},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range periodos {
+		prop := props[p.PropiedadID]
+		venc := vencimientoPago(reglas, prop, p.Anio, p.Mes)
+		if hoy.After(venc) || diasEntre(hoy, venc) > cfg.DiasAntes {
+			continue
+		}
+		res.Periodos++
+		enviados, errores := s.enviar(ctx, cfg, aviso{
+			tipo: models.NotifRecordatorio,
+			prop: prop,
+			anio: p.Anio,
+			mes:  p.Mes,
+			datos: datosAviso{
+				Periodo:     nombreMes(time.Month(p.Mes+1)) + " " + strconv.Itoa(p.Anio),
+				Monto:       utils.FormatMonto(p.Monto),
+				Saldo:       utils.FormatMonto(saldoAviso(p)),
+				Vencimiento: venc.Format("02/01/2006"),
+			},
+			clave: fmt.Sprintf("%s:%s", models.NotifRecordatorio, p.ID.Hex()),
+		})
+		res.Enviados += enviados
+		res.Errores += errores
+	}
+	return res, nil
+}
+
+// ─── Registro ───────────────────────────────────────────────────────────────
+
+// Listar devuelve el registro de envíos, del más reciente al más antiguo.
+func (s *NotificacionService) Listar(f models.FiltroNotificaciones) ([]models.Notificacion, error) {
+	filter := bson.M{}
+	if f.PropiedadID != "" {
+		id, err := primitive.ObjectIDFromHex(f.PropiedadID)
+		if err != nil {
+			return nil, errors.New("propiedad_id inválido")
+		}
+		filter["propiedad_id"] = id
+	}
+	if f.PersonaID != "" {
+		id, err := primitive.ObjectIDFromHex(f.PersonaID)
+		if err != nil {
+			return nil, errors.New("persona_id inválido")
+		}
+		filter["persona_id"] = id
+	}
+	if f.Tipo != "" {
+		filter["tipo"] = f.Tipo
+	}
+	if f.Estado != "" {
+		filter["estado"] = f.Estado
+	}
+	if f.Limite <= 0 || f.Limite > 500 {
+		f.Limite = 100
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.envios.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "fecha", Value: -1}}).SetLimit(f.Limite))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	envios := []models.Notificacion{}
+	if err := cursor.All(ctx, &envios); err != nil {
+		return nil, err
+	}
+	return envios, nil
+}
@@ -0,0 +1,483 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// OcupacionService lleva el historial de ocupación de las propiedades
+// (colección historial_ocupacion): tramos ocupada / desocupada con su motivo.
+// Los tramos se abren y cierran al iniciar y finalizar contratos.
+type OcupacionService struct {
+	coll      *mongo.Collection
+	props     *mongo.Collection
+	contratos *mongo.Collection
+}
+
+func NewOcupacionService() *OcupacionService {
+	return &OcupacionService{
+		coll:      database.MongoDB.Collection(database.CollectionHistorialOcupacion),
+		props:     database.MongoDB.Collection(database.CollectionPropiedades),
+		contratos: database.MongoDB.Collection(database.CollectionContratos),
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Registro de cambios
+// ─────────────────────────────────────────────────────────────────────────────
+
+// registrar cierra el tramo en curso de la propiedad y abre uno nuevo desde la
+// fecha indicada. Si la propiedad ya está en ese estado no hace nada. Un cambio
+// del mismo día (o retroactivo) reemplaza al tramo en curso, sin superponerse
+// con los anteriores.
+func (s *OcupacionService) registrar(ctx context.Context, propID primitive.ObjectID, ocupada bool, fecha time.Time, motivo string, contratoID *primitive.ObjectID, inquilino string, monto float64) error {
+	desde := inicioDia(fecha)
+	now := time.Now()
+
+	var abierto models.TramoOcupacion
+	err := s.coll.FindOne(ctx, bson.M{"propiedad_id": propID, "hasta": bson.M{"$exists": false}}).Decode(&abierto)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+	case err != nil:
+		return err
+	case abierto.Ocupada == ocupada:
+		return nil
+	case desde.After(abierto.Desde):
+		if _, err := s.coll.UpdateOne(ctx, bson.M{"_id": abierto.ID},
+			bson.M{"$set": bson.M{"hasta": desde, "updated_at": now}}); err != nil {
+			return err
+		}
+	default:
+		if _, err := s.coll.DeleteOne(ctx, bson.M{"_id": abierto.ID}); err != nil {
+			return err
+		}
+		var anterior models.TramoOcupacion
+		err := s.coll.FindOne(ctx, bson.M{"propiedad_id": propID},
+			options.FindOne().SetSort(bson.D{{Key: "desde", Value: -1}})).Decode(&anterior)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		if anterior.Hasta != nil && desde.Before(*anterior.Hasta) {
+			desde = *anterior.Hasta
+		}
+	}
+
+	_, err = s.coll.InsertOne(ctx, models.TramoOcupacion{
+		ID:              primitive.NewObjectID(),
+		PropiedadID:     propID,
+		Ocupada:         ocupada,
+		Desde:           desde,
+		Motivo:          motivo,
+		ContratoID:      contratoID,
+		Inquilino:       inquilino,
+		AlquilerMensual: monto,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	})
+	return err
+}
+
+// registrarInicioContrato abre el tramo ocupado de un contrato que comienza.
+func (s *OcupacionService) registrarInicioContrato(ctx context.Context, c *models.Contrato) {
+	if err := s.registrar(ctx, c.PropiedadID, true, c.FechaInicio, "Inicio de contrato", &c.ID, c.Inquilino, c.MontoActual); err != nil {
+		utils.Logger.Warn("No se pudo registrar la ocupación de la propiedad",
+			zap.String("propiedad", c.PropiedadID.Hex()), zap.Error(err))
+	}
+}
+
+// registrarFinContrato abre el tramo desocupado que sigue a un contrato. El
+// último alquiler queda como referencia del ingreso que se deja de percibir.
+func (s *OcupacionService) registrarFinContrato(ctx context.Context, c *models.Contrato, estado models.EstadoContrato, fecha time.Time, motivo string) {
+	if motivo == "" {
+		motivo = "Contrato finalizado"
+		if estado == models.ContratoRescindido {
+			motivo = "Contrato rescindido"
+		}
+	}
+	if err := s.registrar(ctx, c.PropiedadID, false, fecha, motivo, &c.ID, "", c.MontoActual); err != nil {
+		utils.Logger.Warn("No se pudo registrar la desocupación de la propiedad",
+			zap.String("propiedad", c.PropiedadID.Hex()), zap.Error(err))
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Consultas
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Historial devuelve la línea de tiempo de ocupación de una propiedad, del
+// tramo más antiguo al actual, con la duración de cada uno y las métricas de
+// toda su historia.
+func (s *OcupacionService) Historial(propID string) (*models.HistorialOcupacion, error) {
+	prop, err := NewAlquilerService().getPropiedad(propID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tramos, err := s.tramos(ctx, bson.M{"propiedad_id": prop.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(tramos) == 0 {
+		if err := s.reconstruir(ctx, prop); err != nil {
+			return nil, err
+		}
+		if tramos, err = s.tramos(ctx, bson.M{"propiedad_id": prop.ID}); err != nil {
+			return nil, err
+		}
+	}
+
+	hoy := finDeHoy()
+	h := &models.HistorialOcupacion{
+		PropiedadID: prop.ID,
+		Direccion:   prop.Direccion,
+		Ocupada:     prop.Ocupada,
+		Tramos:      tramos,
+	}
+	for i := range tramos {
+		t := &tramos[i]
+		t.Dias = diasEntre(t.Desde, finTramo(*t, hoy))
+		if !t.Ocupada {
+			t.IngresoPerdido = ingresoPerdido(t.AlquilerMensual, t.Dias)
+		}
+	}
+	if len(tramos) > 0 {
+		h.DiasEnEstado = tramos[len(tramos)-1].Dias
+		h.MetricasOcupacion = metricasOcupacion(tramos, tramos[0].Desde, hoy)
+	}
+	return h, nil
+}
+
+// Reporte calcula las métricas de ocupación de [desde, hasta) de todas las
+// propiedades y de cada una.
+func (s *OcupacionService) Reporte(desde, hasta time.Time) (*models.ReporteOcupacion, error) {
+	if !hasta.After(desde) {
+		return nil, errors.New("el rango de fechas es inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tramos, err := s.tramosEnRango(ctx, desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := s.props.Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"direccion": 1, "ocupada": 1}).SetSort(bson.D{{Key: "direccion", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var props []models.Propiedad
+	if err := cursor.All(ctx, &props); err != nil {
+		return nil, err
+	}
+
+	porPropiedad := map[primitive.ObjectID][]models.TramoOcupacion{}
+	for _, t := range tramos {
+		porPropiedad[t.PropiedadID] = append(porPropiedad[t.PropiedadID], t)
+	}
+	rep := &models.ReporteOcupacion{
+		Desde:             desde,
+		Hasta:             hasta,
+		MetricasOcupacion: metricasOcupacion(tramos, desde, hasta),
+		PorPropiedad:      []models.OcupacionPropiedad{},
+	}
+	for _, p := range props {
+		ts, ok := porPropiedad[p.ID]
+		if !ok {
+			continue
+		}
+		rep.PorPropiedad = append(rep.PorPropiedad, models.OcupacionPropiedad{
+			PropiedadID:       p.ID,
+			Direccion:         p.Direccion,
+			Ocupada:           p.Ocupada,
+			MetricasOcupacion: metricasOcupacion(ts, desde, hasta),
+		})
+	}
+	return rep, nil
+}
+
+// metricasRango son las métricas de todas las propiedades en [desde, hasta)
+// (ver ResumenAlquileres.OcupacionHistorica).
+func (s *OcupacionService) metricasRango(ctx context.Context, desde, hasta time.Time) (models.MetricasOcupacion, error) {
+	tramos, err := s.tramosEnRango(ctx, desde, hasta)
+	if err != nil {
+		return models.MetricasOcupacion{}, err
+	}
+	return metricasOcupacion(tramos, desde, hasta), nil
+}
+
+// ActualizarTramo corrige el motivo o el alquiler de referencia de un tramo
+// (por ejemplo, una vacancia por refacciones).
+func (s *OcupacionService) ActualizarTramo(id string, req models.ActualizarTramoRequest) (*models.TramoOcupacion, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	if req.Motivo != nil {
+		set["motivo"] = *req.Motivo
+	}
+	if req.AlquilerMensual != nil {
+		set["alquiler_mensual"] = roundDos(*req.AlquilerMensual)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var t models.TramoOcupacion
+	if err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{"$set": set}, opts).Decode(&t); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("tramo no encontrado")
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Migración
+// ─────────────────────────────────────────────────────────────────────────────
+
+// MigrarOcupacionLegacy arma el historial de las propiedades cargadas antes de
+// existir, a partir de sus contratos.
+func (s *OcupacionService) MigrarOcupacionLegacy() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	conHistorial, err := s.coll.Distinct(ctx, "propiedad_id", bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := s.props.Find(ctx, bson.M{"_id": bson.M{"$nin": conHistorial}})
+	if err != nil {
+		return 0, err
+	}
+	var props []models.Propiedad
+	if err := cursor.All(ctx, &props); err != nil {
+		return 0, err
+	}
+
+	for i := range props {
+		if err := s.reconstruir(ctx, &props[i]); err != nil {
+			return i, err
+		}
+	}
+	if len(props) > 0 {
+		utils.Logger.Info("Historial de ocupación generado para propiedades existentes", zap.Int("propiedades", len(props)))
+	}
+	return len(props), nil
+}
+
+// reconstruir genera los tramos de una propiedad sin historial: desocupada
+// desde el alta hasta el primer contrato, ocupada durante cada contrato y
+// desocupada entre uno y otro.
+func (s *OcupacionService) reconstruir(ctx context.Context, prop *models.Propiedad) error {
+	cursor, err := s.contratos.Find(ctx, bson.M{"propiedad_id": prop.ID},
+		options.Find().SetSort(bson.D{{Key: "fecha_inicio", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	var contratos []models.Contrato
+	if err := cursor.All(ctx, &contratos); err != nil {
+		return err
+	}
+
+	// El libro de períodos empieza en enero del año de alta
+	cursorFecha := inicioDia(prop.CreatedAt)
+	if prop.Anio > 0 {
+		if alta := time.Date(prop.Anio, time.January, 1, 0, 0, 0, 0, time.Local); alta.Before(cursorFecha) {
+			cursorFecha = alta
+		}
+	}
+
+	now := time.Now()
+	tramos := []interface{}{}
+	nuevo := func(ocupada bool, desde time.Time, hasta *time.Time, motivo string, c *models.Contrato, monto float64) {
+		t := models.TramoOcupacion{
+			ID:              primitive.NewObjectID(),
+			PropiedadID:     prop.ID,
+			Ocupada:         ocupada,
+			Desde:           desde,
+			Hasta:           hasta,
+			Motivo:          motivo,
+			AlquilerMensual: monto,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if c != nil {
+			t.ContratoID = &c.ID
+			if ocupada {
+				t.Inquilino = c.Inquilino
+			}
+		}
+		tramos = append(tramos, t)
+	}
+
+	monto := prop.AlquilerMensual
+	motivo := "Sin contrato"
+	var anterior *models.Contrato
+	abierto := false
+	for i := range contratos {
+		c := &contratos[i]
+		inicio := inicioDia(c.FechaInicio)
+		if inicio.Before(cursorFecha) {
+			inicio = cursorFecha
+		}
+		if inicio.After(cursorFecha) {
+			hasta := inicio
+			nuevo(false, cursorFecha, &hasta, motivo, anterior, monto)
+		}
+		if c.Estado == models.ContratoVigente || c.FechaFinalizacion == nil {
+			nuevo(true, inicio, nil, "Inicio de contrato", c, c.MontoActual)
+			abierto = true
+			break
+		}
+		fin := inicioDia(*c.FechaFinalizacion)
+		if fin.After(inicio) {
+			nuevo(true, inicio, &fin, "Inicio de contrato", c, c.MontoActual)
+			cursorFecha = fin
+		}
+		monto = c.MontoActual
+		motivo = c.MotivoFinalizacion
+		if motivo == "" {
+			motivo = "Contrato " + string(c.Estado)
+		}
+		anterior = c
+	}
+	if !abierto {
+		nuevo(false, cursorFecha, nil, motivo, anterior, monto)
+	}
+
+	_, err = s.coll.InsertMany(ctx, tramos)
+	return err
+}
+
+// eliminarPorPropiedad borra el historial de una propiedad eliminada.
+func (s *OcupacionService) eliminarPorPropiedad(ctx context.Context, propID primitive.ObjectID) error {
+	_, err := s.coll.DeleteMany(ctx, bson.M{"propiedad_id": propID})
+	return err
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+func (s *OcupacionService) tramos(ctx context.Context, filtro bson.M) ([]models.TramoOcupacion, error) {
+	cursor, err := s.coll.Find(ctx, filtro, options.Find().SetSort(bson.D{{Key: "desde", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	tramos := []models.TramoOcupacion{}
+	if err := cursor.All(ctx, &tramos); err != nil {
+		return nil, err
+	}
+	return tramos, nil
+}
+
+// tramosEnRango devuelve los tramos de todas las propiedades que se superponen con [desde, hasta).
+func (s *OcupacionService) tramosEnRango(ctx context.Context, desde, hasta time.Time) ([]models.TramoOcupacion, error) {
+	return s.tramos(ctx, bson.M{
+		"desde": bson.M{"$lt": hasta},
+		"$or": bson.A{
+			bson.M{"hasta": bson.M{"$exists": false}},
+			bson.M{"hasta": bson.M{"$gt": desde}},
+		},
+	})
+}
+
+// tramosDePropiedades devuelve los tramos de las propiedades indicadas que se
+// superponen con [desde, hasta).
+func (s *OcupacionService) tramosDePropiedades(ctx context.Context, ids []primitive.ObjectID, desde, hasta time.Time) ([]models.TramoOcupacion, error) {
+	return s.tramos(ctx, bson.M{
+		"propiedad_id": bson.M{"$in": ids},
+		"desde":        bson.M{"$lt": hasta},
+		"$or": bson.A{
+			bson.M{"hasta": bson.M{"$exists": false}},
+			bson.M{"hasta": bson.M{"$gt": desde}},
+		},
+	})
+}
+
+// tramosAl devuelve, por propiedad, el tramo que incluye la fecha.
+func tramosAl(tramos []models.TramoOcupacion, fecha time.Time) map[primitive.ObjectID]models.TramoOcupacion {
+	res := map[primitive.ObjectID]models.TramoOcupacion{}
+	for _, t := range tramos {
+		if !t.Desde.After(fecha) && (t.Hasta == nil || t.Hasta.After(fecha)) {
+			res[t.PropiedadID] = t
+		}
+	}
+	return res
+}
+
+// metricasOcupacion cuenta los días ocupados y desocupados de los tramos dentro
+// de [desde, hasta). El tramo en curso se cuenta hasta hoy inclusive.
+func metricasOcupacion(tramos []models.TramoOcupacion, desde, hasta time.Time) models.MetricasOcupacion {
+	hoy := finDeHoy()
+	var m models.MetricasOcupacion
+	var totalVacancia int
+	for _, t := range tramos {
+		fin := finTramo(t, hoy)
+		ini, finRango := t.Desde, fin
+		if ini.Before(desde) {
+			ini = desde
+		}
+		if finRango.After(hasta) {
+			finRango = hasta
+		}
+		if !finRango.After(ini) {
+			continue
+		}
+		dias := diasEntre(ini, finRango)
+		if t.Ocupada {
+			m.DiasOcupada += dias
+			continue
+		}
+		m.DiasDesocupada += dias
+		m.IngresoPerdido += ingresoPerdido(t.AlquilerMensual, dias)
+		m.Vacancias++
+		duracion := diasEntre(t.Desde, fin)
+		totalVacancia += duracion
+		if duracion > m.VacanciaMaximaDias {
+			m.VacanciaMaximaDias = duracion
+		}
+	}
+	if total := m.DiasOcupada + m.DiasDesocupada; total > 0 {
+		m.TasaOcupacion = roundDos(float64(m.DiasOcupada) / float64(total) * 100)
+	}
+	if m.Vacancias > 0 {
+		m.VacanciaPromedioDias = roundDos(float64(totalVacancia) / float64(m.Vacancias))
+	}
+	m.IngresoPerdido = roundDos(m.IngresoPerdido)
+	return m
+}
+
+// ingresoPerdido estima el alquiler de los días desocupados (mes de 30 días).
+func ingresoPerdido(alquilerMensual float64, dias int) float64 {
+	return roundDos(alquilerMensual * float64(dias) / 30)
+}
+
+// finTramo es el fin del tramo, o el de hoy si está en curso.
+func finTramo(t models.TramoOcupacion, hoy time.Time) time.Time {
+	if t.Hasta != nil && t.Hasta.Before(hoy) {
+		return *t.Hasta
+	}
+	return hoy
+}
+
+// finDeHoy es el comienzo de mañana: el día de hoy cuenta en los tramos en curso.
+func finDeHoy() time.Time {
+	return inicioDia(time.Now()).AddDate(0, 0, 1)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMetricasOcupacion(t *testing.T) {
+	fecha := func(anio int, mes time.Month, dia int) time.Time {
+		return time.Date(anio, mes, dia, 0, 0, 0, 0, time.Local)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+	propA, propB := primitive.NewObjectID(), primitive.NewObjectID()
+	tramo := func(prop primitive.ObjectID, ocupada bool, desde time.Time, hasta *time.Time, alquiler float64) models.TramoOcupacion {
+		return models.TramoOcupacion{PropiedadID: prop, Ocupada: ocupada, Desde: desde, Hasta: hasta, AlquilerMensual: alquiler}
+	}
+	anio := fecha(2025, time.January, 1)
+	finAnio := fecha(2026, time.January, 1)
+	hace9Dias := inicioDia(time.Now()).AddDate(0, 0, -9)
+
+	casos := []struct {
+		nombre       string
+		tramos       []models.TramoOcupacion
+		desde, hasta time.Time
+		want         models.MetricasOcupacion
+	}{
+		{
+			nombre: "ocupada todo el rango",
+			tramos: []models.TramoOcupacion{tramo(propA, true, fecha(2024, time.June, 1), ptr(fecha(2026, time.March, 1)), 100000)},
+			desde:  anio, hasta: finAnio,
+			want: models.MetricasOcupacion{DiasOcupada: 365, TasaOcupacion: 100},
+		},
+		{
+			nombre: "vacancia dentro del rango",
+			tramos: []models.TramoOcupacion{
+				tramo(propA, true, fecha(2024, time.January, 1), ptr(fecha(2025, time.March, 1)), 30000),
+				tramo(propA, false, fecha(2025, time.March, 1), ptr(fecha(2025, time.April, 1)), 30000),
+				tramo(propA, true, fecha(2025, time.April, 1), ptr(fecha(2026, time.February, 1)), 35000),
+			},
+			desde: anio, hasta: finAnio,
+			want: models.MetricasOcupacion{DiasOcupada: 334, DiasDesocupada: 31, TasaOcupacion: 91.51,
+				Vacancias: 1, VacanciaPromedioDias: 31, VacanciaMaximaDias: 31, IngresoPerdido: 31000},
+		},
+		{
+			nombre: "la vacancia empezada antes cuenta entera en la duración",
+			tramos: []models.TramoOcupacion{
+				tramo(propA, false, fecha(2024, time.November, 1), ptr(fecha(2025, time.February, 1)), 60000),
+				tramo(propA, true, fecha(2025, time.February, 1), nil, 60000),
+			},
+			desde: anio, hasta: finAnio,
+			want: models.MetricasOcupacion{DiasOcupada: 334, DiasDesocupada: 31, TasaOcupacion: 91.51,
+				Vacancias: 1, VacanciaPromedioDias: 92, VacanciaMaximaDias: 92, IngresoPerdido: 62000},
+		},
+		{
+			nombre: "varias propiedades",
+			tramos: []models.TramoOcupacion{
+				tramo(propA, false, anio, ptr(fecha(2025, time.January, 11)), 0),
+				tramo(propA, true, fecha(2025, time.January, 11), nil, 50000),
+				tramo(propB, true, fecha(2024, time.March, 1), ptr(fecha(2025, time.December, 22)), 45000),
+				tramo(propB, false, fecha(2025, time.December, 22), ptr(fecha(2026, time.January, 11)), 45000),
+			},
+			desde: anio, hasta: finAnio,
+			want: models.MetricasOcupacion{DiasOcupada: 710, DiasDesocupada: 20, TasaOcupacion: 97.26,
+				Vacancias: 2, VacanciaPromedioDias: 15, VacanciaMaximaDias: 20, IngresoPerdido: 15000},
+		},
+		{
+			nombre: "tramos fuera del rango",
+			tramos: []models.TramoOcupacion{tramo(propA, false, fecha(2023, time.January, 1), ptr(anio), 80000)},
+			desde:  anio, hasta: finAnio,
+			want: models.MetricasOcupacion{},
+		},
+		{
+			nombre: "el tramo en curso cuenta hasta hoy inclusive",
+			tramos: []models.TramoOcupacion{tramo(propA, true, hace9Dias, nil, 100000)},
+			desde:  hace9Dias, hasta: hace9Dias.AddDate(1, 0, 0),
+			want: models.MetricasOcupacion{DiasOcupada: 10, TasaOcupacion: 100},
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := metricasOcupacion(c.tramos, c.desde, c.hasta); got != c.want {
+				t.Errorf("metricasOcupacion() = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// El procesamiento en segundo plano deja pasar este tiempo antes de tomar una
+// operación: la aplica primero quien la registró, después de escribir el período.
+const esperaOperacionCaja = time.Minute
+
+// referenciaCobro es el reference_id del movimiento de caja de un cobro. Al ser
+// fijo, reintentar la operación no duplica el movimiento.
+func referenciaCobro(cobroID primitive.ObjectID) string {
+	return "ALQ-" + cobroID.Hex()
+}
+
+// OperacionCajaService mantiene el outbox de caja (colección operaciones_caja):
+// los movimientos de MySQL que deben crearse o anularse por los cobros de
+// alquiler registrados en MongoDB.
+type OperacionCajaService struct {
+	coll     *mongo.Collection
+	periodos *mongo.Collection
+	recibos  *mongo.Collection
+}
+
+func NewOperacionCajaService() *OperacionCajaService {
+	return &OperacionCajaService{
+		coll:     database.MongoDB.Collection(database.CollectionOperacionesCaja),
+		periodos: database.MongoDB.Collection(database.CollectionPeriodosAlquiler),
+		recibos:  database.MongoDB.Collection(database.CollectionRecibos),
+	}
+}
+
+// Start programa el reintento de las operaciones pendientes cada 5 minutos y
+// la conciliación diaria de cobros y caja.
+func (s *OperacionCajaService) Start() {
+	if _, err := GetScheduler().Programar("operaciones-caja", "*/5 * * * *", func() {
+		res, err := s.ProcesarPendientes()
+		if err != nil {
+			utils.Logger.Error("Error procesando operaciones de caja pendientes", zap.Error(err))
+			return
+		}
+		if res.Procesadas > 0 {
+			utils.Logger.Info("Operaciones de caja procesadas",
+				zap.Int("aplicadas", res.Aplicadas), zap.Int("canceladas", res.Canceladas), zap.Int("pendientes", res.Pendientes))
+		}
+	}); err != nil {
+		utils.Logger.Error("Error programando las operaciones de caja", zap.Error(err))
+	}
+	if _, err := GetScheduler().Programar("conciliacion-caja", "30 3 * * *", NewConciliacionService().conciliacionProgramada); err != nil {
+		utils.Logger.Error("Error programando la conciliación de caja", zap.Error(err))
+	}
+}
+
+// encolar registra una operación pendiente.
+func (s *OperacionCajaService) encolar(ctx context.Context, op *models.OperacionCaja) error {
+	op.ID = primitive.NewObjectID()
+	op.Estado = models.OpPendiente
+	op.CreatedAt = time.Now()
+	if op.Fecha.IsZero() {
+		op.Fecha = op.CreatedAt
+	}
+	_, err := s.coll.InsertOne(ctx, op)
+	return err
+}
+
+// cancelar descarta una operación pendiente (la escritura en Mongo que
+// acompañaba falló).
+func (s *OperacionCajaService) cancelar(ctx context.Context, id primitive.ObjectID) {
+	_, err := s.coll.UpdateOne(ctx, bson.M{"_id": id, "estado": models.OpPendiente},
+		bson.M{"$set": bson.M{"estado": models.OpCancelada}})
+	if err != nil {
+		utils.Logger.Warn("No se pudo cancelar la operación de caja", zap.String("operacion", id.Hex()), zap.Error(err))
+	}
+}
+
+// cancelarCreacion descarta la creación pendiente del movimiento de un cobro revertido.
+func (s *OperacionCajaService) cancelarCreacion(ctx context.Context, cobroID primitive.ObjectID) {
+	_, err := s.coll.UpdateMany(ctx,
+		bson.M{"tipo": models.OpCrearMovimiento, "cobro_id": cobroID, "estado": models.OpPendiente},
+		bson.M{"$set": bson.M{"estado": models.OpCancelada}})
+	if err != nil {
+		utils.Logger.Warn("No se pudo cancelar el movimiento pendiente del cobro", zap.String("cobro", cobroID.Hex()), zap.Error(err))
+	}
+}
+
+// aplicar ejecuta la operación en MySQL y deja el resultado en el período.
+// Es idempotente: el movimiento se crea con una referencia fija y la anulación
+// de un movimiento ya anulado no cambia nada. Devuelve el movimiento creado o anulado.
+func (s *OperacionCajaService) aplicar(ctx context.Context, op *models.OperacionCaja) (*uint, error) {
+	var movID *uint
+	var err error
+	vigente := true
+	switch op.Tipo {
+	case models.OpCrearMovimiento:
+		movID, vigente, err = s.crearMovimiento(ctx, op)
+	case models.OpAnularMovimiento:
+		movID, vigente, err = s.anularMovimiento(ctx, op)
+	default:
+		err = errors.New("tipo de operación desconocido: " + op.Tipo)
+	}
+
+	if err != nil {
+		set := bson.M{"ultimo_error": err.Error()}
+		if errors.Is(err, ErrSinCajaDestino) || errors.Is(err, ErrCajaDestinoCerrada) {
+			// Reintentarla no sirve: queda para revisión manual (ver Reasignar)
+			set["estado"] = models.OpRevision
+			op.Estado = models.OpRevision
+		}
+		s.coll.UpdateOne(ctx, bson.M{"_id": op.ID}, bson.M{
+			"$inc": bson.M{"intentos": 1},
+			"$set": set,
+		})
+		return nil, err
+	}
+
+	now := time.Now()
+	set := bson.M{"estado": models.OpAplicada, "aplicada_en": now}
+	op.Estado = models.OpAplicada
+	if !vigente {
+		set = bson.M{"estado": models.OpCancelada}
+		op.Estado = models.OpCancelada
+	}
+	if movID != nil {
+		set["movement_id"] = *movID
+	}
+	if _, err := s.coll.UpdateOne(ctx, bson.M{"_id": op.ID, "estado": models.OpPendiente},
+		bson.M{"$set": set, "$unset": bson.M{"ultimo_error": ""}}); err != nil {
+		return nil, err
+	}
+	op.MovementID = movID
+	return movID, nil
+}
+
+// crearMovimiento crea el movimiento de un cobro y lo vincula al período y al
+// recibo. Devuelve vigente=false si el cobro ya no existe (se revirtió).
+func (s *OperacionCajaService) crearMovimiento(ctx context.Context, op *models.OperacionCaja) (*uint, bool, error) {
+	filtro := bson.M{"_id": op.PeriodoID, "movement_id": bson.M{"$exists": false}}
+	if op.CobroID != nil {
+		filtro = bson.M{"_id": op.PeriodoID, "cobros._id": *op.CobroID}
+	}
+	if n, err := s.periodos.CountDocuments(ctx, filtro); err != nil {
+		return nil, false, err
+	} else if n == 0 {
+		return nil, false, nil
+	}
+
+	conceptID := NewAlquilerService().getOrCreateAlquilerConcept(op.UsuarioID)
+	if conceptID == 0 {
+		return nil, false, errors.New("no se pudo obtener el concepto de alquiler")
+	}
+	movID, err := crearMovimientoCajaRef("Ingreso", conceptID, op.Monto, op.Detalle, op.UsuarioID, op.ArcoID, op.Referencia, op.Fecha)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if op.CobroID == nil {
+		// Pago anterior a los cobros parciales: el movimiento es del período
+		_, err = s.periodos.UpdateOne(ctx, filtro, bson.M{"$set": bson.M{"movement_id": *movID}})
+		return movID, true, err
+	}
+
+	res, err := s.periodos.UpdateOne(ctx, filtro, bson.M{"$set": bson.M{"cobros.$.movement_id": *movID}})
+	if err != nil {
+		return nil, false, err
+	}
+	if res.MatchedCount == 0 {
+		// El cobro se revirtió mientras se creaba el movimiento: se anula
+		anular := &models.OperacionCaja{
+			Tipo:        models.OpAnularMovimiento,
+			PropiedadID: op.PropiedadID,
+			PeriodoID:   op.PeriodoID,
+			CobroID:     op.CobroID,
+			Anio:        op.Anio,
+			Mes:         op.Mes,
+			MovementID:  movID,
+			UsuarioID:   op.UsuarioID,
+		}
+		if err := s.encolar(ctx, anular); err == nil {
+			s.aplicar(ctx, anular)
+		}
+		return movID, false, nil
+	}
+
+	// El movement_id del período es el del último cobro
+	var periodo models.PeriodoAlquiler
+	if err := s.periodos.FindOne(ctx, bson.M{"_id": op.PeriodoID}).Decode(&periodo); err == nil &&
+		len(periodo.Cobros) > 0 && periodo.Cobros[len(periodo.Cobros)-1].ID == *op.CobroID {
+		s.periodos.UpdateOne(ctx, bson.M{"_id": op.PeriodoID}, bson.M{"$set": bson.M{"movement_id": *movID}})
+	}
+	s.recibos.UpdateMany(ctx, bson.M{"cobro_id": *op.CobroID}, bson.M{"$set": bson.M{"movement_id": *movID}})
+	return movID, true, nil
+}
+
+// anularMovimiento anula el movimiento de un cobro revertido. Devuelve
+// vigente=false si el cobro sigue registrado con ese movimiento (la reversión
+// no llegó a guardarse).
+func (s *OperacionCajaService) anularMovimiento(ctx context.Context, op *models.OperacionCaja) (*uint, bool, error) {
+	if op.MovementID == nil {
+		return nil, false, errors.New("la operación no indica el movimiento a anular")
+	}
+	filtro := bson.M{"_id": op.PeriodoID, "movement_id": *op.MovementID, "cobros": bson.M{"$exists": false}}
+	if op.CobroID != nil {
+		filtro = bson.M{"_id": op.PeriodoID, "cobros": bson.M{"$elemMatch": bson.M{"_id": *op.CobroID, "movement_id": *op.MovementID}}}
+	}
+	if n, err := s.periodos.CountDocuments(ctx, filtro); err != nil {
+		return nil, false, err
+	} else if n > 0 {
+		return op.MovementID, false, nil
+	}
+
+	if err := NewMovementService().SoftDeleteMovement(*op.MovementID, op.UsuarioID); err != nil {
+		return nil, false, err
+	}
+	return op.MovementID, true, nil
+}
+
+// Reasignar cambia la caja destino de la creación de un movimiento pendiente o
+// en revisión (por ejemplo, porque la caja elegida se cerró) y la aplica.
+func (s *OperacionCajaService) Reasignar(id string, arcoID uint) (*models.OperacionCaja, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := arcoDestino(arcoID, 0); err != nil {
+		return nil, err
+	}
+	var op models.OperacionCaja
+	err = s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "tipo": models.OpCrearMovimiento, "estado": bson.M{"$in": bson.A{models.OpPendiente, models.OpRevision}}},
+		bson.M{"$set": bson.M{"arco_id": arcoID, "estado": models.OpPendiente}, "$unset": bson.M{"ultimo_error": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&op)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("operación no encontrada o ya aplicada")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.aplicar(ctx, &op); err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// ProcesarPendientes reintenta las operaciones pendientes, de la más antigua a
+// la más nueva.
+func (s *OperacionCajaService) ProcesarPendientes() (*models.ResultadoOperacionesCaja, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx,
+		bson.M{"estado": models.OpPendiente, "created_at": bson.M{"$lt": time.Now().Add(-esperaOperacionCaja)}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(500))
+	if err != nil {
+		return nil, err
+	}
+	var ops []models.OperacionCaja
+	if err := cursor.All(ctx, &ops); err != nil {
+		return nil, err
+	}
+
+	res := &models.ResultadoOperacionesCaja{}
+	for i := range ops {
+		res.Procesadas++
+		if _, err := s.aplicar(ctx, &ops[i]); err != nil {
+			res.Pendientes++
+			res.Errores = append(res.Errores, ops[i].ID.Hex()+": "+err.Error())
+			continue
+		}
+		if ops[i].Estado == models.OpCancelada {
+			res.Canceladas++
+		} else {
+			res.Aplicadas++
+		}
+	}
+	return res, nil
+}
+
+// Listar devuelve las operaciones de caja, las más recientes primero
+// (estado vacío = todas).
+func (s *OperacionCajaService) Listar(estado string) ([]models.OperacionCaja, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if estado != "" {
+		filter["estado"] = estado
+	}
+	cursor, err := s.coll.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(500))
+	if err != nil {
+		return nil, err
+	}
+	ops := []models.OperacionCaja{}
+	if err := cursor.All(ctx, &ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
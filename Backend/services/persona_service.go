@@ -0,0 +1,553 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// PersonaService maneja el padrón de inquilinos y garantes (colección personas).
+// Los contratos referencian a las personas por ID y guardan una copia del nombre.
+type PersonaService struct {
+	coll      *mongo.Collection
+	contratos *mongo.Collection
+	props     *mongo.Collection
+}
+
+func NewPersonaService() *PersonaService {
+	return &PersonaService{
+		coll:      database.MongoDB.Collection(database.CollectionPersonas),
+		contratos: database.MongoDB.Collection(database.CollectionContratos),
+		props:     database.MongoDB.Collection(database.CollectionPropiedades),
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CRUD Personas
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Listar busca personas por nombre, documento, teléfono o email. `rol`
+// (inquilino | garante) limita a quienes tuvieron ese rol en algún contrato.
+func (s *PersonaService) Listar(busqueda, rol string) ([]models.Persona, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if busqueda = strings.TrimSpace(busqueda); busqueda != "" {
+		patron := bson.M{"$regex": regexp.QuoteMeta(busqueda), "$options": "i"}
+		or := bson.A{
+			bson.M{"nombre": patron},
+			bson.M{"email": patron},
+			bson.M{"telefono": patron},
+		}
+		if doc := normalizarDocumento(busqueda); doc != "" {
+			or = append(or, bson.M{"documento": bson.M{"$regex": regexp.QuoteMeta(doc)}})
+		}
+		filter["$or"] = or
+	}
+
+	if rol != "" {
+		campo := "inquilino_id"
+		if rol == models.RolGarante {
+			campo = "garantes_ids"
+		}
+		ids, err := s.contratos.Distinct(ctx, campo, bson.M{campo: bson.M{"$exists": true}})
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$in": ids}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "nombre", Value: 1}}).SetLimit(200)
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	personas := []models.Persona{}
+	if err := cursor.All(ctx, &personas); err != nil {
+		return nil, err
+	}
+	return personas, nil
+}
+
+// GetDetalle devuelve la ficha de la persona con todos sus contratos (como
+// inquilino o garante) y el historial de pagos de los contratos en que fue inquilino.
+func (s *PersonaService) GetDetalle(id string) (*models.PersonaDetalle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	p, err := s.getPersona(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.contratos.Find(ctx,
+		bson.M{"$or": bson.A{bson.M{"inquilino_id": p.ID}, bson.M{"garantes_ids": p.ID}}},
+		options.Find().SetSort(bson.D{{Key: "fecha_inicio", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var contratos []models.Contrato
+	if err := cursor.All(ctx, &contratos); err != nil {
+		return nil, err
+	}
+
+	// Direcciones de las propiedades involucradas
+	propIDs := []primitive.ObjectID{}
+	for _, c := range contratos {
+		propIDs = append(propIDs, c.PropiedadID)
+	}
+	direcciones, err := s.direcciones(ctx, propIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	detalle := &models.PersonaDetalle{
+		Persona:   *p,
+		Roles:     []string{},
+		Contratos: []models.ContratoPersona{},
+		Pagos:     []models.PagoPersona{},
+	}
+	roles := map[string]bool{}
+	comoInquilino := []primitive.ObjectID{}
+	contratoPorID := map[primitive.ObjectID]models.Contrato{}
+	for _, c := range contratos {
+		rol := models.RolGarante
+		if c.InquilinoID != nil && *c.InquilinoID == p.ID {
+			rol = models.RolInquilino
+			comoInquilino = append(comoInquilino, c.ID)
+			contratoPorID[c.ID] = c
+		}
+		roles[rol] = true
+		detalle.Contratos = append(detalle.Contratos, models.ContratoPersona{
+			Rol:       rol,
+			Direccion: direcciones[c.PropiedadID],
+			Contrato:  c,
+		})
+	}
+	for _, r := range []string{models.RolInquilino, models.RolGarante} {
+		if roles[r] {
+			detalle.Roles = append(detalle.Roles, r)
+		}
+	}
+
+	if len(comoInquilino) == 0 {
+		return detalle, nil
+	}
+
+	periodos, err := NewAlquilerService().listarPeriodos(ctx, bson.M{"contrato_id": bson.M{"$in": comoInquilino}})
+	if err != nil {
+		return nil, err
+	}
+	indiceActual := indiceMes(time.Now())
+	for _, per := range periodos {
+		c := contratoPorID[*per.ContratoID]
+		base := models.PagoPersona{
+			ContratoID:  c.ID,
+			PropiedadID: c.PropiedadID,
+			Direccion:   direcciones[c.PropiedadID],
+			Anio:        per.Anio,
+			Mes:         per.Mes,
+		}
+		if len(per.Cobros) == 0 && per.Estado == models.PagadoEstado {
+			// Pago anterior a los cobros parciales
+			pago := base
+			pago.Fecha = per.FechaPago
+			pago.Monto = per.Cobrado()
+			pago.MovementID = per.MovementID
+			detalle.Pagos = append(detalle.Pagos, pago)
+		}
+		for _, cobro := range per.Cobros {
+			pago := base
+			fecha := cobro.Fecha
+			pago.Fecha = &fecha
+			pago.Monto = cobro.Monto
+			pago.Punitorio = cobro.Punitorio
+			pago.MovementID = cobro.MovementID
+			detalle.Pagos = append(detalle.Pagos, pago)
+		}
+
+		detalle.TotalPagado += per.Cobrado()
+		if per.Estado != models.PagadoEstado && per.Indice() < indiceActual {
+			detalle.TotalAdeudado += per.Saldo()
+			detalle.MesesAdeudados++
+		}
+	}
+	// Historial del más reciente al más antiguo
+	sort.SliceStable(detalle.Pagos, func(i, j int) bool {
+		a, b := detalle.Pagos[i].Fecha, detalle.Pagos[j].Fecha
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.After(*b)
+	})
+	detalle.TotalPagado = roundDos(detalle.TotalPagado)
+	detalle.TotalAdeudado = roundDos(detalle.TotalAdeudado)
+	return detalle, nil
+}
+
+// Crear da de alta una persona en el padrón.
+func (s *PersonaService) Crear(req models.PersonaRequest, createdBy uint) (*models.Persona, error) {
+	now := time.Now()
+	p := models.Persona{
+		ID:        primitive.NewObjectID(),
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	aplicarPersonaRequest(&p, req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.coll.InsertOne(ctx, p); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("ya existe una persona con ese documento")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Actualizar modifica los datos de una persona. Si cambia el nombre se
+// actualiza la copia en sus contratos y en la propiedad del contrato vigente.
+func (s *PersonaService) Actualizar(id string, req models.PersonaRequest, userID uint) (*models.Persona, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p, err := s.getPersona(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	nombreAnterior := p.Nombre
+	aplicarPersonaRequest(p, req)
+	p.UpdatedAt = time.Now()
+
+	if _, err := s.coll.ReplaceOne(ctx, bson.M{"_id": p.ID}, p); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("ya existe una persona con ese documento")
+		}
+		return nil, err
+	}
+
+	if p.Nombre != nombreAnterior {
+		if err := s.propagarNombre(ctx, p, nombreAnterior, userID); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Eliminar borra una persona que no figura en ningún contrato.
+func (s *PersonaService) Eliminar(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := s.getPersona(ctx, id)
+	if err != nil {
+		return err
+	}
+	n, err := s.contratos.CountDocuments(ctx, bson.M{"$or": bson.A{bson.M{"inquilino_id": p.ID}, bson.M{"garantes_ids": p.ID}}})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("la persona figura en contratos y no se puede eliminar")
+	}
+	_, err = s.coll.DeleteOne(ctx, bson.M{"_id": p.ID})
+	return err
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Vinculación con contratos
+// ─────────────────────────────────────────────────────────────────────────────
+
+// buscarOCrearPorNombre devuelve la persona con ese nombre (sin distinguir
+// mayúsculas) que ya figura en un contrato de la misma propiedad (el mismo
+// inquilino que renueva, un garante que se repite) o la crea con solo el nombre
+// para completar la ficha después. Fuera de la propiedad el nombre no alcanza
+// para identificar a alguien: dos homónimos quedan en fichas distintas.
+func (s *PersonaService) buscarOCrearPorNombre(ctx context.Context, nombre string, propiedadID primitive.ObjectID, createdBy uint) (*models.Persona, error) {
+	nombre = strings.TrimSpace(nombre)
+	if nombre == "" {
+		return nil, errors.New("nombre vacío")
+	}
+
+	var p models.Persona
+	conocidas, err := s.personasDePropiedad(ctx, propiedadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(conocidas) > 0 {
+		err := s.coll.FindOne(ctx,
+			bson.M{
+				"_id":    bson.M{"$in": conocidas},
+				"nombre": bson.M{"$regex": "^" + regexp.QuoteMeta(nombre) + "$", "$options": "i"},
+			},
+			options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+		).Decode(&p)
+		if err == nil {
+			return &p, nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	p = models.Persona{
+		ID:        primitive.NewObjectID(),
+		Nombre:    nombre,
+		Notas:     "Alta automática desde un contrato",
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := s.coll.InsertOne(ctx, p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// personasDePropiedad devuelve los inquilinos y garantes de los contratos de
+// una propiedad.
+func (s *PersonaService) personasDePropiedad(ctx context.Context, propiedadID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	ids := []primitive.ObjectID{}
+	for _, campo := range []string{"inquilino_id", "garantes_ids"} {
+		valores, err := s.contratos.Distinct(ctx, campo, bson.M{"propiedad_id": propiedadID})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range valores {
+			if id, ok := v.(primitive.ObjectID); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// resolverIDs busca las personas indicadas (IDs en hex) y valida que existan.
+func (s *PersonaService) resolverIDs(ctx context.Context, ids []string) ([]models.Persona, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errors.New("ID de persona inválido: " + id)
+		}
+		objIDs = append(objIDs, objID)
+	}
+	if len(objIDs) == 0 {
+		return []models.Persona{}, nil
+	}
+
+	cursor, err := s.coll.Find(ctx, bson.M{"_id": bson.M{"$in": objIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var personas []models.Persona
+	if err := cursor.All(ctx, &personas); err != nil {
+		return nil, err
+	}
+	if len(personas) != len(objIDs) {
+		return nil, errors.New("alguna de las personas indicadas no existe")
+	}
+	return personas, nil
+}
+
+// vincularContrato completa InquilinoID / GarantesIDs del contrato a partir de
+// los IDs recibidos o, en su defecto, de los nombres (buscando o creando la
+// ficha, ver buscarOCrearPorNombre).
+func (s *PersonaService) vincularContrato(ctx context.Context, c *models.Contrato, inquilinoID string, garantesIDs []string) error {
+	if inquilinoID != "" {
+		personas, err := s.resolverIDs(ctx, []string{inquilinoID})
+		if err != nil {
+			return err
+		}
+		c.InquilinoID = &personas[0].ID
+		c.Inquilino = personas[0].Nombre
+	} else if c.InquilinoID == nil && strings.TrimSpace(c.Inquilino) != "" {
+		p, err := s.buscarOCrearPorNombre(ctx, c.Inquilino, c.PropiedadID, c.CreatedBy)
+		if err != nil {
+			return err
+		}
+		c.InquilinoID = &p.ID
+	}
+
+	garantes, err := s.resolverIDs(ctx, garantesIDs)
+	if err != nil {
+		return err
+	}
+	// Garantes cargados solo con nombre
+	for _, nombre := range c.Garantes {
+		if strings.TrimSpace(nombre) == "" {
+			continue
+		}
+		p, err := s.buscarOCrearPorNombre(ctx, nombre, c.PropiedadID, c.CreatedBy)
+		if err != nil {
+			return err
+		}
+		garantes = append(garantes, *p)
+	}
+
+	vistos := map[primitive.ObjectID]bool{}
+	c.Garantes = []string{}
+	c.GarantesIDs = []primitive.ObjectID{}
+	for _, g := range garantes {
+		if vistos[g.ID] {
+			continue
+		}
+		vistos[g.ID] = true
+		c.Garantes = append(c.Garantes, g.Nombre)
+		c.GarantesIDs = append(c.GarantesIDs, g.ID)
+	}
+	return nil
+}
+
+// propagarNombre actualiza la copia del nombre en contratos y propiedades.
+func (s *PersonaService) propagarNombre(ctx context.Context, p *models.Persona, nombreAnterior string, userID uint) error {
+	now := time.Now()
+	if _, err := s.contratos.UpdateMany(ctx,
+		bson.M{"inquilino_id": p.ID},
+		bson.M{"$set": bson.M{"inquilino": p.Nombre, "updated_at": now}},
+	); err != nil {
+		return err
+	}
+	if _, err := s.contratos.UpdateMany(ctx,
+		bson.M{"garantes_ids": p.ID, "garantes": nombreAnterior},
+		bson.M{"$set": bson.M{"garantes.$": p.Nombre, "updated_at": now}},
+	); err != nil {
+		return err
+	}
+
+	vigentes, err := s.contratos.Distinct(ctx, "_id", bson.M{"inquilino_id": p.ID, "estado": models.ContratoVigente})
+	if err != nil {
+		return err
+	}
+	historial := NewHistorialPropiedadService()
+	for _, contratoID := range vigentes {
+		if _, err := historial.actualizarPropiedad(ctx,
+			bson.M{"contrato_vigente_id": contratoID},
+			bson.M{"$set": bson.M{"inquilino": p.Nombre, "updated_at": now}},
+			models.OperacionNombreInquilino, userID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrarPersonasLegacy vincula al padrón los contratos que solo tienen el
+// nombre del inquilino o de los garantes.
+func (s *PersonaService) MigrarPersonasLegacy() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := s.contratos.Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"inquilino_id": bson.M{"$exists": false}, "inquilino": bson.M{"$gt": ""}},
+		bson.M{"garantes_ids": bson.M{"$exists": false}, "garantes.0": bson.M{"$exists": true}},
+	}})
+	if err != nil {
+		return 0, err
+	}
+	var contratos []models.Contrato
+	if err := cursor.All(ctx, &contratos); err != nil {
+		return 0, err
+	}
+
+	migrados := 0
+	for i := range contratos {
+		c := &contratos[i]
+		if err := s.vincularContrato(ctx, c, "", nil); err != nil {
+			return migrados, err
+		}
+		set := bson.M{"garantes": c.Garantes, "garantes_ids": c.GarantesIDs}
+		if c.InquilinoID != nil {
+			set["inquilino_id"] = *c.InquilinoID
+		}
+		if _, err := s.contratos.UpdateOne(ctx, bson.M{"_id": c.ID}, bson.M{"$set": set}); err != nil {
+			return migrados, err
+		}
+		migrados++
+	}
+
+	if migrados > 0 {
+		utils.Logger.Info("Contratos vinculados al padrón de personas", zap.Int("contratos", migrados))
+	}
+	return migrados, nil
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+func (s *PersonaService) getPersona(ctx context.Context, id string) (*models.Persona, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	var p models.Persona
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("persona no encontrada")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// direcciones devuelve la dirección de cada propiedad indicada.
+func (s *PersonaService) direcciones(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]string, error) {
+	res := map[primitive.ObjectID]string{}
+	if len(ids) == 0 {
+		return res, nil
+	}
+	cursor, err := s.props.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"direccion": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var props []models.Propiedad
+	if err := cursor.All(ctx, &props); err != nil {
+		return nil, err
+	}
+	for _, p := range props {
+		res[p.ID] = p.Direccion
+	}
+	return res, nil
+}
+
+func aplicarPersonaRequest(p *models.Persona, req models.PersonaRequest) {
+	p.Nombre = strings.TrimSpace(req.Nombre)
+	p.TipoDocumento = req.TipoDocumento
+	p.Documento = normalizarDocumento(req.Documento)
+	p.Telefono = strings.TrimSpace(req.Telefono)
+	p.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	p.Domicilio = strings.TrimSpace(req.Domicilio)
+	p.Notas = req.Notas
+}
+
+// normalizarDocumento deja solo letras y dígitos en mayúscula
+// ("20-12345678-9" → "20123456789", "aa 123" → "AA123").
+func normalizarDocumento(doc string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(doc) {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PropietarioService maneja los dueños de las propiedades administradas por
+// cuenta de terceros (colección propietarios).
+type PropietarioService struct {
+	coll  *mongo.Collection
+	props *mongo.Collection
+}
+
+func NewPropietarioService() *PropietarioService {
+	return &PropietarioService{
+		coll:  database.MongoDB.Collection(database.CollectionPropietarios),
+		props: database.MongoDB.Collection(database.CollectionPropiedades),
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CRUD Propietarios
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Listar busca propietarios por nombre, documento o email.
+func (s *PropietarioService) Listar(busqueda string) ([]models.Propietario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if busqueda = strings.TrimSpace(busqueda); busqueda != "" {
+		patron := bson.M{"$regex": regexp.QuoteMeta(busqueda), "$options": "i"}
+		or := bson.A{bson.M{"nombre": patron}, bson.M{"email": patron}}
+		if doc := normalizarDocumento(busqueda); doc != "" {
+			or = append(or, bson.M{"documento": bson.M{"$regex": regexp.QuoteMeta(doc)}})
+		}
+		filter["$or"] = or
+	}
+
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "nombre", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	propietarios := []models.Propietario{}
+	if err := cursor.All(ctx, &propietarios); err != nil {
+		return nil, err
+	}
+	return propietarios, nil
+}
+
+// GetDetalle devuelve el propietario con sus propiedades.
+func (s *PropietarioService) GetDetalle(id string) (*models.PropietarioDetalle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p, err := s.getPropietario(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := s.props.Find(ctx, bson.M{"propietario_id": p.ID},
+		options.Find().SetSort(bson.D{{Key: "direccion", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	detalle := &models.PropietarioDetalle{Propietario: *p, Propiedades: []models.Propiedad{}}
+	if err := cursor.All(ctx, &detalle.Propiedades); err != nil {
+		return nil, err
+	}
+	return detalle, nil
+}
+
+// Crear da de alta un propietario.
+func (s *PropietarioService) Crear(req models.PropietarioRequest, createdBy uint) (*models.Propietario, error) {
+	now := time.Now()
+	p := models.Propietario{
+		ID:        primitive.NewObjectID(),
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	aplicarPropietarioRequest(&p, req)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.coll.InsertOne(ctx, p); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("ya existe un propietario con ese documento")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Actualizar modifica los datos de un propietario. La comisión nueva solo
+// aplica a los contratos que se inicien después.
+func (s *PropietarioService) Actualizar(id string, req models.PropietarioRequest) (*models.Propietario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := s.getPropietario(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	aplicarPropietarioRequest(p, req)
+	p.UpdatedAt = time.Now()
+
+	if _, err := s.coll.ReplaceOne(ctx, bson.M{"_id": p.ID}, p); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("ya existe un propietario con ese documento")
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// Eliminar borra un propietario sin propiedades asignadas.
+func (s *PropietarioService) Eliminar(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	p, err := s.getPropietario(ctx, id)
+	if err != nil {
+		return err
+	}
+	n, err := s.props.CountDocuments(ctx, bson.M{"propietario_id": p.ID})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("el propietario tiene propiedades asignadas y no se puede eliminar")
+	}
+	_, err = s.coll.DeleteOne(ctx, bson.M{"_id": p.ID})
+	return err
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+// resolverID valida el propietario indicado al cargar una propiedad
+// ("" = propiedad propia).
+func (s *PropietarioService) resolverID(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	p, err := s.getPropietario(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &p.ID, nil
+}
+
+// comisionPorDefecto devuelve la comisión del propietario (0 si no existe).
+func (s *PropietarioService) comisionPorDefecto(ctx context.Context, id primitive.ObjectID) float64 {
+	var p models.Propietario
+	if err := s.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&p); err != nil {
+		return 0
+	}
+	return p.ComisionPct
+}
+
+func (s *PropietarioService) getPropietario(ctx context.Context, id string) (*models.Propietario, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID de propietario inválido")
+	}
+	var p models.Propietario
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&p); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("propietario no encontrado")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func aplicarPropietarioRequest(p *models.Propietario, req models.PropietarioRequest) {
+	p.Nombre = strings.TrimSpace(req.Nombre)
+	p.TipoDocumento = req.TipoDocumento
+	p.Documento = normalizarDocumento(req.Documento)
+	p.Telefono = strings.TrimSpace(req.Telefono)
+	p.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	p.Domicilio = strings.TrimSpace(req.Domicilio)
+	p.CBU = strings.TrimSpace(req.CBU)
+	p.Alias = strings.TrimSpace(req.Alias)
+	p.ComisionPct = req.ComisionPct
+	p.Notas = req.Notas
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProyeccionService proyecta el flujo de caja de los próximos meses combinando
+// los alquileres por cobrar (MongoDB) con los egresos recurrentes del libro de caja (MySQL).
+type ProyeccionService struct{}
+
+func NewProyeccionService() *ProyeccionService {
+	return &ProyeccionService{}
+}
+
+// GetProyeccion proyecta `meses` meses a partir del mes actual.
+//   - Alquileres: monto de contrato de cada propiedad ocupada, con los aumentos
+//     programados (FechaActualizacion + FrecuenciaActualizacion) estimados con la
+//     inflación promedio reciente, ponderado por la proporción de meses que el
+//     inquilino efectivamente pagó. Del mes en curso se proyecta el saldo del
+//     período (descontando los cobros parciales) más la deuda de meses anteriores.
+//   - Otros ingresos y egresos: promedio mensual por concepto de los últimos
+//     `mesesHistoria` meses completos, en pesos del último mes y ajustado por la
+//     misma inflación hacia adelante. Los retiros de caja no se proyectan.
+func (s *ProyeccionService) GetProyeccion(meses, mesesHistoria int) (*models.ProyeccionFlujo, error) {
+	if meses < 1 || meses > 36 {
+		return nil, errors.New("la cantidad de meses debe estar entre 1 y 36")
+	}
+	if mesesHistoria < 1 || mesesHistoria > 24 {
+		return nil, errors.New("la historia debe estar entre 1 y 24 meses")
+	}
+
+	ahora := time.Now()
+	mesActual := time.Date(ahora.Year(), ahora.Month(), 1, 0, 0, 0, 0, time.Local)
+
+	proy := &models.ProyeccionFlujo{
+		Desde:         claveMes(mesActual),
+		Meses:         meses,
+		MesesHistoria: mesesHistoria,
+		Periodos:      make([]models.PeriodoProyeccion, meses),
+		Propiedades:   []models.ProyeccionPropiedad{},
+		Conceptos:     []models.ProyeccionConcepto{},
+	}
+	for i := range proy.Periodos {
+		proy.Periodos[i].Periodo = claveMes(mesActual.AddDate(0, i, 0))
+	}
+
+	// Supuesto de inflación mensual: promedio de los últimos 6 meses publicados
+	indice, err := NewInflacionService().GetIndicePrecios()
+	inflacion := 0.0
+	if err == nil && len(indice.niveles) > 0 {
+		inflacion = indice.InflacionPromedio(6) / 100
+	} else {
+		indice = nil
+		proy.InflacionEstimada = true
+	}
+	proy.InflacionMensualPct = roundDos(inflacion * 100)
+
+	saldo, err := NewCajaGlobalService().GetSaldoEnFecha(ahora)
+	if err != nil {
+		return nil, err
+	}
+	proy.SaldoInicial = roundDos(saldo.SaldoTotal)
+
+	if err := s.proyectarAlquileres(proy, mesActual, inflacion); err != nil {
+		return nil, err
+	}
+	if err := s.proyectarConceptos(proy, mesActual, inflacion, indice); err != nil {
+		return nil, err
+	}
+
+	acumulado := proy.SaldoInicial
+	for i := range proy.Periodos {
+		p := &proy.Periodos[i]
+		p.AlquileresContrato = roundDos(p.AlquileresContrato)
+		p.AlquileresEsperados = roundDos(p.AlquileresEsperados)
+		p.SaldoVencido = roundDos(p.SaldoVencido)
+		p.OtrosIngresos = roundDos(p.OtrosIngresos)
+		p.Egresos = roundDos(p.Egresos)
+		p.Neto = roundDos(p.AlquileresEsperados + p.OtrosIngresos - p.Egresos)
+		acumulado = roundDos(acumulado + p.Neto)
+		p.SaldoProyectado = acumulado
+
+		proy.Totales.AlquileresContrato += p.AlquileresContrato
+		proy.Totales.AlquileresEsperados += p.AlquileresEsperados
+		proy.Totales.SaldoVencido += p.SaldoVencido
+		proy.Totales.OtrosIngresos += p.OtrosIngresos
+		proy.Totales.Egresos += p.Egresos
+		proy.Totales.AumentosProgramados += p.AumentosProgramados
+	}
+	proy.Totales.Periodo = "total"
+	proy.Totales.AlquileresContrato = roundDos(proy.Totales.AlquileresContrato)
+	proy.Totales.AlquileresEsperados = roundDos(proy.Totales.AlquileresEsperados)
+	proy.Totales.SaldoVencido = roundDos(proy.Totales.SaldoVencido)
+	proy.Totales.OtrosIngresos = roundDos(proy.Totales.OtrosIngresos)
+	proy.Totales.Egresos = roundDos(proy.Totales.Egresos)
+	proy.Totales.Neto = roundDos(proy.Totales.AlquileresEsperados + proy.Totales.OtrosIngresos - proy.Totales.Egresos)
+	proy.Totales.SaldoProyectado = acumulado
+
+	return proy, nil
+}
+
+// proyectarAlquileres suma a cada período el alquiler esperado de las propiedades ocupadas.
+func (s *ProyeccionService) proyectarAlquileres(proy *models.ProyeccionFlujo, mesActual time.Time, inflacion float64) error {
+	alquileres := NewAlquilerService()
+	props, err := alquileres.GetPropiedades("", "", "", 0)
+	if err != nil {
+		return err
+	}
+
+	// Libro de períodos: historial de cobro y deuda (meses vencidos) y cobros ya
+	// registrados del mes actual en adelante
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	periodos, err := alquileres.listarPeriodos(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	indiceActual := mesActual.Year()*12 + int(mesActual.Month()) - 1
+
+	// Probabilidad de cobro por propiedad y promedio de la cartera para las que no tienen historial
+	type historial struct{ vencidos, pagados int }
+	historiales := map[primitive.ObjectID]*historial{}
+	adeudado := map[primitive.ObjectID]float64{}
+	futuros := map[primitive.ObjectID]map[int]models.PeriodoAlquiler{}
+	var totalVencidos, totalPagados int
+	for _, per := range periodos {
+		if per.Indice() >= indiceActual {
+			if futuros[per.PropiedadID] == nil {
+				futuros[per.PropiedadID] = map[int]models.PeriodoAlquiler{}
+			}
+			futuros[per.PropiedadID][per.Indice()] = per
+			continue
+		}
+		h := historiales[per.PropiedadID]
+		if h == nil {
+			h = &historial{}
+			historiales[per.PropiedadID] = h
+		}
+		h.vencidos++
+		totalVencidos++
+		if per.Estado == models.PagadoEstado {
+			h.pagados++
+			totalPagados++
+		} else {
+			adeudado[per.PropiedadID] += per.Saldo()
+		}
+	}
+	probCartera := 1.0
+	if totalVencidos > 0 {
+		probCartera = float64(totalPagados) / float64(totalVencidos)
+	}
+
+	for _, p := range props {
+		if !p.Ocupada || p.AlquilerMensual <= 0 {
+			continue
+		}
+
+		item := models.ProyeccionPropiedad{
+			ID:                p.ID.Hex(),
+			Direccion:         p.Direccion,
+			Inquilino:         p.Inquilino,
+			MontoActual:       p.AlquilerMensual,
+			SaldoVencido:      roundDos(adeudado[p.ID]),
+			ProbabilidadCobro: probCartera,
+			Montos:            make([]float64, len(proy.Periodos)),
+			Aumentos:          []string{},
+		}
+		if h := historiales[p.ID]; h != nil && h.vencidos > 0 {
+			item.ProbabilidadCobro = float64(h.pagados) / float64(h.vencidos)
+			item.HistorialPropio = true
+		}
+		item.ProbabilidadCobro = roundCuatro(item.ProbabilidadCobro)
+
+		// La deuda de meses anteriores se espera cobrar en el mes actual
+		proy.Periodos[0].SaldoVencido += item.SaldoVencido
+		proy.Periodos[0].AlquileresContrato += item.SaldoVencido
+		proy.Periodos[0].AlquileresEsperados += item.SaldoVencido * item.ProbabilidadCobro
+
+		// Próxima actualización: las vencidas y no aplicadas se aplican en el mes actual
+		actualiza := !p.PagaEnDolares && p.FechaActualizacion != nil && p.FrecuenciaActualizacion >= 3
+		var proxima time.Time
+		if actualiza {
+			proxima = time.Date(p.FechaActualizacion.Year(), p.FechaActualizacion.Month(), 1, 0, 0, 0, 0, time.Local)
+		}
+
+		monto := p.AlquilerMensual
+		for k := range proy.Periodos {
+			mes := mesActual.AddDate(0, k, 0)
+
+			for actualiza && !proxima.After(mes) {
+				monto = roundDos(monto * math.Pow(1+inflacion, float64(p.FrecuenciaActualizacion)))
+				proxima = proxima.AddDate(0, p.FrecuenciaActualizacion, 0)
+				item.Aumentos = append(item.Aumentos, claveMes(mes))
+				proy.Periodos[k].AumentosProgramados++
+			}
+
+			aCobrar := monto
+			if per, ok := futuros[p.ID][indiceActual+k]; ok {
+				aCobrar = saldoProyectado(per, monto, k == 0)
+			}
+			if aCobrar <= 0 {
+				continue
+			}
+
+			item.Montos[k] = aCobrar
+			proy.Periodos[k].AlquileresContrato += aCobrar
+			proy.Periodos[k].AlquileresEsperados += aCobrar * item.ProbabilidadCobro
+		}
+
+		proy.Propiedades = append(proy.Propiedades, item)
+	}
+
+	sort.Slice(proy.Propiedades, func(i, j int) bool {
+		return proy.Propiedades[i].ProbabilidadCobro < proy.Propiedades[j].ProbabilidadCobro
+	})
+	return nil
+}
+
+// saldoProyectado es lo que falta cobrar de un período ya registrado. El del mes
+// actual tiene su monto definitivo: vale su saldo, con punitorios. En los
+// siguientes rige el monto proyectado (que puede incluir aumentos) menos lo
+// cobrado por adelantado; los ya pagos no se vuelven a cobrar.
+func saldoProyectado(per models.PeriodoAlquiler, monto float64, actual bool) float64 {
+	if actual {
+		return per.Saldo()
+	}
+	if per.Estado == models.PagadoEstado {
+		return 0
+	}
+	return math.Max(monto-per.Pagado, 0)
+}
+
+// proyectarConceptos promedia ingresos (sin alquileres) y egresos por concepto.
+func (s *ProyeccionService) proyectarConceptos(proy *models.ProyeccionFlujo, mesActual time.Time, inflacion float64, indice *IndicePrecios) error {
+	desde := mesActual.AddDate(0, -proy.MesesHistoria, 0)
+	ultimoCompleto := mesActual.AddDate(0, -1, 0)
+	conceptoAlquiler := NewAlquilerService().getAlquilerConceptID()
+
+	type filaConcepto struct {
+		ConceptID    uint
+		ConceptName  string
+		MovementType string
+		Periodo      string
+		Total        float64
+	}
+	var filas []filaConcepto
+	query := database.DB.Table("movements m").
+		Select(`m.concept_id, COALESCE(c.concept_name, 'Sin concepto') AS concept_name, m.movement_type,
+			DATE_FORMAT(m.movement_date, '%Y-%m') AS periodo, SUM(m.amount) AS total`).
+		Joins("LEFT JOIN concept_types c ON c.concept_id = m.concept_id").
+		Where("m.deleted_at IS NULL AND m.movement_type IN ?", []string{"Ingreso", "Egreso"}).
+		Where("m.movement_date >= ? AND m.movement_date < ?", desde, mesActual.AddDate(0, 1, 0))
+	if conceptoAlquiler > 0 {
+		query = query.Where("NOT (m.movement_type = 'Ingreso' AND m.concept_id = ?)", conceptoAlquiler)
+	}
+	if err := query.Group("m.concept_id, c.concept_name, m.movement_type, periodo").Scan(&filas).Error; err != nil {
+		return err
+	}
+
+	type clave struct {
+		id   uint
+		tipo string
+	}
+	conceptos := map[clave]*models.ProyeccionConcepto{}
+	for _, f := range filas {
+		k := clave{f.ConceptID, f.MovementType}
+		c := conceptos[k]
+		if c == nil {
+			c = &models.ProyeccionConcepto{ConceptID: f.ConceptID, Nombre: f.ConceptName, Tipo: f.MovementType}
+			conceptos[k] = c
+		}
+		if f.Periodo == claveMes(mesActual) {
+			c.RegistradoMesActual += f.Total
+			continue
+		}
+		// Llevar cada mes a pesos del último mes completo
+		factor := 1.0
+		if indice != nil {
+			mes, _ := time.ParseInLocation("2006-01", f.Periodo, time.Local)
+			factor, _ = indice.FactorReal(mes, ultimoCompleto)
+		}
+		c.PromedioMensual += f.Total * factor
+		c.MesesConMovimiento++
+	}
+
+	for _, c := range conceptos {
+		c.PromedioMensual = roundDos(c.PromedioMensual / float64(proy.MesesHistoria))
+		c.RegistradoMesActual = roundDos(c.RegistradoMesActual)
+
+		for k := range proy.Periodos {
+			monto := c.PromedioMensual * math.Pow(1+inflacion, float64(k+1))
+			if k == 0 {
+				// Del mes en curso solo falta lo que todavía no se registró
+				monto = math.Max(monto-c.RegistradoMesActual, 0)
+			}
+			if c.Tipo == "Ingreso" {
+				proy.Periodos[k].OtrosIngresos += monto
+			} else {
+				proy.Periodos[k].Egresos += monto
+			}
+		}
+		proy.Conceptos = append(proy.Conceptos, *c)
+	}
+
+	sort.Slice(proy.Conceptos, func(i, j int) bool {
+		return proy.Conceptos[i].PromedioMensual > proy.Conceptos[j].PromedioMensual
+	})
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+)
+
+func TestSaldoProyectado(t *testing.T) {
+	casos := []struct {
+		nombre string
+		per    models.PeriodoAlquiler
+		monto  float64
+		actual bool
+		want   float64
+	}{
+		{"mes actual sin cobros", models.PeriodoAlquiler{Estado: models.PendienteEstado, Monto: 100000}, 100000, true, 100000},
+		{"mes actual con cobro parcial", models.PeriodoAlquiler{Estado: models.PendienteEstado, Monto: 100000, Pagado: 40000}, 100000, true, 60000},
+		{"mes actual con punitorios", models.PeriodoAlquiler{Estado: models.Atraso1Estado, Monto: 100000, Pagado: 40000, PunitorioDevengado: 1500, PunitorioPagado: 500}, 100000, true, 61000},
+		{"mes actual pagado", models.PeriodoAlquiler{Estado: models.PagadoEstado, Monto: 100000, Pagado: 100000}, 100000, true, 0},
+		// El período se creó con el monto anterior al aumento proyectado
+		{"mes actual usa el monto del período", models.PeriodoAlquiler{Estado: models.PendienteEstado, Monto: 90000}, 100000, true, 90000},
+		{"mes futuro con el aumento proyectado", models.PeriodoAlquiler{Estado: models.PendienteEstado, Monto: 90000}, 100000, false, 100000},
+		{"mes futuro con adelanto parcial", models.PeriodoAlquiler{Estado: models.PendienteEstado, Monto: 100000, Pagado: 30000}, 110000, false, 80000},
+		{"mes futuro pagado por adelantado", models.PeriodoAlquiler{Estado: models.PagadoEstado, Monto: 100000, Pagado: 100000}, 110000, false, 0},
+		{"adelanto mayor al monto proyectado", models.PeriodoAlquiler{Estado: models.PendienteEstado, Monto: 100000, Pagado: 95000}, 90000, false, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := saldoProyectado(c.per, c.monto, c.actual); got != c.want {
+				t.Errorf("saldoProyectado() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PunitorioService administra la política de punitorios (intereses por pago
+// fuera de término) y los liquida sobre los períodos de alquiler.
+type PunitorioService struct {
+	config    *mongo.Collection
+	contratos *mongo.Collection
+}
+
+func NewPunitorioService() *PunitorioService {
+	return &PunitorioService{
+		config:    database.MongoDB.Collection(database.CollectionConfiguracionAlquileres),
+		contratos: database.MongoDB.Collection(database.CollectionContratos),
+	}
+}
+
+// ID del documento de configuración con la política general
+const configPunitorios = "punitorios"
+
+// politicaPorDefecto: sin punitorios hasta que se configure una política.
+func politicaPorDefecto() *models.PoliticaPunitorio {
+	return &models.PoliticaPunitorio{Activo: false, Tipo: models.PunitorioDiario}
+}
+
+// GetPolitica devuelve la política general de punitorios.
+func (s *PunitorioService) GetPolitica() (*models.PoliticaPunitorio, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.politicaGeneral(ctx)
+}
+
+// GuardarPolitica reemplaza la política general de punitorios.
+func (s *PunitorioService) GuardarPolitica(pol models.PoliticaPunitorio, userID uint) (*models.PoliticaPunitorio, error) {
+	if err := validarPolitica(&pol); err != nil {
+		return nil, err
+	}
+	pol.UpdatedAt = time.Now()
+	pol.UpdatedBy = userID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := s.config.UpdateOne(ctx,
+		bson.M{"_id": configPunitorios},
+		bson.M{"$set": pol},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pol, nil
+}
+
+// politicaPara devuelve la política que aplica a un período: la del contrato
+// si la tiene, si no la general.
+func (s *PunitorioService) politicaPara(ctx context.Context, contratoID *primitive.ObjectID) (*models.PoliticaPunitorio, error) {
+	if contratoID != nil {
+		var c models.Contrato
+		err := s.contratos.FindOne(ctx, bson.M{"_id": *contratoID},
+			options.FindOne().SetProjection(bson.M{"punitorio": 1})).Decode(&c)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		if c.Punitorio != nil {
+			return c.Punitorio, nil
+		}
+	}
+	return s.politicaGeneral(ctx)
+}
+
+func (s *PunitorioService) politicaGeneral(ctx context.Context) (*models.PoliticaPunitorio, error) {
+	var pol models.PoliticaPunitorio
+	err := s.config.FindOne(ctx, bson.M{"_id": configPunitorios}).Decode(&pol)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return politicaPorDefecto(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pol, nil
+}
+
+// validarPolitica completa y verifica una política (general o de contrato).
+func validarPolitica(pol *models.PoliticaPunitorio) error {
+	switch pol.Tipo {
+	case models.PunitorioDiario:
+		if pol.Activo && pol.TasaDiaria <= 0 {
+			return errors.New("la tasa diaria debe ser mayor a cero")
+		}
+	case models.PunitorioFijo:
+		if pol.Activo && pol.MontoFijo <= 0 {
+			return errors.New("el monto fijo debe ser mayor a cero")
+		}
+	default:
+		return errors.New("tipo de punitorio inválido (diario o fijo)")
+	}
+	return nil
+}
+
+// ─── Cálculo ────────────────────────────────────────────────────────────────
+
+// calcularPunitorio liquida los punitorios devengados por el período al día
+// `fecha` que todavía no fueron liquidados en cobros anteriores. `inicio` es el
+// primer día de mora del período (inicioMora: vencimiento más días de gracia,
+// igual que para los estados de mora). Devuelve el monto y los días de atraso
+// (el primer día de mora cuenta).
+//   - diario: TasaDiaria % por día sobre el alquiler adeudado, desde el día
+//     siguiente a la última liquidación (InteresHasta)
+//   - fijo: MontoFijo una sola vez por período
+//
+// En ambos casos los punitorios acumulados no superan TopePct % del alquiler.
+func calcularPunitorio(pol *models.PoliticaPunitorio, inicio time.Time, p *models.PeriodoAlquiler, fecha time.Time) (float64, int) {
+	if pol == nil || !pol.Activo || p.SaldoCapital() <= 0 {
+		return 0, 0
+	}
+	venc := inicioDia(inicio)
+	hoy := inicioDia(fecha)
+	if hoy.Before(venc) {
+		return 0, 0
+	}
+	dias := diasEntre(venc, hoy) + 1
+
+	var monto float64
+	switch pol.Tipo {
+	case models.PunitorioFijo:
+		if p.PunitorioDevengado > 0 {
+			return 0, dias
+		}
+		monto = pol.MontoFijo
+	default:
+		desde := venc
+		if p.InteresHasta != nil && !inicioDia(*p.InteresHasta).Before(venc) {
+			desde = inicioDia(*p.InteresHasta).AddDate(0, 0, 1)
+		}
+		if hoy.Before(desde) {
+			return 0, dias
+		}
+		monto = p.SaldoCapital() * pol.TasaDiaria / 100 * float64(diasEntre(desde, hoy)+1)
+	}
+
+	if pol.TopePct > 0 {
+		disponible := p.Monto*pol.TopePct/100 - p.PunitorioDevengado
+		monto = math.Min(monto, math.Max(disponible, 0))
+	}
+	return roundDos(monto), dias
+}
+
+// inicioDia devuelve t a las 00:00 (hora local).
+func inicioDia(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// diasEntre cuenta los días calendario de `desde` a `hasta` (ambos a las 00:00).
+func diasEntre(desde, hasta time.Time) int {
+	return int(math.Round(hasta.Sub(desde).Hours() / 24))
+}
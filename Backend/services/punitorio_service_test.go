@@ -0,0 +1,54 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+	"time"
+)
+
+func TestCalcularPunitorio(t *testing.T) {
+	diaria := &models.PoliticaPunitorio{Activo: true, Tipo: models.PunitorioDiario, TasaDiaria: 0.5}
+	conTope := &models.PoliticaPunitorio{Activo: true, Tipo: models.PunitorioDiario, TasaDiaria: 1, TopePct: 5}
+	fija := &models.PoliticaPunitorio{Activo: true, Tipo: models.PunitorioFijo, MontoFijo: 1500}
+	inactiva := &models.PoliticaPunitorio{Activo: false, Tipo: models.PunitorioDiario, TasaDiaria: 0.5}
+
+	dia := func(d int) time.Time { return time.Date(2026, time.March, d, 15, 30, 0, 0, time.Local) }
+	// Primer día de mora de marzo: vencimiento el 5 más 4 días de gracia
+	reglas := &models.ReglasMora{DiaVencimiento: 5, DiasGracia: 4}
+	marzo := inicioMora(reglas, models.Propiedad{}, 2026, 2)
+	// Propiedad con vencimiento propio el 4 y sin gracia: la mora empieza el 5
+	fijoDesde := inicioMora(reglas, models.Propiedad{DiaVencimiento: 4, DiasGracia: new(int)}, 2026, 2)
+	liquidadoHasta := time.Date(2026, time.March, 14, 0, 0, 0, 0, time.Local)
+
+	casos := []struct {
+		nombre    string
+		pol       *models.PoliticaPunitorio
+		inicio    time.Time
+		periodo   models.PeriodoAlquiler
+		fecha     time.Time
+		wantMonto float64
+		wantDias  int
+	}{
+		{"sin política", nil, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000}, dia(20), 0, 0},
+		{"política inactiva", inactiva, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000}, dia(20), 0, 0},
+		{"dentro de los días de gracia", diaria, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000}, dia(9), 0, 0},
+		{"el primer día de mora cuenta", diaria, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000}, dia(10), 500, 1},
+		{"diario sobre el saldo", diaria, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000, Pagado: 40000}, dia(19), 3000, 10},
+		{"diario desde la última liquidación", diaria, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000, PunitorioDevengado: 2500, InteresHasta: &liquidadoHasta}, dia(19), 2500, 10},
+		{"ya liquidado hoy", diaria, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000, PunitorioDevengado: 2500, InteresHasta: &liquidadoHasta}, dia(14), 0, 5},
+		{"tope sobre el alquiler", conTope, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000, PunitorioDevengado: 3000}, dia(30), 2000, 21},
+		{"tope alcanzado", conTope, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000, PunitorioDevengado: 5000}, dia(30), 0, 21},
+		{"fijo una vez", fija, fijoDesde, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000}, dia(8), 1500, 4},
+		{"fijo ya liquidado", fija, fijoDesde, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000, PunitorioDevengado: 1500}, dia(8), 0, 4},
+		{"período pagado", diaria, marzo, models.PeriodoAlquiler{Anio: 2026, Mes: 2, Monto: 100000, Estado: models.PagadoEstado}, dia(20), 0, 0},
+		{"diciembre (mes 11)", diaria, inicioMora(reglas, models.Propiedad{}, 2025, 11), models.PeriodoAlquiler{Anio: 2025, Mes: 11, Monto: 100000}, time.Date(2025, time.December, 11, 0, 0, 0, 0, time.Local), 1000, 2},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			monto, dias := calcularPunitorio(c.pol, c.inicio, &c.periodo, c.fecha)
+			if monto != c.wantMonto || dias != c.wantDias {
+				t.Errorf("calcularPunitorio() = (%v, %d), want (%v, %d)", monto, dias, c.wantMonto, c.wantDias)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"caja-fuerte/config"
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReciboService emite los recibos de alquiler con numeración correlativa propia,
+// los anula cuando se revierte el cobro y genera su PDF.
+type ReciboService struct {
+	coll       *mongo.Collection
+	contadores *mongo.Collection
+	contratos  *mongo.Collection
+}
+
+func NewReciboService() *ReciboService {
+	return &ReciboService{
+		coll:       database.MongoDB.Collection(database.CollectionRecibos),
+		contadores: database.MongoDB.Collection(database.CollectionContadores),
+		contratos:  database.MongoDB.Collection(database.CollectionContratos),
+	}
+}
+
+// siguienteNumero reserva el próximo número del punto de venta. El contador es
+// atómico ($inc), así dos cobros simultáneos nunca comparten número; si una
+// emisión falla después de reservar queda un salto en la numeración.
+func (s *ReciboService) siguienteNumero(ctx context.Context, puntoVenta int) (int64, error) {
+	var contador struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := s.contadores.FindOneAndUpdate(ctx,
+		bson.M{"_id": "recibos-" + strconv.Itoa(puntoVenta)},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&contador)
+	if err != nil {
+		return 0, err
+	}
+	return contador.Seq, nil
+}
+
+// emitir crea el recibo de un cobro recién registrado.
+func (s *ReciboService) emitir(ctx context.Context, prop *models.Propiedad, periodo *models.PeriodoAlquiler,
+	cobro models.CobroPeriodo, saldada bool) (*models.Recibo, error) {
+	cfg := config.AppConfig
+	puntoVenta := cfg.ReciboPuntoVenta
+	if puntoVenta <= 0 {
+		puntoVenta = 1
+	}
+	numero, err := s.siguienteNumero(ctx, puntoVenta)
+	if err != nil {
+		return nil, err
+	}
+
+	medio := cobro.MedioPago
+	if medio == "" {
+		medio = models.MedioEfectivo
+	}
+	rec := &models.Recibo{
+		ID:               primitive.NewObjectID(),
+		PuntoVenta:       puntoVenta,
+		Numero:           numero,
+		NumeroFormateado: fmt.Sprintf("%04d-%08d", puntoVenta, numero),
+		PropiedadID:      prop.ID,
+		Direccion:        prop.Direccion,
+		ContratoID:       periodo.ContratoID,
+		Inquilino:        prop.Inquilino,
+		PeriodoID:        periodo.ID,
+		CobroID:          cobro.ID,
+		Anio:             periodo.Anio,
+		Mes:              periodo.Mes,
+		Monto:            cobro.Monto,
+		Capital:          cobro.Capital,
+		Punitorio:        cobro.Punitorio,
+		MontoEnLetras:    utils.MontoEnLetras(cobro.Monto),
+		MedioPago:        medio,
+		Parcial:          !saldada,
+		Emisor:           cfg.ReciboEmisor,
+		EmisorCUIT:       cfg.ReciboCUIT,
+		EmisorDomicilio:  cfg.ReciboDomicilio,
+		EmitidoPor:       cobro.RegistradoPor,
+		FechaEmision:     cobro.Fecha,
+		MovementID:       cobro.MovementID,
+		Estado:           models.ReciboEmitido,
+	}
+
+	// El inquilino sale del contrato del período (puede no ser el actual)
+	if periodo.ContratoID != nil {
+		var c models.Contrato
+		if err := s.contratos.FindOne(ctx, bson.M{"_id": *periodo.ContratoID}).Decode(&c); err == nil {
+			rec.Inquilino = c.Inquilino
+			rec.InquilinoID = c.InquilinoID
+		}
+	}
+
+	if _, err := s.coll.InsertOne(ctx, rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// anular marca como anulado el recibo de un cobro revertido. Los cobros sin
+// recibo (anteriores a la numeración) se ignoran.
+func (s *ReciboService) anular(ctx context.Context, cobro models.CobroPeriodo, userID uint, motivo string) error {
+	filtro := bson.M{"cobro_id": cobro.ID, "estado": models.ReciboEmitido}
+	if cobro.ReciboID != nil {
+		filtro = bson.M{"_id": *cobro.ReciboID, "estado": models.ReciboEmitido}
+	}
+	now := time.Now()
+	_, err := s.coll.UpdateOne(ctx, filtro, bson.M{"$set": bson.M{
+		"estado":           models.ReciboAnulado,
+		"anulado_en":       now,
+		"anulado_por":      userID,
+		"motivo_anulacion": motivo,
+	}})
+	return err
+}
+
+// Listar devuelve los recibos más recientes primero.
+func (s *ReciboService) Listar(f models.FiltroRecibos) ([]models.Recibo, error) {
+	filtro := bson.M{}
+	if f.PropiedadID != "" {
+		oid, err := primitive.ObjectIDFromHex(f.PropiedadID)
+		if err != nil {
+			return nil, errors.New("propiedad inválida")
+		}
+		filtro["propiedad_id"] = oid
+	}
+	if f.InquilinoID != "" {
+		oid, err := primitive.ObjectIDFromHex(f.InquilinoID)
+		if err != nil {
+			return nil, errors.New("inquilino inválido")
+		}
+		filtro["inquilino_id"] = oid
+	}
+	if f.Estado != "" {
+		if f.Estado != string(models.ReciboEmitido) && f.Estado != string(models.ReciboAnulado) {
+			return nil, errors.New("estado inválido (emitido o anulado)")
+		}
+		filtro["estado"] = f.Estado
+	}
+	if f.Desde != nil || f.Hasta != nil {
+		rango := bson.M{}
+		if f.Desde != nil {
+			rango["$gte"] = *f.Desde
+		}
+		if f.Hasta != nil {
+			rango["$lt"] = *f.Hasta
+		}
+		filtro["fecha_emision"] = rango
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx, filtro, options.Find().SetSort(bson.D{{Key: "fecha_emision", Value: -1}, {Key: "numero", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	recibos := []models.Recibo{}
+	if err := cursor.All(ctx, &recibos); err != nil {
+		return nil, err
+	}
+	return recibos, nil
+}
+
+// GetByID devuelve un recibo.
+func (s *ReciboService) GetByID(id string) (*models.Recibo, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("recibo inválido")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var rec models.Recibo
+	if err := s.coll.FindOne(ctx, bson.M{"_id": oid}).Decode(&rec); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("recibo no encontrado")
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// GenerarPDF devuelve el nombre de archivo y el PDF del recibo. Verlo no lo
+// modifica; después de una reimpresión (Reimprimir) sale como DUPLICADO.
+func (s *ReciboService) GenerarPDF(id string) (string, []byte, error) {
+	rec, err := s.GetByID(id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	datos, err := utils.GenerarReciboPDF(utils.DocumentoRecibo{
+		Numero:          rec.NumeroFormateado,
+		Fecha:           rec.FechaEmision,
+		Emisor:          rec.Emisor,
+		EmisorCUIT:      rec.EmisorCUIT,
+		EmisorDomicilio: rec.EmisorDomicilio,
+		Inquilino:       rec.Inquilino,
+		Direccion:       rec.Direccion,
+		Periodo:         nombreMes(time.Month(rec.Mes+1)) + " " + strconv.Itoa(rec.Anio),
+		Monto:           rec.Monto,
+		Capital:         rec.Capital,
+		Punitorio:       rec.Punitorio,
+		MontoEnLetras:   rec.MontoEnLetras,
+		MedioPago:       rec.MedioPago,
+		Parcial:         rec.Parcial,
+		Anulado:         rec.Estado == models.ReciboAnulado,
+		Reimpresion:     rec.Impresiones > 0,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return "recibo_" + rec.NumeroFormateado + ".pdf", datos, nil
+}
+
+// Reimprimir cuenta una reimpresión del recibo: desde entonces el PDF sale
+// como DUPLICADO.
+func (s *ReciboService) Reimprimir(id string) (*models.Recibo, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var rec models.Recibo
+	err = s.coll.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "estado": bson.M{"$ne": models.ReciboAnulado}},
+		bson.M{"$inc": bson.M{"impresiones": 1}},
+		opts,
+	).Decode(&rec)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("recibo no encontrado o anulado")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
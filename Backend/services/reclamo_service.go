@@ -0,0 +1,458 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Orden de los reclamos en los listados: primero los más urgentes
+var ordenPrioridad = map[string]int{
+	models.PrioridadUrgente: 0,
+	models.PrioridadAlta:    1,
+	models.PrioridadMedia:   2,
+	models.PrioridadBaja:    3,
+}
+
+// ReclamoService maneja los reclamos de mantenimiento de las propiedades
+// (colección reclamos). El pago del arreglo se registra como gasto de la
+// propiedad, así se liquida y suma en la rentabilidad.
+type ReclamoService struct {
+	coll       *mongo.Collection
+	contadores *mongo.Collection
+}
+
+func NewReclamoService() *ReclamoService {
+	return &ReclamoService{
+		coll:       database.MongoDB.Collection(database.CollectionReclamos),
+		contadores: database.MongoDB.Collection(database.CollectionContadores),
+	}
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// CRUD Reclamos
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Crear carga un reclamo de la propiedad. Queda asociado al contrato vigente y,
+// si no se indica quién lo informó, al inquilino.
+func (s *ReclamoService) Crear(propID string, req models.CrearReclamoRequest, userID uint) (*models.Reclamo, error) {
+	prop, err := NewAlquilerService().getPropiedad(propID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	r := &models.Reclamo{
+		ID:                primitive.NewObjectID(),
+		PropiedadID:       prop.ID,
+		Direccion:         prop.Direccion,
+		ContratoID:        prop.ContratoVigenteID,
+		ReportadoPor:      strings.TrimSpace(req.ReportadoPor),
+		Telefono:          strings.TrimSpace(req.Telefono),
+		Titulo:            strings.TrimSpace(req.Titulo),
+		Descripcion:       req.Descripcion,
+		Prioridad:         req.Prioridad,
+		Estado:            models.ReclamoAbierto,
+		Proveedor:         strings.TrimSpace(req.Proveedor),
+		ProveedorTelefono: strings.TrimSpace(req.ProveedorTelefono),
+		Costo:             roundDos(req.Costo),
+		CreatedBy:         userID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if r.Prioridad == "" {
+		r.Prioridad = models.PrioridadMedia
+	}
+	if r.ReportadoPor == "" {
+		r.ReportadoPor = prop.Inquilino
+	}
+	if req.PersonaID != "" {
+		p, err := NewPersonaService().getPersona(ctx, req.PersonaID)
+		if err != nil {
+			return nil, err
+		}
+		r.PersonaID = &p.ID
+		if req.ReportadoPor == "" {
+			r.ReportadoPor = p.Nombre
+		}
+		if r.Telefono == "" {
+			r.Telefono = p.Telefono
+		}
+	}
+	r.Historial = []models.CambioEstadoReclamo{{Estado: r.Estado, Fecha: now, UsuarioID: userID}}
+	if r.Proveedor != "" {
+		r.Estado = models.ReclamoAsignado
+		r.Historial = append(r.Historial, models.CambioEstadoReclamo{
+			Estado: r.Estado, Fecha: now, UsuarioID: userID, Nota: "Proveedor: " + r.Proveedor,
+		})
+	}
+
+	imagenes := NewImagenService()
+	r.Imagenes, err = imagenes.resolverImagenes(req.Imagenes)
+	if err != nil {
+		return nil, err
+	}
+	if r.Numero, err = s.siguienteNumero(ctx); err == nil {
+		_, err = s.coll.InsertOne(ctx, r)
+	}
+	if err != nil {
+		imagenes.eliminar(r.Imagenes)
+		return nil, err
+	}
+	return r, nil
+}
+
+// Listar devuelve los reclamos filtrados por propiedad, estado y prioridad,
+// los más urgentes primero y, a igual prioridad, los más antiguos. El estado
+// "pendientes" agrupa abiertos, asignados y en curso.
+func (s *ReclamoService) Listar(propID, estado, prioridad string) ([]models.Reclamo, error) {
+	filter := bson.M{}
+	if propID != "" {
+		objID, err := primitive.ObjectIDFromHex(propID)
+		if err != nil {
+			return nil, errors.New("ID inválido")
+		}
+		filter["propiedad_id"] = objID
+	}
+	switch estado {
+	case "":
+	case "pendientes":
+		filter["estado"] = bson.M{"$in": models.EstadosReclamoPendientes}
+	default:
+		filter["estado"] = estado
+	}
+	if prioridad != "" {
+		filter["prioridad"] = prioridad
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	reclamos := []models.Reclamo{}
+	if err := cursor.All(ctx, &reclamos); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(reclamos, func(i, j int) bool {
+		return ordenPrioridad[reclamos[i].Prioridad] < ordenPrioridad[reclamos[j].Prioridad]
+	})
+	return reclamos, nil
+}
+
+// GetByID devuelve un reclamo.
+func (s *ReclamoService) GetByID(id string) (*models.Reclamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.getReclamo(ctx, id)
+}
+
+// Actualizar modifica los datos de un reclamo que no está cerrado. Asignar un
+// proveedor a un reclamo abierto lo pasa a "asignado".
+func (s *ReclamoService) Actualizar(id string, req models.ActualizarReclamoRequest, userID uint) (*models.Reclamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	r, err := s.getReclamo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Estado == models.ReclamoCerrado {
+		return nil, errors.New("el reclamo está cerrado")
+	}
+
+	now := time.Now()
+	set := bson.M{"updated_at": now}
+	if req.Titulo != nil {
+		set["titulo"] = strings.TrimSpace(*req.Titulo)
+	}
+	if req.Descripcion != nil {
+		set["descripcion"] = *req.Descripcion
+	}
+	if req.Prioridad != nil {
+		set["prioridad"] = *req.Prioridad
+	}
+	if req.ReportadoPor != nil {
+		set["reportado_por"] = strings.TrimSpace(*req.ReportadoPor)
+	}
+	if req.Telefono != nil {
+		set["telefono"] = strings.TrimSpace(*req.Telefono)
+	}
+	if req.ProveedorTelefono != nil {
+		set["proveedor_telefono"] = strings.TrimSpace(*req.ProveedorTelefono)
+	}
+	if req.Costo != nil {
+		if r.GastoID != nil {
+			return nil, errors.New("el reclamo ya tiene el pago registrado; anúlelo para cambiar el costo")
+		}
+		set["costo"] = roundDos(*req.Costo)
+	}
+	update := bson.M{"$set": set}
+	if req.Proveedor != nil {
+		proveedor := strings.TrimSpace(*req.Proveedor)
+		set["proveedor"] = proveedor
+		switch {
+		case proveedor != "" && r.Estado == models.ReclamoAbierto:
+			set["estado"] = models.ReclamoAsignado
+			update["$push"] = bson.M{"historial": models.CambioEstadoReclamo{
+				Estado: models.ReclamoAsignado, Fecha: now, UsuarioID: userID, Nota: "Proveedor: " + proveedor,
+			}}
+		case proveedor == "" && r.Estado == models.ReclamoAsignado:
+			// Sin proveedor no puede seguir asignado
+			set["estado"] = models.ReclamoAbierto
+			update["$push"] = bson.M{"historial": models.CambioEstadoReclamo{
+				Estado: models.ReclamoAbierto, Fecha: now, UsuarioID: userID, Nota: "Se quitó el proveedor " + r.Proveedor,
+			}}
+		}
+	}
+
+	// Fotos: las nuevas (data-URL) van al almacén y las que se quitan se borran
+	imagenes := NewImagenService()
+	if req.Imagenes != nil {
+		refs, err := imagenes.resolverImagenes(*req.Imagenes)
+		if err != nil {
+			return nil, err
+		}
+		set["imagenes"] = refs
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Reclamo
+	if err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": r.ID}, update, opts).Decode(&updated); err != nil {
+		if req.Imagenes != nil {
+			imagenes.eliminar(imagenesQuitadas(set["imagenes"].([]string), r.Imagenes))
+		}
+		return nil, err
+	}
+	if req.Imagenes != nil {
+		imagenes.eliminar(imagenesQuitadas(r.Imagenes, updated.Imagenes))
+	}
+	return &updated, nil
+}
+
+// CambiarEstado avanza el reclamo en su flujo (ver models.TransicionesReclamo)
+// y deja el cambio en el historial.
+func (s *ReclamoService) CambiarEstado(id string, req models.CambiarEstadoReclamoRequest, userID uint) (*models.Reclamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, err := s.getReclamo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(models.TransicionesReclamo[r.Estado], req.Estado) {
+		return nil, fmt.Errorf("un reclamo %s no puede pasar a %s", r.Estado, req.Estado)
+	}
+	if req.Estado == models.ReclamoAsignado && r.Proveedor == "" {
+		return nil, errors.New("asigne un proveedor al reclamo")
+	}
+	if req.Estado == models.ReclamoCancelado && r.GastoID != nil {
+		return nil, errors.New("el reclamo tiene el pago registrado; anúlelo antes de cancelarlo")
+	}
+
+	now := time.Now()
+	set := bson.M{"estado": req.Estado, "updated_at": now}
+	update := bson.M{
+		"$set": set,
+		"$push": bson.M{"historial": models.CambioEstadoReclamo{
+			Estado: req.Estado, Fecha: now, UsuarioID: userID, Nota: req.Nota,
+		}},
+	}
+	switch req.Estado {
+	case models.ReclamoResuelto:
+		set["fecha_resolucion"] = now
+	case models.ReclamoCerrado:
+	default:
+		update["$unset"] = bson.M{"fecha_resolucion": ""}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Reclamo
+	// El filtro por estado evita pisar un cambio simultáneo
+	err = s.coll.FindOneAndUpdate(ctx, bson.M{"_id": r.ID, "estado": r.Estado}, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.New("el reclamo cambió de estado mientras se actualizaba; vuelva a intentarlo")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Eliminar borra un reclamo sin pago registrado y sus fotos.
+func (s *ReclamoService) Eliminar(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r, err := s.getReclamo(ctx, id)
+	if err != nil {
+		return err
+	}
+	if r.GastoID != nil {
+		return errors.New("el reclamo tiene el pago registrado; anúlelo primero")
+	}
+	if _, err := s.coll.DeleteOne(ctx, bson.M{"_id": r.ID}); err != nil {
+		return err
+	}
+	NewImagenService().eliminar(r.Imagenes)
+	return nil
+}
+
+// ─────────────────────────────────────────────────────────────────────────────
+// Pago del arreglo
+// ─────────────────────────────────────────────────────────────────────────────
+
+// Pagar registra el costo del arreglo como gasto de reparación de la propiedad
+// (con su Egreso en caja o vinculado a uno existente) y lo asocia al reclamo.
+func (s *ReclamoService) Pagar(id string, req models.PagarReclamoRequest, userID uint) (*models.Reclamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	r, err := s.getReclamo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.GastoID != nil {
+		return nil, errors.New("el reclamo ya tiene el pago registrado")
+	}
+	if r.Estado == models.ReclamoCancelado {
+		return nil, errors.New("el reclamo está cancelado")
+	}
+
+	descripcion := fmt.Sprintf("Reclamo #%d - %s", r.Numero, r.Titulo)
+	if r.Proveedor != "" {
+		descripcion += " (" + r.Proveedor + ")"
+	}
+	gastos := NewGastoService()
+	g, err := gastos.Crear(r.PropiedadID.Hex(), models.CrearGastoRequest{
+		Categoria:       models.GastoReparacion,
+		Descripcion:     descripcion,
+		Monto:           req.Monto,
+		Fecha:           req.Fecha,
+		ACargoDe:        req.ACargoDe,
+		RegistrarEnCaja: req.RegistrarEnCaja,
+		MovementID:      req.MovementID,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"costo": g.Monto, "gasto_id": g.ID, "updated_at": time.Now()}
+	if g.MovementID != nil {
+		set["movement_id"] = *g.MovementID
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Reclamo
+	err = s.coll.FindOneAndUpdate(ctx, bson.M{"_id": r.ID, "gasto_id": bson.M{"$exists": false}},
+		bson.M{"$set": set}, opts).Decode(&updated)
+	if err != nil {
+		// Pago simultáneo o falla al guardar: se descarta el gasto recién creado
+		if errEliminar := gastos.Eliminar(g.ID.Hex(), userID); errEliminar != nil {
+			log.Printf("[ALQUILER] Advertencia: no se pudo descartar el gasto %s del reclamo %s: %v", g.ID.Hex(), id, errEliminar)
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("el reclamo ya tiene el pago registrado")
+		}
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// AnularPago elimina el gasto del reclamo (y el Egreso que generó), siempre que
+// no se haya liquidado al propietario.
+func (s *ReclamoService) AnularPago(id string, userID uint) (*models.Reclamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	r, err := s.getReclamo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.GastoID == nil {
+		return nil, errors.New("el reclamo no tiene pago registrado")
+	}
+	if err := NewGastoService().Eliminar(r.GastoID.Hex(), userID); err != nil && !errors.Is(err, ErrGastoNoEncontrado) {
+		return nil, err
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Reclamo
+	if err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": r.ID}, bson.M{
+		"$unset": bson.M{"gasto_id": "", "movement_id": ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+// siguienteNumero reserva el próximo número de reclamo (ver ReciboService.siguienteNumero).
+func (s *ReclamoService) siguienteNumero(ctx context.Context) (int64, error) {
+	var contador struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := s.contadores.FindOneAndUpdate(ctx,
+		bson.M{"_id": "reclamos"},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&contador)
+	if err != nil {
+		return 0, err
+	}
+	return contador.Seq, nil
+}
+
+// eliminarPorPropiedad borra los reclamos de una propiedad eliminada y sus fotos.
+func (s *ReclamoService) eliminarPorPropiedad(ctx context.Context, propID primitive.ObjectID) error {
+	cursor, err := s.coll.Find(ctx, bson.M{"propiedad_id": propID},
+		options.Find().SetProjection(bson.M{"imagenes": 1}))
+	if err != nil {
+		return err
+	}
+	var reclamos []models.Reclamo
+	if err := cursor.All(ctx, &reclamos); err != nil {
+		return err
+	}
+	if _, err := s.coll.DeleteMany(ctx, bson.M{"propiedad_id": propID}); err != nil {
+		return err
+	}
+	imagenes := NewImagenService()
+	for _, r := range reclamos {
+		imagenes.eliminar(r.Imagenes)
+	}
+	return nil
+}
+
+func (s *ReclamoService) getReclamo(ctx context.Context, id string) (*models.Reclamo, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("ID inválido")
+	}
+	var r models.Reclamo
+	if err := s.coll.FindOne(ctx, bson.M{"_id": objID}).Decode(&r); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("reclamo no encontrado")
+		}
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,569 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"caja-fuerte/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	maxIntentosEnvio = 5
+	esperaBaseEnvio  = 5 * time.Minute // 5, 10, 20, 40 minutos entre reintentos
+)
+
+var (
+	entradasReporteMu sync.Mutex
+	entradasReporte   = map[uint]cron.EntryID{} // programacionID -> tarea en el Scheduler
+)
+
+// ReporteProgramadoService administra los reportes que se envían por correo
+// (cierre diario de caja y cobranza mensual de alquileres) y su registro de envíos.
+type ReporteProgramadoService struct {
+	mailer *Mailer
+}
+
+func NewReporteProgramadoService() *ReporteProgramadoService {
+	return &ReporteProgramadoService{
+		mailer: NewMailer(),
+	}
+}
+
+// Start registra en el Scheduler todas las programaciones activas y la tarea
+// que reintenta los envíos fallidos.
+func (s *ReporteProgramadoService) Start() {
+	if !s.mailer.Configurado() {
+		utils.Logger.Warn("SMTP no configurado: los reportes programados quedarán registrados como fallidos")
+	}
+
+	var programaciones []models.ProgramacionReporte
+	if err := database.DB.Where("activo = ?", true).Find(&programaciones).Error; err != nil {
+		utils.Logger.Error("Error cargando reportes programados", zap.Error(err))
+	}
+	for _, p := range programaciones {
+		s.programar(p)
+	}
+
+	// Envíos que quedaron a medio procesar si el servidor se detuvo: vuelven a la cola
+	ahora := time.Now()
+	database.DB.Model(&models.EnvioReporte{}).Where("estado = ?", "enviando").
+		Updates(map[string]interface{}{"estado": "pendiente", "proximo_intento": &ahora})
+
+	if _, err := GetScheduler().Programar("reintentar-envios-reportes", "* * * * *", s.reintentarPendientes); err != nil {
+		utils.Logger.Error("Error programando reintentos de reportes", zap.Error(err))
+	}
+}
+
+// ─── ABM de programaciones ──────────────────────────────────────────────────
+
+func (s *ReporteProgramadoService) Listar() ([]models.ProgramacionReporte, error) {
+	var programaciones []models.ProgramacionReporte
+	err := database.DB.Order("id ASC").Find(&programaciones).Error
+	return programaciones, err
+}
+
+func (s *ReporteProgramadoService) Crear(req models.ProgramacionReporteRequest, createdBy uint) (*models.ProgramacionReporte, error) {
+	if err := ValidarCron(req.Cron); err != nil {
+		return nil, fmt.Errorf("expresión cron inválida: %w", err)
+	}
+
+	p := models.ProgramacionReporte{CreatedBy: createdBy, Activo: true}
+	aplicarProgramacionRequest(&p, req)
+	if err := database.DB.Create(&p).Error; err != nil {
+		return nil, err
+	}
+	s.programar(p)
+	return &p, nil
+}
+
+func (s *ReporteProgramadoService) Actualizar(id uint, req models.ProgramacionReporteRequest) (*models.ProgramacionReporte, error) {
+	if err := ValidarCron(req.Cron); err != nil {
+		return nil, fmt.Errorf("expresión cron inválida: %w", err)
+	}
+
+	var p models.ProgramacionReporte
+	if err := database.DB.First(&p, id).Error; err != nil {
+		return nil, errors.New("reporte programado no encontrado")
+	}
+	aplicarProgramacionRequest(&p, req)
+	if err := database.DB.Save(&p).Error; err != nil {
+		return nil, err
+	}
+	s.programar(p)
+	return &p, nil
+}
+
+func (s *ReporteProgramadoService) Eliminar(id uint) error {
+	result := database.DB.Delete(&models.ProgramacionReporte{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("reporte programado no encontrado")
+	}
+	desprogramar(id)
+	database.DB.Where("programacion_id = ? AND estado = ?", id, "pendiente").
+		Model(&models.EnvioReporte{}).
+		Updates(map[string]interface{}{"estado": "fallido", "error": "programación eliminada", "proximo_intento": nil})
+	return nil
+}
+
+// GetEnvios devuelve el registro de envíos, opcionalmente de una programación.
+func (s *ReporteProgramadoService) GetEnvios(programacionID uint, limite int) ([]models.EnvioReporte, error) {
+	if limite <= 0 || limite > 500 {
+		limite = 100
+	}
+	query := database.DB.Preload("Programacion").Order("id DESC").Limit(limite)
+	if programacionID > 0 {
+		query = query.Where("programacion_id = ?", programacionID)
+	}
+	var envios []models.EnvioReporte
+	err := query.Find(&envios).Error
+	return envios, err
+}
+
+// EnviarAhora ejecuta una programación fuera de horario (por ejemplo para probarla).
+func (s *ReporteProgramadoService) EnviarAhora(id uint) (*models.EnvioReporte, error) {
+	var p models.ProgramacionReporte
+	if err := database.DB.First(&p, id).Error; err != nil {
+		return nil, errors.New("reporte programado no encontrado")
+	}
+	return s.ejecutar(p)
+}
+
+// ReintentarEnvio vuelve a intentar un envío fallido o pendiente.
+func (s *ReporteProgramadoService) ReintentarEnvio(id uint) (*models.EnvioReporte, error) {
+	var envio models.EnvioReporte
+	if err := database.DB.Preload("Programacion").First(&envio, id).Error; err != nil {
+		return nil, errors.New("envío no encontrado")
+	}
+	if envio.Estado == "enviado" {
+		return nil, errors.New("el envío ya fue realizado")
+	}
+	// La tarea de reintentos puede estar procesando el mismo envío: solo lo
+	// envía quien logra tomarlo.
+	if !reclamarEnvio(envio.ID, "pendiente", "fallido") {
+		return nil, errors.New("el envío se está procesando, intente nuevamente en unos minutos")
+	}
+	s.procesarEnvio(&envio)
+	return &envio, nil
+}
+
+// ─── Generación de reportes ─────────────────────────────────────────────────
+
+// GenerarArchivo arma el reporte indicado para la fecha de referencia y lo
+// exporta al formato pedido. Devuelve nombre de archivo, content-type y contenido.
+func (s *ReporteProgramadoService) GenerarArchivo(reporte, formato string, fecha time.Time) (string, string, []byte, error) {
+	var doc *utils.DocumentoTabular
+	var nombre string
+	var err error
+
+	switch reporte {
+	case "cierre_diario":
+		doc, err = s.documentoCierreDiario(fecha)
+		nombre = "cierre_diario_" + fecha.Format("2006-01-02")
+	case "cobranza_alquileres":
+		doc, err = s.documentoCobranzaAlquileres(fecha)
+		nombre = "cobranza_alquileres_" + fecha.Format("2006-01")
+	default:
+		return "", "", nil, errors.New("tipo de reporte inválido")
+	}
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	switch formato {
+	case "pdf":
+		datos, err := utils.GenerarPDF(*doc)
+		return nombre + ".pdf", "application/pdf", datos, err
+	case "xlsx":
+		datos, err := utils.GenerarXLSX(*doc)
+		return nombre + ".xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", datos, err
+	default:
+		return "", "", nil, errors.New("formato inválido (use pdf o xlsx)")
+	}
+}
+
+// documentoCierreDiario: saldo global al cierre del día, detalle por caja,
+// arcos cerrados en el día y movimientos agrupados por concepto.
+func (s *ReporteProgramadoService) documentoCierreDiario(dia time.Time) (*utils.DocumentoTabular, error) {
+	inicio := time.Date(dia.Year(), dia.Month(), dia.Day(), 0, 0, 0, 0, time.Local)
+	fin := finDelDia(inicio)
+	corte := fin
+	if corte.After(time.Now()) {
+		corte = time.Now()
+	}
+
+	saldo, err := NewCajaGlobalService().GetSaldoEnFecha(corte)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &utils.DocumentoTabular{
+		Titulo:    "Cierre diario de caja",
+		Subtitulo: "Día " + inicio.Format("02/01/2006"),
+		Generado:  time.Now(),
+	}
+
+	doc.Secciones = append(doc.Secciones, utils.SeccionTabular{
+		Titulo:   "Resumen",
+		Columnas: []string{"Concepto", "Valor"},
+		Filas: [][]interface{}{
+			{"Saldo global al cierre", saldo.SaldoTotal},
+			{"Ingresos del día", saldo.TotalIngresos},
+			{"Egresos del día", saldo.TotalEgresos},
+			{"Retiros del día", saldo.TotalRetiros},
+			{"Cajas abiertas", saldo.CajasAbiertas},
+			{"Cajas cerradas", saldo.CajasCerradas},
+		},
+	})
+
+	cajas := utils.SeccionTabular{
+		Titulo:   "Saldo por caja",
+		Columnas: []string{"Usuario", "Arco", "Estado", "Saldo"},
+	}
+	for _, c := range saldo.Detalle {
+		estado := "Cerrada"
+		if c.Activo {
+			estado = "Abierta"
+		}
+		cajas.Filas = append(cajas.Filas, []interface{}{c.OwnerName, fmt.Sprintf("#%d", c.ArcoID), estado, c.Saldo})
+	}
+	cajas.Totales = []interface{}{"Total", "", "", saldo.SaldoTotal}
+	doc.Secciones = append(doc.Secciones, cajas)
+
+	var arcos []models.Arco
+	if err := database.DB.Preload("Owner").
+		Where("is_global = ? AND fecha_cierre >= ? AND fecha_cierre <= ?", false, inicio, fin).
+		Order("fecha_cierre ASC").Find(&arcos).Error; err != nil {
+		return nil, err
+	}
+	cerrados := utils.SeccionTabular{
+		Titulo:   "Arcos cerrados en el día",
+		Columnas: []string{"Usuario", "Turno", "Apertura", "Cierre", "Saldo inicial", "Saldo final"},
+	}
+	for _, a := range arcos {
+		cierre := ""
+		if a.HoraCierre != nil {
+			cierre = a.HoraCierre.Format("02/01 15:04")
+		}
+		cerrados.Filas = append(cerrados.Filas, []interface{}{
+			a.Owner.FullName, a.Turno, a.HoraApertura.Format("02/01 15:04"), cierre, a.SaldoInicial, a.SaldoFinal,
+		})
+	}
+	doc.Secciones = append(doc.Secciones, cerrados)
+
+	type filaConcepto struct {
+		ConceptName  string
+		MovementType string
+		Cantidad     int
+		Total        float64
+	}
+	var filas []filaConcepto
+	if err := database.DB.Table("movements m").
+		Select("COALESCE(c.concept_name, 'Sin concepto') AS concept_name, m.movement_type, COUNT(*) AS cantidad, SUM(m.amount) AS total").
+		Joins("LEFT JOIN concept_types c ON c.concept_id = m.concept_id").
+		Where("m.deleted_at IS NULL AND m.movement_date >= ? AND m.movement_date <= ?", inicio, fin).
+		Group("c.concept_name, m.movement_type").
+		Order("m.movement_type, total DESC").
+		Scan(&filas).Error; err != nil {
+		return nil, err
+	}
+	porConcepto := utils.SeccionTabular{
+		Titulo:   "Movimientos por concepto",
+		Columnas: []string{"Concepto", "Tipo", "Cantidad", "Total"},
+	}
+	for _, f := range filas {
+		porConcepto.Filas = append(porConcepto.Filas, []interface{}{f.ConceptName, f.MovementType, f.Cantidad, f.Total})
+	}
+	doc.Secciones = append(doc.Secciones, porConcepto)
+
+	return doc, nil
+}
+
+// documentoCobranzaAlquileres: estado de cobro de cada propiedad en el mes de `mes`.
+func (s *ReporteProgramadoService) documentoCobranzaAlquileres(mes time.Time) (*utils.DocumentoTabular, error) {
+	props, err := NewAlquilerService().GetPropiedades("", "", "", mes.Year())
+	if err != nil {
+		return nil, err
+	}
+	idx := int(mes.Month()) - 1
+
+	doc := &utils.DocumentoTabular{
+		Titulo:    "Cobranza de alquileres",
+		Subtitulo: fmt.Sprintf("%s %d", nombreMes(mes.Month()), mes.Year()),
+		Generado:  time.Now(),
+	}
+
+	detalle := utils.SeccionTabular{
+		Titulo:   "Detalle por propiedad",
+		Columnas: []string{"Dirección", "Inquilino", "Alquiler", "Estado", "Fecha de pago", "Cobrado", "Saldo"},
+	}
+	var esperado, cobrado, saldo float64
+	var pagadas, pendientes, atrasadas int
+	for _, p := range props {
+		if !p.Ocupada || idx >= len(p.Pagos) {
+			continue
+		}
+		pago := p.Pagos[idx]
+		fechaPago := ""
+		// Incluye cobros parciales y punitorios
+		montoCobrado := pago.Cobrado
+		if pago.FechaPago != nil {
+			fechaPago = pago.FechaPago.Format("02/01/2006")
+		}
+		if pago.Estado == models.PagadoEstado {
+			pagadas++
+		} else if pago.Estado == models.PendienteEstado {
+			pendientes++
+		} else {
+			atrasadas++
+		}
+		esperado += p.AlquilerMensual
+		cobrado += montoCobrado
+		detalle.Filas = append(detalle.Filas, []interface{}{
+			p.Direccion, p.Inquilino, p.AlquilerMensual, etiquetaEstadoPago(pago.Estado), fechaPago, montoCobrado, pago.Saldo,
+		})
+		saldo += pago.Saldo
+	}
+	detalle.Totales = []interface{}{"Total", "", roundDos(esperado), "", "", roundDos(cobrado), roundDos(saldo)}
+
+	porcentaje := "-"
+	if esperado > 0 {
+		porcentaje = fmt.Sprintf("%.1f %%", cobrado/esperado*100)
+	}
+	doc.Secciones = append(doc.Secciones, utils.SeccionTabular{
+		Titulo:   "Resumen",
+		Columnas: []string{"Concepto", "Valor"},
+		Filas: [][]interface{}{
+			{"Alquileres esperados", roundDos(esperado)},
+			{"Cobrado", roundDos(cobrado)},
+			{"Pendiente de cobro", roundDos(saldo)},
+			{"Porcentaje cobrado", porcentaje},
+			{"Propiedades pagadas", pagadas},
+			{"Propiedades pendientes", pendientes},
+			{"Propiedades con atraso", atrasadas},
+		},
+	}, detalle)
+
+	return doc, nil
+}
+
+// ─── Ejecución y reintentos ─────────────────────────────────────────────────
+
+// programar (re)registra la programación en el Scheduler según su estado actual.
+func (s *ReporteProgramadoService) programar(p models.ProgramacionReporte) {
+	desprogramar(p.ID)
+	if !p.Activo {
+		return
+	}
+
+	id := p.ID
+	entryID, err := GetScheduler().Programar("reporte:"+p.Nombre, p.Cron, func() {
+		var actual models.ProgramacionReporte
+		if err := database.DB.First(&actual, id).Error; err != nil || !actual.Activo {
+			return
+		}
+		if _, err := s.ejecutar(actual); err != nil {
+			utils.Logger.Error("Error ejecutando reporte programado",
+				zap.Uint("programacion_id", id),
+				zap.Error(err),
+			)
+		}
+	})
+	if err != nil {
+		utils.Logger.Error("No se pudo programar el reporte",
+			zap.Uint("programacion_id", p.ID),
+			zap.String("cron", p.Cron),
+			zap.Error(err),
+		)
+		return
+	}
+
+	entradasReporteMu.Lock()
+	entradasReporte[p.ID] = entryID
+	entradasReporteMu.Unlock()
+}
+
+func desprogramar(programacionID uint) {
+	entradasReporteMu.Lock()
+	defer entradasReporteMu.Unlock()
+	if entryID, ok := entradasReporte[programacionID]; ok {
+		GetScheduler().Quitar(entryID)
+		delete(entradasReporte, programacionID)
+	}
+}
+
+// ejecutar crea el registro de envío para el período que corresponde y lo procesa.
+func (s *ReporteProgramadoService) ejecutar(p models.ProgramacionReporte) (*models.EnvioReporte, error) {
+	envio := models.EnvioReporte{
+		ProgramacionID:  p.ID,
+		FechaReferencia: fechaReferencia(p, time.Now()),
+		Destinatarios:   p.Destinatarios,
+		Estado:          "enviando",
+		CreatedAt:       time.Now(),
+	}
+	if err := database.DB.Create(&envio).Error; err != nil {
+		return nil, err
+	}
+	envio.Programacion = p
+
+	s.procesarEnvio(&envio)
+	return &envio, nil
+}
+
+// procesarEnvio genera el archivo, lo envía y actualiza el registro.
+// Si falla, agenda un reintento con espera exponencial hasta maxIntentosEnvio.
+// El envío tiene que estar reclamado (en estado "enviando") por quien lo llama.
+func (s *ReporteProgramadoService) procesarEnvio(envio *models.EnvioReporte) {
+	p := envio.Programacion
+	envio.Intentos++
+
+	nombre, contentType, datos, err := s.GenerarArchivo(p.Reporte, p.Formato, envio.FechaReferencia)
+	if err == nil {
+		envio.Archivo = nombre
+		err = s.mailer.Enviar(Email{
+			Para:   separarDestinatarios(envio.Destinatarios),
+			Asunto: fmt.Sprintf("%s - %s", p.Nombre, etiquetaPeriodo(p.Reporte, envio.FechaReferencia)),
+			Cuerpo: fmt.Sprintf("Se adjunta el reporte \"%s\" correspondiente a %s.\n\nEste correo fue generado automáticamente.",
+				p.Nombre, etiquetaPeriodo(p.Reporte, envio.FechaReferencia)),
+			Adjuntos: []Adjunto{{Nombre: nombre, ContentType: contentType, Datos: datos}},
+		})
+	}
+
+	ahora := time.Now()
+	if err == nil {
+		envio.Estado = "enviado"
+		envio.EnviadoEn = &ahora
+		envio.ProximoIntento = nil
+		envio.Error = ""
+		database.DB.Model(&models.ProgramacionReporte{}).Where("id = ?", p.ID).Update("ultimo_envio", ahora)
+		utils.Logger.Info("Reporte programado enviado",
+			zap.Uint("envio_id", envio.ID),
+			zap.String("reporte", p.Reporte),
+			zap.String("destinatarios", envio.Destinatarios),
+		)
+	} else {
+		envio.Error = err.Error()
+		if envio.Intentos >= maxIntentosEnvio {
+			envio.Estado = "fallido"
+			envio.ProximoIntento = nil
+		} else {
+			envio.Estado = "pendiente"
+			proximo := ahora.Add(esperaBaseEnvio * time.Duration(1<<(envio.Intentos-1)))
+			envio.ProximoIntento = &proximo
+		}
+		utils.Logger.Warn("Falló el envío de reporte programado",
+			zap.Uint("envio_id", envio.ID),
+			zap.Int("intento", envio.Intentos),
+			zap.Error(err),
+		)
+	}
+
+	database.DB.Model(envio).Select("archivo", "estado", "intentos", "proximo_intento", "error", "enviado_en").Updates(envio)
+}
+
+// reintentarPendientes procesa los envíos fallidos cuyo próximo intento ya venció.
+func (s *ReporteProgramadoService) reintentarPendientes() {
+	var envios []models.EnvioReporte
+	if err := database.DB.Preload("Programacion").
+		Where("estado = ? AND proximo_intento IS NOT NULL AND proximo_intento <= ?", "pendiente", time.Now()).
+		Find(&envios).Error; err != nil {
+		utils.Logger.Error("Error buscando envíos pendientes", zap.Error(err))
+		return
+	}
+	for i := range envios {
+		if reclamarEnvio(envios[i].ID, "pendiente") {
+			s.procesarEnvio(&envios[i])
+		}
+	}
+}
+
+// reclamarEnvio pasa el envío a "enviando" si sigue en alguno de los estados
+// indicados. Devuelve false si otro proceso ya lo tomó.
+func reclamarEnvio(id uint, estados ...string) bool {
+	res := database.DB.Model(&models.EnvioReporte{}).
+		Where("id = ? AND estado IN ?", id, estados).
+		Update("estado", "enviando")
+	if res.Error != nil {
+		utils.Logger.Error("Error reclamando envío de reporte", zap.Uint("envio_id", id), zap.Error(res.Error))
+		return false
+	}
+	return res.RowsAffected == 1
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+func aplicarProgramacionRequest(p *models.ProgramacionReporte, req models.ProgramacionReporteRequest) {
+	p.Nombre = strings.TrimSpace(req.Nombre)
+	p.Reporte = req.Reporte
+	p.Formato = req.Formato
+	p.Cron = strings.TrimSpace(req.Cron)
+	p.Periodo = req.Periodo
+	if p.Periodo == "" {
+		p.Periodo = "actual"
+	}
+	p.Destinatarios = strings.Join(req.Destinatarios, ",")
+	if req.Activo != nil {
+		p.Activo = *req.Activo
+	}
+}
+
+// fechaReferencia devuelve el día (cierre diario) o el primer día del mes
+// (cobranza) que cubre el envío, según el período configurado.
+func fechaReferencia(p models.ProgramacionReporte, ahora time.Time) time.Time {
+	hoy := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.Local)
+	if p.Reporte == "cobranza_alquileres" {
+		mes := time.Date(hoy.Year(), hoy.Month(), 1, 0, 0, 0, 0, time.Local)
+		if p.Periodo == "anterior" {
+			mes = mes.AddDate(0, -1, 0)
+		}
+		return mes
+	}
+	if p.Periodo == "anterior" {
+		return hoy.AddDate(0, 0, -1)
+	}
+	return hoy
+}
+
+func etiquetaPeriodo(reporte string, fecha time.Time) string {
+	if reporte == "cobranza_alquileres" {
+		return fmt.Sprintf("%s %d", nombreMes(fecha.Month()), fecha.Year())
+	}
+	return fecha.Format("02/01/2006")
+}
+
+func separarDestinatarios(s string) []string {
+	var result []string
+	for _, d := range strings.Split(s, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func etiquetaEstadoPago(e models.EstadoPago) string {
+	switch e {
+	case models.PagadoEstado:
+		return "Pagado"
+	case models.Atraso1Estado:
+		return "Atraso 1 mes"
+	case models.Atraso2Estado:
+		return "Atraso 2+ meses"
+	default:
+		return "Pendiente"
+	}
+}
+
+func nombreMes(m time.Month) string {
+	return [...]string{"Enero", "Febrero", "Marzo", "Abril", "Mayo", "Junio", "Julio",
+		"Agosto", "Septiembre", "Octubre", "Noviembre", "Diciembre"}[m-1]
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"caja-fuerte/database"
+	"caja-fuerte/models"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// ReporteService arma los reportes agregados de movimientos (MySQL).
+type ReporteService struct{}
+
+func NewReporteService() *ReporteService {
+	return &ReporteService{}
+}
+
+// GetResumenMensual agrega los movimientos mes a mes entre desde y hasta (inclusive).
+// Si base no es nil, además expresa cada mes en pesos constantes del mes base
+// usando la copia local del IPC.
+func (s *ReporteService) GetResumenMensual(desde, hasta time.Time, base *time.Time, conceptID uint) (*models.ReporteMensual, error) {
+	desde = primerDiaMes(desde)
+	hasta = primerDiaMes(hasta)
+	if hasta.Before(desde) {
+		return nil, errors.New("el rango de meses es inválido")
+	}
+	if hasta.Sub(desde) > 10*366*24*time.Hour {
+		return nil, errors.New("el rango no puede superar diez años")
+	}
+
+	type filaMes struct {
+		Periodo  string
+		Ingresos float64
+		Egresos  float64
+		Retiros  float64
+	}
+	var filas []filaMes
+	query := database.DB.Model(&models.Movement{}).
+		Select(`DATE_FORMAT(movement_date, '%Y-%m') AS periodo,
+			COALESCE(SUM(CASE WHEN movement_type = 'Ingreso' THEN amount ELSE 0 END),0) AS ingresos,
+			COALESCE(SUM(CASE WHEN movement_type = 'Egreso' THEN amount ELSE 0 END),0) AS egresos,
+			COALESCE(SUM(CASE WHEN movement_type = 'RetiroCaja' THEN amount ELSE 0 END),0) AS retiros`).
+		Where("deleted_at IS NULL").
+		Where("movement_date >= ? AND movement_date < ?",
+			time.Date(desde.Year(), desde.Month(), 1, 0, 0, 0, 0, time.Local),
+			time.Date(hasta.Year(), hasta.Month()+1, 1, 0, 0, 0, 0, time.Local))
+	if conceptID > 0 {
+		query = query.Where("concept_id = ?", conceptID)
+	}
+	if err := query.Group("periodo").Scan(&filas).Error; err != nil {
+		return nil, err
+	}
+	porMes := map[string]filaMes{}
+	for _, f := range filas {
+		porMes[f.Periodo] = f
+	}
+
+	var indice *IndicePrecios
+	if base != nil {
+		var err error
+		indice, err = NewInflacionService().GetIndicePrecios()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reporte := &models.ReporteMensual{
+		Desde: claveMes(desde),
+		Hasta: claveMes(hasta),
+		Meses: []models.ResumenMensual{},
+	}
+	if indice != nil {
+		reporte.BaseReal = claveMes(*base)
+		reporte.TotalReal = &models.MontosReporte{}
+		reporte.FuenteIPC = fuenteIPC
+		actualizado := indice.ActualizadoEn()
+		reporte.IPCActualizado = &actualizado
+		reporte.UltimoPeriodoIPC = claveMes(indice.UltimoPeriodo())
+	}
+
+	for mes := desde; !mes.After(hasta); mes = mes.AddDate(0, 1, 0) {
+		f := porMes[claveMes(mes)]
+		resumen := models.ResumenMensual{
+			Periodo: claveMes(mes),
+			Nominal: montosReporte(f.Ingresos, f.Egresos, f.Retiros, 1),
+		}
+		sumarMontos(&reporte.TotalNominal, resumen.Nominal)
+
+		if indice != nil {
+			factor, estimado := indice.FactorReal(mes, *base)
+			enReales := montosReporte(f.Ingresos, f.Egresos, f.Retiros, factor)
+			resumen.Real = &enReales
+			resumen.FactorIPC = roundCuatro(factor)
+			resumen.IPCEstimado = estimado
+			sumarMontos(reporte.TotalReal, enReales)
+		}
+
+		reporte.Meses = append(reporte.Meses, resumen)
+	}
+
+	return reporte, nil
+}
+
+// GetComparativoInteranual compara cada mes de `anio` con el mismo mes del año
+// anterior, en pesos nominales y en pesos constantes del mes base.
+// Si base es nil se usa el último mes con IPC publicado.
+func (s *ReporteService) GetComparativoInteranual(anio int, base *time.Time) (*models.ComparativoInteranual, error) {
+	if base == nil {
+		indice, err := NewInflacionService().GetIndicePrecios()
+		if err != nil {
+			return nil, err
+		}
+		ultimo := indice.UltimoPeriodo()
+		base = &ultimo
+	}
+
+	inicioActual := time.Date(anio, 1, 1, 0, 0, 0, 0, time.UTC)
+	inicioAnterior := inicioActual.AddDate(-1, 0, 0)
+
+	actual, err := s.GetResumenMensual(inicioActual, inicioActual.AddDate(0, 11, 0), base, 0)
+	if err != nil {
+		return nil, err
+	}
+	anterior, err := s.GetResumenMensual(inicioAnterior, inicioAnterior.AddDate(0, 11, 0), base, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	comp := &models.ComparativoInteranual{
+		Anio:        anio,
+		BaseReal:    claveMes(*base),
+		Actual:      actual,
+		Anterior:    anterior,
+		Variaciones: make([]models.VariacionMensual, 12),
+	}
+	for i := 0; i < 12; i++ {
+		act, ant := actual.Meses[i], anterior.Meses[i]
+		comp.Variaciones[i] = models.VariacionMensual{
+			Mes:                i + 1,
+			IngresosNominalPct: variacionPct(act.Nominal.Ingresos, ant.Nominal.Ingresos),
+			IngresosRealPct:    variacionPct(act.Real.Ingresos, ant.Real.Ingresos),
+			EgresosNominalPct:  variacionPct(act.Nominal.Egresos, ant.Nominal.Egresos),
+			EgresosRealPct:     variacionPct(act.Real.Egresos, ant.Real.Egresos),
+		}
+	}
+	return comp, nil
+}
+
+// GetDesempenoCajeros arma el reporte de discrepancias por usuario para los arcos
+// abiertos entre desde y hasta (inclusive). Con userID > 0 se limita a ese usuario.
+func (s *ReporteService) GetDesempenoCajeros(desde, hasta time.Time, userID uint) (*models.ReporteCajeros, error) {
+	desde = time.Date(desde.Year(), desde.Month(), desde.Day(), 0, 0, 0, 0, time.Local)
+	hasta = time.Date(hasta.Year(), hasta.Month(), hasta.Day(), 0, 0, 0, 0, time.Local)
+	if hasta.Before(desde) {
+		return nil, errors.New("el rango de fechas es inválido")
+	}
+	fin := hasta.AddDate(0, 0, 1)
+
+	type filaArcos struct {
+		OwnerID       uint
+		Periodo       string
+		Arcos         int
+		Cerrados      int
+		SegundosTotal float64
+		ConConteo     int
+		ConFaltante   int
+		ConSobrante   int
+		Faltante      float64
+		Sobrante      float64
+		Automaticos   int
+	}
+	var filas []filaArcos
+	query := database.DB.Model(&models.Arco{}).
+		Select(`owner_id,
+			DATE_FORMAT(fecha_apertura, '%Y-%m') AS periodo,
+			COUNT(*) AS arcos,
+			COALESCE(SUM(CASE WHEN fecha_cierre IS NOT NULL THEN 1 ELSE 0 END),0) AS cerrados,
+			COALESCE(SUM(CASE WHEN fecha_cierre IS NOT NULL THEN TIMESTAMPDIFF(SECOND, fecha_apertura, fecha_cierre) ELSE 0 END),0) AS segundos_total,
+			COALESCE(SUM(CASE WHEN total_contado IS NOT NULL THEN 1 ELSE 0 END),0) AS con_conteo,
+			COALESCE(SUM(CASE WHEN diferencia < -0.005 THEN 1 ELSE 0 END),0) AS con_faltante,
+			COALESCE(SUM(CASE WHEN diferencia > 0.005 THEN 1 ELSE 0 END),0) AS con_sobrante,
+			COALESCE(SUM(CASE WHEN diferencia < -0.005 THEN -diferencia ELSE 0 END),0) AS faltante,
+			COALESCE(SUM(CASE WHEN diferencia > 0.005 THEN diferencia ELSE 0 END),0) AS sobrante,
+			COALESCE(SUM(CASE WHEN cierre_automatico THEN 1 ELSE 0 END),0) AS automaticos`).
+		Where("is_global = ? AND fecha_apertura >= ? AND fecha_apertura < ?", false, desde, fin)
+	if userID > 0 {
+		query = query.Where("owner_id = ?", userID)
+	}
+	if err := query.Group("owner_id, periodo").Order("periodo").Scan(&filas).Error; err != nil {
+		return nil, err
+	}
+
+	// Movimientos eliminados por cada usuario en el período (incluye soft-deleted)
+	type filaEliminados struct {
+		DeletedBy uint
+		Periodo   string
+		Cantidad  int
+	}
+	var eliminados []filaEliminados
+	queryElim := database.DB.Unscoped().Model(&models.Movement{}).
+		Select("deleted_by, DATE_FORMAT(deleted_at, '%Y-%m') AS periodo, COUNT(*) AS cantidad").
+		Where("deleted_by IS NOT NULL AND deleted_at >= ? AND deleted_at < ?", desde, fin)
+	if userID > 0 {
+		queryElim = queryElim.Where("deleted_by = ?", userID)
+	}
+	if err := queryElim.Group("deleted_by, periodo").Scan(&eliminados).Error; err != nil {
+		return nil, err
+	}
+
+	cajeros := map[uint]*models.DesempenoCajero{}
+	tendencias := map[uint]map[string]*models.TendenciaCajero{}
+	segundos := map[uint]float64{}
+	obtener := func(id uint, periodo string) (*models.DesempenoCajero, *models.TendenciaCajero) {
+		if cajeros[id] == nil {
+			cajeros[id] = &models.DesempenoCajero{OwnerID: id}
+			tendencias[id] = map[string]*models.TendenciaCajero{}
+		}
+		if tendencias[id][periodo] == nil {
+			tendencias[id][periodo] = &models.TendenciaCajero{Periodo: periodo}
+		}
+		return cajeros[id], tendencias[id][periodo]
+	}
+
+	for _, f := range filas {
+		c, t := obtener(f.OwnerID, f.Periodo)
+		c.CantidadArcos += f.Arcos
+		c.ArcosCerrados += f.Cerrados
+		c.ArcosConConteo += f.ConConteo
+		c.ArcosConFaltante += f.ConFaltante
+		c.ArcosConSobrante += f.ConSobrante
+		c.TotalFaltante += f.Faltante
+		c.TotalSobrante += f.Sobrante
+		c.CierresAutomaticos += f.Automaticos
+		segundos[f.OwnerID] += f.SegundosTotal
+
+		t.CantidadArcos = f.Arcos
+		t.ArcosConConteo = f.ConConteo
+		t.TotalFaltante = roundDos(f.Faltante)
+		t.TotalSobrante = roundDos(f.Sobrante)
+		t.CierresAutomaticos = f.Automaticos
+		if f.Cerrados > 0 {
+			t.DuracionPromedioMin = roundDos(f.SegundosTotal / float64(f.Cerrados) / 60)
+		}
+	}
+	for _, e := range eliminados {
+		c, t := obtener(e.DeletedBy, e.Periodo)
+		c.MovimientosEliminados += e.Cantidad
+		t.MovimientosEliminados = e.Cantidad
+	}
+
+	ids := make([]uint, 0, len(cajeros))
+	for id := range cajeros {
+		ids = append(ids, id)
+	}
+	var usuarios []models.User
+	if len(ids) > 0 {
+		if err := database.DB.Where("user_id IN ?", ids).Find(&usuarios).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range usuarios {
+		cajeros[u.UserID].Nombre = u.FullName
+		cajeros[u.UserID].Email = u.Email
+	}
+
+	reporte := &models.ReporteCajeros{
+		Desde:   desde.Format("2006-01-02"),
+		Hasta:   hasta.Format("2006-01-02"),
+		Cajeros: []models.DesempenoCajero{},
+	}
+	for id, c := range cajeros {
+		if c.ArcosCerrados > 0 {
+			c.DuracionPromedioMin = roundDos(segundos[id] / float64(c.ArcosCerrados) / 60)
+			c.PctCierresAutomaticos = roundDos(float64(c.CierresAutomaticos) / float64(c.ArcosCerrados) * 100)
+		}
+		if c.ArcosConConteo > 0 {
+			c.FaltantePromedio = roundDos(c.TotalFaltante / float64(c.ArcosConConteo))
+			c.SobrantePromedio = roundDos(c.TotalSobrante / float64(c.ArcosConConteo))
+		}
+		c.TotalFaltante = roundDos(c.TotalFaltante)
+		c.TotalSobrante = roundDos(c.TotalSobrante)
+		c.DiferenciaNeta = roundDos(c.TotalSobrante - c.TotalFaltante)
+
+		// Tendencia mes a mes, incluyendo los meses sin actividad
+		c.Tendencia = []models.TendenciaCajero{}
+		for mes := primerDiaMes(desde); claveMes(mes) <= claveMes(hasta); mes = mes.AddDate(0, 1, 0) {
+			if t, ok := tendencias[id][claveMes(mes)]; ok {
+				c.Tendencia = append(c.Tendencia, *t)
+			} else {
+				c.Tendencia = append(c.Tendencia, models.TendenciaCajero{Periodo: claveMes(mes)})
+			}
+		}
+		reporte.Cajeros = append(reporte.Cajeros, *c)
+	}
+
+	// Los que más faltante acumulan primero
+	sort.Slice(reporte.Cajeros, func(i, j int) bool {
+		if reporte.Cajeros[i].TotalFaltante != reporte.Cajeros[j].TotalFaltante {
+			return reporte.Cajeros[i].TotalFaltante > reporte.Cajeros[j].TotalFaltante
+		}
+		return reporte.Cajeros[i].Nombre < reporte.Cajeros[j].Nombre
+	})
+
+	return reporte, nil
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+func montosReporte(ingresos, egresos, retiros, factor float64) models.MontosReporte {
+	m := models.MontosReporte{
+		Ingresos: roundDos(ingresos * factor),
+		Egresos:  roundDos(egresos * factor),
+		Retiros:  roundDos(retiros * factor),
+	}
+	m.Neto = roundDos(m.Ingresos - m.Egresos - m.Retiros)
+	return m
+}
+
+func sumarMontos(total *models.MontosReporte, m models.MontosReporte) {
+	total.Ingresos = roundDos(total.Ingresos + m.Ingresos)
+	total.Egresos = roundDos(total.Egresos + m.Egresos)
+	total.Retiros = roundDos(total.Retiros + m.Retiros)
+	total.Neto = roundDos(total.Neto + m.Neto)
+}
+
+func variacionPct(actual, anterior float64) *float64 {
+	if anterior == 0 {
+		return nil
+	}
+	v := roundDos((actual/anterior - 1) * 100)
+	return &v
+}
+
+func roundCuatro(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
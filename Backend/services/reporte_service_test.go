@@ -0,0 +1,58 @@
+package services
+
+import (
+	"caja-fuerte/models"
+	"testing"
+)
+
+func TestMontosReporte(t *testing.T) {
+	casos := []struct {
+		nombre                     string
+		ingresos, egresos, retiros float64
+		factor                     float64
+		want                       models.MontosReporte
+	}{
+		{"mes con ganancia", 1000, 400, 100, 1, models.MontosReporte{Ingresos: 1000, Egresos: 400, Retiros: 100, Neto: 500}},
+		{"mes con pérdida", 1000, 1500.25, 0, 1, models.MontosReporte{Ingresos: 1000, Egresos: 1500.25, Neto: -500.25}},
+		{"pérdida de un centavo", 100, 100.01, 0, 1, models.MontosReporte{Ingresos: 100, Egresos: 100.01, Neto: -0.01}},
+		{"pérdida en pesos constantes", 1000, 1100, 0, 1.5, models.MontosReporte{Ingresos: 1500, Egresos: 1650, Neto: -150}},
+		{"redondeo del factor", 333.33, 0, 0, 1.015, models.MontosReporte{Ingresos: 338.33, Neto: 338.33}},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := montosReporte(c.ingresos, c.egresos, c.retiros, c.factor); got != c.want {
+				t.Errorf("montosReporte() = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestVariacionPct(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+	casos := []struct {
+		nombre           string
+		actual, anterior float64
+		want             *float64
+	}{
+		{"sin año anterior", 1000, 0, nil},
+		{"suba", 1500, 1000, ptr(50)},
+		{"baja", 750, 1000, ptr(-25)},
+		{"baja pequeña", 999.9, 1000, ptr(-0.01)},
+		{"baja con redondeo", 2, 3, ptr(-33.33)},
+		{"de ganancia a pérdida", -500, 1000, ptr(-150)},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			got := variacionPct(c.actual, c.anterior)
+			if got == nil || c.want == nil {
+				if got != c.want {
+					t.Errorf("variacionPct(%v, %v) = %v, want %v", c.actual, c.anterior, got, c.want)
+				}
+				return
+			}
+			if *got != *c.want {
+				t.Errorf("variacionPct(%v, %v) = %v, want %v", c.actual, c.anterior, *got, *c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"caja-fuerte/utils"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// Scheduler es el programador de tareas interno (expresiones cron de 5 campos,
+// hora local). Es único para todo el proceso: los servicios registran sus
+// tareas y main.go lo arranca y detiene.
+type Scheduler struct {
+	mu   sync.Mutex
+	cron *cron.Cron
+}
+
+var (
+	schedulerOnce     sync.Once
+	schedulerInstance *Scheduler
+)
+
+// GetScheduler devuelve el programador compartido.
+func GetScheduler() *Scheduler {
+	schedulerOnce.Do(func() {
+		logger := cronLogger{}
+		schedulerInstance = &Scheduler{
+			cron: cron.New(
+				cron.WithLocation(time.Local),
+				cron.WithLogger(logger),
+				// Una tarea lenta no se superpone con su siguiente ejecución
+				// y un panic no tira abajo el programador
+				cron.WithChain(cron.Recover(logger), cron.SkipIfStillRunning(logger)),
+			),
+		}
+	})
+	return schedulerInstance
+}
+
+// ValidarCron verifica una expresión cron estándar ("min hora dia mes dia_semana").
+func ValidarCron(expr string) error {
+	_, err := cron.ParseStandard(expr)
+	return err
+}
+
+// Programar registra una tarea y devuelve su ID para poder quitarla después.
+func (s *Scheduler) Programar(nombre, expr string, tarea func()) (cron.EntryID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := s.cron.AddFunc(expr, func() {
+		utils.Logger.Debug("Ejecutando tarea programada", zap.String("tarea", nombre))
+		tarea()
+	})
+	if err != nil {
+		return 0, err
+	}
+	utils.Logger.Info("Tarea programada",
+		zap.String("tarea", nombre),
+		zap.String("cron", expr),
+	)
+	return id, nil
+}
+
+// Quitar elimina una tarea registrada.
+func (s *Scheduler) Quitar(id cron.EntryID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cron.Remove(id)
+}
+
+// ProximaEjecucion devuelve cuándo corre la tarea (cero si no existe o si el
+// programador no está iniciado).
+func (s *Scheduler) ProximaEjecucion(id cron.EntryID) time.Time {
+	return s.cron.Entry(id).Next
+}
+
+// Start inicia el programador.
+func (s *Scheduler) Start() {
+	s.cron.Start()
+	utils.Logger.Info("Programador de tareas iniciado")
+}
+
+// Stop detiene el programador esperando a que terminen las tareas en curso.
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+	utils.Logger.Info("Programador de tareas detenido")
+}
+
+// cronLogger adapta los logs de robfig/cron a zap.
+type cronLogger struct{}
+
+func (cronLogger) Info(msg string, keysAndValues ...interface{}) {
+	utils.SugarLogger.Debugw("cron: "+msg, keysAndValues...)
+}
+
+func (cronLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	utils.SugarLogger.Errorw("cron: "+msg, append(keysAndValues, "error", err)...)
+}
@@ -0,0 +1,284 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// DocumentoTabular es un reporte genérico formado por secciones con tablas.
+// El mismo documento se puede exportar a PDF o a XLSX.
+type DocumentoTabular struct {
+	Titulo    string
+	Subtitulo string
+	Generado  time.Time
+	Secciones []SeccionTabular
+}
+
+// SeccionTabular es una tabla del documento. Las celdas pueden ser string,
+// int o float64; los float64 se tratan como montos en pesos.
+type SeccionTabular struct {
+	Titulo   string
+	Columnas []string
+	Filas    [][]interface{}
+	Totales  []interface{} // Fila final opcional, se muestra en negrita
+}
+
+// FormatMonto formatea un monto al estilo argentino: 1.234.567,89
+func FormatMonto(v float64) string {
+	neg := v < 0
+	v = math.Abs(v)
+
+	parteEntera := int64(v)
+	parteDecimal := int64(math.Round((v - float64(parteEntera)) * 100))
+	if parteDecimal == 100 {
+		parteEntera++
+		parteDecimal = 0
+	}
+
+	s := strconv.FormatInt(parteEntera, 10)
+	result := ""
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			result += "."
+		}
+		result += string(c)
+	}
+	result = result + "," + fmt.Sprintf("%02d", parteDecimal)
+
+	if neg {
+		return "-" + result
+	}
+	return result
+}
+
+// GenerarPDF exporta el documento a PDF (A4 vertical).
+func GenerarPDF(doc DocumentoTabular) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 12, 10)
+	pdf.SetAutoPageBreak(true, 12)
+	// fpdf usa cp1252 con las fuentes estándar: traducimos UTF-8 para acentos y ñ
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.CellFormat(0, 8, tr(doc.Titulo), "", 1, "L", false, 0, "")
+	if doc.Subtitulo != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, tr(doc.Subtitulo), "", 1, "L", false, 0, "")
+	}
+	generado := doc.Generado
+	if generado.IsZero() {
+		generado = time.Now()
+	}
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.CellFormat(0, 5, tr("Generado el "+generado.Format("02/01/2006 15:04")), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	anchoUtil, _ := pdf.GetPageSize()
+	izq, _, der, _ := pdf.GetMargins()
+	anchoUtil -= izq + der
+
+	for _, sec := range doc.Secciones {
+		if sec.Titulo != "" {
+			pdf.SetFont("Helvetica", "B", 11)
+			pdf.CellFormat(0, 7, tr(sec.Titulo), "", 1, "L", false, 0, "")
+		}
+		if len(sec.Columnas) == 0 {
+			continue
+		}
+
+		pdf.SetFont("Helvetica", "", 8)
+		anchos := anchosColumnas(pdf, tr, sec, anchoUtil)
+
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(230, 230, 230)
+		for i, col := range sec.Columnas {
+			pdf.CellFormat(anchos[i], 6, tr(col), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("Helvetica", "", 8)
+		for _, fila := range sec.Filas {
+			escribirFilaPDF(pdf, tr, anchos, fila)
+		}
+		if len(sec.Totales) > 0 {
+			pdf.SetFont("Helvetica", "B", 8)
+			escribirFilaPDF(pdf, tr, anchos, sec.Totales)
+		}
+		pdf.Ln(4)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerarXLSX exporta el documento a Excel: una hoja por sección.
+func GenerarXLSX(doc DocumentoTabular) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	estiloTitulo, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 13}})
+	estiloHeader, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#E6E6E6"}},
+	})
+	formatoMonto := "#,##0.00"
+	estiloMonto, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &formatoMonto})
+	estiloTotal, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &formatoMonto})
+
+	usadas := map[string]bool{}
+	for i, sec := range doc.Secciones {
+		hoja := nombreHoja(sec.Titulo, i, usadas)
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", hoja); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(hoja); err != nil {
+			return nil, err
+		}
+
+		f.SetCellValue(hoja, "A1", doc.Titulo)
+		f.SetCellStyle(hoja, "A1", "A1", estiloTitulo)
+		f.SetCellValue(hoja, "A2", doc.Subtitulo)
+		f.SetCellValue(hoja, "A3", sec.Titulo)
+
+		filaExcel := 5
+		for c, col := range sec.Columnas {
+			celda, _ := excelize.CoordinatesToCellName(c+1, filaExcel)
+			f.SetCellValue(hoja, celda, col)
+			f.SetCellStyle(hoja, celda, celda, estiloHeader)
+			nombreCol, _ := excelize.ColumnNumberToName(c + 1)
+			f.SetColWidth(hoja, nombreCol, nombreCol, 18)
+		}
+
+		for _, fila := range sec.Filas {
+			filaExcel++
+			escribirFilaXLSX(f, hoja, filaExcel, fila, estiloMonto)
+		}
+		if len(sec.Totales) > 0 {
+			filaExcel++
+			escribirFilaXLSX(f, hoja, filaExcel, sec.Totales, estiloTotal)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ─── Helpers ────────────────────────────────────────────────────────────────
+
+func textoCelda(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return "$ " + FormatMonto(x)
+	case int:
+		return strconv.Itoa(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+func alineacionCelda(v interface{}) string {
+	switch v.(type) {
+	case float64, int:
+		return "R"
+	default:
+		return "L"
+	}
+}
+
+func escribirFilaPDF(pdf *fpdf.Fpdf, tr func(string) string, anchos []float64, fila []interface{}) {
+	for i := range anchos {
+		var v interface{}
+		if i < len(fila) {
+			v = fila[i]
+		}
+		texto := tr(textoCelda(v))
+		// Recortar textos que no entran en la celda
+		for len(texto) > 1 && pdf.GetStringWidth(texto) > anchos[i]-2 {
+			texto = texto[:len(texto)-1]
+		}
+		pdf.CellFormat(anchos[i], 5, texto, "1", 0, alineacionCelda(v), false, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// anchosColumnas reparte el ancho útil proporcionalmente al texto más largo de cada columna.
+func anchosColumnas(pdf *fpdf.Fpdf, tr func(string) string, sec SeccionTabular, anchoUtil float64) []float64 {
+	anchos := make([]float64, len(sec.Columnas))
+	medir := func(i int, v interface{}) {
+		if w := pdf.GetStringWidth(tr(textoCelda(v))) + 4; w > anchos[i] {
+			anchos[i] = w
+		}
+	}
+	for i, col := range sec.Columnas {
+		medir(i, col)
+	}
+	for _, fila := range append(sec.Filas, sec.Totales) {
+		for i := 0; i < len(fila) && i < len(anchos); i++ {
+			medir(i, fila[i])
+		}
+	}
+
+	total := 0.0
+	for _, w := range anchos {
+		total += w
+	}
+	if total == 0 {
+		return anchos
+	}
+	for i := range anchos {
+		anchos[i] = anchos[i] / total * anchoUtil
+	}
+	return anchos
+}
+
+func escribirFilaXLSX(f *excelize.File, hoja string, filaExcel int, fila []interface{}, estiloNumero int) {
+	for c, v := range fila {
+		celda, _ := excelize.CoordinatesToCellName(c+1, filaExcel)
+		f.SetCellValue(hoja, celda, v)
+		if _, ok := v.(float64); ok {
+			f.SetCellStyle(hoja, celda, celda, estiloNumero)
+		}
+	}
+}
+
+// nombreHoja genera un nombre de hoja válido (máx. 31 caracteres, único).
+func nombreHoja(titulo string, i int, usadas map[string]bool) string {
+	nombre := titulo
+	for _, c := range []string{":", "\\", "/", "?", "*", "[", "]"} {
+		nombre = replaceAll(nombre, c, " ")
+	}
+	if r := []rune(nombre); len(r) > 28 {
+		nombre = string(r[:28])
+	}
+	if nombre == "" {
+		nombre = fmt.Sprintf("Hoja %d", i+1)
+	}
+	base := nombre
+	for n := 2; usadas[nombre]; n++ {
+		nombre = fmt.Sprintf("%s %d", base, n)
+	}
+	usadas[nombre] = true
+	return nombre
+}
+
+func replaceAll(s, viejo, nuevo string) string {
+	return string(bytes.ReplaceAll([]byte(s), []byte(viejo), []byte(nuevo)))
+}
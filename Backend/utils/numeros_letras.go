@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	unidadesLetras = []string{"", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve",
+		"diez", "once", "doce", "trece", "catorce", "quince", "dieciséis", "diecisiete", "dieciocho", "diecinueve",
+		"veinte", "veintiuno", "veintidós", "veintitrés", "veinticuatro", "veinticinco", "veintiséis",
+		"veintisiete", "veintiocho", "veintinueve"}
+	decenasLetras  = []string{"", "", "", "treinta", "cuarenta", "cincuenta", "sesenta", "setenta", "ochenta", "noventa"}
+	centenasLetras = []string{"", "ciento", "doscientos", "trescientos", "cuatrocientos", "quinientos",
+		"seiscientos", "setecientos", "ochocientos", "novecientos"}
+)
+
+// NumeroEnLetras escribe un entero no negativo en castellano ("1521" → "mil quinientos veintiuno").
+func NumeroEnLetras(n int64) string {
+	if n == 0 {
+		return "cero"
+	}
+	return strings.TrimSpace(enLetras(n))
+}
+
+// MontoEnLetras escribe un monto en pesos para recibos:
+// 1234.5 → "PESOS MIL DOSCIENTOS TREINTA Y CUATRO CON 50/100".
+func MontoEnLetras(v float64) string {
+	v = math.Abs(v)
+	entero := int64(v)
+	centavos := int64(math.Round((v - float64(entero)) * 100))
+	if centavos == 100 {
+		entero++
+		centavos = 0
+	}
+	// "pesos" va antes del número, así que "uno" no se apocopa: "PESOS VEINTIUNO"
+	return strings.ToUpper(fmt.Sprintf("pesos %s con %02d/100", NumeroEnLetras(entero), centavos))
+}
+
+func enLetras(n int64) string {
+	switch {
+	case n >= 1_000_000_000_000:
+		return escalaLetras(n, 1_000_000_000_000, "billón", "billones")
+	case n >= 1_000_000:
+		return escalaLetras(n, 1_000_000, "millón", "millones")
+	case n >= 1000:
+		miles, resto := n/1000, n%1000
+		s := "mil"
+		if miles > 1 {
+			s = apocopar(enLetras(miles)) + " mil"
+		}
+		if resto > 0 {
+			s += " " + enLetras(resto)
+		}
+		return s
+	case n >= 100:
+		if n == 100 {
+			return "cien"
+		}
+		s := centenasLetras[n/100]
+		if n%100 > 0 {
+			s += " " + enLetras(n%100)
+		}
+		return s
+	case n >= 30:
+		s := decenasLetras[n/10]
+		if n%10 > 0 {
+			s += " y " + unidadesLetras[n%10]
+		}
+		return s
+	default:
+		return unidadesLetras[n]
+	}
+}
+
+// escalaLetras escribe millones o billones: "un millón", "dos millones trescientos mil".
+func escalaLetras(n, escala int64, singular, plural string) string {
+	cant, resto := n/escala, n%escala
+	s := "un " + singular
+	if cant > 1 {
+		s = apocopar(enLetras(cant)) + " " + plural
+	}
+	if resto > 0 {
+		s += " " + enLetras(resto)
+	}
+	return s
+}
+
+// apocopar adapta "uno" delante de "mil"/"millones": "veintiún mil", "treinta y un millones".
+func apocopar(s string) string {
+	switch {
+	case strings.HasSuffix(s, "veintiuno"):
+		return strings.TrimSuffix(s, "veintiuno") + "veintiún"
+	case strings.HasSuffix(s, "uno"):
+		return strings.TrimSuffix(s, "uno") + "un"
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+package utils
+
+import "testing"
+
+func TestNumeroEnLetras(t *testing.T) {
+	casos := []struct {
+		n    int64
+		want string
+	}{
+		{0, "cero"},
+		{1, "uno"},
+		{15, "quince"},
+		{21, "veintiuno"},
+		{30, "treinta"},
+		{45, "cuarenta y cinco"},
+		{100, "cien"},
+		{101, "ciento uno"},
+		{999, "novecientos noventa y nueve"},
+		{1000, "mil"},
+		{1521, "mil quinientos veintiuno"},
+		{21000, "veintiún mil"},
+		{31000, "treinta y un mil"},
+		{100000, "cien mil"},
+		{1_000_000, "un millón"},
+		{2_300_000, "dos millones trescientos mil"},
+		{21_000_001, "veintiún millones uno"},
+		{1_000_000_000_000, "un billón"},
+	}
+	for _, c := range casos {
+		if got := NumeroEnLetras(c.n); got != c.want {
+			t.Errorf("NumeroEnLetras(%d) = %q, want %q", c.n, got, c.want)
+		}
+	}
+}
+
+func TestMontoEnLetras(t *testing.T) {
+	casos := []struct {
+		monto float64
+		want  string
+	}{
+		{0, "PESOS CERO CON 00/100"},
+		{1, "PESOS UNO CON 00/100"},
+		{21, "PESOS VEINTIUNO CON 00/100"},
+		{100, "PESOS CIEN CON 00/100"},
+		{1000, "PESOS MIL CON 00/100"},
+		{1_000_000, "PESOS UN MILLÓN CON 00/100"},
+		{1234.5, "PESOS MIL DOSCIENTOS TREINTA Y CUATRO CON 50/100"},
+		{0.07, "PESOS CERO CON 07/100"},
+		{150000.99, "PESOS CIENTO CINCUENTA MIL CON 99/100"},
+		{99.999, "PESOS CIEN CON 00/100"}, // los centavos redondean al peso siguiente
+		{-250.25, "PESOS DOSCIENTOS CINCUENTA CON 25/100"},
+	}
+	for _, c := range casos {
+		if got := MontoEnLetras(c.monto); got != c.want {
+			t.Errorf("MontoEnLetras(%v) = %q, want %q", c.monto, got, c.want)
+		}
+	}
+}
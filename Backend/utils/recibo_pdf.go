@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// DocumentoRecibo son los datos impresos en un recibo de alquiler.
+type DocumentoRecibo struct {
+	Numero          string // 0001-00000123
+	Fecha           time.Time
+	Emisor          string
+	EmisorCUIT      string
+	EmisorDomicilio string
+	Inquilino       string
+	Direccion       string
+	Periodo         string // "Marzo 2026"
+	Monto           float64
+	Capital         float64
+	Punitorio       float64
+	MontoEnLetras   string
+	MedioPago       string
+	Parcial         bool
+	Anulado         bool
+	Reimpresion     bool
+}
+
+// GenerarReciboPDF arma el recibo (A5 apaisado). Los recibos anulados llevan
+// la leyenda ANULADO y las reimpresiones se marcan como DUPLICADO.
+func GenerarReciboPDF(r DocumentoRecibo) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A5", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(false, 10)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	ancho, _ := pdf.GetPageSize()
+	ancho -= 24
+
+	// Encabezado: emisor a la izquierda, número y fecha a la derecha
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(ancho/2, 7, tr(r.Emisor), "", 0, "L", false, 0, "")
+	pdf.CellFormat(ancho/2, 7, tr("RECIBO N° "+r.Numero), "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	izq := r.EmisorDomicilio
+	if r.EmisorCUIT != "" {
+		if izq != "" {
+			izq += " - "
+		}
+		izq += "CUIT " + r.EmisorCUIT
+	}
+	pdf.CellFormat(ancho/2, 5, tr(izq), "", 0, "L", false, 0, "")
+	pdf.CellFormat(ancho/2, 5, tr("Fecha: "+r.Fecha.Format("02/01/2006")), "", 1, "R", false, 0, "")
+	if r.Reimpresion {
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(ancho, 4, "DUPLICADO", "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+	y := pdf.GetY()
+	pdf.Line(12, y, 12+ancho, y)
+	pdf.Ln(5)
+
+	// Cuerpo
+	fila := func(etiqueta, valor string) {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(40, 7, tr(etiqueta), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(ancho-40, 7, tr(valor), "", "L", false)
+	}
+	fila("Recibí de:", r.Inquilino)
+	fila("La suma de:", r.MontoEnLetras)
+	concepto := "Alquiler " + r.Periodo + " - " + r.Direccion
+	if r.Parcial {
+		concepto += " (pago parcial)"
+	}
+	fila("En concepto de:", concepto)
+	if r.Punitorio > 0 {
+		fila("Detalle:", "Alquiler $ "+FormatMonto(r.Capital)+" + punitorios $ "+FormatMonto(r.Punitorio))
+	}
+	fila("Medio de pago:", r.MedioPago)
+
+	// Total
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(ancho, 9, tr("TOTAL $ "+FormatMonto(r.Monto)), "1", 1, "R", false, 0, "")
+
+	// Firma
+	pdf.SetY(-30)
+	pdf.SetX(12 + ancho - 70)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(70, 5, tr("Firma y aclaración"), "T", 1, "C", false, 0, "")
+
+	if r.Anulado {
+		pdf.SetFont("Helvetica", "B", 60)
+		pdf.SetTextColor(200, 30, 30)
+		pdf.TransformBegin()
+		pdf.TransformRotate(20, 105, 80)
+		pdf.Text(45, 95, "ANULADO")
+		pdf.TransformEnd()
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
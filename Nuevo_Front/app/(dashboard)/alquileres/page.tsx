@@ -11,6 +11,7 @@ import { useNotification } from '@/components/ui/Notification'
 import { getPropiedades, getResumenKPIs, deshacerPago, getPropiedad } from '@/lib/api/alquileres'
 import { Propiedad, ResumenKPIs, FiltroEstado, EstadoPago } from '@/types/alquiler'
 import { KPICards }               from '@/components/alquileres/KPICards'
+import { DocumentosPorVencer }     from '@/components/alquileres/DocumentosPorVencer'
 import { ReportePanel }            from '@/components/alquileres/ReportePanel'
 import { TablaPropiedades }        from '@/components/alquileres/TablaPropiedades'
 import { ModalDetalle }            from '@/components/alquileres/ModalDetalle'
@@ -83,7 +84,7 @@ export default function AlquileresPage() {
       if (!admin) return
       if (!confirm(`¿Revertir el pago de ${['Ene','Feb','Mar','Abr','May','Jun','Jul','Ago','Sep','Oct','Nov','Dic'][mes]}?`)) return
       try {
-        const res = await deshacerPago(propId, mes)
+        const res = await deshacerPago(propId, mes, anio)
         actualizarProp(res.propiedad)
         show('Pago revertido', 'info')
         recargarKpis()
@@ -96,7 +97,7 @@ export default function AlquileresPage() {
   // ── Abrir detalle (carga datos frescos del backend) ───────────────────
   const handleVerDetalle = async (propId: string) => {
     try {
-      const prop = await getPropiedad(propId)
+      const prop = await getPropiedad(propId, anio)
       setPropDetalle(prop)
     } catch (err: any) {
       show(err.message, 'error')
@@ -163,6 +164,9 @@ export default function AlquileresPage() {
       {/* ── KPIs ──────────────────────────────────────────────────────── */}
       <KPICards kpis={kpis} loading={loadingTabla} />
 
+      {/* ── Documentos vencidos o por vencer ──────────────────────────── */}
+      {kpis?.documentos_por_vencer && <DocumentosPorVencer documentos={kpis.documentos_por_vencer} />}
+
       {/* ── Panel de reporte (solo Admin) ─────────────────────────────── */}
       {admin && <ReportePanel />}
 
@@ -5,7 +5,7 @@
 // Equivale a actOverlay del alquileres.html original.
 
 import { useState, useEffect } from 'react'
-import { ActualizacionPendiente } from '@/types/alquiler'
+import { ActualizacionPendiente, DatosInflacion } from '@/types/alquiler'
 import {
   getActualizacionesPendientes,
   confirmarActualizacion,
@@ -59,7 +59,8 @@ export function ActualizacionOverlay({ onActualizado }: Props) {
 
   const item  = pendientes[indice]
   const prop  = item.propiedad
-  const infl  = item.inflacion || { fuente: '', meses: [], acumulado_pct: 0 }
+  const infl: DatosInflacion = item.inflacion || { fuente: '', meses: [], acumulado_pct: 0 }
+  const nombreIndice = (infl.indice || prop.tipo_indice || 'ipc').toUpperCase()
   const total = pendientes.length
   const fmtFull = (n: number) =>
     new Intl.NumberFormat('es-AR', { style: 'currency', currency: 'ARS', minimumFractionDigits: 2 }).format(n)
@@ -162,15 +163,46 @@ export function ActualizacionOverlay({ onActualizado }: Props) {
           <div className="bg-gray-50 rounded-2xl p-4">
             <div className="flex items-center justify-between mb-3">
               <p className="font-semibold text-gray-700 flex items-center gap-2 text-sm">
-                📊 Inflación del período
+                📊 {nombreIndice === 'IPC' ? 'Inflación del período' : `Variación del ${nombreIndice}`}
               </p>
-              <span className="text-xs text-gray-400">{infl.fuente || 'INDEC vía ArgentinaDatos'}</span>
+              <span className="text-xs text-gray-400 text-right">
+                {infl.fuente || 'INDEC vía ArgentinaDatos'}
+                {infl.manual && ' · incluye carga manual'}
+                {infl.actualizado_en && (
+                  <span className="block">Actualizado {new Date(infl.actualizado_en).toLocaleDateString('es-AR')}</span>
+                )}
+              </span>
             </div>
 
-            {infl.meses.length === 0 ? (
+            {infl.valor_desde && infl.valor_hasta ? (
+              <table className="w-full text-sm mb-2">
+                <tbody className="divide-y divide-gray-100">
+                  <tr>
+                    <td className="py-1.5 text-gray-700">
+                      {nombreIndice} al {infl.fecha_valor_desde ? new Date(infl.fecha_valor_desde).toLocaleDateString('es-AR') : '—'}
+                    </td>
+                    <td className="py-1.5 text-right font-medium text-blue-600">{infl.valor_desde.toFixed(4)}</td>
+                  </tr>
+                  <tr>
+                    <td className="py-1.5 text-gray-700">
+                      {nombreIndice} al {infl.fecha_valor_hasta ? new Date(infl.fecha_valor_hasta).toLocaleDateString('es-AR') : '—'}
+                    </td>
+                    <td className="py-1.5 text-right font-medium text-blue-600">{infl.valor_hasta.toFixed(4)}</td>
+                  </tr>
+                </tbody>
+                <tfoot>
+                  <tr className="border-t-2 border-gray-300">
+                    <td className="py-2 font-bold text-gray-800">Variación</td>
+                    <td className="py-2 text-right font-bold text-2xl text-amber-600">
+                      {infl.acumulado_pct?.toFixed(2) ?? '0.00'}%
+                    </td>
+                  </tr>
+                </tfoot>
+              </table>
+            ) : infl.meses.length === 0 ? (
               <div className="flex items-center gap-2 text-amber-600 text-sm">
                 <span>⚠️</span>
-                <span>Sin datos de IPC disponibles. Podés ingresar el monto manualmente.</span>
+                <span>Sin datos de {nombreIndice} disponibles. Podés ingresar el monto manualmente.</span>
               </div>
             ) : (
               <div>
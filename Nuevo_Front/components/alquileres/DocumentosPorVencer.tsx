@@ -0,0 +1,70 @@
+'use client'
+
+// ─── Documentos vencidos o por vencer (seguros, garantías…) ──────────────────
+
+import { TipoDocumentoAdjunto, VencimientoDocumento } from '@/types/alquiler'
+import { urlDocumentoArchivo } from '@/lib/api/alquileres'
+import { AppIcon } from '@/components/ui/AppIcon'
+
+const TIPO_LABEL: Record<TipoDocumentoAdjunto, string> = {
+  contrato_firmado: 'Contrato firmado',
+  inventario:       'Inventario',
+  seguro:           'Seguro',
+  garantia:         'Garantía',
+  otro:             'Documento',
+}
+
+interface Props {
+  documentos: VencimientoDocumento[]
+}
+
+export function DocumentosPorVencer({ documentos }: Props) {
+  if (documentos.length === 0) return null
+
+  return (
+    <section className="surface-card min-w-0 border-t-4 border-t-amber-500 p-4 sm:p-5" aria-label="Documentos por vencer">
+      <div className="mb-3 flex items-center gap-2">
+        <span className="flex h-9 w-9 items-center justify-center rounded-xl bg-amber-50 text-amber-700">
+          <AppIcon name="calendar" className="h-5 w-5" />
+        </span>
+        <h2 className="text-sm font-bold uppercase tracking-wide text-slate-600">
+          Documentos por vencer ({documentos.length})
+        </h2>
+      </div>
+      <ul className="divide-y divide-slate-100">
+        {documentos.map((d) => {
+          const vencido = d.dias_restantes < 0
+          return (
+            <li key={d.id} className="flex min-w-0 flex-wrap items-center justify-between gap-2 py-2 text-sm">
+              <div className="min-w-0">
+                <p className="truncate font-semibold text-slate-800">{d.direccion}</p>
+                <p className="truncate text-slate-500">
+                  {TIPO_LABEL[d.tipo]}{d.descripcion ? ` · ${d.descripcion}` : ''}
+                  {d.vencimiento && ` · vence ${new Date(d.vencimiento).toLocaleDateString('es-AR')}`}
+                </p>
+              </div>
+              <div className="flex items-center gap-2">
+                <span className={`rounded-full px-2.5 py-0.5 text-xs font-bold
+                  ${vencido ? 'bg-red-50 text-red-700' : 'bg-amber-50 text-amber-700'}`}>
+                  {vencido
+                    ? `Vencido hace ${-d.dias_restantes} ${d.dias_restantes === -1 ? 'día' : 'días'}`
+                    : d.dias_restantes === 0 ? 'Vence hoy'
+                    : `Vence en ${d.dias_restantes} ${d.dias_restantes === 1 ? 'día' : 'días'}`}
+                </span>
+                <a
+                  href={urlDocumentoArchivo(d.id)}
+                  target="_blank"
+                  rel="noopener noreferrer"
+                  className="flex items-center gap-1 rounded-lg border border-slate-200 px-2 py-1 text-xs font-medium text-slate-600 hover:bg-slate-50"
+                >
+                  <AppIcon name="eye" className="h-4 w-4" />
+                  Ver
+                </a>
+              </div>
+            </li>
+          )
+        })}
+      </ul>
+    </section>
+  )
+}
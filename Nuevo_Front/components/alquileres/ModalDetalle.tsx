@@ -65,7 +65,7 @@ export function ModalDetalle({ propiedad, open, onClose, onUpdate, onDelete, esA
       if (!esAdmin) return
       if (!confirm(`¿Revertir el pago de ${MESES[i]}?`)) return
       try {
-        const res = await deshacerPago(prop.id, i)
+        const res = await deshacerPago(prop.id, i, prop.anio_pagos)
         actualizar(res.propiedad)
         show(`Pago de ${MESES[i]} revertido`, 'info')
       } catch (err: any) { show(err.message, 'error') }
@@ -198,7 +198,7 @@ export function ModalDetalle({ propiedad, open, onClose, onUpdate, onDelete, esA
                 <div className="grid grid-cols-3 gap-2">
                   {(prop.imagenes || []).map((src, idx) => (
                     <div key={idx} className="relative group rounded-xl overflow-hidden aspect-video bg-gray-100">
-                      <img src={src} alt="" className="w-full h-full object-cover cursor-pointer"
+                      <img src={miniatura(src)} alt="" className="w-full h-full object-cover cursor-pointer"
                         onClick={() => setLightboxSrc(src)} />
                       {esAdmin && (
                         <button onClick={() => eliminarImagen(idx)}
@@ -351,6 +351,11 @@ export function ModalDetalle({ propiedad, open, onClose, onUpdate, onDelete, esA
 }
 
 // ── Sub-componentes ───────────────────────────────────────────────────────────
+// Las imágenes guardadas en el backend tienen una miniatura para la grilla
+function miniatura(src: string): string {
+  return src.startsWith('/api/alquileres/imagenes/') ? `${src}/miniatura` : src
+}
+
 function InfoItem({ label, value, color = 'text-gray-800' }: { label: string; value: string; color?: string }) {
   return (
     <div className="bg-gray-50 rounded-xl px-3 py-2">
@@ -2,11 +2,11 @@
 
 // ─── Modal: Registrar pago de un mes ─────────────────────────────────────────
 
-import { useState } from 'react'
+import { useState, useEffect } from 'react'
 import { Modal } from '@/components/ui/Modal'
 import { useNotification } from '@/components/ui/Notification'
-import { registrarPago } from '@/lib/api/alquileres'
-import { Propiedad } from '@/types/alquiler'
+import { registrarPago, getLiquidacion, getCajasDestino, urlReciboPDF } from '@/lib/api/alquileres'
+import { Propiedad, LiquidacionPeriodo, MedioPago, CajaDestino } from '@/types/alquiler'
 import { MESES, fmt } from './helpers'
 
 interface Props {
@@ -22,16 +22,38 @@ export function ModalPago({ open, propId, mes, propiedad, onClose, onSuccess }:
   const { show } = useNotification()
   const [monto, setMonto]       = useState(String(propiedad.alquiler_mensual || ''))
   const [guardando, setGuardando] = useState(false)
+  const [liq, setLiq]           = useState<LiquidacionPeriodo | null>(null)
+  const [medio, setMedio]       = useState<MedioPago>('efectivo')
+  const [cajas, setCajas]       = useState<CajaDestino[]>([])
+  const [arcoId, setArcoId]     = useState<number | undefined>()
+
+  // Precargar el saldo del mes con los punitorios a la fecha
+  useEffect(() => {
+    if (!open) return
+    getLiquidacion(propId, mes, propiedad.anio_pagos)
+      .then((l) => { setLiq(l); setMonto(String(l.total)) })
+      .catch(() => setLiq(null))
+  }, [open, propId, mes, propiedad.anio_pagos])
+
+  // Cajas abiertas: por defecto la propia
+  useEffect(() => {
+    if (!open) return
+    getCajasDestino()
+      .then(({ cajas }) => { setCajas(cajas); setArcoId(cajas[0]?.arco_id) })
+      .catch(() => setCajas([]))
+  }, [open])
 
   const esDolar = propiedad.paga_en_dolares
 
   const confirmar = async () => {
     const parsed = parseFloat(monto)
     if (!parsed || parsed <= 0) { show('Ingresá un monto válido', 'warning'); return }
+    if (!arcoId) { show('No hay ninguna caja abierta para recibir el pago', 'warning'); return }
     setGuardando(true)
     try {
-      const res = await registrarPago(propId, mes, parsed)
+      const res = await registrarPago(propId, mes, parsed, propiedad.anio_pagos, medio, arcoId)
       show(`✅ Pago de ${MESES[mes]} registrado`, 'success')
+      if (res.recibo) window.open(urlReciboPDF(res.recibo.id), '_blank')
       onSuccess(res.propiedad)
       onClose()
     } catch (err: any) {
@@ -78,6 +100,16 @@ export function ModalPago({ open, propId, mes, propiedad, onClose, onSuccess }:
               Alquiler habitual: <strong>{fmt(propiedad.alquiler_mensual)}</strong>
             </p>
           )}
+          {liq && liq.pagado > 0 && (
+            <p className="text-gray-600">
+              Ya cobrado: <strong>{fmt(liq.pagado)}</strong>
+            </p>
+          )}
+          {liq && (liq.punitorio_pendiente + liq.punitorio_nuevo) > 0 && (
+            <p className="text-red-600">
+              Punitorios ({liq.dias_atraso} días): <strong>{fmt(liq.punitorio_pendiente + liq.punitorio_nuevo)}</strong>
+            </p>
+          )}
         </div>
 
         <div>
@@ -95,6 +127,41 @@ export function ModalPago({ open, propId, mes, propiedad, onClose, onSuccess }:
             className="w-full border border-gray-200 rounded-xl px-4 py-2.5 text-lg font-bold text-emerald-600 focus:ring-2 focus:ring-emerald-300 focus:outline-none"
           />
         </div>
+
+        <div>
+          <label className="block text-sm font-medium text-gray-700 mb-1">Medio de pago</label>
+          <select
+            value={medio}
+            onChange={(e) => setMedio(e.target.value as MedioPago)}
+            className="w-full border border-gray-200 rounded-xl px-4 py-2.5 focus:ring-2 focus:ring-emerald-300 focus:outline-none"
+          >
+            <option value="efectivo">Efectivo</option>
+            <option value="transferencia">Transferencia</option>
+            <option value="cheque">Cheque</option>
+            <option value="otro">Otro</option>
+          </select>
+        </div>
+
+        <div>
+          <label className="block text-sm font-medium text-gray-700 mb-1">
+            Caja destino <span className="text-red-500">*</span>
+          </label>
+          {cajas.length === 0 ? (
+            <p className="text-sm text-red-600">No hay cajas abiertas. Abrí una caja para registrar el pago.</p>
+          ) : (
+            <select
+              value={arcoId ?? ''}
+              onChange={(e) => setArcoId(Number(e.target.value))}
+              className="w-full border border-gray-200 rounded-xl px-4 py-2.5 focus:ring-2 focus:ring-emerald-300 focus:outline-none"
+            >
+              {cajas.map((c) => (
+                <option key={c.arco_id} value={c.arco_id}>
+                  {c.propia ? 'Mi caja' : c.responsable} — turno {c.turno === 'M' ? 'mañana' : 'tarde'}
+                </option>
+              ))}
+            </select>
+          )}
+        </div>
       </div>
     </Modal>
   )
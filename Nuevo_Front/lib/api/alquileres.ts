@@ -1,7 +1,10 @@
 // ─── Capa de servicios: Alquileres ───────────────────────────────────────────
 
 import {
-  Propiedad, ResumenKPIs, ResumenMovimientos, ActualizacionPendiente,
+  Propiedad, ResumenKPIs, ResumenMovimientos, ActualizacionPendiente, LiquidacionPeriodo,
+  MedioPago, Recibo, CajaDestino, Edificio, GastoEdificio, ResumenEdificio,
+  HistorialOcupacion, ReporteOcupacion, TramoOcupacion, Reclamo, PrioridadReclamo, EstadoReclamo,
+  CambioPropiedad, MontoAlquiler, Documento, VencimientoDocumento, TipoDocumentoAdjunto,
 } from '@/types/alquiler'
 import { apiRequest } from './client'
 
@@ -13,13 +16,18 @@ async function apiFetch<T>(method: string, url: string, body?: object): Promise<
 }
 
 // ── Propiedades ───────────────────────────────────────────────────────────────
-export async function getPropiedades(anio?: number): Promise<{ propiedades: Propiedad[] }> {
-  const qs = anio ? `?anio=${anio}` : ''
+// edificioId: solo las unidades de ese edificio
+export async function getPropiedades(anio?: number, edificioId?: string): Promise<{ propiedades: Propiedad[] }> {
+  const params = new URLSearchParams()
+  if (anio) params.set('anio', String(anio))
+  if (edificioId) params.set('edificio_id', edificioId)
+  const qs = params.toString() ? `?${params}` : ''
   return apiFetch('GET', `/api/alquileres/propiedades${qs}`)
 }
 
-export async function getPropiedad(id: string): Promise<Propiedad> {
-  return apiFetch('GET', `/api/alquileres/propiedades/${id}`)
+export async function getPropiedad(id: string, anio?: number): Promise<Propiedad> {
+  const qs = anio ? `?anio=${anio}` : ''
+  return apiFetch('GET', `/api/alquileres/propiedades/${id}${qs}`)
 }
 
 export async function createPropiedad(data: Partial<Propiedad>): Promise<{ propiedad: Propiedad }> {
@@ -34,17 +42,223 @@ export async function deletePropiedad(id: string): Promise<void> {
   return apiFetch('DELETE', `/api/alquileres/propiedades/${id}`)
 }
 
+// ── Historial de ocupación ────────────────────────────────────────────────────
+export async function getHistorialOcupacion(propId: string): Promise<HistorialOcupacion> {
+  return apiFetch('GET', `/api/alquileres/propiedades/${propId}/ocupacion`)
+}
+
+// desde / hasta en AAAA-MM-DD (sin desde: el 1° de enero)
+export async function getReporteOcupacion(desde?: string, hasta?: string): Promise<ReporteOcupacion> {
+  const params = new URLSearchParams()
+  if (desde) params.set('desde', desde)
+  if (hasta) params.set('hasta', hasta)
+  const qs = params.toString() ? `?${params}` : ''
+  return apiFetch('GET', `/api/alquileres/ocupacion${qs}`)
+}
+
+export async function updateTramoOcupacion(
+  tramoId: string, data: { motivo?: string; alquiler_mensual?: number }
+): Promise<{ tramo: TramoOcupacion }> {
+  return apiFetch('PUT', `/api/alquileres/ocupacion/${tramoId}`, data)
+}
+
+// ── Reclamos de mantenimiento ─────────────────────────────────────────────────
+// Sin estado: pendientes (abiertos, asignados y en curso); 'todos' los incluye a todos
+export async function getReclamos(
+  filtros: { estado?: EstadoReclamo | 'pendientes' | 'todos'; prioridad?: PrioridadReclamo } = {}
+): Promise<{ reclamos: Reclamo[]; total: number }> {
+  const params = new URLSearchParams()
+  if (filtros.estado) params.set('estado', filtros.estado)
+  if (filtros.prioridad) params.set('prioridad', filtros.prioridad)
+  const qs = params.toString() ? `?${params}` : ''
+  return apiFetch('GET', `/api/alquileres/reclamos${qs}`)
+}
+
+export async function getReclamosPropiedad(
+  propId: string, estado?: EstadoReclamo | 'pendientes'
+): Promise<{ reclamos: Reclamo[]; total: number }> {
+  const qs = estado ? `?estado=${estado}` : ''
+  return apiFetch('GET', `/api/alquileres/propiedades/${propId}/reclamos${qs}`)
+}
+
+export async function getReclamo(id: string): Promise<Reclamo> {
+  return apiFetch('GET', `/api/alquileres/reclamos/${id}`)
+}
+
+export async function createReclamo(
+  propId: string,
+  data: {
+    titulo: string; descripcion?: string; prioridad?: PrioridadReclamo
+    reportado_por?: string; telefono?: string; persona_id?: string
+    imagenes?: string[]; proveedor?: string; proveedor_telefono?: string; costo?: number
+  }
+): Promise<{ reclamo: Reclamo }> {
+  return apiFetch('POST', `/api/alquileres/propiedades/${propId}/reclamos`, data)
+}
+
+export async function updateReclamo(
+  id: string,
+  data: Partial<Pick<Reclamo,
+    'titulo' | 'descripcion' | 'prioridad' | 'reportado_por' | 'telefono' |
+    'imagenes' | 'proveedor' | 'proveedor_telefono' | 'costo'>>
+): Promise<{ reclamo: Reclamo }> {
+  return apiFetch('PUT', `/api/alquileres/reclamos/${id}`, data)
+}
+
+export async function cambiarEstadoReclamo(
+  id: string, estado: EstadoReclamo, nota?: string
+): Promise<{ reclamo: Reclamo }> {
+  return apiFetch('POST', `/api/alquileres/reclamos/${id}/estado`, { estado, nota })
+}
+
+export async function deleteReclamo(id: string): Promise<void> {
+  return apiFetch('DELETE', `/api/alquileres/reclamos/${id}`)
+}
+
+// Registra el arreglo como gasto de reparación de la propiedad
+export async function pagarReclamo(
+  id: string,
+  data: {
+    monto: number; fecha?: string; a_cargo_de?: 'propietario' | 'inquilino' | 'administracion'
+    registrar_en_caja?: boolean; movement_id?: number
+  }
+): Promise<{ reclamo: Reclamo }> {
+  return apiFetch('POST', `/api/alquileres/reclamos/${id}/pago`, data)
+}
+
+export async function anularPagoReclamo(id: string): Promise<{ reclamo: Reclamo }> {
+  return apiFetch('DELETE', `/api/alquileres/reclamos/${id}/pago`)
+}
+
+// ── Documentos adjuntos ───────────────────────────────────────────────────────
+export async function getDocumentosPropiedad(propId: string): Promise<{ documentos: Documento[] }> {
+  return apiFetch('GET', `/api/alquileres/propiedades/${propId}/documentos`)
+}
+
+export async function getDocumentosContrato(contratoId: string): Promise<{ documentos: Documento[] }> {
+  return apiFetch('GET', `/api/alquileres/contratos/${contratoId}/documentos`)
+}
+
+// vencimiento: AAAA-MM-DD (vacío = no vence); contratoId asocia el documento a un contrato
+export async function subirDocumento(
+  propId: string,
+  archivo: File,
+  datos: { tipo: TipoDocumentoAdjunto; descripcion?: string; vencimiento?: string; contratoId?: string }
+): Promise<{ documento: Documento }> {
+  const form = new FormData()
+  form.set('archivo', archivo)
+  form.set('tipo', datos.tipo)
+  if (datos.descripcion) form.set('descripcion', datos.descripcion)
+  if (datos.vencimiento) form.set('vencimiento', datos.vencimiento)
+  if (datos.contratoId) form.set('contrato_id', datos.contratoId)
+  return apiRequest(`/api/alquileres/propiedades/${propId}/documentos`, { method: 'POST', body: form })
+}
+
+// vencimiento: '' quita el vencimiento
+export async function actualizarDocumento(
+  id: string, data: { tipo?: TipoDocumentoAdjunto; descripcion?: string; vencimiento?: string }
+): Promise<{ documento: Documento }> {
+  return apiFetch('PUT', `/api/alquileres/documentos/${id}`, data)
+}
+
+export async function eliminarDocumento(id: string): Promise<{ message: string }> {
+  return apiFetch('DELETE', `/api/alquileres/documentos/${id}`)
+}
+
+// Vencidos o por vencer en los próximos `dias` días (contratos vigentes)
+export async function getVencimientosDocumentos(dias = 30): Promise<{ documentos: VencimientoDocumento[] }> {
+  return apiFetch('GET', `/api/alquileres/documentos/vencimientos?dias=${dias}`)
+}
+
+// URL del archivo (descargar: como adjunto en lugar de verlo en el navegador)
+export function urlDocumentoArchivo(id: string, descargar = false): string {
+  return `/api/alquileres/documentos/${id}/archivo${descargar ? '?descargar=1' : ''}`
+}
+
+// ── Edificios ─────────────────────────────────────────────────────────────────
+export async function getEdificios(): Promise<{ edificios: Edificio[] }> {
+  return apiFetch('GET', '/api/alquileres/edificios')
+}
+
+export async function getEdificio(id: string): Promise<Edificio & { propiedades: Propiedad[] }> {
+  return apiFetch('GET', `/api/alquileres/edificios/${id}`)
+}
+
+export async function getResumenEdificio(id: string, anio?: number): Promise<ResumenEdificio> {
+  const qs = anio ? `?anio=${anio}` : ''
+  return apiFetch('GET', `/api/alquileres/edificios/${id}/resumen${qs}`)
+}
+
+export async function createEdificio(
+  data: { nombre: string; direccion?: string; notas?: string }
+): Promise<{ edificio: Edificio }> {
+  return apiFetch('POST', '/api/alquileres/edificios', data)
+}
+
+export async function updateEdificio(
+  id: string, data: { nombre: string; direccion?: string; notas?: string }
+): Promise<{ edificio: Edificio }> {
+  return apiFetch('PUT', `/api/alquileres/edificios/${id}`, data)
+}
+
+export async function deleteEdificio(id: string): Promise<void> {
+  return apiFetch('DELETE', `/api/alquileres/edificios/${id}`)
+}
+
+export async function getGastosEdificio(id: string): Promise<{ gastos: GastoEdificio[] }> {
+  return apiFetch('GET', `/api/alquileres/edificios/${id}/gastos`)
+}
+
+// Gasto común: se prorratea entre las unidades según su coeficiente
+export async function createGastoEdificio(
+  id: string,
+  data: {
+    categoria: string; monto: number; descripcion?: string; fecha?: string
+    a_cargo_de?: string; registrar_en_caja?: boolean; movement_id?: number
+  }
+): Promise<{ gasto: GastoEdificio }> {
+  return apiFetch('POST', `/api/alquileres/edificios/${id}/gastos`, data)
+}
+
+export async function deleteGastoEdificio(gastoId: string): Promise<void> {
+  return apiFetch('DELETE', `/api/alquileres/gastos-edificio/${gastoId}`)
+}
+
 // ── Pagos ─────────────────────────────────────────────────────────────────────
+// arco_id: caja que recibe el cobro (sin indicar, la caja abierta del usuario)
 export async function registrarPago(
-  propId: string, mes: number, monto: number
-): Promise<{ propiedad: Propiedad }> {
-  return apiFetch('POST', `/api/alquileres/propiedades/${propId}/pago`, { mes, monto })
+  propId: string, mes: number, monto: number, anio?: number, medio_pago?: MedioPago, arco_id?: number
+): Promise<{ propiedad: Propiedad; recibo?: Recibo }> {
+  return apiFetch('POST', `/api/alquileres/propiedades/${propId}/pago`, { mes, monto, anio, medio_pago, arco_id })
+}
+
+// Cajas abiertas que pueden recibir un cobro (la del usuario primero)
+export async function getCajasDestino(): Promise<{ cajas: CajaDestino[] }> {
+  return apiFetch('GET', '/api/alquileres/cajas-destino')
+}
+
+// URL del PDF del recibo (verlo no cuenta como reimpresión)
+export function urlReciboPDF(reciboId: string): string {
+  return `/api/alquileres/recibos/${reciboId}/pdf`
+}
+
+// Registra una reimpresión; después urlReciboPDF descarga el DUPLICADO
+export async function reimprimirRecibo(reciboId: string): Promise<{ recibo: Recibo }> {
+  return apiFetch('POST', `/api/alquileres/recibos/${reciboId}/reimprimir`)
+}
+
+export async function getLiquidacion(
+  propId: string, mes: number, anio?: number
+): Promise<LiquidacionPeriodo> {
+  const qs = anio ? `?anio=${anio}` : ''
+  return apiFetch('GET', `/api/alquileres/propiedades/${propId}/pago/${mes}/liquidacion${qs}`)
 }
 
 export async function deshacerPago(
-  propId: string, mes: number
+  propId: string, mes: number, anio?: number
 ): Promise<{ propiedad: Propiedad }> {
-  return apiFetch('DELETE', `/api/alquileres/propiedades/${propId}/pago/${mes}`)
+  const qs = anio ? `?anio=${anio}` : ''
+  return apiFetch('DELETE', `/api/alquileres/propiedades/${propId}/pago/${mes}${qs}`)
 }
 
 // ── Resúmenes ─────────────────────────────────────────────────────────────────
@@ -64,12 +278,14 @@ export async function getActualizacionesPendientes(): Promise<{ pendientes: Actu
   return apiFetch('GET', '/api/alquileres/actualizaciones-pendientes')
 }
 
+// vigenteDesde: mes desde el que rige el nuevo monto (por defecto, el actual)
 export async function confirmarActualizacion(
-  propId: string, nuevoMonto: number, notas?: string
+  propId: string, nuevoMonto: number, notas?: string, vigenteDesde?: string
 ): Promise<void> {
   return apiFetch('PUT', `/api/alquileres/propiedades/${propId}/actualizar-monto`, {
     nuevo_monto: nuevoMonto,
     notas,
+    vigente_desde: vigenteDesde,
   })
 }
 
@@ -81,6 +297,18 @@ export async function posponerActualizacion(
   })
 }
 
+// ── Historial de la propiedad ─────────────────────────────────────────────────
+export async function getHistorialPropiedad(
+  propId: string, campo?: string
+): Promise<{ cambios: CambioPropiedad[]; total: number }> {
+  const qs = campo ? `?campo=${encodeURIComponent(campo)}` : ''
+  return apiFetch('GET', `/api/alquileres/propiedades/${propId}/historial${qs}`)
+}
+
+export async function getMontosPropiedad(propId: string): Promise<{ montos: MontoAlquiler[]; total: number }> {
+  return apiFetch('GET', `/api/alquileres/propiedades/${propId}/montos`)
+}
+
 // ── Metadata ──────────────────────────────────────────────────────────────────
 export async function deleteMetadataCampo(
   propId: string, campo: string
@@ -5,7 +5,65 @@ export type EstadoPago = 'paid' | 'pending' | 'late_1' | 'late_2'
 export interface PagoMes {
   mes:    number      // 0-11
   estado: EstadoPago
-  monto:  number      // monto pagado (solo si estado === 'paid')
+  monto:  number      // alquiler del mes
+  cobrado?: number    // cobrado hasta ahora (incluye pagos parciales y punitorios)
+  saldo?:   number    // pendiente de cobro
+  cantidad_pagos?: number
+}
+
+export interface LiquidacionPeriodo {
+  anio:                number
+  mes:                 number
+  vencimiento:         string
+  alquiler:            number
+  pagado:              number
+  saldo_alquiler:      number
+  punitorio_pendiente: number
+  punitorio_nuevo:     number
+  dias_atraso:         number
+  total:               number
+}
+
+export type MedioPago = 'efectivo' | 'transferencia' | 'cheque' | 'otro'
+
+export interface Recibo {
+  id:                string
+  numero_formateado: string
+  propiedad_id:      string
+  direccion:         string
+  inquilino:         string
+  anio:              number
+  mes:               number
+  monto:             number
+  monto_en_letras:   string
+  medio_pago:        MedioPago
+  parcial:           boolean
+  fecha_emision:     string
+  impresiones:       number // reimpresiones registradas (el PDF sale como DUPLICADO)
+  estado:            'emitido' | 'anulado'
+}
+
+// Caja abierta que puede recibir un cobro
+export interface CajaDestino {
+  arco_id:        number
+  responsable:    string
+  owner_id:       number
+  turno:          'M' | 'T'
+  fecha_apertura: string
+  propia:         boolean
+}
+
+export type TipoIndice = 'ipc' | 'icl' | 'cer' | 'uva' | 'cac' | 'ninguno'
+export type TipoCotizacion = 'oficial' | 'mep' | 'blue'
+
+export interface Cotizacion {
+  tipo:           TipoCotizacion
+  fecha:          string
+  compra:         number
+  venta:          number
+  fuente:         string
+  actualizado_en: string
+  manual:         boolean
 }
 
 export interface Propiedad {
@@ -16,11 +74,20 @@ export interface Propiedad {
   alquiler_mensual:        number
   paga_en_dolares:         boolean
   monto_dolares?:          number
+  tipo_cotizacion?:        TipoCotizacion // vacío = oficial
   indice_inflacion?:       number
   fecha_actualizacion?:    string   // ISO string
   frecuencia_actualizacion?: number // meses
+  tipo_indice?:            TipoIndice // vacío = IPC
+  dia_vencimiento?:        number   // vacío = reglas generales de mora
+  dias_gracia?:            number
   pagos:                   PagoMes[]
-  imagenes?:               string[] // base64 o URLs
+  anio_pagos?:             number   // año al que corresponde `pagos`
+  propietario_id?:         string   // dueño si se administra por cuenta de terceros
+  edificio_id?:            string   // edificio del que es unidad
+  unidad?:                 string   // "3° B"
+  coeficiente?:            number   // participación en los gastos comunes
+  imagenes?:               string[] // URLs (/api/alquileres/imagenes/:id); al guardar se aceptan data-URLs
   metadata?:               Record<string, string>
 }
 
@@ -33,6 +100,23 @@ export interface ResumenKPIs {
   propiedades_ocupadas:     number
   total_propiedades:        number
   propiedades_con_atraso:   number
+  // Rentabilidad del año (cobrado menos gastos no a cargo del inquilino)
+  ingresos_cobrados:        number
+  gastos_totales:           number
+  rentabilidad:             number
+  por_propiedad:            RentabilidadPropiedad[]
+  documentos_por_vencer:    VencimientoDocumento[]  // vencidos o por vencer en 30 días
+  ocupacion_historica:      MetricasOcupacion       // del año según el historial (tasa_ocupacion es la foto de hoy)
+}
+
+export interface RentabilidadPropiedad {
+  propiedad_id:     string
+  direccion:        string
+  ingresos:         number
+  gastos:           number
+  gastos_inquilino: number
+  resultado:        number
+  margen:           number
 }
 
 export interface ResumenMovimientos {
@@ -40,6 +124,306 @@ export interface ResumenMovimientos {
   cantidad:    number
 }
 
+// ─── Historial de ocupación ───────────────────────────────────────────────────
+export interface TramoOcupacion {
+  id:               string
+  propiedad_id:     string
+  ocupada:          boolean
+  desde:            string
+  hasta?:           string   // exclusivo; vacío = tramo en curso
+  motivo:           string
+  contrato_id?:     string
+  inquilino?:       string
+  alquiler_mensual: number   // de referencia si está desocupada
+  dias:             number
+  ingreso_perdido?: number
+}
+
+export interface MetricasOcupacion {
+  dias_ocupada:           number
+  dias_desocupada:        number
+  tasa_ocupacion:         number   // % de días ocupada
+  vacancias:              number
+  vacancia_promedio_dias: number
+  vacancia_maxima_dias:   number
+  ingreso_perdido:        number   // alquiler estimado no cobrado
+}
+
+export interface HistorialOcupacion extends MetricasOcupacion {
+  propiedad_id:   string
+  direccion:      string
+  ocupada:        boolean
+  dias_en_estado: number
+  tramos:         TramoOcupacion[]
+}
+
+export interface ReporteOcupacion extends MetricasOcupacion {
+  desde:         string
+  hasta:         string
+  por_propiedad: (MetricasOcupacion & { propiedad_id: string; direccion: string; ocupada: boolean })[]
+}
+
+// ─── Historial de la propiedad ────────────────────────────────────────────────
+export interface CambioPropiedad {
+  id:           string
+  propiedad_id: string
+  campo:        string    // nombre en la base; los de metadata como "metadata.<clave>"
+  anterior:     unknown   // null si el campo no existía
+  nuevo:        unknown   // null si el campo se quitó
+  operacion:    'edicion' | 'actualizacion_monto' | 'posponer' | 'eliminar_metadata' | 'imagenes' | 'eliminacion'
+              | 'alta' | 'inicio_contrato' | 'fin_contrato' | 'nombre_inquilino' | 'eliminar_edificio'
+  usuario_id:   number
+  fecha:        string
+}
+
+export interface MontoAlquiler {
+  id:             string
+  propiedad_id:   string
+  monto:          number
+  monto_anterior: number
+  vigente_desde:  string   // primer día del mes desde el que rige
+  origen:         'alta' | 'actualizacion' | 'edicion' | 'contrato' | 'migracion'
+  contrato_id?:   string
+  notas?:         string
+  usuario_id:     number
+  created_at:     string
+}
+
+// ─── Reclamos de mantenimiento ────────────────────────────────────────────────
+export type PrioridadReclamo = 'baja' | 'media' | 'alta' | 'urgente'
+export type EstadoReclamo =
+  'abierto' | 'asignado' | 'en_curso' | 'resuelto' | 'cerrado' | 'cancelado'
+
+export interface CambioEstadoReclamo {
+  estado:     EstadoReclamo
+  fecha:      string
+  usuario_id: number
+  nota?:      string
+}
+
+export interface Reclamo {
+  id:                  string
+  numero:              number
+  propiedad_id:        string
+  direccion:           string
+  contrato_id?:        string
+  reportado_por:       string
+  telefono:            string
+  persona_id?:         string
+  titulo:              string
+  descripcion:         string
+  imagenes:            string[]
+  prioridad:           PrioridadReclamo
+  estado:              EstadoReclamo
+  proveedor?:          string
+  proveedor_telefono?: string
+  costo:               number   // presupuesto o, pagado, monto del gasto
+  gasto_id?:           string
+  movement_id?:        number   // Egreso de caja del pago
+  historial:           CambioEstadoReclamo[]
+  fecha_resolucion?:   string
+  created_by:          number
+  created_at:          string
+  updated_at:          string
+}
+
+// ─── Edificios ────────────────────────────────────────────────────────────────
+export interface Edificio {
+  id:                 string
+  nombre:             string
+  direccion:          string
+  notas:              string
+  cantidad_unidades:  number
+  created_at:         string
+  updated_at:         string
+}
+
+export interface ParteGastoEdificio {
+  propiedad_id: string
+  direccion:    string
+  unidad:       string
+  coeficiente:  number
+  monto:        number
+  gasto_id:     string
+}
+
+// Gasto común prorrateado entre las unidades por coeficiente
+export interface GastoEdificio {
+  id:                  string
+  edificio_id:         string
+  fecha:               string
+  categoria:           string
+  descripcion:         string
+  monto:               number
+  a_cargo_de?:         string   // vacío = el que corresponde a cada unidad
+  prorrateo:           ParteGastoEdificio[]
+  movement_id?:        number
+  movement_vinculado?: boolean
+}
+
+export interface UnidadEdificio extends RentabilidadPropiedad {
+  unidad:           string
+  coeficiente:      number
+  ocupada:          boolean
+  inquilino:        string
+  alquiler_mensual: number
+  deuda:            number
+}
+
+export interface ResumenEdificio extends Edificio {
+  anio:                     number
+  total_unidades:           number
+  unidades_ocupadas:        number
+  tasa_ocupacion:           number
+  ingreso_anual_proyectado: number
+  deuda_total:              number
+  ingresos_cobrados:        number
+  gastos_totales:           number   // propios de cada unidad más su parte de los comunes
+  resultado:                number
+  gastos_comunes:           number   // total de gastos del edificio en el año
+  unidades:                 UnidadEdificio[]
+}
+
+// ─── Documentos adjuntos ──────────────────────────────────────────────────────
+export type TipoDocumentoAdjunto = 'contrato_firmado' | 'inventario' | 'seguro' | 'garantia' | 'otro'
+
+export interface Documento {
+  id:           string
+  propiedad_id: string
+  contrato_id?: string
+  tipo:         TipoDocumentoAdjunto
+  descripcion?: string
+  vencimiento?: string
+  nombre:       string
+  content_type: string
+  tamanio:      number
+  created_by:   number
+  created_at:   string
+  updated_at:   string
+}
+
+export interface VencimientoDocumento extends Documento {
+  direccion:      string
+  dias_restantes: number  // negativo = vencido
+}
+
+// ─── Mora ─────────────────────────────────────────────────────────────────────
+export interface NivelMora {
+  estado:     Exclude<EstadoPago, 'paid' | 'pending'>
+  dias_desde: number
+  nombre:     string
+}
+
+export interface ReglasMora {
+  dia_vencimiento: number
+  dias_gracia:     number
+  niveles:         NivelMora[]
+  cron:            string
+}
+
+export interface TransicionEstado {
+  id:              string
+  anio:            number
+  mes:             number
+  estado_anterior: EstadoPago
+  estado_nuevo:    EstadoPago
+  dias_atraso:     number
+  origen:          'programado' | 'manual' | 'pago' | 'reversion_pago'
+  usuario_id?:     number
+  fecha:           string
+}
+
+// ─── Avisos a inquilinos ──────────────────────────────────────────────────────
+export type TipoNotificacion = 'recordatorio_vencimiento' | 'aviso_mora' | 'aviso_actualizacion'
+export type CanalNotificacion = 'email' | 'whatsapp' | 'sms'
+
+export interface PlantillaNotificacion {
+  asunto: string  // solo email
+  cuerpo: string
+}
+
+export interface ConfigNotificaciones {
+  activo:     boolean
+  canales:    CanalNotificacion[]
+  dias_antes: number
+  cron:       string
+  plantillas: Partial<Record<TipoNotificacion, PlantillaNotificacion>>
+}
+
+export interface Notificacion {
+  id:           string
+  tipo:         TipoNotificacion
+  canal:        CanalNotificacion
+  persona_id?:  string
+  inquilino:    string
+  destino:      string
+  propiedad_id: string
+  direccion:    string
+  anio?:        number
+  mes?:         number
+  asunto?:      string
+  cuerpo:       string
+  estado:       'enviando' | 'enviada' | 'error' | 'omitida'
+  error?:       string
+  fecha:        string
+  intentos:     number // reintentos de un envío con error
+}
+
+// ─── Conciliación de cobros y caja ────────────────────────────────────────────
+export type TipoDiferenciaCaja =
+  | 'cobro_sin_movimiento'
+  | 'movimiento_eliminado'
+  | 'monto_distinto'
+  | 'movimiento_sin_cobro'
+  | 'operacion_pendiente'
+
+export interface DiferenciaCaja {
+  tipo:          TipoDiferenciaCaja
+  propiedad_id?: string
+  direccion?:    string
+  periodo_id?:   string
+  cobro_id?:     string
+  anio?:         number
+  mes:           number
+  monto_cobro?:  number
+  movement_id?:  number
+  monto_caja?:   number
+  detalle:       string
+  reparable:     boolean
+}
+
+export interface ReporteConciliacion {
+  desde:                 string
+  hasta:                 string
+  cobros_revisados:      number
+  movimientos_revisados: number
+  diferencias:           DiferenciaCaja[]
+  reparadas?:            number
+  errores?:              string[]
+}
+
+export interface OperacionCaja {
+  id:            string
+  tipo:          'crear_movimiento' | 'anular_movimiento'
+  estado:        'pendiente' | 'aplicada' | 'cancelada' | 'revision'  // revision: falta indicar la caja destino
+  propiedad_id:  string
+  periodo_id:    string
+  cobro_id?:     string
+  anio:          number
+  mes:           number
+  referencia?:   string
+  monto?:        number
+  detalle?:      string
+  fecha:         string
+  movement_id?:  number
+  usuario_id:    number
+  arco_id?:      number   // caja destino elegida
+  intentos:      number
+  ultimo_error?: string
+  created_at:    string
+  aplicada_en?:  string
+}
+
 // ─── Actualización de monto ───────────────────────────────────────────────────
 export interface InflacionMes {
   periodo: string  // "Mar 2025"
@@ -48,8 +432,19 @@ export interface InflacionMes {
 
 export interface DatosInflacion {
   fuente:         string
-  meses:          InflacionMes[]
+  meses:          InflacionMes[]   // solo IPC (variación mensual)
   acumulado_pct:  number
+  indice?:        TipoIndice
+  coeficiente?:   number
+  // Índices de nivel (ICL, CER, UVA, CAC): valores usados en el cociente
+  valor_desde?:       number
+  valor_hasta?:       number
+  fecha_valor_desde?: string
+  fecha_valor_hasta?: string
+  error?:             string
+  // Frescura de la copia local de la serie
+  actualizado_en?:    string
+  manual?:            boolean  // incluye valores cargados a mano
 }
 
 export interface ActualizacionPendiente {